@@ -0,0 +1,10 @@
+package adapter
+
+import "github.com/sagernet/sing-dns"
+
+// HostsTransport answers from static entries only. DNS rules targeting it
+// fall through to the next rule for domains it has no entry for.
+type HostsTransport interface {
+	dns.Transport
+	Contains(domain string) bool
+}
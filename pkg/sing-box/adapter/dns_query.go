@@ -0,0 +1,24 @@
+package adapter
+
+import "time"
+
+// DNSQueryRecorder receives the DNS queries finished by the router.
+type DNSQueryRecorder interface {
+	RecordDNSQuery(record DNSQueryRecord)
+}
+
+// DNSQueryRecord describes a finished DNS query. Latency is in milliseconds.
+type DNSQueryRecord struct {
+	Time      time.Time `json:"time"`
+	Domain    string    `json:"domain"`
+	QueryType string    `json:"query_type"`
+	Rule      string    `json:"rule,omitempty"`
+	Upstream  string    `json:"upstream,omitempty"`
+	RCode     string    `json:"rcode,omitempty"`
+	Answers   []string  `json:"answers,omitempty"`
+	Latency   int64     `json:"latency"`
+	Cached    bool      `json:"cached"`
+	Client    string    `json:"client,omitempty"`
+	Inbound   string    `json:"inbound,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
@@ -19,7 +19,12 @@ type ClashServer interface {
 	PreStarter
 	Mode() string
 	ModeList() []string
+	SetMode(newMode string)
 	HistoryStorage() *urltest.HistoryStorage
+}
+
+// ConnectionTracker is notified of every routed connection, and of its end through the returned Tracker.
+type ConnectionTracker interface {
 	RoutedConnection(ctx context.Context, conn net.Conn, metadata InboundContext, matchedRule Rule) (net.Conn, Tracker)
 	RoutedPacketConnection(ctx context.Context, conn N.PacketConn, metadata InboundContext, matchedRule Rule) (N.PacketConn, Tracker)
 }
@@ -42,6 +47,66 @@ type CacheFile interface {
 	StoreGroupExpand(group string, expand bool) error
 	LoadRuleSet(tag string) *SavedRuleSet
 	SaveRuleSet(tag string, set *SavedRuleSet) error
+
+	StoreUsers() bool
+	LoadInboundUsers(inbound string) *SavedInboundUsers
+	SaveInboundUsers(inbound string, users *SavedInboundUsers) error
+	LoadUserUsage(inbound string, user string) *SavedUserUsage
+	SaveUserUsage(usages map[string]map[string]*SavedUserUsage) error
+
+	StoreStatistics() bool
+	LoadStatistics() *SavedStatistics
+	SaveStatistics(statistics *SavedStatistics) error
+
+	LoadDNSCache(transportName string, key string) *SavedDNSCache
+	SaveDNSCache(caches map[string]map[string]*SavedDNSCache) error
+	PurgeDNSCache(transportName string, expiredBefore time.Time) error
+	ClearDNSCache(transportName string) error
+}
+
+type SavedDNSCache struct {
+	Message  []byte
+	TTL      uint32
+	ExpireAt time.Time
+}
+
+func (s *SavedDNSCache) MarshalBinary() ([]byte, error) {
+	var buffer bytes.Buffer
+	err := binary.Write(&buffer, binary.BigEndian, uint8(1))
+	if err != nil {
+		return nil, err
+	}
+	err = binary.Write(&buffer, binary.BigEndian, s.ExpireAt.Unix())
+	if err != nil {
+		return nil, err
+	}
+	err = binary.Write(&buffer, binary.BigEndian, s.TTL)
+	if err != nil {
+		return nil, err
+	}
+	buffer.Write(s.Message)
+	return buffer.Bytes(), nil
+}
+
+func (s *SavedDNSCache) UnmarshalBinary(data []byte) error {
+	reader := bytes.NewReader(data)
+	var version uint8
+	err := binary.Read(reader, binary.BigEndian, &version)
+	if err != nil {
+		return err
+	}
+	var expireAt int64
+	err = binary.Read(reader, binary.BigEndian, &expireAt)
+	if err != nil {
+		return err
+	}
+	s.ExpireAt = time.Unix(expireAt, 0)
+	err = binary.Read(reader, binary.BigEndian, &s.TTL)
+	if err != nil {
+		return err
+	}
+	s.Message, err = io.ReadAll(reader)
+	return err
 }
 
 type SavedRuleSet struct {
@@ -101,8 +166,162 @@ func (s *SavedRuleSet) UnmarshalBinary(data []byte) error {
 	return nil
 }
 
+type SavedStatistics struct {
+	Upload    int64
+	Download  int64
+	Outbounds map[string]SavedTraffic
+	Inbounds  map[string]SavedTraffic
+	Processes map[string]SavedTraffic
+	Users     []SavedUserTraffic
+}
+
+type SavedTraffic struct {
+	Upload      int64
+	Download    int64
+	Connections int64
+}
+
+type SavedUserTraffic struct {
+	Inbound string
+	User    string
+	SavedTraffic
+}
+
+func (s *SavedStatistics) MarshalBinary() ([]byte, error) {
+	var buffer bytes.Buffer
+	err := binary.Write(&buffer, binary.BigEndian, uint8(1))
+	if err != nil {
+		return nil, err
+	}
+	err = binary.Write(&buffer, binary.BigEndian, s.Upload)
+	if err != nil {
+		return nil, err
+	}
+	err = binary.Write(&buffer, binary.BigEndian, s.Download)
+	if err != nil {
+		return nil, err
+	}
+	for _, statistics := range []map[string]SavedTraffic{s.Outbounds, s.Inbounds, s.Processes} {
+		err = writeSavedTraffic(&buffer, statistics)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = rw.WriteUVariant(&buffer, uint64(len(s.Users)))
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range s.Users {
+		err = rw.WriteVString(&buffer, user.Inbound)
+		if err != nil {
+			return nil, err
+		}
+		err = rw.WriteVString(&buffer, user.User)
+		if err != nil {
+			return nil, err
+		}
+		err = binary.Write(&buffer, binary.BigEndian, user.SavedTraffic)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buffer.Bytes(), nil
+}
+
+func (s *SavedStatistics) UnmarshalBinary(data []byte) error {
+	reader := bytes.NewReader(data)
+	var version uint8
+	err := binary.Read(reader, binary.BigEndian, &version)
+	if err != nil {
+		return err
+	}
+	err = binary.Read(reader, binary.BigEndian, &s.Upload)
+	if err != nil {
+		return err
+	}
+	err = binary.Read(reader, binary.BigEndian, &s.Download)
+	if err != nil {
+		return err
+	}
+	s.Outbounds, err = readSavedTraffic(reader)
+	if err != nil {
+		return err
+	}
+	s.Inbounds, err = readSavedTraffic(reader)
+	if err != nil {
+		return err
+	}
+	s.Processes, err = readSavedTraffic(reader)
+	if err != nil {
+		return err
+	}
+	length, err := rw.ReadUVariant(reader)
+	if err != nil {
+		return err
+	}
+	s.Users = make([]SavedUserTraffic, 0, length)
+	for i := uint64(0); i < length; i++ {
+		var user SavedUserTraffic
+		user.Inbound, err = rw.ReadVString(reader)
+		if err != nil {
+			return err
+		}
+		user.User, err = rw.ReadVString(reader)
+		if err != nil {
+			return err
+		}
+		err = binary.Read(reader, binary.BigEndian, &user.SavedTraffic)
+		if err != nil {
+			return err
+		}
+		s.Users = append(s.Users, user)
+	}
+	return nil
+}
+
+func writeSavedTraffic(writer io.Writer, statistics map[string]SavedTraffic) error {
+	err := rw.WriteUVariant(writer, uint64(len(statistics)))
+	if err != nil {
+		return err
+	}
+	for name, traffic := range statistics {
+		err = rw.WriteVString(writer, name)
+		if err != nil {
+			return err
+		}
+		err = binary.Write(writer, binary.BigEndian, traffic)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readSavedTraffic(reader *bytes.Reader) (map[string]SavedTraffic, error) {
+	length, err := rw.ReadUVariant(reader)
+	if err != nil {
+		return nil, err
+	}
+	statistics := make(map[string]SavedTraffic, length)
+	for i := uint64(0); i < length; i++ {
+		name, err := rw.ReadVString(reader)
+		if err != nil {
+			return nil, err
+		}
+		var traffic SavedTraffic
+		err = binary.Read(reader, binary.BigEndian, &traffic)
+		if err != nil {
+			return nil, err
+		}
+		statistics[name] = traffic
+	}
+	return statistics, nil
+}
+
 type Tracker interface {
-	Leave()
+	// Leave is called when routing of the connection has finished,
+	// with the error returned by the outbound if any.
+	Leave(err error)
 }
 
 type OutboundGroup interface {
@@ -111,8 +330,36 @@ type OutboundGroup interface {
 	All() []string
 }
 
+type OutboundChain interface {
+	Outbound
+	Hops() []string
+}
+
+// AppendOutboundChain appends tag and the outbounds it currently forwards to,
+// following the hops of chains and the selected outbound of groups.
+func AppendOutboundChain(chain []string, router Router, tag string) []string {
+	if tag == "" {
+		return chain
+	}
+	chain = append(chain, tag)
+	detour, loaded := router.Outbound(tag)
+	if !loaded {
+		return chain
+	}
+	switch outbound := detour.(type) {
+	case OutboundChain:
+		for _, hop := range outbound.Hops() {
+			chain = AppendOutboundChain(chain, router, hop)
+		}
+	case OutboundGroup:
+		chain = AppendOutboundChain(chain, router, outbound.Now())
+	}
+	return chain
+}
+
 type ProxyProvider interface {
 	AllOutbound() map[string]Outbound
+	UpdatedAt() time.Time
 }
 
 type URLTestGroup interface {
@@ -120,6 +367,11 @@ type URLTestGroup interface {
 	URLTest(ctx context.Context) (map[string]uint16, error)
 }
 
+type FailoverGroup interface {
+	OutboundGroup
+	FailoverCounts() map[string]uint64
+}
+
 func OutboundTag(detour Outbound) string {
 	if group, isGroup := detour.(OutboundGroup); isGroup {
 		return group.Now()
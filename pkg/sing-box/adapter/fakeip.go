@@ -2,6 +2,7 @@ package adapter
 
 import (
 	"net/netip"
+	"time"
 
 	"github.com/sagernet/sing-dns"
 	"github.com/sagernet/sing/common/logger"
@@ -10,11 +11,31 @@ import (
 type FakeIPStore interface {
 	Service
 	Contains(address netip.Addr) bool
-	Create(domain string, isIPv6 bool) (netip.Addr, error)
+	Create(pool string, domain string, isIPv6 bool) (netip.Addr, error)
 	Lookup(address netip.Addr) (string, bool)
+	Entries() []FakeIPEntry
+	Pools() []FakeIPPoolStatus
 	Reset() error
 }
 
+type FakeIPEntry struct {
+	Pool     string
+	Address  netip.Addr
+	Domain   string
+	LastUsed time.Time
+}
+
+type FakeIPPoolStatus struct {
+	Tag           string
+	Inet4Range    netip.Prefix
+	Inet6Range    netip.Prefix
+	Inet4Used     uint64
+	Inet4Capacity uint64
+	Inet6Used     uint64
+	Inet6Capacity uint64
+	Recycled      uint64
+}
+
 type FakeIPStorage interface {
 	FakeIPMetadata() *FakeIPMetadata
 	FakeIPSaveMetadata(metadata *FakeIPMetadata) error
@@ -23,6 +44,7 @@ type FakeIPStorage interface {
 	FakeIPStoreAsync(address netip.Addr, domain string, logger logger.Logger)
 	FakeIPLoad(address netip.Addr) (string, bool)
 	FakeIPLoadDomain(domain string, isIPv6 bool) (netip.Addr, bool)
+	FakeIPForEach(fn func(address netip.Addr, domain string)) error
 	FakeIPReset() error
 }
 
@@ -15,6 +15,13 @@ type FakeIPMetadata struct {
 	Inet6Range   netip.Prefix
 	Inet4Current netip.Addr
 	Inet6Current netip.Addr
+	Pools        []FakeIPPoolMetadata
+}
+
+type FakeIPPoolMetadata struct {
+	Tag        string
+	Inet4Range netip.Prefix
+	Inet6Range netip.Prefix
 }
 
 func (m *FakeIPMetadata) MarshalBinary() (data []byte, err error) {
@@ -27,17 +34,69 @@ func (m *FakeIPMetadata) MarshalBinary() (data []byte, err error) {
 		common.Must(binary.Write(&buffer, binary.BigEndian, uint16(len(data))))
 		buffer.Write(data)
 	}
+	common.Must(binary.Write(&buffer, binary.BigEndian, uint16(len(m.Pools))))
+	for _, pool := range m.Pools {
+		common.Must(binary.Write(&buffer, binary.BigEndian, uint16(len(pool.Tag))))
+		buffer.WriteString(pool.Tag)
+		for _, marshaler := range []encoding.BinaryMarshaler{pool.Inet4Range, pool.Inet6Range} {
+			data, err = marshaler.MarshalBinary()
+			if err != nil {
+				return
+			}
+			common.Must(binary.Write(&buffer, binary.BigEndian, uint16(len(data))))
+			buffer.Write(data)
+		}
+	}
 	data = buffer.Bytes()
 	return
 }
 
 func (m *FakeIPMetadata) UnmarshalBinary(data []byte) error {
 	reader := bytes.NewReader(data)
-	for _, unmarshaler := range []encoding.BinaryUnmarshaler{&m.Inet4Range, &m.Inet6Range, &m.Inet4Current, &m.Inet6Current} {
+	err := readFakeIPMetadataElements(reader, &m.Inet4Range, &m.Inet6Range, &m.Inet4Current, &m.Inet6Current)
+	if err != nil {
+		return err
+	}
+	// metadata saved by older versions ends here
+	if reader.Len() == 0 {
+		return nil
+	}
+	var poolCount uint16
+	err = binary.Read(reader, binary.BigEndian, &poolCount)
+	if err != nil {
+		return err
+	}
+	m.Pools = make([]FakeIPPoolMetadata, poolCount)
+	for i := range m.Pools {
+		pool := &m.Pools[i]
+		var tagLength uint16
+		err = binary.Read(reader, binary.BigEndian, &tagLength)
+		if err != nil {
+			return err
+		}
+		tag := make([]byte, tagLength)
+		_, err = io.ReadFull(reader, tag)
+		if err != nil {
+			return err
+		}
+		pool.Tag = string(tag)
+		err = readFakeIPMetadataElements(reader, &pool.Inet4Range, &pool.Inet6Range)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readFakeIPMetadataElements(reader *bytes.Reader, unmarshalers ...encoding.BinaryUnmarshaler) error {
+	for _, unmarshaler := range unmarshalers {
 		var length uint16
-		common.Must(binary.Read(reader, binary.BigEndian, &length))
+		err := binary.Read(reader, binary.BigEndian, &length)
+		if err != nil {
+			return err
+		}
 		element := make([]byte, length)
-		_, err := io.ReadFull(reader, element)
+		_, err = io.ReadFull(reader, element)
 		if err != nil {
 			return err
 		}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"net/netip"
+	"time"
 
 	"github.com/sagernet/sing-box/common/geoip"
 	"github.com/sagernet/sing-dns"
@@ -20,6 +21,8 @@ type Router interface {
 	PreStarter
 	PostStarter
 
+	Inbounds() []Inbound
+	Inbound(tag string) (Inbound, bool)
 	Outbounds() []Outbound
 	Outbound(tag string) (Outbound, bool)
 	DefaultOutbound(network string) (Outbound, error)
@@ -32,6 +35,7 @@ type Router interface {
 	LoadGeosite(code string) (Rule, error)
 
 	RuleSet(tag string) (RuleSet, bool)
+	RuleSets() map[string]RuleSet
 
 	NeedWIFIState() bool
 
@@ -39,6 +43,8 @@ type Router interface {
 	Lookup(ctx context.Context, domain string, strategy dns.DomainStrategy) ([]netip.Addr, error)
 	LookupDefault(ctx context.Context, domain string) ([]netip.Addr, error)
 	ClearDNSCache()
+	FlushDNSCache()
+	SetDNSQueryRecorder(recorder DNSQueryRecorder)
 
 	InterfaceFinder() control.InterfaceFinder
 	UpdateInterfaces() error
@@ -54,6 +60,7 @@ type Router interface {
 
 	ClashServer() ClashServer
 	SetClashServer(server ClashServer)
+	AppendTracker(tracker ConnectionTracker)
 
 	V2RayServer() V2RayServer
 	SetV2RayServer(server V2RayServer)
@@ -95,6 +102,7 @@ type RuleSet interface {
 	StartContext(ctx context.Context, startContext RuleSetStartContext) error
 	PostStart() error
 	Metadata() RuleSetMetadata
+	LastUpdated() time.Time
 	Close() error
 	HeadlessRule
 }
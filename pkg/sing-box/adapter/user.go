@@ -0,0 +1,93 @@
+package adapter
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/sagernet/sing/common/rw"
+)
+
+// InboundUser is a user of a multi-user inbound.
+// Only the credentials used by the inbound protocol are set.
+type InboundUser struct {
+	Name     string
+	Password string
+	UUID     string
+	AlterID  int
+	Flow     string
+}
+
+// ManagedUserInbound is an inbound whose users can be replaced at runtime.
+type ManagedUserInbound interface {
+	Inbound
+	Users() []InboundUser
+	UpdateUsers(users []InboundUser) error
+}
+
+type UserManager interface {
+	Service
+	Inbounds() []ManagedUserInbound
+	Users(inbound string) ([]InboundUser, error)
+	AddUser(inbound string, user InboundUser) error
+	UpdateUser(inbound string, user InboundUser) error
+	RemoveUser(inbound string, name string) error
+}
+
+type SavedInboundUsers struct {
+	Users []InboundUser
+}
+
+func (s *SavedInboundUsers) MarshalBinary() ([]byte, error) {
+	var buffer bytes.Buffer
+	err := binary.Write(&buffer, binary.BigEndian, uint8(1))
+	if err != nil {
+		return nil, err
+	}
+	err = rw.WriteUVariant(&buffer, uint64(len(s.Users)))
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range s.Users {
+		for _, value := range []string{user.Name, user.Password, user.UUID, user.Flow} {
+			err = rw.WriteVString(&buffer, value)
+			if err != nil {
+				return nil, err
+			}
+		}
+		err = rw.WriteUVariant(&buffer, uint64(user.AlterID))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buffer.Bytes(), nil
+}
+
+func (s *SavedInboundUsers) UnmarshalBinary(data []byte) error {
+	reader := bytes.NewReader(data)
+	var version uint8
+	err := binary.Read(reader, binary.BigEndian, &version)
+	if err != nil {
+		return err
+	}
+	userCount, err := rw.ReadUVariant(reader)
+	if err != nil {
+		return err
+	}
+	s.Users = make([]InboundUser, 0, userCount)
+	for i := uint64(0); i < userCount; i++ {
+		var user InboundUser
+		for _, value := range []*string{&user.Name, &user.Password, &user.UUID, &user.Flow} {
+			*value, err = rw.ReadVString(reader)
+			if err != nil {
+				return err
+			}
+		}
+		alterID, err := rw.ReadUVariant(reader)
+		if err != nil {
+			return err
+		}
+		user.AlterID = int(alterID)
+		s.Users = append(s.Users, user)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"time"
+
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/common/rw"
+)
+
+// UserLimiter enforces the user_limits of inbounds on routed connections.
+type UserLimiter interface {
+	Service
+	RoutedConnection(ctx context.Context, conn net.Conn, metadata InboundContext) (net.Conn, func(), error)
+	RoutedPacketConnection(ctx context.Context, conn N.PacketConn, metadata InboundContext) (N.PacketConn, func(), error)
+	Usage() []UserUsage
+}
+
+type UserUsage struct {
+	Inbound        string
+	User           string
+	Used           uint64
+	Quota          uint64
+	Connections    int
+	MaxConnections int
+	ExpireAt       time.Time
+	ResetAt        time.Time
+	Exceeded       bool
+}
+
+type SavedUserUsage struct {
+	Used        uint64
+	PeriodStart time.Time
+}
+
+func (s *SavedUserUsage) MarshalBinary() ([]byte, error) {
+	var buffer bytes.Buffer
+	err := binary.Write(&buffer, binary.BigEndian, uint8(1))
+	if err != nil {
+		return nil, err
+	}
+	err = rw.WriteUVariant(&buffer, s.Used)
+	if err != nil {
+		return nil, err
+	}
+	err = binary.Write(&buffer, binary.BigEndian, s.PeriodStart.Unix())
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func (s *SavedUserUsage) UnmarshalBinary(data []byte) error {
+	reader := bytes.NewReader(data)
+	var version uint8
+	err := binary.Read(reader, binary.BigEndian, &version)
+	if err != nil {
+		return err
+	}
+	s.Used, err = rw.ReadUVariant(reader)
+	if err != nil {
+		return err
+	}
+	var periodStart int64
+	err = binary.Read(reader, binary.BigEndian, &periodStart)
+	if err != nil {
+		return err
+	}
+	s.PeriodStart = time.Unix(periodStart, 0)
+	return nil
+}
@@ -0,0 +1,20 @@
+package adapter
+
+import "time"
+
+// WireGuardInbound is an inbound terminating WireGuard tunnels of its peers.
+type WireGuardInbound interface {
+	Inbound
+	Peers() []WireGuardPeer
+}
+
+// WireGuardPeer is the state of a WireGuard peer as reported by the device.
+// LastHandshake is zero if no handshake has been completed yet.
+type WireGuardPeer struct {
+	Name          string
+	PublicKey     string
+	Endpoint      string
+	LastHandshake time.Time
+	RxBytes       uint64
+	TxBytes       uint64
+}
@@ -9,10 +9,14 @@ import (
 	"time"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/accesslog"
+	"github.com/sagernet/sing-box/common/capture"
 	"github.com/sagernet/sing-box/common/taskmonitor"
+	"github.com/sagernet/sing-box/common/usermanager"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/experimental"
 	"github.com/sagernet/sing-box/experimental/cachefile"
+	"github.com/sagernet/sing-box/experimental/clashapi/trafficontrol"
 	"github.com/sagernet/sing-box/experimental/libbox/platform"
 	"github.com/sagernet/sing-box/inbound"
 	"github.com/sagernet/sing-box/log"
@@ -61,6 +65,7 @@ func New(options Options) (*Box, error) {
 	var needCacheFile bool
 	var needClashAPI bool
 	var needV2RayAPI bool
+	var needAccessLog bool
 	if experimentalOptions.CacheFile != nil && experimentalOptions.CacheFile.Enabled || options.PlatformLogWriter != nil {
 		needCacheFile = true
 	}
@@ -70,6 +75,10 @@ func New(options Options) (*Box, error) {
 	if experimentalOptions.V2RayAPI != nil && experimentalOptions.V2RayAPI.Listen != "" {
 		needV2RayAPI = true
 	}
+	accessLogOptions := common.PtrValueOrDefault(options.Log).Access
+	if accessLogOptions != nil && accessLogOptions.Enabled {
+		needAccessLog = true
+	}
 	var defaultLogWriter io.Writer
 	if options.PlatformInterface != nil {
 		defaultLogWriter = io.Discard
@@ -92,6 +101,7 @@ func New(options Options) (*Box, error) {
 		common.PtrValueOrDefault(options.DNS),
 		common.PtrValueOrDefault(options.NTP),
 		options.Inbounds,
+		options.Outbounds,
 		options.PlatformInterface,
 	)
 	if err != nil {
@@ -164,7 +174,31 @@ func New(options Options) (*Box, error) {
 		}
 		preServices1["cache file"] = cacheFile
 	}
+	if needClashAPI || needAccessLog {
+		var closedConnections int
+		if needClashAPI {
+			closedConnections = common.PtrValueOrDefault(experimentalOptions.ClashAPI).ClosedConnections
+			if closedConnections == 0 {
+				closedConnections = trafficontrol.DefaultClosedConnections
+			}
+		}
+		trafficManager := trafficontrol.NewManager(router, closedConnections)
+		service.MustRegisterPtr(ctx, trafficManager)
+		router.AppendTracker(trafficManager)
+		preServices1["traffic manager"] = trafficManager
+		if needAccessLog {
+			accessLog := accesslog.New(ctx, logFactory.NewLogger("access-log"), *accessLogOptions)
+			trafficManager.AddCloseListener(accessLog)
+			preServices2["access log"] = accessLog
+		}
+	}
+	userManager := usermanager.New(ctx, logFactory.NewLogger("user-manager"), inbounds)
+	service.MustRegister[adapter.UserManager](ctx, userManager)
+	preServices2["user manager"] = userManager
 	if needClashAPI {
+		captureManager := capture.NewManager(router, logFactory.NewLogger("capture"))
+		service.MustRegisterPtr(ctx, captureManager)
+		router.AppendTracker(captureManager)
 		clashAPIOptions := common.PtrValueOrDefault(experimentalOptions.ClashAPI)
 		clashAPIOptions.ModeList = experimental.CalculateClashModeList(options.Options)
 		clashServer, err := experimental.NewClashServer(ctx, router, logFactory.(log.ObservableFactory), clashAPIOptions)
@@ -175,7 +209,7 @@ func New(options Options) (*Box, error) {
 		preServices2["clash api"] = clashServer
 	}
 	if needV2RayAPI {
-		v2rayServer, err := experimental.NewV2RayServer(logFactory.NewLogger("v2ray-api"), common.PtrValueOrDefault(experimentalOptions.V2RayAPI))
+		v2rayServer, err := experimental.NewV2RayServer(ctx, logFactory.NewLogger("v2ray-api"), common.PtrValueOrDefault(experimentalOptions.V2RayAPI))
 		if err != nil {
 			return nil, E.Cause(err, "create v2ray api server")
 		}
@@ -361,14 +395,16 @@ func (s *Box) Close() error {
 		})
 	}
 	monitor.Finish()
-	for serviceName, service := range s.preServices1 {
+	// close in the reverse order of start, as services of preServices2 use those of preServices1,
+	// e.g. the Clash API saves statistics to the cache file when closed.
+	for serviceName, service := range s.preServices2 {
 		monitor.Start("close ", serviceName)
 		errors = E.Append(errors, service.Close(), func(err error) error {
 			return E.Cause(err, "close ", serviceName)
 		})
 		monitor.Finish()
 	}
-	for serviceName, service := range s.preServices2 {
+	for serviceName, service := range s.preServices1 {
 		monitor.Start("close ", serviceName)
 		errors = E.Append(errors, service.Close(), func(err error) error {
 			return E.Cause(err, "close ", serviceName)
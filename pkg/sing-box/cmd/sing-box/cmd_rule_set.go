@@ -1,6 +1,16 @@
 package main
 
 import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/sagernet/sing-box/common/srs"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/json"
+
 	"github.com/spf13/cobra"
 )
 
@@ -12,3 +22,65 @@ var commandRuleSet = &cobra.Command{
 func init() {
 	mainCommand.AddCommand(commandRuleSet)
 }
+
+// readRuleSet reads a source or binary rule-set, detected by content.
+// Domain and IP lists of binary rule-sets are recovered.
+func readRuleSet(sourcePath string) (option.PlainRuleSet, error) {
+	var (
+		reader io.Reader
+		err    error
+	)
+	if sourcePath == "stdin" {
+		reader = os.Stdin
+	} else {
+		file, err := os.Open(sourcePath)
+		if err != nil {
+			return option.PlainRuleSet{}, err
+		}
+		defer file.Close()
+		reader = file
+	}
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return option.PlainRuleSet{}, err
+	}
+	if bytes.HasPrefix(content, srs.MagicBytes[:]) {
+		return srs.Read(bytes.NewReader(content), true)
+	}
+	plainRuleSet, err := json.UnmarshalExtended[option.PlainRuleSetCompat](content)
+	if err != nil {
+		return option.PlainRuleSet{}, err
+	}
+	return plainRuleSet.Upgrade(), nil
+}
+
+// writeRuleSet writes a binary rule-set if the output name ends with .srs,
+// otherwise source.
+func writeRuleSet(outputPath string, ruleSet option.PlainRuleSet) error {
+	var content []byte
+	if strings.HasSuffix(outputPath, ".srs") {
+		buffer := new(bytes.Buffer)
+		err := srs.Write(buffer, ruleSet)
+		if err != nil {
+			return err
+		}
+		content = buffer.Bytes()
+	} else {
+		buffer := new(bytes.Buffer)
+		encoder := json.NewEncoder(buffer)
+		encoder.SetIndent("", "  ")
+		err := encoder.Encode(option.PlainRuleSetCompat{
+			Version: C.RuleSetVersion1,
+			Options: ruleSet,
+		})
+		if err != nil {
+			return err
+		}
+		content = buffer.Bytes()
+	}
+	if outputPath == "stdout" {
+		_, err := os.Stdout.Write(content)
+		return err
+	}
+	return os.WriteFile(outputPath, content, 0o644)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sagernet/sing-box/common/ruleset"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	E "github.com/sagernet/sing/common/exceptions"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagRuleSetConvertFrom   string
+	flagRuleSetConvertOutput string
+)
+
+const flagRuleSetConvertDefaultOutput = "<file_name>.json"
+
+var commandRuleSetConvert = &cobra.Command{
+	Use:   "convert [source-path]",
+	Short: "Convert third-party rule lists to rule-set",
+	Long: "Convert Clash rule-providers, hosts files, AdGuard/ABP filter lists or plain domain lists to rule-set.\n" +
+		"The output is written as binary rule-set if its name ends with .srs, otherwise as source, \"stdout\" prints it.",
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := convertRuleSet(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	commandRuleSet.AddCommand(commandRuleSetConvert)
+	commandRuleSetConvert.Flags().StringVarP(&flagRuleSetConvertFrom, "from", "f", "", "Source format: clash, hosts, adblock or domain-list")
+	commandRuleSetConvert.Flags().StringVarP(&flagRuleSetConvertOutput, "output", "o", flagRuleSetConvertDefaultOutput, "Output file")
+	commandRuleSetConvert.MarkFlagRequired("from")
+}
+
+func convertRuleSet(sourcePath string) error {
+	var format string
+	switch flagRuleSetConvertFrom {
+	case "clash":
+		format = C.RuleSetFormatClash
+	case "hosts":
+		format = C.RuleSetFormatHosts
+	case "adblock", "adguard":
+		format = C.RuleSetFormatAdBlock
+	case "domain-list", "domain_list":
+		format = C.RuleSetFormatDomainList
+	default:
+		return E.New("unknown source format: ", flagRuleSetConvertFrom)
+	}
+	var (
+		reader io.Reader
+		err    error
+	)
+	if sourcePath == "stdin" {
+		reader = os.Stdin
+	} else {
+		var file *os.File
+		file, err = os.Open(sourcePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		reader = file
+	}
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	plainRuleSet, skipped, err := ruleset.Convert(format, content)
+	if err != nil {
+		return err
+	}
+	for _, entry := range skipped {
+		log.Warn("skipped unsupported entry: ", entry)
+	}
+	if len(plainRuleSet.Rules) == 0 {
+		return E.New("no supported entries found")
+	}
+	var outputPath string
+	if flagRuleSetConvertOutput == flagRuleSetConvertDefaultOutput {
+		outputPath = strings.TrimSuffix(sourcePath, filepath.Ext(sourcePath)) + ".json"
+	} else {
+		outputPath = flagRuleSetConvertOutput
+	}
+	return writeRuleSet(outputPath, plainRuleSet)
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"os"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/geoip"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/route"
+	"github.com/sagernet/sing-dns"
+	"github.com/sagernet/sing-tun"
+	"github.com/sagernet/sing/common/control"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	"github.com/sagernet/sing/common/json"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+
+	mdns "github.com/miekg/dns"
+	"github.com/spf13/cobra"
+)
+
+var commandRuleSetMatch = &cobra.Command{
+	Use:   "match <rule-set-path> <domain|ip>[:port]",
+	Short: "Check if a domain or IP address matches the rule-set",
+	Long:  "Check if a domain or IP address matches the rule-set and print the matching rules.\nProcess and WIFI conditions never match.",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := matchRuleSet(args[0], args[1])
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	commandRuleSet.AddCommand(commandRuleSetMatch)
+}
+
+var _ adapter.Router = (*matchRouter)(nil)
+
+var errMatchRouterUnsupported = E.New("not available when matching rule-set outside a running instance")
+
+// matchRouter stands in for the router of headless rules outside a running instance.
+// There are no network, process or WIFI states, so rule items depending on them never match.
+type matchRouter struct{}
+
+func (r *matchRouter) Start() error {
+	return nil
+}
+
+func (r *matchRouter) Close() error {
+	return nil
+}
+
+func (r *matchRouter) PreStart() error {
+	return nil
+}
+
+func (r *matchRouter) PostStart() error {
+	return nil
+}
+
+func (r *matchRouter) Inbounds() []adapter.Inbound {
+	return nil
+}
+
+func (r *matchRouter) Inbound(tag string) (adapter.Inbound, bool) {
+	return nil, false
+}
+
+func (r *matchRouter) Outbounds() []adapter.Outbound {
+	return nil
+}
+
+func (r *matchRouter) Outbound(tag string) (adapter.Outbound, bool) {
+	return nil, false
+}
+
+func (r *matchRouter) DefaultOutbound(network string) (adapter.Outbound, error) {
+	return nil, errMatchRouterUnsupported
+}
+
+func (r *matchRouter) FakeIPStore() adapter.FakeIPStore {
+	return nil
+}
+
+func (r *matchRouter) RouteConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
+	return errMatchRouterUnsupported
+}
+
+func (r *matchRouter) RoutePacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext) error {
+	return errMatchRouterUnsupported
+}
+
+func (r *matchRouter) GeoIPReader() *geoip.Reader {
+	return nil
+}
+
+func (r *matchRouter) LoadGeosite(code string) (adapter.Rule, error) {
+	return nil, errMatchRouterUnsupported
+}
+
+func (r *matchRouter) RuleSet(tag string) (adapter.RuleSet, bool) {
+	return nil, false
+}
+
+func (r *matchRouter) RuleSets() map[string]adapter.RuleSet {
+	return nil
+}
+
+func (r *matchRouter) NeedWIFIState() bool {
+	return false
+}
+
+func (r *matchRouter) Exchange(ctx context.Context, message *mdns.Msg) (*mdns.Msg, error) {
+	return nil, errMatchRouterUnsupported
+}
+
+func (r *matchRouter) Lookup(ctx context.Context, domain string, strategy dns.DomainStrategy) ([]netip.Addr, error) {
+	return nil, errMatchRouterUnsupported
+}
+
+func (r *matchRouter) LookupDefault(ctx context.Context, domain string) ([]netip.Addr, error) {
+	return nil, errMatchRouterUnsupported
+}
+
+func (r *matchRouter) ClearDNSCache() {
+}
+
+func (r *matchRouter) FlushDNSCache() {
+}
+
+func (r *matchRouter) SetDNSQueryRecorder(recorder adapter.DNSQueryRecorder) {
+}
+
+func (r *matchRouter) InterfaceFinder() control.InterfaceFinder {
+	return nil
+}
+
+func (r *matchRouter) UpdateInterfaces() error {
+	return nil
+}
+
+func (r *matchRouter) DefaultInterface() string {
+	return ""
+}
+
+func (r *matchRouter) AutoDetectInterface() bool {
+	return false
+}
+
+func (r *matchRouter) AutoDetectInterfaceFunc() control.Func {
+	return nil
+}
+
+func (r *matchRouter) DefaultMark() int {
+	return 0
+}
+
+func (r *matchRouter) NetworkMonitor() tun.NetworkUpdateMonitor {
+	return nil
+}
+
+func (r *matchRouter) InterfaceMonitor() tun.DefaultInterfaceMonitor {
+	return nil
+}
+
+func (r *matchRouter) PackageManager() tun.PackageManager {
+	return nil
+}
+
+func (r *matchRouter) WIFIState() adapter.WIFIState {
+	return adapter.WIFIState{}
+}
+
+func (r *matchRouter) Rules() []adapter.Rule {
+	return nil
+}
+
+func (r *matchRouter) ClashServer() adapter.ClashServer {
+	return nil
+}
+
+func (r *matchRouter) SetClashServer(server adapter.ClashServer) {
+}
+
+func (r *matchRouter) AppendTracker(tracker adapter.ConnectionTracker) {
+}
+
+func (r *matchRouter) V2RayServer() adapter.V2RayServer {
+	return nil
+}
+
+func (r *matchRouter) SetV2RayServer(server adapter.V2RayServer) {
+}
+
+func (r *matchRouter) ResetNetwork() error {
+	return nil
+}
+
+func matchRuleSet(sourcePath string, address string) error {
+	plainRuleSet, err := readRuleSet(sourcePath)
+	if err != nil {
+		return err
+	}
+	destination := M.ParseSocksaddr(address)
+	if !destination.IsValid() {
+		return E.New("invalid address: ", address)
+	}
+	router := &matchRouter{}
+	var matched bool
+	for i, ruleOptions := range plainRuleSet.Rules {
+		rule, err := route.NewHeadlessRule(router, ruleOptions)
+		if err != nil {
+			return E.Cause(err, "parse rules.[", i, "]")
+		}
+		metadata := adapter.InboundContext{
+			Destination: destination,
+		}
+		if destination.IsFqdn() {
+			metadata.Domain = destination.Fqdn
+		}
+		if !rule.Match(&metadata) {
+			continue
+		}
+		matched = true
+		content, err := json.Marshal(ruleOptions)
+		if err != nil {
+			return err
+		}
+		os.Stdout.WriteString(F.ToString("rules.[", i, "]: ", string(content), "\n"))
+	}
+	if !matched {
+		return E.New("no rule matched: ", address)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"github.com/sagernet/sing-box/common/ruleset"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+
+	"github.com/spf13/cobra"
+)
+
+var commandRuleSetMerge = &cobra.Command{
+	Use:   "merge <output-path> [source-path]...",
+	Short: "Merge rule-sets",
+	Long: "Merge source or binary rule-sets, removing duplicated and redundant entries and aggregating IP prefixes.\n" +
+		"The output is written as binary rule-set if its name ends with .srs, otherwise as source.",
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := mergeRuleSets(args[0], args[1:])
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	commandRuleSet.AddCommand(commandRuleSetMerge)
+}
+
+func mergeRuleSets(outputPath string, sourcePaths []string) error {
+	ruleSets := make([]option.PlainRuleSet, 0, len(sourcePaths))
+	for _, sourcePath := range sourcePaths {
+		ruleSet, err := readRuleSet(sourcePath)
+		if err != nil {
+			return E.Cause(err, "read ", sourcePath)
+		}
+		ruleSets = append(ruleSets, ruleSet)
+	}
+	ruleSet, err := ruleset.Merge(ruleSets...)
+	if err != nil {
+		return err
+	}
+	return writeRuleSet(outputPath, ruleSet)
+}
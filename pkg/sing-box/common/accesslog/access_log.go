@@ -0,0 +1,143 @@
+package accesslog
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/experimental/clashapi/trafficontrol"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/json"
+	"github.com/sagernet/sing/common/logger"
+)
+
+const defaultOutput = "access.log"
+
+var (
+	_ adapter.Service             = (*AccessLog)(nil)
+	_ trafficontrol.CloseListener = (*AccessLog)(nil)
+)
+
+// AccessLog writes a JSON record for every finished routed connection,
+// as reported by the connection tracker of the traffic manager.
+type AccessLog struct {
+	logger       logger.ContextLogger
+	access       sync.Mutex
+	writer       io.Writer
+	rotateWriter *rotateWriter
+	closed       bool
+}
+
+type Record struct {
+	Time        time.Time `json:"time"`
+	Network     string    `json:"network"`
+	Inbound     string    `json:"inbound"`
+	InboundType string    `json:"inbound_type"`
+	User        string    `json:"user,omitempty"`
+	Source      string    `json:"source"`
+	Destination string    `json:"destination"`
+	Domain      string    `json:"domain,omitempty"`
+	Protocol    string    `json:"protocol,omitempty"`
+	Process     string    `json:"process,omitempty"`
+	Rule        string    `json:"rule"`
+	Chain       []string  `json:"chain"`
+	Upload      int64     `json:"upload"`
+	Download    int64     `json:"download"`
+	Duration    int64     `json:"duration"`
+	Error       string    `json:"error,omitempty"`
+}
+
+func New(ctx context.Context, logger logger.ContextLogger, options option.AccessLogOptions) *AccessLog {
+	accessLog := &AccessLog{
+		logger: logger,
+	}
+	switch options.Output {
+	case "stdout":
+		accessLog.writer = os.Stdout
+	case "stderr":
+		accessLog.writer = os.Stderr
+	default:
+		output := options.Output
+		if output == "" {
+			output = defaultOutput
+		}
+		maxBackups := options.MaxBackups
+		if maxBackups <= 0 {
+			maxBackups = 1
+		}
+		accessLog.rotateWriter = newRotateWriter(ctx, output, int64(options.MaxSize), maxBackups)
+		accessLog.writer = accessLog.rotateWriter
+	}
+	return accessLog
+}
+
+func (l *AccessLog) Start() error {
+	if l.rotateWriter != nil {
+		return l.rotateWriter.open()
+	}
+	return nil
+}
+
+func (l *AccessLog) Close() error {
+	l.access.Lock()
+	defer l.access.Unlock()
+	// connections may still be closed after the log, e.g. by the traffic manager
+	l.closed = true
+	return common.Close(common.PtrOrNil(l.rotateWriter))
+}
+
+func (l *AccessLog) ConnectionClosed(connection *trafficontrol.ClosedConnection, metadata adapter.InboundContext, matchedRule adapter.Rule, err error) {
+	record := &Record{
+		Time:        connection.End,
+		Network:     metadata.Network,
+		Inbound:     metadata.Inbound,
+		InboundType: metadata.InboundType,
+		User:        metadata.User,
+		Source:      metadata.Source.String(),
+		Destination: metadata.Destination.String(),
+		Domain:      metadata.Domain,
+		Protocol:    metadata.Protocol,
+		Upload:      connection.Upload,
+		Download:    connection.Download,
+		Duration:    connection.Duration,
+	}
+	if metadata.ProcessInfo != nil {
+		if metadata.ProcessInfo.ProcessPath != "" {
+			record.Process = metadata.ProcessInfo.ProcessPath
+		} else {
+			record.Process = metadata.ProcessInfo.PackageName
+		}
+	}
+	if matchedRule != nil {
+		record.Rule = matchedRule.String()
+	} else {
+		record.Rule = "final"
+	}
+	// the tracker stores the chain from the selected outbound to the routed one
+	record.Chain = common.Reverse(append([]string(nil), connection.Chain...))
+	if err != nil {
+		record.Error = err.Error()
+	}
+	l.write(record)
+}
+
+func (l *AccessLog) write(record *Record) {
+	content, err := json.Marshal(record)
+	if err != nil {
+		l.logger.Error("encode access log: ", err)
+		return
+	}
+	l.access.Lock()
+	defer l.access.Unlock()
+	if l.closed {
+		return
+	}
+	_, err = l.writer.Write(append(content, '\n'))
+	if err != nil {
+		l.logger.Error("write access log: ", err)
+	}
+}
@@ -0,0 +1,126 @@
+package accesslog
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/experimental/clashapi/trafficontrol"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/json"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testRouter struct {
+	adapter.Router
+	outbounds map[string]adapter.Outbound
+}
+
+func (r *testRouter) Outbound(tag string) (adapter.Outbound, bool) {
+	outbound, loaded := r.outbounds[tag]
+	return outbound, loaded
+}
+
+func (r *testRouter) DefaultOutbound(network string) (adapter.Outbound, error) {
+	return r.outbounds["default"], nil
+}
+
+type testOutbound struct {
+	adapter.Outbound
+	tag string
+}
+
+func (o *testOutbound) Tag() string {
+	return o.tag
+}
+
+type testGroup struct {
+	testOutbound
+	now string
+}
+
+func (g *testGroup) Now() string {
+	return g.now
+}
+
+func (g *testGroup) All() []string {
+	return []string{g.now}
+}
+
+func TestAccessLogRecord(t *testing.T) {
+	t.Parallel()
+	router := &testRouter{outbounds: map[string]adapter.Outbound{
+		"default": &testGroup{testOutbound: testOutbound{tag: "default"}, now: "proxy"},
+		"proxy":   &testOutbound{tag: "proxy"},
+	}}
+	manager := trafficontrol.NewManager(router, 0)
+	path := filepath.Join(t.TempDir(), "access.log")
+	accessLog := New(context.Background(), log.NewNOPFactory().Logger(), option.AccessLogOptions{Output: path})
+	require.NoError(t, accessLog.Start())
+	manager.AddCloseListener(accessLog)
+
+	client, server := net.Pipe()
+	defer server.Close()
+	conn, tracker := manager.RoutedConnection(context.Background(), client, adapter.InboundContext{
+		Inbound:     "mixed-in",
+		InboundType: "mixed",
+		Network:     N.NetworkTCP,
+		User:        "alice",
+		Source:      M.ParseSocksaddr("10.0.0.2:50000"),
+		Destination: M.ParseSocksaddr("example.com:443"),
+		Domain:      "example.com",
+	}, nil)
+	// the client sends two bytes and receives five
+	go server.Write([]byte("hi"))
+	_, err := io.ReadFull(conn, make([]byte, 2))
+	require.NoError(t, err)
+	go io.ReadFull(server, make([]byte, 5))
+	_, err = conn.Write([]byte("hello"))
+	require.NoError(t, err)
+	conn.Close()
+	tracker.Leave(E.New("connection reset"))
+	// a second leave is not recorded
+	tracker.Leave(nil)
+	require.NoError(t, accessLog.Close())
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var record Record
+	require.NoError(t, json.Unmarshal(content, &record))
+	require.Equal(t, "mixed-in", record.Inbound)
+	require.Equal(t, "alice", record.User)
+	require.Equal(t, "example.com:443", record.Destination)
+	require.Equal(t, "final", record.Rule)
+	require.Equal(t, []string{"default", "proxy"}, record.Chain)
+	require.Equal(t, int64(2), record.Upload)
+	require.Equal(t, int64(5), record.Download)
+	require.Equal(t, "connection reset", record.Error)
+}
+
+type closedWriter struct {
+	t *testing.T
+}
+
+func (w closedWriter) Write(p []byte) (int, error) {
+	w.t.Errorf("unexpected write after close: %s", p)
+	return len(p), nil
+}
+
+func TestAccessLogClosed(t *testing.T) {
+	t.Parallel()
+	accessLog := New(context.Background(), log.NewNOPFactory().Logger(), option.AccessLogOptions{Output: "stdout"})
+	require.NoError(t, accessLog.Start())
+	require.NoError(t, accessLog.Close())
+	// the traffic manager is closed after the log and may still report connections
+	accessLog.writer = closedWriter{t}
+	accessLog.ConnectionClosed(&trafficontrol.ClosedConnection{}, adapter.InboundContext{}, nil, nil)
+}
@@ -0,0 +1,90 @@
+package accesslog
+
+import (
+	"context"
+	"os"
+	"strconv"
+
+	"github.com/sagernet/sing/service/filemanager"
+)
+
+// rotateWriter appends to a file, moving it to path.1, path.2, ... once
+// it would grow beyond maxSize.
+type rotateWriter struct {
+	ctx        context.Context
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func newRotateWriter(ctx context.Context, path string, maxSize int64, maxBackups int) *rotateWriter {
+	return &rotateWriter{
+		ctx:        ctx,
+		path:       filemanager.BasePath(ctx, path),
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+}
+
+func (w *rotateWriter) open() error {
+	file, err := filemanager.OpenFile(w.ctx, w.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	fileInfo, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	w.file = file
+	w.size = fileInfo.Size()
+	return nil
+}
+
+func (w *rotateWriter) Write(p []byte) (int, error) {
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		err := w.rotate()
+		if err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *rotateWriter) rotate() error {
+	err := w.file.Close()
+	if err != nil {
+		return err
+	}
+	err = w.moveBackups()
+	openErr := w.open()
+	if openErr != nil {
+		return openErr
+	}
+	return err
+}
+
+func (w *rotateWriter) moveBackups() error {
+	for i := w.maxBackups - 1; i > 0; i-- {
+		err := os.Rename(w.backupPath(i), w.backupPath(i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Rename(w.path, w.backupPath(1))
+}
+
+func (w *rotateWriter) backupPath(index int) string {
+	return w.path + "." + strconv.Itoa(index)
+}
+
+func (w *rotateWriter) Close() error {
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Close()
+}
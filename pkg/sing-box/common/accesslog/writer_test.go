@@ -0,0 +1,33 @@
+package accesslog
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotateWriter(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "access.log")
+	writer := newRotateWriter(context.Background(), path, 8, 2)
+	require.NoError(t, writer.open())
+	defer writer.Close()
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err := writer.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	for name, content := range map[string]string{
+		path:        "fourth\n",
+		path + ".1": "third\n",
+		path + ".2": "second\n",
+	} {
+		data, err := os.ReadFile(name)
+		require.NoError(t, err)
+		require.Equal(t, content, string(data))
+	}
+	_, err := os.Stat(path + ".3")
+	require.True(t, os.IsNotExist(err))
+}
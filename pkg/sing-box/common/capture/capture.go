@@ -0,0 +1,335 @@
+package capture
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/humanize"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/outbound"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/atomic"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	"github.com/sagernet/sing/common/logger"
+	N "github.com/sagernet/sing/common/network"
+)
+
+const (
+	defaultMaxSize  = 10 * 1024 * 1024
+	maxMaxSize      = 256 * 1024 * 1024
+	defaultDuration = time.Minute
+)
+
+var _ adapter.ConnectionTracker = (*Manager)(nil)
+
+// Options selects what a capture records. Raw TUN packets are recorded if
+// TUN is set, plaintext streams are recorded for connections matching one
+// of Rules (indexes of route rules) or Outbounds.
+type Options struct {
+	TUN       bool               `json:"tun,omitempty"`
+	CIDR      []netip.Prefix     `json:"cidr,omitempty"`
+	Port      []uint16           `json:"port,omitempty"`
+	Rules     []int              `json:"rules,omitempty"`
+	Outbounds []string           `json:"outbounds,omitempty"`
+	MaxSize   option.MemoryBytes `json:"max_size,omitempty"`
+	Duration  option.Duration    `json:"duration,omitempty"`
+}
+
+type Status struct {
+	Running   bool       `json:"running"`
+	Options   Options    `json:"options"`
+	Warning   string     `json:"warning,omitempty"`
+	StartedAt time.Time  `json:"started_at"`
+	StoppedAt *time.Time `json:"stopped_at,omitempty"`
+	Reason    string     `json:"reason,omitempty"`
+	Packets   uint64     `json:"packets"`
+	Size      int        `json:"size"`
+}
+
+// Manager runs one capture at a time, keeping the last one in memory
+// until the next is started.
+type Manager struct {
+	router  adapter.Router
+	logger  logger.Logger
+	access  sync.Mutex
+	current *session
+	active  atomic.Pointer[session]
+
+	// TUN inbounds whose packets can be recorded, and tags of those which can not
+	tuns            int
+	unsupportedTuns []string
+}
+
+func NewManager(router adapter.Router, logger logger.Logger) *Manager {
+	return &Manager{
+		router: router,
+		logger: logger,
+	}
+}
+
+func (m *Manager) Start(options Options) error {
+	if !options.TUN && len(options.Rules) == 0 && len(options.Outbounds) == 0 {
+		return E.New("missing tun, rules or outbounds to capture")
+	}
+	if options.MaxSize > maxMaxSize {
+		return E.New("max_size exceeds ", humanize.MemoryBytes(maxMaxSize))
+	}
+	rules := m.router.Rules()
+	s := &session{
+		manager:   m,
+		options:   options,
+		startedAt: time.Now(),
+		maxSize:   int(options.MaxSize),
+	}
+	for _, index := range options.Rules {
+		if index < 0 || index >= len(rules) {
+			return E.New("rule index ", index, " out of range")
+		}
+		s.rules = append(s.rules, rules[index])
+	}
+	for _, tag := range options.Outbounds {
+		if _, loaded := m.router.Outbound(tag); !loaded {
+			return E.New("outbound not found: ", tag)
+		}
+	}
+	if s.maxSize == 0 {
+		s.maxSize = defaultMaxSize
+	}
+	duration := time.Duration(options.Duration)
+	if duration == 0 {
+		duration = defaultDuration
+	}
+	m.access.Lock()
+	defer m.access.Unlock()
+	if options.TUN {
+		if m.tuns == 0 && len(m.unsupportedTuns) > 0 {
+			return E.New("tun capture is not supported by the gvisor stack of inbound ", strings.Join(m.unsupportedTuns, ", "))
+		} else if m.tuns == 0 {
+			return E.New("missing tun inbound to capture")
+		} else if len(m.unsupportedTuns) > 0 {
+			s.warning = "packets of inbound " + strings.Join(m.unsupportedTuns, ", ") + " are not recorded with the gvisor stack"
+		}
+	}
+	if !m.active.CompareAndSwap(nil, s) {
+		return E.New("capture already running")
+	}
+	m.current = s
+	s.access.Lock()
+	s.timer = time.AfterFunc(duration, func() {
+		s.stop("time limit reached")
+	})
+	s.access.Unlock()
+	m.logger.Info("capture started")
+	return nil
+}
+
+func (m *Manager) Stop() {
+	if s := m.active.Load(); s != nil {
+		s.stop("stopped")
+	}
+}
+
+// Status returns the state of the running or last capture, or nil if none.
+func (m *Manager) Status() *Status {
+	m.access.Lock()
+	s := m.current
+	m.access.Unlock()
+	if s == nil {
+		return nil
+	}
+	return s.status()
+}
+
+// Data returns the pcapng file of the running or last capture, or nil if none.
+func (m *Manager) Data() []byte {
+	m.access.Lock()
+	s := m.current
+	m.access.Unlock()
+	if s == nil {
+		return nil
+	}
+	s.access.Lock()
+	defer s.access.Unlock()
+	return append(fileHeader(), s.data...)
+}
+
+func (m *Manager) RoutedConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, matchedRule adapter.Rule) (net.Conn, adapter.Tracker) {
+	s := m.active.Load()
+	if s == nil || !s.matchStream(ctx, matchedRule) {
+		return conn, nopTracker{}
+	}
+	streamConn := newStreamConn(conn, s, metadata, streamComment(ctx, metadata, matchedRule))
+	return streamConn, streamConn
+}
+
+func (m *Manager) RoutedPacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext, matchedRule adapter.Rule) (N.PacketConn, adapter.Tracker) {
+	s := m.active.Load()
+	if s == nil || !s.matchStream(ctx, matchedRule) {
+		return conn, nopTracker{}
+	}
+	return newStreamPacketConn(conn, s, metadata, streamComment(ctx, metadata, matchedRule)), nopTracker{}
+}
+
+// capturePacket records a raw TUN packet if a capture is running.
+func (m *Manager) capturePacket(packet []byte) {
+	s := m.active.Load()
+	if s == nil || !s.options.TUN || !s.matchPacket(packet) {
+		return
+	}
+	s.write(interfaceTUN, packet, "")
+}
+
+func streamComment(ctx context.Context, metadata adapter.InboundContext, matchedRule adapter.Rule) string {
+	var rule string
+	if matchedRule != nil {
+		rule = matchedRule.String()
+	} else {
+		rule = "final"
+	}
+	outboundTag, _ := outbound.TagFromContext(ctx)
+	return F.ToString(metadata.Network, " ", metadata.Source, " => ", metadata.Destination, ", inbound: ", metadata.Inbound, ", rule: ", rule, ", outbound: ", outboundTag)
+}
+
+type nopTracker struct{}
+
+func (nopTracker) Leave(err error) {
+}
+
+type session struct {
+	manager   *Manager
+	options   Options
+	rules     []adapter.Rule
+	startedAt time.Time
+	maxSize   int
+	warning   string
+	timer     *time.Timer
+
+	access     sync.Mutex
+	data       []byte
+	packets    uint64
+	stoppedAt  time.Time
+	stopReason string
+}
+
+func (s *session) matchStream(ctx context.Context, matchedRule adapter.Rule) bool {
+	if matchedRule != nil && common.Contains(s.rules, matchedRule) {
+		return true
+	}
+	if len(s.options.Outbounds) == 0 {
+		return false
+	}
+	outboundTag, loaded := outbound.TagFromContext(ctx)
+	if !loaded {
+		return false
+	}
+	if common.Contains(s.options.Outbounds, outboundTag) {
+		return true
+	}
+	detour, loaded := s.manager.router.Outbound(outboundTag)
+	return loaded && common.Contains(s.options.Outbounds, adapter.OutboundTag(detour))
+}
+
+func (s *session) matchPacket(packet []byte) bool {
+	if len(s.options.CIDR) == 0 && len(s.options.Port) == 0 {
+		return true
+	}
+	var (
+		source      netip.Addr
+		destination netip.Addr
+		protocol    byte
+		transport   []byte
+	)
+	switch {
+	case len(packet) >= 20 && packet[0]>>4 == 4:
+		source = netip.AddrFrom4([4]byte(packet[12:16]))
+		destination = netip.AddrFrom4([4]byte(packet[16:20]))
+		protocol = packet[9]
+		headerLength := int(packet[0]&0x0F) * 4
+		if headerLength <= len(packet) {
+			transport = packet[headerLength:]
+		}
+	case len(packet) >= 40 && packet[0]>>4 == 6:
+		source = netip.AddrFrom16([16]byte(packet[8:24]))
+		destination = netip.AddrFrom16([16]byte(packet[24:40]))
+		protocol = packet[6]
+		transport = packet[40:]
+	default:
+		return false
+	}
+	if len(s.options.CIDR) > 0 && !common.Any(s.options.CIDR, func(prefix netip.Prefix) bool {
+		return prefix.Contains(source) || prefix.Contains(destination)
+	}) {
+		return false
+	}
+	if len(s.options.Port) > 0 {
+		if protocol != protocolTCP && protocol != protocolUDP || len(transport) < 4 {
+			return false
+		}
+		sourcePort := binary.BigEndian.Uint16(transport[0:2])
+		destinationPort := binary.BigEndian.Uint16(transport[2:4])
+		if !common.Contains(s.options.Port, sourcePort) && !common.Contains(s.options.Port, destinationPort) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *session) write(interfaceID uint32, packet []byte, comment string) {
+	block := appendPacket(nil, interfaceID, time.Now(), packet, comment)
+	s.access.Lock()
+	if !s.stoppedAt.IsZero() {
+		s.access.Unlock()
+		return
+	}
+	if len(s.data)+len(block) > s.maxSize {
+		s.access.Unlock()
+		s.stop("size limit reached")
+		return
+	}
+	s.data = append(s.data, block...)
+	s.packets++
+	s.access.Unlock()
+}
+
+func (s *session) stop(reason string) {
+	s.access.Lock()
+	if !s.stoppedAt.IsZero() {
+		s.access.Unlock()
+		return
+	}
+	s.stoppedAt = time.Now()
+	s.stopReason = reason
+	timer := s.timer
+	s.access.Unlock()
+	if timer != nil {
+		timer.Stop()
+	}
+	s.manager.active.CompareAndSwap(s, nil)
+	s.manager.logger.Info("capture ", reason)
+}
+
+func (s *session) status() *Status {
+	s.access.Lock()
+	defer s.access.Unlock()
+	status := &Status{
+		Running:   s.stoppedAt.IsZero(),
+		Options:   s.options,
+		Warning:   s.warning,
+		StartedAt: s.startedAt,
+		Reason:    s.stopReason,
+		Packets:   s.packets,
+		Size:      len(s.data),
+	}
+	if !s.stoppedAt.IsZero() {
+		stoppedAt := s.stoppedAt
+		status.StoppedAt = &stoppedAt
+	}
+	return status
+}
@@ -0,0 +1,158 @@
+package capture
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildTCPChecksum(t *testing.T) {
+	t.Parallel()
+	for _, pair := range [][2]string{
+		{"10.0.0.1:1234", "10.0.0.2:80"},
+		{"[fd00::1]:1234", "[fd00::2]:80"},
+	} {
+		source, destination := netip.MustParseAddrPort(pair[0]), netip.MustParseAddrPort(pair[1])
+		packet := buildTCP(source, destination, 1, 1, tcpFlagPSH|tcpFlagACK, []byte("hello"))
+		var segment []byte
+		if source.Addr().Is4() {
+			require.Zero(t, ^uint16(foldChecksum(sum(packet[:20], 0))))
+			require.Equal(t, len(packet), int(binary.BigEndian.Uint16(packet[2:])))
+			segment = packet[20:]
+		} else {
+			segment = packet[40:]
+		}
+		require.Zero(t, transportChecksum(source.Addr(), destination.Addr(), protocolTCP, segment))
+		require.Equal(t, []byte("hello"), segment[20:])
+	}
+}
+
+func TestSessionSizeLimit(t *testing.T) {
+	t.Parallel()
+	manager := &Manager{logger: logger.NOP()}
+	s := &session{manager: manager, maxSize: 256}
+	manager.active.Store(s)
+	s.write(interfaceStream, make([]byte, 64), "")
+	require.Equal(t, uint64(1), s.status().Packets)
+	s.write(interfaceStream, make([]byte, 256), "")
+	status := s.status()
+	require.False(t, status.Running)
+	require.Equal(t, "size limit reached", status.Reason)
+	require.Equal(t, uint64(1), status.Packets)
+	require.Nil(t, manager.active.Load())
+}
+
+type testRouter struct {
+	adapter.Router
+}
+
+func (r *testRouter) Rules() []adapter.Rule {
+	return nil
+}
+
+func TestManagerStartTUN(t *testing.T) {
+	t.Parallel()
+	manager := NewManager(&testRouter{}, logger.NOP())
+	require.ErrorContains(t, manager.Start(Options{TUN: true}), "missing tun inbound")
+	manager.AddUnsupportedTun("tun-in")
+	require.ErrorContains(t, manager.Start(Options{TUN: true}), "gvisor stack of inbound tun-in")
+	require.ErrorContains(t, manager.Start(Options{TUN: true, MaxSize: option.MemoryBytes(maxMaxSize + 1)}), "max_size")
+	manager.tuns++
+	require.NoError(t, manager.Start(Options{TUN: true}))
+	defer manager.Stop()
+	require.Contains(t, manager.Status().Warning, "tun-in")
+}
+
+func TestSessionMatchPacket(t *testing.T) {
+	t.Parallel()
+	tcp4 := buildTCP(netip.MustParseAddrPort("10.0.0.1:1234"), netip.MustParseAddrPort("1.1.1.1:443"), 0, 0, tcpFlagSYN, nil)
+	udp6 := buildUDP(netip.MustParseAddrPort("[fd00::1]:1234"), netip.MustParseAddrPort("[2606:4700::1111]:53"), []byte("query"))
+	for _, testCase := range []struct {
+		options Options
+		tcp4    bool
+		udp6    bool
+	}{
+		{Options{}, true, true},
+		{Options{CIDR: []netip.Prefix{netip.MustParsePrefix("1.1.1.0/24")}}, true, false},
+		{Options{CIDR: []netip.Prefix{netip.MustParsePrefix("fd00::/8")}}, false, true},
+		{Options{Port: []uint16{443}}, true, false},
+		{Options{Port: []uint16{1234}}, true, true},
+		{Options{CIDR: []netip.Prefix{netip.MustParsePrefix("1.1.1.0/24")}, Port: []uint16{53}}, false, false},
+	} {
+		s := &session{options: testCase.options}
+		require.Equal(t, testCase.tcp4, s.matchPacket(tcp4), testCase.options)
+		require.Equal(t, testCase.udp6, s.matchPacket(udp6), testCase.options)
+	}
+	s := &session{options: Options{Port: []uint16{443}}}
+	require.False(t, s.matchPacket(tcp4[:22]))
+	require.False(t, s.matchPacket([]byte{0x45}))
+}
+
+type testSegment struct {
+	sourcePort uint16
+	seq        uint32
+	ack        uint32
+	flags      uint8
+	payload    string
+}
+
+// readSegments parses the IPv4 TCP segments of captured packet blocks.
+func readSegments(t *testing.T, data []byte) []testSegment {
+	var segments []testSegment
+	for len(data) > 0 {
+		length := binary.LittleEndian.Uint32(data[4:8])
+		body := data[8 : length-4]
+		packet := body[20 : 20+binary.LittleEndian.Uint32(body[12:16])]
+		require.Equal(t, byte(protocolTCP), packet[9])
+		segment := packet[20:]
+		segments = append(segments, testSegment{
+			sourcePort: binary.BigEndian.Uint16(segment[0:2]),
+			seq:        binary.BigEndian.Uint32(segment[4:8]),
+			ack:        binary.BigEndian.Uint32(segment[8:12]),
+			flags:      segment[13],
+			payload:    string(segment[20:]),
+		})
+		data = data[length:]
+	}
+	return segments
+}
+
+func TestStreamConnSequence(t *testing.T) {
+	t.Parallel()
+	manager := &Manager{logger: logger.NOP()}
+	s := &session{manager: manager, maxSize: defaultMaxSize}
+	manager.active.Store(s)
+	client, server := net.Pipe()
+	defer server.Close()
+	conn := newStreamConn(client, s, adapter.InboundContext{
+		Source:      M.ParseSocksaddr("10.0.0.1:1234"),
+		Destination: M.ParseSocksaddr("10.0.0.2:80"),
+	}, "")
+	go server.Write([]byte("hello"))
+	_, err := io.ReadFull(conn, make([]byte, 5))
+	require.NoError(t, err)
+	go io.ReadFull(server, make([]byte, 2))
+	_, err = conn.Write([]byte("hi"))
+	require.NoError(t, err)
+	conn.Leave(nil)
+	conn.Leave(nil)
+	require.Equal(t, []testSegment{
+		{1234, 0, 0, tcpFlagSYN, ""},
+		{80, 0, 1, tcpFlagSYN | tcpFlagACK, ""},
+		{1234, 1, 1, tcpFlagACK, ""},
+		{1234, 1, 1, tcpFlagPSH | tcpFlagACK, "hello"},
+		{80, 1, 6, tcpFlagPSH | tcpFlagACK, "hi"},
+		{1234, 6, 3, tcpFlagFIN | tcpFlagACK, ""},
+		{80, 3, 7, tcpFlagFIN | tcpFlagACK, ""},
+		{1234, 7, 4, tcpFlagACK, ""},
+	}, readSegments(t, s.data))
+}
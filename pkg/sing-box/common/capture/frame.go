@@ -0,0 +1,117 @@
+package capture
+
+import (
+	"encoding/binary"
+	"net/netip"
+)
+
+const (
+	protocolTCP = 6
+	protocolUDP = 17
+
+	tcpFlagFIN = 0x01
+	tcpFlagSYN = 0x02
+	tcpFlagPSH = 0x08
+	tcpFlagACK = 0x10
+
+	// maxSegmentSize keeps synthesized packets within the IPv4 total length.
+	maxSegmentSize = 65000
+)
+
+// frameAddrs returns addresses of the same family for a synthesized frame,
+// using the unspecified address if unknown, e.g. for domain destinations.
+func frameAddrs(source netip.Addr, destination netip.Addr) (netip.Addr, netip.Addr) {
+	source, destination = source.Unmap(), destination.Unmap()
+	if !source.IsValid() {
+		source = netip.IPv4Unspecified()
+	}
+	if !destination.IsValid() {
+		destination = netip.IPv4Unspecified()
+	}
+	if source.Is4() != destination.Is4() {
+		source = netip.AddrFrom16(source.As16())
+		destination = netip.AddrFrom16(destination.As16())
+	}
+	return source, destination
+}
+
+func buildTCP(source netip.AddrPort, destination netip.AddrPort, seq uint32, ack uint32, flags uint8, payload []byte) []byte {
+	segment := make([]byte, 20, 20+len(payload))
+	binary.BigEndian.PutUint16(segment[0:], source.Port())
+	binary.BigEndian.PutUint16(segment[2:], destination.Port())
+	binary.BigEndian.PutUint32(segment[4:], seq)
+	binary.BigEndian.PutUint32(segment[8:], ack)
+	segment[12] = 5 << 4
+	segment[13] = flags
+	binary.BigEndian.PutUint16(segment[14:], 65535)
+	segment = append(segment, payload...)
+	binary.BigEndian.PutUint16(segment[16:], transportChecksum(source.Addr(), destination.Addr(), protocolTCP, segment))
+	return buildIP(source.Addr(), destination.Addr(), protocolTCP, segment)
+}
+
+func buildUDP(source netip.AddrPort, destination netip.AddrPort, payload []byte) []byte {
+	datagram := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint16(datagram[0:], source.Port())
+	binary.BigEndian.PutUint16(datagram[2:], destination.Port())
+	binary.BigEndian.PutUint16(datagram[4:], uint16(8+len(payload)))
+	datagram = append(datagram, payload...)
+	checksum := transportChecksum(source.Addr(), destination.Addr(), protocolUDP, datagram)
+	if checksum == 0 {
+		checksum = 0xFFFF
+	}
+	binary.BigEndian.PutUint16(datagram[6:], checksum)
+	return buildIP(source.Addr(), destination.Addr(), protocolUDP, datagram)
+}
+
+func buildIP(source netip.Addr, destination netip.Addr, protocol uint8, payload []byte) []byte {
+	var packet []byte
+	if source.Is4() {
+		packet = make([]byte, 20, 20+len(payload))
+		packet[0] = 0x45
+		binary.BigEndian.PutUint16(packet[2:], uint16(20+len(payload)))
+		binary.BigEndian.PutUint16(packet[6:], 0x4000)
+		packet[8] = 64
+		packet[9] = protocol
+		sourceBytes, destinationBytes := source.As4(), destination.As4()
+		copy(packet[12:], sourceBytes[:])
+		copy(packet[16:], destinationBytes[:])
+		binary.BigEndian.PutUint16(packet[10:], ^uint16(foldChecksum(sum(packet, 0))))
+	} else {
+		packet = make([]byte, 40, 40+len(payload))
+		packet[0] = 0x60
+		binary.BigEndian.PutUint16(packet[4:], uint16(len(payload)))
+		packet[6] = protocol
+		packet[7] = 64
+		sourceBytes, destinationBytes := source.As16(), destination.As16()
+		copy(packet[8:], sourceBytes[:])
+		copy(packet[24:], destinationBytes[:])
+	}
+	return append(packet, payload...)
+}
+
+func transportChecksum(source netip.Addr, destination netip.Addr, protocol uint8, payload []byte) uint16 {
+	var pseudoHeader []byte
+	pseudoHeader = append(pseudoHeader, source.AsSlice()...)
+	pseudoHeader = append(pseudoHeader, destination.AsSlice()...)
+	pseudoHeader = binary.BigEndian.AppendUint32(pseudoHeader, uint32(len(payload)))
+	pseudoHeader = binary.BigEndian.AppendUint32(pseudoHeader, uint32(protocol))
+	return ^uint16(foldChecksum(sum(payload, sum(pseudoHeader, 0))))
+}
+
+func sum(data []byte, initial uint32) uint32 {
+	for len(data) >= 2 {
+		initial += uint32(binary.BigEndian.Uint16(data))
+		data = data[2:]
+	}
+	if len(data) == 1 {
+		initial += uint32(data[0]) << 8
+	}
+	return initial
+}
+
+func foldChecksum(sum uint32) uint32 {
+	for sum>>16 != 0 {
+		sum = sum&0xFFFF + sum>>16
+	}
+	return sum
+}
@@ -0,0 +1,97 @@
+package capture
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// pcapng block types and options, see
+// https://www.ietf.org/archive/id/draft-tuexen-opsawg-pcapng-05.html
+const (
+	blockTypeSectionHeader        = 0x0A0D0D0A
+	blockTypeInterfaceDescription = 0x00000001
+	blockTypeEnhancedPacket       = 0x00000006
+
+	byteOrderMagic = 0x1A2B3C4D
+	linkTypeRaw    = 101
+
+	optionEndOfOpt = 0
+	optionComment  = 1
+	optionIfName   = 2
+)
+
+const (
+	interfaceTUN = iota
+	interfaceStream
+)
+
+func appendUint16(b []byte, v uint16) []byte {
+	return binary.LittleEndian.AppendUint16(b, v)
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return binary.LittleEndian.AppendUint32(b, v)
+}
+
+func appendPadding(b []byte, n int) []byte {
+	for n%4 != 0 {
+		b = append(b, 0)
+		n++
+	}
+	return b
+}
+
+func appendOption(b []byte, code uint16, value string) []byte {
+	b = appendUint16(b, code)
+	b = appendUint16(b, uint16(len(value)))
+	b = append(b, value...)
+	return appendPadding(b, len(value))
+}
+
+// appendBlock wraps a block body with its type and total length.
+func appendBlock(b []byte, blockType uint32, body []byte) []byte {
+	length := uint32(12 + len(body))
+	b = appendUint32(b, blockType)
+	b = appendUint32(b, length)
+	b = append(b, body...)
+	return appendUint32(b, length)
+}
+
+// fileHeader returns a section header followed by the descriptions of the
+// TUN and stream interfaces, both carrying raw IP packets.
+func fileHeader() []byte {
+	var header []byte
+	var body []byte
+	body = appendUint32(body, byteOrderMagic)
+	body = appendUint16(body, 1)
+	body = appendUint16(body, 0)
+	body = binary.LittleEndian.AppendUint64(body, 0xFFFFFFFFFFFFFFFF)
+	header = appendBlock(header, blockTypeSectionHeader, body)
+	for _, name := range []string{"tun", "stream"} {
+		body = body[:0]
+		body = appendUint16(body, linkTypeRaw)
+		body = appendUint16(body, 0)
+		body = appendUint32(body, 0)
+		body = appendOption(body, optionIfName, name)
+		body = appendUint32(body, optionEndOfOpt)
+		header = appendBlock(header, blockTypeInterfaceDescription, body)
+	}
+	return header
+}
+
+func appendPacket(b []byte, interfaceID uint32, timestamp time.Time, packet []byte, comment string) []byte {
+	var body []byte
+	micros := uint64(timestamp.UnixMicro())
+	body = appendUint32(body, interfaceID)
+	body = appendUint32(body, uint32(micros>>32))
+	body = appendUint32(body, uint32(micros))
+	body = appendUint32(body, uint32(len(packet)))
+	body = appendUint32(body, uint32(len(packet)))
+	body = append(body, packet...)
+	body = appendPadding(body, len(packet))
+	if comment != "" {
+		body = appendOption(body, optionComment, comment)
+		body = appendUint32(body, optionEndOfOpt)
+	}
+	return appendBlock(b, blockTypeEnhancedPacket, body)
+}
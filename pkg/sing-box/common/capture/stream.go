@@ -0,0 +1,157 @@
+package capture
+
+import (
+	"net"
+	"net/netip"
+	"sync"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing/common/buf"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+// streamConn records the plaintext of a routed TCP connection as
+// synthesized TCP segments, with a handshake before the first read and
+// FIN segments when routing finishes.
+type streamConn struct {
+	net.Conn
+	session     *session
+	source      netip.AddrPort
+	destination netip.AddrPort
+	access      sync.Mutex
+	clientSeq   uint32
+	serverSeq   uint32
+	finished    bool
+}
+
+func newStreamConn(conn net.Conn, s *session, metadata adapter.InboundContext, comment string) *streamConn {
+	source, destination := frameAddrs(metadata.Source.Addr, destinationAddr(metadata))
+	c := &streamConn{
+		Conn:        conn,
+		session:     s,
+		source:      netip.AddrPortFrom(source, metadata.Source.Port),
+		destination: netip.AddrPortFrom(destination, metadata.Destination.Port),
+		clientSeq:   1,
+		serverSeq:   1,
+	}
+	s.write(interfaceStream, buildTCP(c.source, c.destination, 0, 0, tcpFlagSYN, nil), comment)
+	s.write(interfaceStream, buildTCP(c.destination, c.source, 0, 1, tcpFlagSYN|tcpFlagACK, nil), "")
+	s.write(interfaceStream, buildTCP(c.source, c.destination, 1, 1, tcpFlagACK, nil), "")
+	return c
+}
+
+func (c *streamConn) Read(p []byte) (n int, err error) {
+	n, err = c.Conn.Read(p)
+	if n > 0 {
+		c.writeSegments(true, p[:n])
+	}
+	return
+}
+
+func (c *streamConn) Write(p []byte) (n int, err error) {
+	n, err = c.Conn.Write(p)
+	if n > 0 {
+		c.writeSegments(false, p[:n])
+	}
+	return
+}
+
+func (c *streamConn) writeSegments(upload bool, payload []byte) {
+	c.access.Lock()
+	defer c.access.Unlock()
+	for len(payload) > 0 {
+		segment := payload
+		if len(segment) > maxSegmentSize {
+			segment = segment[:maxSegmentSize]
+		}
+		payload = payload[len(segment):]
+		if upload {
+			c.session.write(interfaceStream, buildTCP(c.source, c.destination, c.clientSeq, c.serverSeq, tcpFlagPSH|tcpFlagACK, segment), "")
+			c.clientSeq += uint32(len(segment))
+		} else {
+			c.session.write(interfaceStream, buildTCP(c.destination, c.source, c.serverSeq, c.clientSeq, tcpFlagPSH|tcpFlagACK, segment), "")
+			c.serverSeq += uint32(len(segment))
+		}
+	}
+}
+
+func (c *streamConn) Leave(err error) {
+	c.access.Lock()
+	defer c.access.Unlock()
+	if c.finished {
+		return
+	}
+	c.finished = true
+	var comment string
+	if err != nil {
+		comment = err.Error()
+	}
+	c.session.write(interfaceStream, buildTCP(c.source, c.destination, c.clientSeq, c.serverSeq, tcpFlagFIN|tcpFlagACK, nil), comment)
+	c.session.write(interfaceStream, buildTCP(c.destination, c.source, c.serverSeq, c.clientSeq+1, tcpFlagFIN|tcpFlagACK, nil), "")
+	c.session.write(interfaceStream, buildTCP(c.source, c.destination, c.clientSeq+1, c.serverSeq+1, tcpFlagACK, nil), "")
+}
+
+// streamPacketConn records the plaintext of a routed UDP connection as
+// synthesized UDP datagrams.
+type streamPacketConn struct {
+	N.PacketConn
+	session     *session
+	source      netip.AddrPort
+	destination netip.Addr
+	access      sync.Mutex
+	comment     string
+}
+
+func newStreamPacketConn(conn N.PacketConn, s *session, metadata adapter.InboundContext, comment string) *streamPacketConn {
+	return &streamPacketConn{
+		PacketConn:  conn,
+		session:     s,
+		source:      netip.AddrPortFrom(metadata.Source.Addr, metadata.Source.Port),
+		destination: destinationAddr(metadata),
+		comment:     comment,
+	}
+}
+
+func (c *streamPacketConn) ReadPacket(buffer *buf.Buffer) (destination M.Socksaddr, err error) {
+	destination, err = c.PacketConn.ReadPacket(buffer)
+	if err == nil {
+		source, remote := c.frameAddrs(destination)
+		c.session.write(interfaceStream, buildUDP(source, remote, buffer.Bytes()), c.loadComment())
+	}
+	return
+}
+
+func (c *streamPacketConn) WritePacket(buffer *buf.Buffer, destination M.Socksaddr) error {
+	source, remote := c.frameAddrs(destination)
+	c.session.write(interfaceStream, buildUDP(remote, source, buffer.Bytes()), c.loadComment())
+	return c.PacketConn.WritePacket(buffer, destination)
+}
+
+func (c *streamPacketConn) frameAddrs(destination M.Socksaddr) (netip.AddrPort, netip.AddrPort) {
+	remoteAddr := c.destination
+	if destination.IsIP() {
+		remoteAddr = destination.Addr
+	}
+	sourceAddr, remoteAddr := frameAddrs(c.source.Addr(), remoteAddr)
+	return netip.AddrPortFrom(sourceAddr, c.source.Port()), netip.AddrPortFrom(remoteAddr, destination.Port)
+}
+
+// loadComment returns the connection comment for the first datagram only.
+func (c *streamPacketConn) loadComment() string {
+	c.access.Lock()
+	defer c.access.Unlock()
+	comment := c.comment
+	c.comment = ""
+	return comment
+}
+
+func destinationAddr(metadata adapter.InboundContext) netip.Addr {
+	if metadata.Destination.IsIP() {
+		return metadata.Destination.Addr
+	}
+	if len(metadata.DestinationAddresses) > 0 {
+		return metadata.DestinationAddresses[0]
+	}
+	return netip.Addr{}
+}
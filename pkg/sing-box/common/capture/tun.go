@@ -0,0 +1,108 @@
+package capture
+
+import (
+	"github.com/sagernet/sing-tun"
+	"github.com/sagernet/sing/common/buf"
+)
+
+// WrapTun records packets read from and written to the TUN interface.
+// The wrapper keeps the batch and Windows interfaces used by the system
+// and mixed stacks, the gVisor stack reads the interface directly and is
+// not supported, see AddUnsupportedTun.
+func (m *Manager) WrapTun(tunInterface tun.Tun) tun.Tun {
+	m.access.Lock()
+	m.tuns++
+	m.access.Unlock()
+	captureTun := &captureTun{Tun: tunInterface, manager: m}
+	switch tunInterface := tunInterface.(type) {
+	case tun.LinuxTUN:
+		return &captureLinuxTUN{captureTun, tunInterface}
+	case tun.WinTun:
+		return &captureWinTun{captureTun, tunInterface}
+	default:
+		return captureTun
+	}
+}
+
+// AddUnsupportedTun records a TUN inbound whose packets can not be captured,
+// so tun captures are rejected or warned about.
+func (m *Manager) AddUnsupportedTun(tag string) {
+	m.access.Lock()
+	m.unsupportedTuns = append(m.unsupportedTuns, tag)
+	m.access.Unlock()
+}
+
+type captureTun struct {
+	tun.Tun
+	manager *Manager
+}
+
+func (t *captureTun) Read(p []byte) (n int, err error) {
+	n, err = t.Tun.Read(p)
+	if n > tun.PacketOffset {
+		t.manager.capturePacket(p[tun.PacketOffset:n])
+	}
+	return
+}
+
+func (t *captureTun) Write(p []byte) (n int, err error) {
+	if len(p) > tun.PacketOffset {
+		t.manager.capturePacket(p[tun.PacketOffset:])
+	}
+	return t.Tun.Write(p)
+}
+
+func (t *captureTun) WriteVectorised(buffers []*buf.Buffer) error {
+	if t.manager.active.Load() != nil {
+		packet := buf.Get(buf.LenMulti(buffers))
+		buf.CopyMulti(packet, buffers)
+		t.manager.capturePacket(packet)
+		buf.Put(packet)
+	}
+	return t.Tun.WriteVectorised(buffers)
+}
+
+type captureLinuxTUN struct {
+	*captureTun
+	linuxTUN tun.LinuxTUN
+}
+
+func (t *captureLinuxTUN) FrontHeadroom() int {
+	return t.linuxTUN.FrontHeadroom()
+}
+
+func (t *captureLinuxTUN) BatchSize() int {
+	return t.linuxTUN.BatchSize()
+}
+
+func (t *captureLinuxTUN) BatchRead(buffers [][]byte, offset int, readN []int) (n int, err error) {
+	n, err = t.linuxTUN.BatchRead(buffers, offset, readN)
+	for i := 0; i < n; i++ {
+		t.manager.capturePacket(buffers[i][offset : offset+readN[i]])
+	}
+	return
+}
+
+func (t *captureLinuxTUN) BatchWrite(buffers [][]byte, offset int) error {
+	for _, buffer := range buffers {
+		t.manager.capturePacket(buffer[offset:])
+	}
+	return t.linuxTUN.BatchWrite(buffers, offset)
+}
+
+func (t *captureLinuxTUN) TXChecksumOffload() bool {
+	return t.linuxTUN.TXChecksumOffload()
+}
+
+type captureWinTun struct {
+	*captureTun
+	winTun tun.WinTun
+}
+
+func (t *captureWinTun) ReadPacket() ([]byte, func(), error) {
+	packet, release, err := t.winTun.ReadPacket()
+	if err == nil {
+		t.manager.capturePacket(packet)
+	}
+	return packet, release, err
+}
@@ -0,0 +1,26 @@
+package dialer
+
+import (
+	"context"
+
+	N "github.com/sagernet/sing/common/network"
+)
+
+type chainDialerKey struct{}
+
+// ContextWithChainDialer makes dialers created by this package send their
+// connections through upstream instead of the system network, so that an
+// outbound can be stacked on top of another one. A nil upstream clears it.
+func ContextWithChainDialer(ctx context.Context, upstream N.Dialer) context.Context {
+	return context.WithValue(ctx, chainDialerKey{}, upstream)
+}
+
+// chainDialerFromContext returns the upstream dialer and a context in which it
+// is cleared, so that the upstream does not dial through itself.
+func chainDialerFromContext(ctx context.Context) (N.Dialer, context.Context) {
+	upstream, _ := ctx.Value(chainDialerKey{}).(N.Dialer)
+	if upstream == nil {
+		return nil, ctx
+	}
+	return upstream, ContextWithChainDialer(ctx, nil)
+}
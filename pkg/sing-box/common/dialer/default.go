@@ -130,6 +130,9 @@ func (d *DefaultDialer) DialContext(ctx context.Context, network string, address
 	if !address.IsValid() {
 		return nil, E.New("invalid address")
 	}
+	if upstream, upstreamCtx := chainDialerFromContext(ctx); upstream != nil {
+		return upstream.DialContext(upstreamCtx, network, address)
+	}
 	switch N.NetworkName(network) {
 	case N.NetworkUDP:
 		if !address.IsIPv6() {
@@ -146,6 +149,9 @@ func (d *DefaultDialer) DialContext(ctx context.Context, network string, address
 }
 
 func (d *DefaultDialer) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
+	if upstream, upstreamCtx := chainDialerFromContext(ctx); upstream != nil {
+		return upstream.ListenPacket(upstreamCtx, destination)
+	}
 	if destination.IsIPv6() {
 		return trackPacketConn(d.udpListener.ListenPacket(ctx, N.NetworkUDP, d.udpAddr6))
 	} else if destination.IsIPv4() && !destination.Addr.IsUnspecified() {
@@ -33,6 +33,10 @@ func NewResolveDialer(router adapter.Router, dialer N.Dialer, parallel bool, str
 }
 
 func (d *ResolveDialer) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	if upstream, upstreamCtx := chainDialerFromContext(ctx); upstream != nil {
+		// let the upstream resolve the domain
+		return upstream.DialContext(upstreamCtx, network, destination)
+	}
 	if !destination.IsFqdn() {
 		return d.dialer.DialContext(ctx, network, destination)
 	}
@@ -58,6 +62,10 @@ func (d *ResolveDialer) DialContext(ctx context.Context, network string, destina
 }
 
 func (d *ResolveDialer) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
+	if upstream, upstreamCtx := chainDialerFromContext(ctx); upstream != nil {
+		// let the upstream resolve the domain
+		return upstream.ListenPacket(upstreamCtx, destination)
+	}
 	if !destination.IsFqdn() {
 		return d.dialer.ListenPacket(ctx, destination)
 	}
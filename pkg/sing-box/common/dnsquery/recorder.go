@@ -0,0 +1,182 @@
+package dnsquery
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing/common/observable"
+)
+
+const (
+	DefaultSize = 1024
+
+	// latencySamples bounds the latencies kept per upstream for percentiles.
+	latencySamples = 1024
+)
+
+var _ adapter.DNSQueryRecorder = (*Recorder)(nil)
+
+type UpstreamStats struct {
+	Upstream    string  `json:"upstream"`
+	Queries     uint64  `json:"queries"`
+	Failures    uint64  `json:"failures"`
+	FailureRate float64 `json:"failure_rate"`
+	LatencyP50  int64   `json:"latency_p50"`
+	LatencyP90  int64   `json:"latency_p90"`
+	LatencyP99  int64   `json:"latency_p99"`
+}
+
+type upstreamCounter struct {
+	queries   uint64
+	failures  uint64
+	latencies []int64
+	next      int
+}
+
+// Recorder keeps the latest DNS queries in a ring buffer,
+// streams new ones to subscribers and aggregates statistics per upstream.
+// Latencies are in milliseconds.
+type Recorder struct {
+	access    sync.Mutex
+	records   []adapter.DNSQueryRecord
+	next      int
+	full      bool
+	upstreams map[string]*upstreamCounter
+	queries   uint64
+	cacheHits uint64
+
+	subscriber *observable.Subscriber[adapter.DNSQueryRecord]
+	observer   *observable.Observer[adapter.DNSQueryRecord]
+}
+
+func NewRecorder(size int) *Recorder {
+	if size <= 0 {
+		size = DefaultSize
+	}
+	recorder := &Recorder{
+		records:    make([]adapter.DNSQueryRecord, size),
+		upstreams:  make(map[string]*upstreamCounter),
+		subscriber: observable.NewSubscriber[adapter.DNSQueryRecord](128),
+	}
+	recorder.observer = observable.NewObserver[adapter.DNSQueryRecord](recorder.subscriber, 64)
+	return recorder
+}
+
+// RecordDNSQuery records a finished query. Cached records do not count
+// towards upstream statistics, since no upstream was asked.
+func (r *Recorder) RecordDNSQuery(record adapter.DNSQueryRecord) {
+	r.access.Lock()
+	r.records[r.next] = record
+	r.next++
+	if r.next == len(r.records) {
+		r.next = 0
+		r.full = true
+	}
+	r.queries++
+	if record.Cached {
+		r.cacheHits++
+	}
+	if !record.Cached && record.Upstream != "" {
+		counter := r.upstreams[record.Upstream]
+		if counter == nil {
+			counter = &upstreamCounter{}
+			r.upstreams[record.Upstream] = counter
+		}
+		counter.queries++
+		if record.RCode == "SERVFAIL" || record.Error != "" && record.RCode == "" {
+			counter.failures++
+		} else if len(counter.latencies) < latencySamples {
+			counter.latencies = append(counter.latencies, record.Latency)
+		} else {
+			counter.latencies[counter.next] = record.Latency
+			counter.next = (counter.next + 1) % latencySamples
+		}
+	}
+	r.access.Unlock()
+	r.observer.Emit(record)
+}
+
+// Records returns up to limit records, oldest first. Zero means no limit.
+func (r *Recorder) Records(limit int) []adapter.DNSQueryRecord {
+	r.access.Lock()
+	defer r.access.Unlock()
+	var records []adapter.DNSQueryRecord
+	if r.full {
+		records = append(records, r.records[r.next:]...)
+	}
+	records = append(records, r.records[:r.next]...)
+	if limit > 0 && len(records) > limit {
+		records = records[len(records)-limit:]
+	}
+	return records
+}
+
+func (r *Recorder) Reset() {
+	r.access.Lock()
+	defer r.access.Unlock()
+	for i := range r.records {
+		r.records[i] = adapter.DNSQueryRecord{}
+	}
+	r.next = 0
+	r.full = false
+	r.upstreams = make(map[string]*upstreamCounter)
+	r.queries = 0
+	r.cacheHits = 0
+}
+
+// Totals returns the number of queries and of queries answered from cache
+// since the last reset.
+func (r *Recorder) Totals() (queries uint64, cacheHits uint64) {
+	r.access.Lock()
+	defer r.access.Unlock()
+	return r.queries, r.cacheHits
+}
+
+func (r *Recorder) Stats() []UpstreamStats {
+	r.access.Lock()
+	defer r.access.Unlock()
+	stats := make([]UpstreamStats, 0, len(r.upstreams))
+	for upstream, counter := range r.upstreams {
+		latencies := append([]int64(nil), counter.latencies...)
+		sort.Slice(latencies, func(i, j int) bool {
+			return latencies[i] < latencies[j]
+		})
+		stats = append(stats, UpstreamStats{
+			Upstream:    upstream,
+			Queries:     counter.queries,
+			Failures:    counter.failures,
+			FailureRate: float64(counter.failures) / float64(counter.queries),
+			LatencyP50:  percentile(latencies, 50),
+			LatencyP90:  percentile(latencies, 90),
+			LatencyP99:  percentile(latencies, 99),
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Upstream < stats[j].Upstream
+	})
+	return stats
+}
+
+func percentile(sorted []int64, p int) int64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	index := (len(sorted)*p + 99) / 100
+	if index > 0 {
+		index--
+	}
+	return sorted[index]
+}
+
+func (r *Recorder) Subscribe() (subscription observable.Subscription[adapter.DNSQueryRecord], done <-chan struct{}, err error) {
+	return r.observer.Subscribe()
+}
+
+func (r *Recorder) UnSubscribe(subscription observable.Subscription[adapter.DNSQueryRecord]) {
+	r.observer.UnSubscribe(subscription)
+}
+
+func (r *Recorder) Close() error {
+	return r.observer.Close()
+}
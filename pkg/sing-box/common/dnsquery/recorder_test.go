@@ -0,0 +1,91 @@
+package dnsquery_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/dnsquery"
+	F "github.com/sagernet/sing/common/format"
+
+	"github.com/stretchr/testify/require"
+)
+
+func domains(records []adapter.DNSQueryRecord) []string {
+	var result []string
+	for _, record := range records {
+		result = append(result, record.Domain)
+	}
+	return result
+}
+
+func TestRecorderRing(t *testing.T) {
+	t.Parallel()
+	recorder := dnsquery.NewRecorder(3)
+	defer recorder.Close()
+	require.Empty(t, recorder.Records(0))
+	recorder.RecordDNSQuery(adapter.DNSQueryRecord{Domain: "a"})
+	recorder.RecordDNSQuery(adapter.DNSQueryRecord{Domain: "b"})
+	require.Equal(t, []string{"a", "b"}, domains(recorder.Records(0)))
+	recorder.RecordDNSQuery(adapter.DNSQueryRecord{Domain: "c"})
+	recorder.RecordDNSQuery(adapter.DNSQueryRecord{Domain: "d"})
+	recorder.RecordDNSQuery(adapter.DNSQueryRecord{Domain: "e"})
+	require.Equal(t, []string{"c", "d", "e"}, domains(recorder.Records(0)))
+	require.Equal(t, []string{"d", "e"}, domains(recorder.Records(2)))
+	require.Equal(t, []string{"c", "d", "e"}, domains(recorder.Records(10)))
+	queries, _ := recorder.Totals()
+	require.Equal(t, uint64(5), queries)
+	recorder.Reset()
+	require.Empty(t, recorder.Records(0))
+	queries, _ = recorder.Totals()
+	require.Zero(t, queries)
+	recorder.RecordDNSQuery(adapter.DNSQueryRecord{Domain: "f"})
+	require.Equal(t, []string{"f"}, domains(recorder.Records(0)))
+}
+
+func TestRecorderStats(t *testing.T) {
+	t.Parallel()
+	recorder := dnsquery.NewRecorder(dnsquery.DefaultSize)
+	defer recorder.Close()
+	for i := 1; i <= 100; i++ {
+		recorder.RecordDNSQuery(adapter.DNSQueryRecord{Domain: F.ToString(i), Upstream: "remote", RCode: "NOERROR", Latency: int64(i)})
+	}
+	recorder.RecordDNSQuery(adapter.DNSQueryRecord{Upstream: "remote", RCode: "SERVFAIL", Latency: 1000})
+	recorder.RecordDNSQuery(adapter.DNSQueryRecord{Upstream: "remote", Error: "timeout"})
+	recorder.RecordDNSQuery(adapter.DNSQueryRecord{Upstream: "local", RCode: "NXDOMAIN", Latency: 5})
+	recorder.RecordDNSQuery(adapter.DNSQueryRecord{Cached: true, RCode: "NOERROR"})
+	queries, cacheHits := recorder.Totals()
+	require.Equal(t, uint64(104), queries)
+	require.Equal(t, uint64(1), cacheHits)
+	require.Equal(t, []dnsquery.UpstreamStats{{
+		Upstream:   "local",
+		Queries:    1,
+		LatencyP50: 5,
+		LatencyP90: 5,
+		LatencyP99: 5,
+	}, {
+		Upstream:    "remote",
+		Queries:     102,
+		Failures:    2,
+		FailureRate: 2.0 / 102,
+		LatencyP50:  50,
+		LatencyP90:  90,
+		LatencyP99:  99,
+	}}, recorder.Stats())
+}
+
+func TestRecorderSubscribe(t *testing.T) {
+	t.Parallel()
+	recorder := dnsquery.NewRecorder(dnsquery.DefaultSize)
+	defer recorder.Close()
+	subscription, _, err := recorder.Subscribe()
+	require.NoError(t, err)
+	defer recorder.UnSubscribe(subscription)
+	recorder.RecordDNSQuery(adapter.DNSQueryRecord{Domain: "example.com"})
+	select {
+	case record := <-subscription:
+		require.Equal(t, "example.com", record.Domain)
+	case <-time.After(time.Second):
+		t.Fatal("record not received")
+	}
+}
@@ -0,0 +1,117 @@
+package filewatcher
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDelay coalesces the several events produced by a single change,
+// e.g. by editors, atomic replacements or a certificate updated with its key.
+const reloadDelay = 500 * time.Millisecond
+
+// Watcher calls reload when one of the files is written or created.
+// The directories are watched instead of the files, so files replaced by
+// rename, or symlinks updated by certbot, are seen as well as writes.
+// A file renamed away or removed is kept until it is created again.
+type Watcher struct {
+	logger  logger.Logger
+	paths   []string
+	reload  func() error
+	watcher *fsnotify.Watcher
+	access  sync.Mutex
+	timer   *time.Timer
+	closed  bool
+}
+
+// New creates a watcher for paths, empty paths are ignored.
+// Errors returned by reload are logged.
+func New(logger logger.Logger, paths []string, reload func() error) *Watcher {
+	return &Watcher{
+		logger: logger,
+		paths: common.Map(common.FilterNotDefault(paths), func(path string) string {
+			absPath, err := filepath.Abs(path)
+			if err != nil {
+				return filepath.Clean(path)
+			}
+			return absPath
+		}),
+		reload: reload,
+	}
+}
+
+func (w *Watcher) Start() error {
+	if len(w.paths) == 0 {
+		return nil
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	for _, directory := range common.Uniq(common.Map(w.paths, filepath.Dir)) {
+		err = watcher.Add(directory)
+		if err != nil {
+			watcher.Close()
+			return E.Cause(err, "watch ", directory)
+		}
+	}
+	w.watcher = watcher
+	go w.loopUpdate()
+	return nil
+}
+
+func (w *Watcher) loopUpdate() {
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create) == 0 || !common.Contains(w.paths, filepath.Clean(event.Name)) {
+				continue
+			}
+			w.access.Lock()
+			if w.closed {
+				w.access.Unlock()
+				return
+			}
+			if w.timer == nil {
+				w.timer = time.AfterFunc(reloadDelay, w.reloadFiles)
+			} else {
+				w.timer.Reset(reloadDelay)
+			}
+			w.access.Unlock()
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			w.logger.Error(E.Cause(err, "fsnotify error"))
+		}
+	}
+}
+
+func (w *Watcher) reloadFiles() {
+	err := w.reload()
+	if err != nil {
+		w.logger.Error(err)
+	}
+}
+
+func (w *Watcher) Close() error {
+	if w.watcher == nil {
+		return nil
+	}
+	w.access.Lock()
+	w.closed = true
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.access.Unlock()
+	return w.watcher.Close()
+}
@@ -0,0 +1,48 @@
+package filewatcher
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/log"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcher(t *testing.T) {
+	t.Parallel()
+	directory := t.TempDir()
+	path := filepath.Join(directory, "watched")
+	require.NoError(t, os.WriteFile(path, []byte("0"), 0o644))
+	var reloads atomic.Int32
+	watcher := New(log.NewNOPFactory().Logger(), []string{path, ""}, func() error {
+		reloads.Add(1)
+		return nil
+	})
+	require.NoError(t, watcher.Start())
+	defer watcher.Close()
+
+	// other files of the directory are ignored
+	require.NoError(t, os.WriteFile(filepath.Join(directory, "other"), []byte("0"), 0o644))
+	time.Sleep(2 * reloadDelay)
+	require.Zero(t, reloads.Load())
+
+	// several writes are coalesced
+	require.NoError(t, os.WriteFile(path, []byte("1"), 0o644))
+	require.NoError(t, os.WriteFile(path, []byte("2"), 0o644))
+	require.Eventually(t, func() bool {
+		return reloads.Load() == 1
+	}, 5*time.Second, 50*time.Millisecond)
+
+	// files renamed away are kept until replaced
+	require.NoError(t, os.Rename(path, filepath.Join(directory, "backup")))
+	time.Sleep(2 * reloadDelay)
+	require.Equal(t, int32(1), reloads.Load())
+	require.NoError(t, os.Rename(filepath.Join(directory, "other"), path))
+	require.Eventually(t, func() bool {
+		return reloads.Load() == 2
+	}, 5*time.Second, 50*time.Millisecond)
+}
@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Bucket is a token bucket of bytes. Tokens may go negative to reserve
+// bytes for waiting callers, so waiters are served in order.
+type Bucket struct {
+	access sync.Mutex
+	rate   float64
+	burst  int
+	tokens float64
+	last   time.Time
+}
+
+// NewBucket creates a full bucket refilled with rate bytes per second.
+func NewBucket(rate uint64, burst uint64) *Bucket {
+	return &Bucket{
+		rate:   float64(rate),
+		burst:  int(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until n bytes may pass, or returns false once done is closed.
+// Requests larger than the burst are split into several reservations.
+func (b *Bucket) Wait(n int, done <-chan struct{}) bool {
+	for n > 0 {
+		chunk := n
+		if chunk > b.burst {
+			chunk = b.burst
+		}
+		delay := b.reserve(chunk, time.Now())
+		if delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-done:
+				timer.Stop()
+				b.cancel(chunk)
+				return false
+			}
+		}
+		n -= chunk
+	}
+	return true
+}
+
+func (b *Bucket) reserve(n int, now time.Time) time.Duration {
+	b.access.Lock()
+	defer b.access.Unlock()
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if b.tokens > float64(b.burst) {
+			b.tokens = float64(b.burst)
+		}
+		b.last = now
+	}
+	b.tokens -= float64(n)
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel returns the tokens of a reservation that was not waited for.
+func (b *Bucket) cancel(n int) {
+	b.access.Lock()
+	defer b.access.Unlock()
+	b.tokens += float64(n)
+	if b.tokens > float64(b.burst) {
+		b.tokens = float64(b.burst)
+	}
+}
@@ -0,0 +1,34 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/option"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBucketReserve(t *testing.T) {
+	t.Parallel()
+	bucket := NewBucket(1000, 500)
+	now := bucket.last
+	require.Zero(t, bucket.reserve(500, now))
+	require.Equal(t, 500*time.Millisecond, bucket.reserve(500, now))
+	require.Equal(t, time.Second, bucket.reserve(500, now))
+	now = now.Add(time.Second)
+	require.Equal(t, 500*time.Millisecond, bucket.reserve(500, now))
+	now = now.Add(10 * time.Second)
+	require.Zero(t, bucket.reserve(500, now))
+	require.Equal(t, 100*time.Millisecond, bucket.reserve(100, now))
+}
+
+func TestNewLimiter(t *testing.T) {
+	t.Parallel()
+	require.Nil(t, NewLimiter(nil))
+	limiter := NewLimiter(&option.RateLimitOptions{DownMbps: 8})
+	require.NotNil(t, limiter)
+	require.Nil(t, limiter.up)
+	require.Equal(t, float64(1000000), limiter.down.rate)
+	require.Equal(t, 1000000, limiter.down.burst)
+}
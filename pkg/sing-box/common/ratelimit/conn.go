@@ -0,0 +1,119 @@
+package ratelimit
+
+import (
+	"net"
+	"sync"
+
+	"github.com/sagernet/sing/common/buf"
+	"github.com/sagernet/sing/common/bufio"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+// NewConn limits a routed connection, where reads are uploads and
+// writes are downloads. The connection is not replaceable by its upstream,
+// so copying can not bypass the limiters. Closing it cancels waiting
+// reads and writes.
+func NewConn(conn net.Conn, limiters []*Limiter) net.Conn {
+	if len(limiters) == 0 {
+		return conn
+	}
+	return &Conn{ExtendedConn: bufio.NewExtendedConn(conn), limiters: limiters, done: make(chan struct{})}
+}
+
+type Conn struct {
+	N.ExtendedConn
+	limiters  []*Limiter
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (c *Conn) Read(p []byte) (n int, err error) {
+	n, err = c.ExtendedConn.Read(p)
+	if n > 0 && !waitUp(c.limiters, n, c.done) {
+		err = net.ErrClosed
+	}
+	return
+}
+
+func (c *Conn) ReadBuffer(buffer *buf.Buffer) error {
+	err := c.ExtendedConn.ReadBuffer(buffer)
+	if err != nil {
+		return err
+	}
+	if !waitUp(c.limiters, buffer.Len(), c.done) {
+		return net.ErrClosed
+	}
+	return nil
+}
+
+func (c *Conn) Write(p []byte) (n int, err error) {
+	if !waitDown(c.limiters, len(p), c.done) {
+		return 0, net.ErrClosed
+	}
+	return c.ExtendedConn.Write(p)
+}
+
+func (c *Conn) WriteBuffer(buffer *buf.Buffer) error {
+	if !waitDown(c.limiters, buffer.Len(), c.done) {
+		buffer.Release()
+		return net.ErrClosed
+	}
+	return c.ExtendedConn.WriteBuffer(buffer)
+}
+
+func (c *Conn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+	return c.ExtendedConn.Close()
+}
+
+func (c *Conn) Upstream() any {
+	return c.ExtendedConn
+}
+
+// NewPacketConn limits a routed packet connection like NewConn.
+func NewPacketConn(conn N.PacketConn, limiters []*Limiter) N.PacketConn {
+	if len(limiters) == 0 {
+		return conn
+	}
+	return &PacketConn{PacketConn: conn, limiters: limiters, done: make(chan struct{})}
+}
+
+type PacketConn struct {
+	N.PacketConn
+	limiters  []*Limiter
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (c *PacketConn) ReadPacket(buffer *buf.Buffer) (destination M.Socksaddr, err error) {
+	destination, err = c.PacketConn.ReadPacket(buffer)
+	if err != nil {
+		return
+	}
+	if !waitUp(c.limiters, buffer.Len(), c.done) {
+		err = net.ErrClosed
+	}
+	return
+}
+
+func (c *PacketConn) WritePacket(buffer *buf.Buffer, destination M.Socksaddr) error {
+	if !waitDown(c.limiters, buffer.Len(), c.done) {
+		buffer.Release()
+		return net.ErrClosed
+	}
+	return c.PacketConn.WritePacket(buffer, destination)
+}
+
+func (c *PacketConn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+	return c.PacketConn.Close()
+}
+
+func (c *PacketConn) Upstream() any {
+	return c.PacketConn
+}
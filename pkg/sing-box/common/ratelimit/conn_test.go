@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/buf"
+	"github.com/sagernet/sing/common/bufio"
+	M "github.com/sagernet/sing/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnThroughput(t *testing.T) {
+	t.Parallel()
+	client, server := net.Pipe()
+	defer server.Close()
+	conn := NewConn(client, []*Limiter{{up: NewBucket(100000, 10000), down: NewBucket(100000, 10000)}})
+	defer conn.Close()
+
+	// 30000 bytes minus the burst of 10000 take 200ms at 100000 bytes per second
+	go io.Copy(io.Discard, server)
+	start := time.Now()
+	_, err := conn.Write(make([]byte, 30000))
+	require.NoError(t, err)
+	elapsed := time.Since(start)
+	require.GreaterOrEqual(t, elapsed, 180*time.Millisecond)
+	require.Less(t, elapsed, time.Second)
+
+	go server.Write(make([]byte, 30000))
+	start = time.Now()
+	_, err = io.ReadFull(conn, make([]byte, 30000))
+	require.NoError(t, err)
+	elapsed = time.Since(start)
+	require.GreaterOrEqual(t, elapsed, 180*time.Millisecond)
+	require.Less(t, elapsed, time.Second)
+}
+
+func TestConnCloseCancelsWait(t *testing.T) {
+	t.Parallel()
+	client, server := net.Pipe()
+	defer server.Close()
+	go io.Copy(io.Discard, server)
+	conn := NewConn(client, []*Limiter{{down: NewBucket(1000, 1000)}})
+	_, err := conn.Write(make([]byte, 1000))
+	require.NoError(t, err)
+	time.AfterFunc(100*time.Millisecond, func() {
+		conn.Close()
+	})
+	start := time.Now()
+	// would wait ten seconds for the bucket to refill
+	_, err = conn.Write(make([]byte, 10000))
+	require.ErrorIs(t, err, net.ErrClosed)
+	require.Less(t, time.Since(start), time.Second)
+}
+
+func TestPacketConnThroughput(t *testing.T) {
+	t.Parallel()
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	udpConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	conn := NewPacketConn(bufio.NewPacketConn(udpConn), []*Limiter{{down: NewBucket(100000, 10000)}})
+	defer conn.Close()
+
+	// ten packets of 5000 bytes minus the burst of 10000 take 400ms
+	destination := M.SocksaddrFromNet(listener.LocalAddr())
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		buffer := buf.NewSize(5000)
+		buffer.Extend(5000)
+		require.NoError(t, conn.WritePacket(buffer, destination))
+	}
+	elapsed := time.Since(start)
+	require.GreaterOrEqual(t, elapsed, 380*time.Millisecond)
+	require.Less(t, elapsed, 1500*time.Millisecond)
+}
+
+func TestManagerOutboundChain(t *testing.T) {
+	t.Parallel()
+	manager := NewManager([]option.Outbound{
+		{Tag: "select"},
+		{Tag: "proxy", RateLimit: &option.RateLimitOptions{UpMbps: 1}},
+	})
+	require.Empty(t, manager.Limiters(adapter.InboundContext{}, []string{"select"}))
+	limiters := manager.Limiters(adapter.InboundContext{}, []string{"select", "proxy", "proxy"})
+	require.Len(t, limiters, 1)
+	require.Equal(t, manager.outbounds["proxy"], limiters[0])
+}
@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+)
+
+// Limiter limits the upload and download of connections sharing it.
+// Upload is the traffic sent by the client.
+type Limiter struct {
+	up   *Bucket
+	down *Bucket
+}
+
+// NewLimiter returns nil if options limit nothing. The burst defaults to
+// the bytes of one second at each rate.
+func NewLimiter(options *option.RateLimitOptions) *Limiter {
+	if options == nil || options.UpMbps <= 0 && options.DownMbps <= 0 {
+		return nil
+	}
+	var limiter Limiter
+	if options.UpMbps > 0 {
+		limiter.up = newBucket(options.UpMbps, uint64(options.Burst))
+	}
+	if options.DownMbps > 0 {
+		limiter.down = newBucket(options.DownMbps, uint64(options.Burst))
+	}
+	return &limiter
+}
+
+func newBucket(mbps int, burst uint64) *Bucket {
+	rate := uint64(mbps) * C.MbpsToBps
+	if burst == 0 {
+		burst = rate
+	}
+	return NewBucket(rate, burst)
+}
+
+func waitUp(limiters []*Limiter, n int, done <-chan struct{}) bool {
+	for _, limiter := range limiters {
+		if limiter.up != nil && !limiter.up.Wait(n, done) {
+			return false
+		}
+	}
+	return true
+}
+
+func waitDown(limiters []*Limiter, n int, done <-chan struct{}) bool {
+	for _, limiter := range limiters {
+		if limiter.down != nil && !limiter.down.Wait(n, done) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"sync"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+)
+
+// Manager holds the limiters of inbounds, inbound users and outbounds.
+// Limiters of inbounds and users are created by their first connection.
+type Manager struct {
+	access    sync.Mutex
+	inbounds  map[string]*Limiter
+	users     map[userKey]*Limiter
+	outbounds map[string]*Limiter
+}
+
+type userKey struct {
+	inbound string
+	user    string
+}
+
+func NewManager(outbounds []option.Outbound) *Manager {
+	manager := &Manager{
+		inbounds:  make(map[string]*Limiter),
+		users:     make(map[userKey]*Limiter),
+		outbounds: make(map[string]*Limiter),
+	}
+	for _, outbound := range outbounds {
+		if limiter := NewLimiter(outbound.RateLimit); limiter != nil {
+			manager.outbounds[outbound.Tag] = limiter
+		}
+	}
+	return manager
+}
+
+// Limiters returns the limiters of a connection routed through the outbounds of chain,
+// as resolved by adapter.AppendOutboundChain.
+func (m *Manager) Limiters(metadata adapter.InboundContext, chain []string) []*Limiter {
+	var limiters []*Limiter
+	m.access.Lock()
+	if metadata.InboundOptions.RateLimit != nil {
+		limiter, loaded := m.inbounds[metadata.Inbound]
+		if !loaded {
+			limiter = NewLimiter(metadata.InboundOptions.RateLimit)
+			m.inbounds[metadata.Inbound] = limiter
+		}
+		if limiter != nil {
+			limiters = append(limiters, limiter)
+		}
+	}
+	if metadata.User != "" && len(metadata.InboundOptions.UserLimits) > 0 {
+		key := userKey{metadata.Inbound, metadata.User}
+		limiter, loaded := m.users[key]
+		if !loaded {
+			index := common.Index(metadata.InboundOptions.UserLimits, func(it option.UserLimitOptions) bool {
+				return it.Name == metadata.User
+			})
+			if index != -1 {
+				limiter = NewLimiter(metadata.InboundOptions.UserLimits[index].RateLimit)
+			}
+			m.users[key] = limiter
+		}
+		if limiter != nil {
+			limiters = append(limiters, limiter)
+		}
+	}
+	m.access.Unlock()
+	for _, outbound := range common.Uniq(chain) {
+		if limiter := m.outbounds[outbound]; limiter != nil {
+			limiters = append(limiters, limiter)
+		}
+	}
+	return limiters
+}
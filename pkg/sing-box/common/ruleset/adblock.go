@@ -0,0 +1,143 @@
+package ruleset
+
+import (
+	"net/netip"
+	"regexp"
+	"strings"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+)
+
+// convertAdBlock parses the DNS-level subset of AdGuard and Adblock Plus
+// filter lists:
+//
+//	||example.com^         example.com and subdomains
+//	|example.com^          example.com only
+//	/ads[0-9]+\.com/       domain regex
+//	0.0.0.0 example.com    hosts syntax
+//	example.com            same as ||example.com^
+//	@@||example.com^       exception
+//
+// Exceptions are applied to the blocked domains by an inverted rule, except
+// for blocks marked $important. Cosmetic, URL path and other modifier rules
+// can not be expressed by domain matching and are skipped.
+func convertAdBlock(content []byte) (option.PlainRuleSet, []string, error) {
+	var (
+		blockRule     option.DefaultHeadlessRule
+		importantRule option.DefaultHeadlessRule
+		exceptionRule option.DefaultHeadlessRule
+		skipped       []string
+	)
+	for _, line := range readLines(content, "!", "[", "#") {
+		if isCosmeticRule(line) {
+			skipped = append(skipped, line)
+			continue
+		}
+		pattern := line
+		isException := strings.HasPrefix(pattern, "@@")
+		if isException {
+			pattern = pattern[2:]
+		}
+		var isImportant bool
+		if index := strings.LastIndexByte(pattern, '$'); index != -1 && !isRegexRule(pattern) {
+			if pattern[index+1:] != "important" {
+				skipped = append(skipped, line)
+				continue
+			}
+			pattern = pattern[:index]
+			isImportant = true
+		}
+		target := &blockRule
+		if isException {
+			target = &exceptionRule
+		} else if isImportant {
+			target = &importantRule
+		}
+		if !convertAdBlockPattern(pattern, target) {
+			skipped = append(skipped, line)
+		}
+	}
+	var rules []option.HeadlessRule
+	if exceptionRule.IsValid() && blockRule.IsValid() {
+		exceptionRule.Invert = true
+		rules = append(rules, option.HeadlessRule{
+			Type: C.RuleTypeLogical,
+			LogicalOptions: option.LogicalHeadlessRule{
+				Mode:  C.LogicalTypeAnd,
+				Rules: []option.HeadlessRule{newRule(blockRule), newRule(exceptionRule)},
+			},
+		})
+	} else {
+		rules = appendRule(rules, blockRule)
+	}
+	rules = appendRule(rules, importantRule)
+	return newRuleSet(rules), skipped, nil
+}
+
+func isCosmeticRule(line string) bool {
+	for _, separator := range []string{"##", "#@#", "#?#", "#$#", "#%#", "$$", "$@$"} {
+		if strings.Contains(line, separator) {
+			return true
+		}
+	}
+	return false
+}
+
+func isRegexRule(pattern string) bool {
+	return len(pattern) > 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/")
+}
+
+func convertAdBlockPattern(pattern string, rule *option.DefaultHeadlessRule) bool {
+	if isRegexRule(pattern) {
+		expression := pattern[1 : len(pattern)-1]
+		if _, err := regexp.Compile(expression); err != nil {
+			return false
+		}
+		rule.DomainRegex = append(rule.DomainRegex, expression)
+		return true
+	}
+	if fields := strings.Fields(pattern); len(fields) == 2 {
+		if _, err := netip.ParseAddr(fields[0]); err == nil {
+			name := normalizeDomain(fields[1])
+			if hostsIgnoredNames[name] || !isValidDomain(name) {
+				return false
+			}
+			rule.Domain = append(rule.Domain, name)
+			return true
+		}
+	}
+	var isSuffix bool
+	switch {
+	case strings.HasPrefix(pattern, "||"):
+		pattern = pattern[2:]
+		isSuffix = true
+	case strings.HasPrefix(pattern, "|"):
+		pattern = pattern[1:]
+	default:
+		isSuffix = true
+	}
+	pattern = strings.TrimSuffix(pattern, "|")
+	pattern = strings.TrimSuffix(pattern, "^")
+	name := normalizeDomain(pattern)
+	if strings.Contains(name, "*") {
+		if !isValidDomain(strings.ReplaceAll(name, "*", "x")) {
+			return false
+		}
+		expression := wildcardToRegex(name)
+		if isSuffix {
+			expression = "(^|\\.)" + expression[1:]
+		}
+		rule.DomainRegex = append(rule.DomainRegex, expression)
+		return true
+	}
+	if !isValidDomain(name) {
+		return false
+	}
+	if isSuffix {
+		rule.DomainSuffix = append(rule.DomainSuffix, name)
+	} else {
+		rule.Domain = append(rule.Domain, name)
+	}
+	return true
+}
@@ -0,0 +1,232 @@
+package ruleset
+
+import (
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+	N "github.com/sagernet/sing/common/network"
+
+	"gopkg.in/yaml.v2"
+)
+
+type clashRuleProvider struct {
+	Payload []string `yaml:"payload"`
+}
+
+// convertClash parses a Clash rule-provider, either as YAML with a payload
+// list or as the plain text variant with one entry per line.
+// The provider behavior is detected once from the first entry: a comma means
+// classical rules, an IP prefix means ipcidr and anything else means domain.
+// Providers mixing behaviors are rejected.
+func convertClash(content []byte) (option.PlainRuleSet, []string, error) {
+	var provider clashRuleProvider
+	err := yaml.Unmarshal(content, &provider)
+	if err != nil || len(provider.Payload) == 0 {
+		provider.Payload = readLines(content, "#", "//", "payload:")
+	}
+	var (
+		destinationRule option.DefaultHeadlessRule
+		sourceRule      option.DefaultHeadlessRule
+		portRule        option.DefaultHeadlessRule
+		sourcePortRule  option.DefaultHeadlessRule
+		processRule     option.DefaultHeadlessRule
+		networkRule     option.DefaultHeadlessRule
+		skipped         []string
+	)
+	var behavior string
+	for _, entry := range provider.Payload {
+		entry = strings.TrimSpace(entry)
+		entry = strings.TrimPrefix(entry, "- ")
+		entry = strings.Trim(entry, "'\"")
+		if entry == "" {
+			continue
+		}
+		entryBehavior := clashBehaviorOf(entry)
+		if behavior == "" {
+			behavior = entryBehavior
+		} else if entryBehavior != behavior {
+			return option.PlainRuleSet{}, nil, E.New("mixed rule-provider behaviors: ", behavior, " provider contains ", entryBehavior, " entry: ", entry)
+		}
+		switch behavior {
+		case clashBehaviorClassical:
+			if !convertClashClassical(entry, &destinationRule, &sourceRule, &portRule, &sourcePortRule, &processRule, &networkRule) {
+				skipped = append(skipped, entry)
+			}
+		case clashBehaviorIPCIDR:
+			prefix, _ := parseClashPrefix(entry)
+			destinationRule.IPCIDR = append(destinationRule.IPCIDR, prefix)
+		default:
+			if !convertClashDomain(entry, &destinationRule) {
+				skipped = append(skipped, entry)
+			}
+		}
+	}
+	var rules []option.HeadlessRule
+	rules = appendRule(rules, destinationRule)
+	rules = appendRule(rules, sourceRule)
+	rules = appendRule(rules, portRule)
+	rules = appendRule(rules, sourcePortRule)
+	rules = appendRule(rules, processRule)
+	rules = appendRule(rules, networkRule)
+	return newRuleSet(rules), skipped, nil
+}
+
+const (
+	clashBehaviorClassical = "classical"
+	clashBehaviorIPCIDR    = "ipcidr"
+	clashBehaviorDomain    = "domain"
+)
+
+func clashBehaviorOf(entry string) string {
+	if strings.Contains(entry, ",") {
+		return clashBehaviorClassical
+	}
+	if _, err := parseClashPrefix(entry); err == nil {
+		return clashBehaviorIPCIDR
+	}
+	return clashBehaviorDomain
+}
+
+func parseClashPrefix(value string) (string, error) {
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return "", err
+		}
+		return prefix.String(), nil
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return "", err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()).String(), nil
+}
+
+// convertClashDomain handles the domain behavior wildcards:
+// "+.example.com" matches the domain and all subdomains,
+// ".example.com" matches all subdomains and
+// "*.example.com" matches exactly one level of subdomain.
+func convertClashDomain(entry string, rule *option.DefaultHeadlessRule) bool {
+	entry = normalizeDomain(entry)
+	switch {
+	case strings.HasPrefix(entry, "+."):
+		entry = entry[2:]
+		if !isValidDomain(entry) {
+			return false
+		}
+		rule.DomainSuffix = append(rule.DomainSuffix, entry)
+	case strings.HasPrefix(entry, "."):
+		if !isValidDomain(entry[1:]) {
+			return false
+		}
+		rule.DomainSuffix = append(rule.DomainSuffix, entry)
+	case strings.Contains(entry, "*"):
+		if !isValidDomain(strings.ReplaceAll(entry, "*", "x")) {
+			return false
+		}
+		rule.DomainRegex = append(rule.DomainRegex, wildcardToRegex(entry))
+	default:
+		if !isValidDomain(entry) {
+			return false
+		}
+		rule.Domain = append(rule.Domain, entry)
+	}
+	return true
+}
+
+// wildcardToRegex converts a domain pattern where "*" stands for one label.
+func wildcardToRegex(pattern string) string {
+	parts := strings.Split(pattern, "*")
+	for i := range parts {
+		parts[i] = regexp.QuoteMeta(parts[i])
+	}
+	return "^" + strings.Join(parts, "[^.]+") + "$"
+}
+
+func convertClashClassical(
+	entry string,
+	destinationRule *option.DefaultHeadlessRule,
+	sourceRule *option.DefaultHeadlessRule,
+	portRule *option.DefaultHeadlessRule,
+	sourcePortRule *option.DefaultHeadlessRule,
+	processRule *option.DefaultHeadlessRule,
+	networkRule *option.DefaultHeadlessRule,
+) bool {
+	fields := strings.Split(entry, ",")
+	if len(fields) < 2 {
+		return false
+	}
+	ruleType := strings.ToUpper(strings.TrimSpace(fields[0]))
+	payload := strings.TrimSpace(fields[1])
+	switch ruleType {
+	case "DOMAIN":
+		destinationRule.Domain = append(destinationRule.Domain, normalizeDomain(payload))
+	case "DOMAIN-SUFFIX":
+		destinationRule.DomainSuffix = append(destinationRule.DomainSuffix, normalizeDomain(payload))
+	case "DOMAIN-KEYWORD":
+		destinationRule.DomainKeyword = append(destinationRule.DomainKeyword, strings.ToLower(payload))
+	case "DOMAIN-REGEX":
+		destinationRule.DomainRegex = append(destinationRule.DomainRegex, payload)
+	case "IP-CIDR", "IP-CIDR6":
+		prefix, err := parseClashPrefix(payload)
+		if err != nil {
+			return false
+		}
+		destinationRule.IPCIDR = append(destinationRule.IPCIDR, prefix)
+	case "SRC-IP-CIDR":
+		prefix, err := parseClashPrefix(payload)
+		if err != nil {
+			return false
+		}
+		sourceRule.SourceIPCIDR = append(sourceRule.SourceIPCIDR, prefix)
+	case "DST-PORT":
+		return appendClashPort(payload, &portRule.Port, &portRule.PortRange)
+	case "SRC-PORT":
+		return appendClashPort(payload, &sourcePortRule.SourcePort, &sourcePortRule.SourcePortRange)
+	case "PROCESS-NAME":
+		processRule.ProcessName = append(processRule.ProcessName, payload)
+	case "PROCESS-PATH":
+		processRule.ProcessPath = append(processRule.ProcessPath, payload)
+	case "NETWORK":
+		network := strings.ToLower(payload)
+		if network != N.NetworkTCP && network != N.NetworkUDP {
+			return false
+		}
+		networkRule.Network = append(networkRule.Network, network)
+	default:
+		return false
+	}
+	return true
+}
+
+// appendClashPort accepts a single port or a "start-end" range,
+// possibly several of them separated by "/".
+func appendClashPort(payload string, ports *option.Listable[uint16], portRanges *option.Listable[string]) bool {
+	var (
+		newPorts      []uint16
+		newPortRanges []string
+	)
+	for _, item := range strings.Split(payload, "/") {
+		start, end, isRange := strings.Cut(strings.TrimSpace(item), "-")
+		startPort, err := strconv.ParseUint(start, 10, 16)
+		if err != nil {
+			return false
+		}
+		if !isRange {
+			newPorts = append(newPorts, uint16(startPort))
+			continue
+		}
+		endPort, err := strconv.ParseUint(end, 10, 16)
+		if err != nil || endPort < startPort {
+			return false
+		}
+		newPortRanges = append(newPortRanges, strconv.FormatUint(startPort, 10)+":"+strconv.FormatUint(endPort, 10))
+	}
+	*ports = append(*ports, newPorts...)
+	*portRanges = append(*portRanges, newPortRanges...)
+	return true
+}
@@ -0,0 +1,49 @@
+package ruleset
+
+import (
+	"strings"
+
+	"github.com/sagernet/sing-box/option"
+)
+
+// convertDomainList parses one domain per line. A bare domain matches itself
+// and its subdomains, a leading dot matches subdomains only, and the
+// v2fly domain-list-community prefixes full:, domain:, keyword: and regexp:
+// are recognized. Attributes after the entry (@cn) are ignored.
+func convertDomainList(content []byte) (option.PlainRuleSet, []string, error) {
+	var (
+		rule    option.DefaultHeadlessRule
+		skipped []string
+	)
+	for _, line := range readLines(content, "#", "//", ";") {
+		entry, _, _ := strings.Cut(line, " ")
+		entry, _, _ = strings.Cut(entry, "\t")
+		kind, value, found := strings.Cut(entry, ":")
+		if !found {
+			kind, value = "domain", entry
+		}
+		switch kind {
+		case "full":
+			value = normalizeDomain(value)
+			if !isValidDomain(value) {
+				skipped = append(skipped, line)
+				continue
+			}
+			rule.Domain = append(rule.Domain, value)
+		case "domain":
+			value = normalizeDomain(value)
+			if !isValidDomain(strings.TrimPrefix(value, ".")) {
+				skipped = append(skipped, line)
+				continue
+			}
+			rule.DomainSuffix = append(rule.DomainSuffix, value)
+		case "keyword":
+			rule.DomainKeyword = append(rule.DomainKeyword, strings.ToLower(value))
+		case "regexp":
+			rule.DomainRegex = append(rule.DomainRegex, value)
+		default:
+			skipped = append(skipped, line)
+		}
+	}
+	return newRuleSet(appendRule(nil, rule)), skipped, nil
+}
@@ -0,0 +1,58 @@
+package ruleset
+
+import (
+	"net/netip"
+	"strings"
+
+	"github.com/sagernet/sing-box/option"
+)
+
+var hostsIgnoredNames = map[string]bool{
+	"localhost":             true,
+	"localhost.localdomain": true,
+	"local":                 true,
+	"broadcasthost":         true,
+	"ip6-localhost":         true,
+	"ip6-loopback":          true,
+	"ip6-localnet":          true,
+	"ip6-mcastprefix":       true,
+	"ip6-allnodes":          true,
+	"ip6-allrouters":        true,
+	"ip6-allhosts":          true,
+	"0.0.0.0":               true,
+}
+
+// convertHosts matches every host name in a hosts file exactly,
+// whatever address it is mapped to.
+func convertHosts(content []byte) (option.PlainRuleSet, []string, error) {
+	var (
+		rule    option.DefaultHeadlessRule
+		skipped []string
+	)
+	loaded := make(map[string]bool)
+	for _, line := range readLines(content, "#") {
+		line, _, _ = strings.Cut(line, "#")
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			skipped = append(skipped, line)
+			continue
+		}
+		if _, err := netip.ParseAddr(fields[0]); err != nil {
+			skipped = append(skipped, line)
+			continue
+		}
+		for _, name := range fields[1:] {
+			name = normalizeDomain(name)
+			if hostsIgnoredNames[name] || loaded[name] {
+				continue
+			}
+			if !isValidDomain(name) {
+				skipped = append(skipped, name)
+				continue
+			}
+			loaded[name] = true
+			rule.Domain = append(rule.Domain, name)
+		}
+	}
+	return newRuleSet(appendRule(nil, rule)), skipped, nil
+}
@@ -0,0 +1,160 @@
+package ruleset
+
+import (
+	"net/netip"
+	"sort"
+	"strings"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/json"
+
+	"go4.org/netipx"
+)
+
+// Merge combines rule sets into one. Plain destination rules (domain,
+// domain_suffix, domain_keyword, domain_regex and ip_cidr only) are folded
+// into a single rule with redundant entries removed and IP prefixes
+// aggregated; other rules are kept once each.
+func Merge(ruleSets ...option.PlainRuleSet) (option.PlainRuleSet, error) {
+	var (
+		destinationRule option.DefaultHeadlessRule
+		rules           []option.HeadlessRule
+	)
+	loaded := make(map[string]bool)
+	for _, ruleSet := range ruleSets {
+		for _, rule := range ruleSet.Rules {
+			if isDestinationRule(rule) {
+				destinationRule.Domain = append(destinationRule.Domain, rule.DefaultOptions.Domain...)
+				destinationRule.DomainSuffix = append(destinationRule.DomainSuffix, rule.DefaultOptions.DomainSuffix...)
+				destinationRule.DomainKeyword = append(destinationRule.DomainKeyword, rule.DefaultOptions.DomainKeyword...)
+				destinationRule.DomainRegex = append(destinationRule.DomainRegex, rule.DefaultOptions.DomainRegex...)
+				destinationRule.IPCIDR = append(destinationRule.IPCIDR, rule.DefaultOptions.IPCIDR...)
+				continue
+			}
+			content, err := json.Marshal(rule)
+			if err != nil {
+				return option.PlainRuleSet{}, err
+			}
+			if loaded[string(content)] {
+				continue
+			}
+			loaded[string(content)] = true
+			rules = append(rules, rule)
+		}
+	}
+	ipCIDR, err := aggregatePrefixes(destinationRule.IPCIDR)
+	if err != nil {
+		return option.PlainRuleSet{}, E.Cause(err, "ip_cidr")
+	}
+	destinationRule.IPCIDR = ipCIDR
+	destinationRule.DomainKeyword = dedupeKeywords(destinationRule.DomainKeyword)
+	destinationRule.DomainRegex = dedupeStrings(destinationRule.DomainRegex)
+	destinationRule.Domain, destinationRule.DomainSuffix = dedupeDomains(destinationRule.Domain, destinationRule.DomainSuffix, destinationRule.DomainKeyword)
+	return newRuleSet(append(appendRule(nil, destinationRule), rules...)), nil
+}
+
+func isDestinationRule(rule option.HeadlessRule) bool {
+	if rule.Type != "" && rule.Type != C.RuleTypeDefault {
+		return false
+	}
+	options := rule.DefaultOptions
+	if options.Invert || options.DomainMatcher != nil && len(options.Domain) == 0 && len(options.DomainSuffix) == 0 || options.IPSet != nil && len(options.IPCIDR) == 0 {
+		return false
+	}
+	options.Domain = nil
+	options.DomainSuffix = nil
+	options.DomainKeyword = nil
+	options.DomainRegex = nil
+	options.IPCIDR = nil
+	options.DomainMatcher = nil
+	options.IPSet = nil
+	return !options.IsValid()
+}
+
+func aggregatePrefixes(prefixStrings []string) ([]string, error) {
+	if len(prefixStrings) == 0 {
+		return nil, nil
+	}
+	var builder netipx.IPSetBuilder
+	for _, prefixString := range prefixStrings {
+		prefix, err := parseClashPrefix(prefixString)
+		if err != nil {
+			return nil, err
+		}
+		builder.AddPrefix(netip.MustParsePrefix(prefix))
+	}
+	ipSet, err := builder.IPSet()
+	if err != nil {
+		return nil, err
+	}
+	return common.Map(ipSet.Prefixes(), netip.Prefix.String), nil
+}
+
+func dedupeStrings(values []string) []string {
+	values = common.Uniq(values)
+	sort.Strings(values)
+	return values
+}
+
+// dedupeKeywords drops keywords containing another keyword.
+func dedupeKeywords(keywords []string) []string {
+	keywords = dedupeStrings(keywords)
+	return common.Filter(keywords, func(it string) bool {
+		return !containsKeyword(it, keywords, it)
+	})
+}
+
+func containsKeyword(value string, keywords []string, except string) bool {
+	for _, keyword := range keywords {
+		if keyword != except && strings.Contains(value, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// dedupeDomains drops domains and suffixes already matched by a broader
+// suffix or by a keyword. A suffix "example.com" matches the domain itself
+// and its subdomains, while ".example.com" matches subdomains only.
+func dedupeDomains(domains []string, domainSuffix []string, keywords []string) ([]string, []string) {
+	rootSuffixes := make(map[string]bool)
+	dotSuffixes := make(map[string]bool)
+	for _, suffix := range domainSuffix {
+		if strings.HasPrefix(suffix, ".") {
+			dotSuffixes[suffix[1:]] = true
+		} else {
+			rootSuffixes[suffix] = true
+		}
+	}
+	coveredByParent := func(domain string) bool {
+		for parent := domain; ; {
+			index := strings.IndexByte(parent, '.')
+			if index == -1 {
+				return false
+			}
+			parent = parent[index+1:]
+			if rootSuffixes[parent] || dotSuffixes[parent] {
+				return true
+			}
+		}
+	}
+	var newSuffix []string
+	for _, suffix := range dedupeStrings(domainSuffix) {
+		name := strings.TrimPrefix(suffix, ".")
+		if containsKeyword(name, keywords, "") || coveredByParent(name) || name != suffix && rootSuffixes[name] {
+			continue
+		}
+		newSuffix = append(newSuffix, suffix)
+	}
+	var newDomains []string
+	for _, domain := range dedupeStrings(domains) {
+		if containsKeyword(domain, keywords, "") || rootSuffixes[domain] || coveredByParent(domain) {
+			continue
+		}
+		newDomains = append(newDomains, domain)
+	}
+	return newDomains, newSuffix
+}
@@ -0,0 +1,95 @@
+package ruleset
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+// Convert parses a third-party rule list into a plain rule set.
+// Entries that have no sing-box equivalent are returned as skipped
+// instead of failing the whole list.
+func Convert(format string, content []byte) (ruleSet option.PlainRuleSet, skipped []string, err error) {
+	switch format {
+	case C.RuleSetFormatClash:
+		return convertClash(content)
+	case C.RuleSetFormatHosts:
+		return convertHosts(content)
+	case C.RuleSetFormatAdBlock:
+		return convertAdBlock(content)
+	case C.RuleSetFormatDomainList:
+		return convertDomainList(content)
+	default:
+		return option.PlainRuleSet{}, nil, E.New("unknown rule set format: ", format)
+	}
+}
+
+func readLines(content []byte, commentPrefixes ...string) []string {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var isComment bool
+		for _, prefix := range commentPrefixes {
+			if strings.HasPrefix(line, prefix) {
+				isComment = true
+				break
+			}
+		}
+		if !isComment {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func newRule(rule option.DefaultHeadlessRule) option.HeadlessRule {
+	return option.HeadlessRule{
+		Type:           C.RuleTypeDefault,
+		DefaultOptions: rule,
+	}
+}
+
+// appendRule drops empty rules, since an empty default rule matches nothing
+// and is rejected when the rule set is loaded.
+func appendRule(rules []option.HeadlessRule, rule option.DefaultHeadlessRule) []option.HeadlessRule {
+	if !rule.IsValid() {
+		return rules
+	}
+	return append(rules, newRule(rule))
+}
+
+func newRuleSet(rules []option.HeadlessRule) option.PlainRuleSet {
+	return option.PlainRuleSet{Rules: rules}
+}
+
+func normalizeDomain(domain string) string {
+	return strings.ToLower(strings.TrimSuffix(domain, "."))
+}
+
+func isValidDomain(domain string) bool {
+	if domain == "" || len(domain) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(domain, ".") {
+		if label == "" || len(label) > 63 {
+			return false
+		}
+		for _, c := range label {
+			switch {
+			case c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '_':
+			default:
+				return false
+			}
+		}
+	}
+	return true
+}
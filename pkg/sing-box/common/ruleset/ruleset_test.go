@@ -0,0 +1,157 @@
+package ruleset_test
+
+import (
+	"testing"
+
+	"github.com/sagernet/sing-box/common/ruleset"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertClash(t *testing.T) {
+	t.Parallel()
+	content := `payload:
+  - 'DOMAIN-SUFFIX,bar.com'
+  - 'IP-CIDR,1.1.1.0/24,no-resolve'
+  - 'DST-PORT,80/8000-8080'
+  - 'GEOIP,CN'
+`
+	ruleSet, skipped, err := ruleset.Convert(C.RuleSetFormatClash, []byte(content))
+	require.NoError(t, err)
+	require.Equal(t, []string{"GEOIP,CN"}, skipped)
+	require.Len(t, ruleSet.Rules, 2)
+	destination := ruleSet.Rules[0].DefaultOptions
+	require.Equal(t, option.Listable[string]{"bar.com"}, destination.DomainSuffix)
+	require.Equal(t, option.Listable[string]{"1.1.1.0/24"}, destination.IPCIDR)
+	port := ruleSet.Rules[1].DefaultOptions
+	require.Equal(t, option.Listable[uint16]{80}, port.Port)
+	require.Equal(t, option.Listable[string]{"8000:8080"}, port.PortRange)
+}
+
+func TestConvertClashDomain(t *testing.T) {
+	t.Parallel()
+	content := `payload:
+  - '+.google.com'
+  - '.ads.example.com'
+  - '*.foo.com'
+`
+	ruleSet, skipped, err := ruleset.Convert(C.RuleSetFormatClash, []byte(content))
+	require.NoError(t, err)
+	require.Empty(t, skipped)
+	require.Len(t, ruleSet.Rules, 1)
+	destination := ruleSet.Rules[0].DefaultOptions
+	require.Equal(t, option.Listable[string]{"google.com", ".ads.example.com"}, destination.DomainSuffix)
+	require.Equal(t, option.Listable[string]{`^[^.]+\.foo\.com$`}, destination.DomainRegex)
+}
+
+func TestConvertClashText(t *testing.T) {
+	t.Parallel()
+	ruleSet, _, err := ruleset.Convert(C.RuleSetFormatClash, []byte("# comment\n10.0.0.1\n192.168.0.0/16\n"))
+	require.NoError(t, err)
+	require.Len(t, ruleSet.Rules, 1)
+	require.Equal(t, option.Listable[string]{"10.0.0.1/32", "192.168.0.0/16"}, ruleSet.Rules[0].DefaultOptions.IPCIDR)
+}
+
+func TestConvertClashMixed(t *testing.T) {
+	t.Parallel()
+	for _, content := range []string{
+		"example.com\n10.0.0.1\n",
+		"10.0.0.1\nexample.com\n",
+		"DOMAIN,example.com\nexample.org\n",
+	} {
+		_, _, err := ruleset.Convert(C.RuleSetFormatClash, []byte(content))
+		require.ErrorContains(t, err, "mixed rule-provider behaviors", content)
+	}
+}
+
+func TestConvertHosts(t *testing.T) {
+	t.Parallel()
+	content := "127.0.0.1 localhost\n0.0.0.0 ads.example.com tracker.example.com # ads\n::1 ip6-localhost\n"
+	ruleSet, skipped, err := ruleset.Convert(C.RuleSetFormatHosts, []byte(content))
+	require.NoError(t, err)
+	require.Empty(t, skipped)
+	require.Equal(t, option.Listable[string]{"ads.example.com", "tracker.example.com"}, ruleSet.Rules[0].DefaultOptions.Domain)
+}
+
+func TestConvertAdBlock(t *testing.T) {
+	t.Parallel()
+	content := `[Adblock Plus 2.0]
+! comment
+||ads.com^
+|exact.com^
+@@||good.ads.com^
+||x*.net^$important
+example.com##.banner
+||t.com^$third-party
+0.0.0.0 track.org
+`
+	ruleSet, skipped, err := ruleset.Convert(C.RuleSetFormatAdBlock, []byte(content))
+	require.NoError(t, err)
+	require.Equal(t, []string{"example.com##.banner", "||t.com^$third-party"}, skipped)
+	require.Len(t, ruleSet.Rules, 2)
+	logical := ruleSet.Rules[0].LogicalOptions
+	require.Equal(t, C.LogicalTypeAnd, logical.Mode)
+	block := logical.Rules[0].DefaultOptions
+	require.Equal(t, option.Listable[string]{"ads.com"}, block.DomainSuffix)
+	require.Equal(t, option.Listable[string]{"exact.com", "track.org"}, block.Domain)
+	exception := logical.Rules[1].DefaultOptions
+	require.True(t, exception.Invert)
+	require.Equal(t, option.Listable[string]{"good.ads.com"}, exception.DomainSuffix)
+	require.Equal(t, option.Listable[string]{`(^|\.)x[^.]+\.net$`}, ruleSet.Rules[1].DefaultOptions.DomainRegex)
+}
+
+func TestConvertDomainList(t *testing.T) {
+	t.Parallel()
+	content := "# comment\nexample.com\nfull:www.example.org @cn\nkeyword:ads\nregexp:^ad\\.\ninclude:other\n"
+	ruleSet, skipped, err := ruleset.Convert(C.RuleSetFormatDomainList, []byte(content))
+	require.NoError(t, err)
+	require.Equal(t, []string{"include:other"}, skipped)
+	rule := ruleSet.Rules[0].DefaultOptions
+	require.Equal(t, option.Listable[string]{"example.com"}, rule.DomainSuffix)
+	require.Equal(t, option.Listable[string]{"www.example.org"}, rule.Domain)
+	require.Equal(t, option.Listable[string]{"ads"}, rule.DomainKeyword)
+	require.Equal(t, option.Listable[string]{`^ad\.`}, rule.DomainRegex)
+}
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+	portRule := option.HeadlessRule{
+		Type:           C.RuleTypeDefault,
+		DefaultOptions: option.DefaultHeadlessRule{Port: []uint16{443}},
+	}
+	ruleSet, err := ruleset.Merge(option.PlainRuleSet{
+		Rules: []option.HeadlessRule{
+			{
+				Type: C.RuleTypeDefault,
+				DefaultOptions: option.DefaultHeadlessRule{
+					Domain:        []string{"example.com", "www.example.com", "other.org", "tracker.net"},
+					DomainKeyword: []string{"track", "tracker"},
+					IPCIDR:        []string{"10.0.0.0/9", "10.128.0.0/9"},
+				},
+			},
+			portRule,
+		},
+	}, option.PlainRuleSet{
+		Rules: []option.HeadlessRule{
+			{
+				Type: C.RuleTypeDefault,
+				DefaultOptions: option.DefaultHeadlessRule{
+					Domain:       []string{"other.org"},
+					DomainSuffix: []string{"example.com", ".a.example.com", ".other.org"},
+					IPCIDR:       []string{"10.1.2.3"},
+				},
+			},
+			portRule,
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, ruleSet.Rules, 2)
+	rule := ruleSet.Rules[0].DefaultOptions
+	require.Equal(t, option.Listable[string]{"other.org"}, rule.Domain)
+	require.Equal(t, option.Listable[string]{".other.org", "example.com"}, rule.DomainSuffix)
+	require.Equal(t, option.Listable[string]{"track"}, rule.DomainKeyword)
+	require.Equal(t, option.Listable[string]{"10.0.0.0/8"}, rule.IPCIDR)
+	require.Equal(t, portRule, ruleSet.Rules[1])
+}
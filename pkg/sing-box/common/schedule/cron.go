@@ -0,0 +1,191 @@
+package schedule
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+// Cron is a parsed five-field cron expression:
+// minute, hour, day of month, month and day of week.
+type Cron struct {
+	spec       string
+	minute     uint64
+	hour       uint64
+	dayOfMonth uint64
+	month      uint64
+	dayOfWeek  uint64
+	anyDay     bool
+	anyWeekday bool
+}
+
+type cronField struct {
+	min   int
+	max   int
+	names map[string]int
+}
+
+var (
+	minuteField     = cronField{0, 59, nil}
+	hourField       = cronField{0, 23, nil}
+	dayOfMonthField = cronField{1, 31, nil}
+	monthField      = cronField{1, 12, map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dayOfWeekField = cronField{0, 7, map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+func ParseCron(spec string) (*Cron, error) {
+	expression := strings.TrimSpace(spec)
+	if descriptor, loaded := cronDescriptors[strings.ToLower(expression)]; loaded {
+		expression = descriptor
+	}
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, E.New("invalid cron expression: ", spec, ": expected 5 fields, got ", len(fields))
+	}
+	cron := &Cron{spec: spec}
+	var err error
+	cron.minute, err = parseCronField(fields[0], minuteField)
+	if err != nil {
+		return nil, E.Cause(err, "parse minute")
+	}
+	cron.hour, err = parseCronField(fields[1], hourField)
+	if err != nil {
+		return nil, E.Cause(err, "parse hour")
+	}
+	cron.dayOfMonth, err = parseCronField(fields[2], dayOfMonthField)
+	if err != nil {
+		return nil, E.Cause(err, "parse day of month")
+	}
+	cron.month, err = parseCronField(fields[3], monthField)
+	if err != nil {
+		return nil, E.Cause(err, "parse month")
+	}
+	cron.dayOfWeek, err = parseCronField(fields[4], dayOfWeekField)
+	if err != nil {
+		return nil, E.Cause(err, "parse day of week")
+	}
+	// 7 is an alias of Sunday
+	if cron.dayOfWeek&(1<<7) != 0 {
+		cron.dayOfWeek |= 1
+	}
+	cron.anyDay = fields[2] == "*" || fields[2] == "?"
+	cron.anyWeekday = fields[4] == "*" || fields[4] == "?"
+	return cron, nil
+}
+
+func parseCronField(field string, bounds cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step <= 0 {
+				return 0, E.New("invalid step: ", part)
+			}
+		}
+		var start, end int
+		if rangePart == "*" || rangePart == "?" {
+			start, end = bounds.min, bounds.max
+		} else {
+			startPart, endPart, isRange := strings.Cut(rangePart, "-")
+			var err error
+			start, err = parseCronValue(startPart, bounds)
+			if err != nil {
+				return 0, err
+			}
+			if isRange {
+				end, err = parseCronValue(endPart, bounds)
+				if err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				end = bounds.max
+			} else {
+				end = start
+			}
+		}
+		if start > end {
+			return 0, E.New("invalid range: ", part)
+		}
+		for value := start; value <= end; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+	return bits, nil
+}
+
+func parseCronValue(value string, bounds cronField) (int, error) {
+	if bounds.names != nil {
+		if named, loaded := bounds.names[strings.ToLower(value)]; loaded {
+			return named, nil
+		}
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, E.New("invalid value: ", value)
+	}
+	if number < bounds.min || number > bounds.max {
+		return 0, E.New("value out of range [", bounds.min, ", ", bounds.max, "]: ", value)
+	}
+	return number, nil
+}
+
+// Next returns the first activation time strictly after t, in t's location.
+// A zero time is returned if the expression never matches within five years.
+func (c *Cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (c *Cron) matchDay(t time.Time) bool {
+	dayMatch := c.dayOfMonth&(1<<uint(t.Day())) != 0
+	weekdayMatch := c.dayOfWeek&(1<<uint(t.Weekday())) != 0
+	if c.anyDay || c.anyWeekday {
+		return dayMatch && weekdayMatch
+	}
+	// like cron(8), restricting both fields matches either of them
+	return dayMatch || weekdayMatch
+}
+
+func (c *Cron) String() string {
+	return c.spec
+}
@@ -0,0 +1,110 @@
+package schedule
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing/common/ntp"
+	"github.com/sagernet/sing/service"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCronNext(t *testing.T) {
+	t.Parallel()
+	base := time.Date(2024, time.March, 1, 10, 30, 15, 0, time.UTC) // Friday
+	cron, err := ParseCron("0 22 * * mon-fri")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, time.March, 1, 22, 0, 0, 0, time.UTC), cron.Next(base))
+	require.Equal(t, time.Date(2024, time.March, 4, 22, 0, 0, 0, time.UTC), cron.Next(cron.Next(base)))
+	cron, err = ParseCron("*/15 * * * *")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, time.March, 1, 10, 45, 0, 0, time.UTC), cron.Next(base))
+	cron, err = ParseCron("@monthly")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC), cron.Next(base))
+	cron, err = ParseCron("0 0 29 2 *")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC), cron.Next(time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)))
+	_, err = ParseCron("0 25 * * *")
+	require.Error(t, err)
+	_, err = ParseCron("0 0 * *")
+	require.Error(t, err)
+}
+
+func TestTimeRange(t *testing.T) {
+	t.Parallel()
+	overnight, err := ParseTimeRange("22:00-06:00")
+	require.NoError(t, err)
+	require.True(t, overnight.Contains(time.Date(2024, time.March, 1, 23, 0, 0, 0, time.UTC)))
+	require.True(t, overnight.Contains(time.Date(2024, time.March, 1, 5, 59, 0, 0, time.UTC)))
+	require.False(t, overnight.Contains(time.Date(2024, time.March, 1, 6, 0, 0, 0, time.UTC)))
+	daytime, err := ParseTimeRange("09:00-18:30")
+	require.NoError(t, err)
+	require.True(t, daytime.Contains(time.Date(2024, time.March, 1, 9, 0, 0, 0, time.UTC)))
+	require.False(t, daytime.Contains(time.Date(2024, time.March, 1, 18, 30, 0, 0, time.UTC)))
+	require.Equal(t, "09:00-18:30", daytime.String())
+	_, err = ParseTimeRange("9-18")
+	require.Error(t, err)
+	weekday, err := ParseWeekday("Sat")
+	require.NoError(t, err)
+	require.Equal(t, time.Saturday, weekday)
+	weekday, err = ParseWeekday("7")
+	require.NoError(t, err)
+	require.Equal(t, time.Sunday, weekday)
+}
+
+type fakeTimeService struct {
+	offset time.Duration
+}
+
+func (s *fakeTimeService) TimeFunc() func() time.Time {
+	return func() time.Time {
+		return time.Now().Add(s.offset)
+	}
+}
+
+func TestSchedulerClock(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	nextMinute := now.Truncate(time.Minute).Add(time.Minute)
+	clock := &fakeTimeService{offset: nextMinute.Add(-100 * time.Millisecond).Sub(now)}
+	ctx := service.ContextWith[ntp.TimeService](context.Background(), clock)
+	cron, err := ParseCron("* * * * *")
+	require.NoError(t, err)
+	done := make(chan struct{}, 1)
+	scheduler := NewScheduler(ctx, []Task{{
+		Cron:     cron,
+		Location: time.UTC,
+		Action: func() {
+			select {
+			case done <- struct{}{}:
+			default:
+			}
+		},
+	}})
+	require.NoError(t, scheduler.Start())
+	defer scheduler.Close()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduled task not triggered by the injected clock")
+	}
+}
+
+func TestTimeRangeStartDay(t *testing.T) {
+	t.Parallel()
+	overnight, err := ParseTimeRange("22:00-06:00")
+	require.NoError(t, err)
+	friday := time.Date(2024, time.March, 1, 23, 0, 0, 0, time.UTC)
+	require.Equal(t, time.Friday, overnight.StartDay(friday))
+	saturdayMorning := time.Date(2024, time.March, 2, 5, 30, 0, 0, time.UTC)
+	require.True(t, overnight.Contains(saturdayMorning))
+	require.Equal(t, time.Friday, overnight.StartDay(saturdayMorning))
+	sundayMorning := time.Date(2024, time.March, 3, 0, 0, 0, 0, time.UTC)
+	require.Equal(t, time.Saturday, overnight.StartDay(sundayMorning))
+	daytime, err := ParseTimeRange("00:00-06:00")
+	require.NoError(t, err)
+	require.Equal(t, time.Saturday, daytime.StartDay(saturdayMorning))
+}
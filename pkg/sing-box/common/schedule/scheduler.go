@@ -0,0 +1,80 @@
+package schedule
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/ntp"
+)
+
+type Task struct {
+	Name     string
+	Cron     *Cron
+	Location *time.Location
+	Action   func()
+}
+
+// Scheduler runs tasks at their cron activation times.
+// The clock is taken from the time service in the context if present,
+// so NTP-corrected time (or a fake clock in tests) is respected.
+type Scheduler struct {
+	ctx      context.Context
+	cancel   common.ContextCancelCauseFunc
+	timeFunc func() time.Time
+	tasks    []Task
+}
+
+func NewScheduler(ctx context.Context, tasks []Task) *Scheduler {
+	ctx, cancel := common.ContextWithCancelCause(ctx)
+	return &Scheduler{
+		ctx:    ctx,
+		cancel: cancel,
+		tasks:  tasks,
+	}
+}
+
+func (s *Scheduler) Start() error {
+	s.timeFunc = ntp.TimeFuncFromContext(s.ctx)
+	if s.timeFunc == nil {
+		s.timeFunc = time.Now
+	}
+	for _, task := range s.tasks {
+		go s.loopTask(task)
+	}
+	return nil
+}
+
+func (s *Scheduler) Close() error {
+	s.cancel(os.ErrClosed)
+	return nil
+}
+
+func (s *Scheduler) loopTask(task Task) {
+	location := task.Location
+	if location == nil {
+		location = time.Local
+	}
+	var lastRun time.Time
+	for {
+		now := s.timeFunc()
+		if now.Before(lastRun) {
+			// timers may fire slightly before the clock reaches the activation time
+			now = lastRun
+		}
+		next := task.Cron.Next(now.In(location))
+		if next.IsZero() {
+			return
+		}
+		timer := time.NewTimer(next.Sub(s.timeFunc()))
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		lastRun = next
+		task.Action()
+	}
+}
@@ -0,0 +1,121 @@
+package schedule
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+// TimeRange is a daily clock window in minutes since midnight.
+// A range whose end is before its start wraps around midnight.
+type TimeRange struct {
+	Start int
+	End   int
+}
+
+func ParseTimeRange(value string) (TimeRange, error) {
+	startPart, endPart, found := strings.Cut(value, "-")
+	if !found {
+		return TimeRange{}, E.New("invalid time range: ", value, ": expected HH:MM-HH:MM")
+	}
+	start, err := parseClock(strings.TrimSpace(startPart))
+	if err != nil {
+		return TimeRange{}, E.Cause(err, "invalid time range: ", value)
+	}
+	end, err := parseClock(strings.TrimSpace(endPart))
+	if err != nil {
+		return TimeRange{}, E.Cause(err, "invalid time range: ", value)
+	}
+	return TimeRange{start, end}, nil
+}
+
+func parseClock(value string) (int, error) {
+	hourPart, minutePart, found := strings.Cut(value, ":")
+	if !found {
+		return 0, E.New("bad clock: ", value)
+	}
+	hour, err := strconv.Atoi(hourPart)
+	if err != nil || hour < 0 || hour > 24 {
+		return 0, E.New("bad hour: ", value)
+	}
+	minute, err := strconv.Atoi(minutePart)
+	if err != nil || minute < 0 || minute > 59 || hour == 24 && minute != 0 {
+		return 0, E.New("bad minute: ", value)
+	}
+	return hour*60 + minute, nil
+}
+
+// Contains reports whether the wall clock of t falls in [Start, End).
+func (r TimeRange) Contains(t time.Time) bool {
+	clock := t.Hour()*60 + t.Minute()
+	if r.Start <= r.End {
+		return clock >= r.Start && clock < r.End
+	}
+	return clock >= r.Start || clock < r.End
+}
+
+// StartDay returns the weekday on which the window containing t began,
+// which is the previous day for the part of a window after midnight.
+func (r TimeRange) StartDay(t time.Time) time.Weekday {
+	if r.Start > r.End && t.Hour()*60+t.Minute() < r.End {
+		return t.AddDate(0, 0, -1).Weekday()
+	}
+	return t.Weekday()
+}
+
+func (r TimeRange) String() string {
+	return formatClock(r.Start) + "-" + formatClock(r.End)
+}
+
+func formatClock(clock int) string {
+	hour := strconv.Itoa(clock / 60)
+	minute := strconv.Itoa(clock % 60)
+	if len(hour) == 1 {
+		hour = "0" + hour
+	}
+	if len(minute) == 1 {
+		minute = "0" + minute
+	}
+	return hour + ":" + minute
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun":       time.Sunday,
+	"sunday":    time.Sunday,
+	"mon":       time.Monday,
+	"monday":    time.Monday,
+	"tue":       time.Tuesday,
+	"tuesday":   time.Tuesday,
+	"wed":       time.Wednesday,
+	"wednesday": time.Wednesday,
+	"thu":       time.Thursday,
+	"thursday":  time.Thursday,
+	"fri":       time.Friday,
+	"friday":    time.Friday,
+	"sat":       time.Saturday,
+	"saturday":  time.Saturday,
+}
+
+func ParseWeekday(value string) (time.Weekday, error) {
+	if weekday, loaded := weekdayNames[strings.ToLower(strings.TrimSpace(value))]; loaded {
+		return weekday, nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 0 || number > 7 {
+		return 0, E.New("invalid weekday: ", value)
+	}
+	return time.Weekday(number % 7), nil
+}
+
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, E.Cause(err, "load time zone: ", name)
+	}
+	return location, nil
+}
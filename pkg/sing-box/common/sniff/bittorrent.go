@@ -0,0 +1,153 @@
+package sniff
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+)
+
+const bitTorrentProtocolName = "BitTorrent protocol"
+
+// BitTorrentHandshake sniffs the peer wire protocol handshake (BEP 3).
+func BitTorrentHandshake(ctx context.Context, reader io.Reader) (*adapter.InboundContext, error) {
+	var header [1 + len(bitTorrentProtocolName)]byte
+	_, err := io.ReadFull(reader, header[:])
+	if err != nil {
+		return nil, err
+	}
+	if header[0] != byte(len(bitTorrentProtocolName)) || string(header[1:]) != bitTorrentProtocolName {
+		return nil, os.ErrInvalid
+	}
+	return &adapter.InboundContext{Protocol: C.ProtocolBitTorrent}, nil
+}
+
+// BitTorrentUTP sniffs the ST_SYN packet that starts a uTP connection (BEP 29).
+func BitTorrentUTP(ctx context.Context, packet []byte) (*adapter.InboundContext, error) {
+	const headerLength = 20
+	if len(packet) < headerLength {
+		return nil, os.ErrInvalid
+	}
+	// type ST_SYN, version 1
+	if packet[0] != 0x41 {
+		return nil, os.ErrInvalid
+	}
+	extension := packet[1]
+	extensions := packet[headerLength:]
+	for extension != 0 {
+		// selective ACK and extension bits
+		if extension > 2 || len(extensions) < 2 {
+			return nil, os.ErrInvalid
+		}
+		extension = extensions[0]
+		extensionLength := int(extensions[1])
+		if len(extensions) < 2+extensionLength {
+			return nil, os.ErrInvalid
+		}
+		extensions = extensions[2+extensionLength:]
+	}
+	if len(extensions) > 0 {
+		return nil, os.ErrInvalid
+	}
+	return &adapter.InboundContext{Protocol: C.ProtocolBitTorrent}, nil
+}
+
+// BitTorrentDHT sniffs a KRPC message of the DHT protocol (BEP 5),
+// a bencoded dictionary with a transaction ID and a message type.
+func BitTorrentDHT(ctx context.Context, packet []byte) (*adapter.InboundContext, error) {
+	if len(packet) < 2 || packet[0] != 'd' {
+		return nil, os.ErrInvalid
+	}
+	var (
+		hasTransactionID bool
+		hasMessageType   bool
+	)
+	content := packet[1:]
+	for {
+		if len(content) == 0 {
+			return nil, os.ErrInvalid
+		}
+		if content[0] == 'e' {
+			content = content[1:]
+			break
+		}
+		key, next, err := bencodeString(content)
+		if err != nil {
+			return nil, err
+		}
+		valueEnd, err := bencodeSkip(next, 0)
+		if err != nil {
+			return nil, err
+		}
+		value := next[:valueEnd]
+		switch string(key) {
+		case "t":
+			hasTransactionID = value[0] >= '0' && value[0] <= '9'
+		case "y":
+			hasMessageType = bytes.Equal(value, []byte("1:q")) || bytes.Equal(value, []byte("1:r")) || bytes.Equal(value, []byte("1:e"))
+		}
+		content = next[valueEnd:]
+	}
+	if len(content) != 0 || !hasTransactionID || !hasMessageType {
+		return nil, os.ErrInvalid
+	}
+	return &adapter.InboundContext{Protocol: C.ProtocolBitTorrent}, nil
+}
+
+func bencodeString(content []byte) ([]byte, []byte, error) {
+	separator := bytes.IndexByte(content, ':')
+	if separator < 1 {
+		return nil, nil, os.ErrInvalid
+	}
+	var length int
+	for _, digit := range content[:separator] {
+		if digit < '0' || digit > '9' || length > len(content) {
+			return nil, nil, os.ErrInvalid
+		}
+		length = length*10 + int(digit-'0')
+	}
+	content = content[separator+1:]
+	if len(content) < length {
+		return nil, nil, os.ErrInvalid
+	}
+	return content[:length], content[length:], nil
+}
+
+// bencodeSkip returns the length of the bencoded value at the start of content.
+func bencodeSkip(content []byte, depth int) (int, error) {
+	if len(content) == 0 || depth > 16 {
+		return 0, os.ErrInvalid
+	}
+	switch content[0] {
+	case 'i':
+		end := bytes.IndexByte(content, 'e')
+		if end < 2 {
+			return 0, os.ErrInvalid
+		}
+		return end + 1, nil
+	case 'l', 'd':
+		offset := 1
+		for {
+			if offset >= len(content) {
+				return 0, os.ErrInvalid
+			}
+			if content[offset] == 'e' {
+				return offset + 1, nil
+			}
+			length, err := bencodeSkip(content[offset:], depth+1)
+			if err != nil {
+				return 0, err
+			}
+			offset += length
+		}
+	default:
+		_, next, err := bencodeString(content)
+		if err != nil {
+			return 0, err
+		}
+		return len(content) - len(next), nil
+	}
+}
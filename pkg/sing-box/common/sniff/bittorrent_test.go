@@ -0,0 +1,81 @@
+package sniff_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/sagernet/sing-box/common/sniff"
+	C "github.com/sagernet/sing-box/constant"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSniffBitTorrent(t *testing.T) {
+	t.Parallel()
+	pkt, err := hex.DecodeString("13426974546f7272656e742070726f746f636f6c0000000000100005dd8255ecdc7ca55fb0bbf81323d87062db1f6d1c2d7142343635302d6b3461303864634e4d2e7833")
+	require.NoError(t, err)
+	metadata, err := sniff.BitTorrentHandshake(context.Background(), bytes.NewReader(pkt))
+	require.NoError(t, err)
+	require.Equal(t, C.ProtocolBitTorrent, metadata.Protocol)
+}
+
+func TestSniffUTP(t *testing.T) {
+	t.Parallel()
+	pkt, err := hex.DecodeString("41007e1f5f2b63e100000000003800005b1c0000")
+	require.NoError(t, err)
+	metadata, err := sniff.BitTorrentUTP(context.Background(), pkt)
+	require.NoError(t, err)
+	require.Equal(t, C.ProtocolBitTorrent, metadata.Protocol)
+}
+
+func TestSniffUTPWithExtension(t *testing.T) {
+	t.Parallel()
+	pkt, err := hex.DecodeString("41027e1f5f2b63e100000000003800005b1c000000080000000000000000")
+	require.NoError(t, err)
+	metadata, err := sniff.BitTorrentUTP(context.Background(), pkt)
+	require.NoError(t, err)
+	require.Equal(t, C.ProtocolBitTorrent, metadata.Protocol)
+}
+
+func TestSniffNotUTP(t *testing.T) {
+	t.Parallel()
+	// WireGuard handshake initiation
+	pkt, err := hex.DecodeString("01000000d837d0305fd8ca0bf18f9e00000000000000000000000000000000")
+	require.NoError(t, err)
+	_, err = sniff.BitTorrentUTP(context.Background(), pkt)
+	require.Error(t, err)
+}
+
+func TestSniffDHT(t *testing.T) {
+	t.Parallel()
+	for _, pkt := range []string{
+		"d1:ad2:id20:abcdefghij0123456789e1:q4:ping1:t2:aa1:y1:qe",
+		"d1:rd2:id20:mnopqrstuvwxyz123456e1:t2:aa1:y1:re",
+		"d1:ad2:id20:abcdefghij01234567896:target20:mnopqrstuvwxyz123456e1:q9:find_node1:t2:aa1:y1:qe",
+	} {
+		metadata, err := sniff.BitTorrentDHT(context.Background(), []byte(pkt))
+		require.NoError(t, err, pkt)
+		require.Equal(t, C.ProtocolBitTorrent, metadata.Protocol)
+	}
+}
+
+func TestSniffNotDHT(t *testing.T) {
+	t.Parallel()
+	for _, pkt := range []string{
+		"d1:ad2:id20:abcdefghij0123456789e1:q4:ping1:t2:aae",
+		"d1:ad2:id20:abcdefghij0123456789e1:q4:ping1:t2:aa1:y1:q",
+		"d1:y1:q1:t2:aae?",
+	} {
+		_, err := sniff.BitTorrentDHT(context.Background(), []byte(pkt))
+		require.Error(t, err, pkt)
+	}
+}
+
+func FuzzSniffDHT(f *testing.F) {
+	f.Add([]byte("d1:ad2:id20:abcdefghij0123456789e1:q4:ping1:t2:aa1:y1:qe"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		sniff.BitTorrentDHT(context.Background(), data)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	std_bufio "bufio"
 	"context"
 	"io"
+	"os"
 
 	"github.com/sagernet/sing-box/adapter"
 	C "github.com/sagernet/sing-box/constant"
@@ -16,5 +17,9 @@ func HTTPHost(ctx context.Context, reader io.Reader) (*adapter.InboundContext, e
 	if err != nil {
 		return nil, err
 	}
+	// HTTP/2 connection preface, handled by HTTP2Authority
+	if request.Method == "PRI" && request.ProtoMajor == 2 {
+		return nil, os.ErrInvalid
+	}
 	return &adapter.InboundContext{Protocol: C.ProtocolHTTP, Domain: M.ParseSocksaddr(request.Host).AddrString()}, nil
 }
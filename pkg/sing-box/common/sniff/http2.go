@@ -0,0 +1,109 @@
+package sniff
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"os"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+
+	"golang.org/x/net/http2/hpack"
+)
+
+const http2ClientPreface = "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"
+
+const (
+	http2FrameHeaders      = 0x1
+	http2FrameContinuation = 0x9
+
+	http2FlagEndHeaders = 0x4
+	http2FlagPadded     = 0x8
+	http2FlagPriority   = 0x20
+
+	http2MaxFrameSize = 16384
+	http2MaxFrames    = 8
+)
+
+// HTTP2Authority sniffs the :authority of the first request
+// of HTTP/2 with prior knowledge (RFC 9113 3.3).
+func HTTP2Authority(ctx context.Context, reader io.Reader) (*adapter.InboundContext, error) {
+	var preface [len(http2ClientPreface)]byte
+	_, err := io.ReadFull(reader, preface[:])
+	if err != nil {
+		return nil, err
+	}
+	if string(preface[:]) != http2ClientPreface {
+		return nil, os.ErrInvalid
+	}
+	var headerBlock []byte
+	for i := 0; i < http2MaxFrames; i++ {
+		var header [9]byte
+		_, err = io.ReadFull(reader, header[:])
+		if err != nil {
+			return nil, err
+		}
+		length := int(header[0])<<16 | int(binary.BigEndian.Uint16(header[1:3]))
+		frameType := header[3]
+		flags := header[4]
+		if length > http2MaxFrameSize {
+			return nil, E.New("frame too large: ", length)
+		}
+		payload := make([]byte, length)
+		_, err = io.ReadFull(reader, payload)
+		if err != nil {
+			return nil, err
+		}
+		switch frameType {
+		case http2FrameHeaders:
+			if headerBlock != nil {
+				return nil, os.ErrInvalid
+			}
+			if flags&http2FlagPadded != 0 {
+				if len(payload) < 1 || int(payload[0]) >= len(payload) {
+					return nil, os.ErrInvalid
+				}
+				payload = payload[1 : len(payload)-int(payload[0])]
+			}
+			if flags&http2FlagPriority != 0 {
+				if len(payload) < 5 {
+					return nil, os.ErrInvalid
+				}
+				payload = payload[5:]
+			}
+			headerBlock = payload
+		case http2FrameContinuation:
+			if headerBlock == nil {
+				return nil, os.ErrInvalid
+			}
+			headerBlock = append(headerBlock, payload...)
+		default:
+			if headerBlock != nil {
+				return nil, os.ErrInvalid
+			}
+			continue
+		}
+		if flags&http2FlagEndHeaders == 0 {
+			continue
+		}
+		headerFields, err := hpack.NewDecoder(4096, nil).DecodeFull(headerBlock)
+		if err != nil {
+			return nil, err
+		}
+		var authority string
+		for _, field := range headerFields {
+			if field.Name == ":authority" || field.Name == "host" && authority == "" {
+				authority = field.Value
+			}
+		}
+		metadata := &adapter.InboundContext{Protocol: C.ProtocolHTTP}
+		if authority != "" {
+			metadata.Domain = M.ParseSocksaddr(authority).AddrString()
+		}
+		return metadata, nil
+	}
+	return nil, E.New("missing HTTP/2 headers")
+}
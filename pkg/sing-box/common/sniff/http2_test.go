@@ -0,0 +1,38 @@
+package sniff_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/sagernet/sing-box/common/sniff"
+	C "github.com/sagernet/sing-box/constant"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSniffHTTP2(t *testing.T) {
+	t.Parallel()
+	pkt, err := hex.DecodeString("505249202a20485454502f322e300d0a0d0a534d0d0a0d0a00001204000000000000030000006400040200000000020000000000000408000000000001ff0001000020010500000001828486418c2f91d35d055c87a6e05e03c17a8825b650c3abbcf2e153032a2f2a")
+	require.NoError(t, err)
+	metadata, err := sniff.HTTP2Authority(context.Background(), bytes.NewReader(pkt))
+	require.NoError(t, err)
+	require.Equal(t, C.ProtocolHTTP, metadata.Protocol)
+	require.Equal(t, "example.com", metadata.Domain)
+}
+
+func TestSniffHTTP2Incomplete(t *testing.T) {
+	t.Parallel()
+	pkt, err := hex.DecodeString("505249202a20485454502f322e300d0a0d0a534d0d0a0d0a000012040000000000000300000064000402000000000002000000000000")
+	require.NoError(t, err)
+	_, err = sniff.HTTP2Authority(context.Background(), bytes.NewReader(pkt))
+	require.Error(t, err)
+}
+
+func TestSniffHTTP2Preface(t *testing.T) {
+	t.Parallel()
+	_, err := sniff.HTTPHost(context.Background(), strings.NewReader("PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"))
+	require.Error(t, err)
+}
@@ -0,0 +1,32 @@
+package sniff
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"os"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+)
+
+// RDPConnectionRequest sniffs the X.224 Connection Request in a TPKT
+// header that starts an RDP connection (MS-RDPBCGR 2.2.1.1).
+func RDPConnectionRequest(ctx context.Context, reader io.Reader) (*adapter.InboundContext, error) {
+	var header [8]byte
+	_, err := io.ReadFull(reader, header[:])
+	if err != nil {
+		return nil, err
+	}
+	// TPKT version 3
+	if header[0] != 0x03 || header[1] != 0x00 {
+		return nil, os.ErrInvalid
+	}
+	length := int(binary.BigEndian.Uint16(header[2:4]))
+	// X.224 length indicator covers the rest of the packet,
+	// and the Connection Request code has a zero destination reference.
+	if length < 11 || int(header[4]) != length-5 || header[5] != 0xE0 || header[6] != 0x00 || header[7] != 0x00 {
+		return nil, os.ErrInvalid
+	}
+	return &adapter.InboundContext{Protocol: C.ProtocolRDP}, nil
+}
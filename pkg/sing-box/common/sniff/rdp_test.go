@@ -0,0 +1,31 @@
+package sniff_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/sagernet/sing-box/common/sniff"
+	C "github.com/sagernet/sing-box/constant"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSniffRDP(t *testing.T) {
+	t.Parallel()
+	pkt, err := hex.DecodeString("030000130ee000000000000100080003000000")
+	require.NoError(t, err)
+	metadata, err := sniff.RDPConnectionRequest(context.Background(), bytes.NewReader(pkt))
+	require.NoError(t, err)
+	require.Equal(t, C.ProtocolRDP, metadata.Protocol)
+}
+
+func TestSniffRDPWithCookie(t *testing.T) {
+	t.Parallel()
+	pkt, err := hex.DecodeString("0300002a25e00000000000436f6f6b69653a206d737473686173683d61646d696e0d0a010008000b000000")
+	require.NoError(t, err)
+	metadata, err := sniff.RDPConnectionRequest(context.Background(), bytes.NewReader(pkt))
+	require.NoError(t, err)
+	require.Equal(t, C.ProtocolRDP, metadata.Protocol)
+}
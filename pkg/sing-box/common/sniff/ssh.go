@@ -0,0 +1,26 @@
+package sniff
+
+import (
+	std_bufio "bufio"
+	"context"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+)
+
+// SSHBanner sniffs the identification string sent by SSH clients (RFC 4253).
+func SSHBanner(ctx context.Context, reader io.Reader) (*adapter.InboundContext, error) {
+	const maxBannerLength = 255
+	line, err := std_bufio.NewReaderSize(reader, maxBannerLength).ReadSlice('\n')
+	if err != nil {
+		return nil, err
+	}
+	banner := strings.TrimRight(string(line), "\r\n")
+	if !strings.HasPrefix(banner, "SSH-2.0-") && !strings.HasPrefix(banner, "SSH-1.99-") {
+		return nil, os.ErrInvalid
+	}
+	return &adapter.InboundContext{Protocol: C.ProtocolSSH}, nil
+}
@@ -0,0 +1,29 @@
+package sniff_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/sagernet/sing-box/common/sniff"
+	C "github.com/sagernet/sing-box/constant"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSniffSSH(t *testing.T) {
+	t.Parallel()
+	pkt, err := hex.DecodeString("5353482d322e302d4f70656e5353485f392e3270312044656269616e2d322b646562313275370d0a")
+	require.NoError(t, err)
+	metadata, err := sniff.SSHBanner(context.Background(), bytes.NewReader(pkt))
+	require.NoError(t, err)
+	require.Equal(t, C.ProtocolSSH, metadata.Protocol)
+}
+
+func TestSniffNotSSH(t *testing.T) {
+	t.Parallel()
+	_, err := sniff.SSHBanner(context.Background(), strings.NewReader("GET / HTTP/1.1\r\nHost: www.google.com\r\n\r\n"))
+	require.Error(t, err)
+}
@@ -153,7 +153,7 @@ func readDefaultRule(reader io.Reader, recovery bool) (rule option.DefaultHeadle
 			rule.Network, err = readRuleItemString(reader)
 		case ruleItemDomain:
 			var matcher *domain.Matcher
-			matcher, err = domain.ReadMatcher(reader)
+			matcher, rule.Domain, rule.DomainSuffix, err = readDomainMatcher(reader, recovery)
 			if err != nil {
 				return
 			}
@@ -0,0 +1,60 @@
+package srs_test
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/sagernet/sing-box/common/srs"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+)
+
+func generateDomain(index int) string {
+	return "host" + strconv.Itoa(index*7919%100003) + ".example" + strconv.Itoa(index%97) + ".com"
+}
+
+// generateDomainRuleSet builds a geosite-like rule set where every third
+// entry is a domain suffix.
+func generateDomainRuleSet(size int) option.PlainRuleSet {
+	var rule option.DefaultHeadlessRule
+	for i := 0; i < size; i++ {
+		if i%3 == 0 {
+			rule.DomainSuffix = append(rule.DomainSuffix, generateDomain(i))
+		} else {
+			rule.Domain = append(rule.Domain, generateDomain(i))
+		}
+	}
+	return option.PlainRuleSet{
+		Rules: []option.HeadlessRule{{Type: C.RuleTypeDefault, DefaultOptions: rule}},
+	}
+}
+
+// BenchmarkRead reports load time and allocations per load, and the heap
+// retained by loaded rule sets as heap-B/op.
+func BenchmarkRead(b *testing.B) {
+	var buffer bytes.Buffer
+	err := srs.Write(&buffer, generateDomainRuleSet(200000))
+	if err != nil {
+		b.Fatal(err)
+	}
+	content := buffer.Bytes()
+	ruleSets := make([]option.PlainRuleSet, b.N)
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ruleSets[i], err = srs.Read(bytes.NewReader(content), false)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	b.ReportMetric(float64(after.HeapAlloc-before.HeapAlloc)/float64(b.N), "heap-B/op")
+	runtime.KeepAlive(ruleSets)
+}
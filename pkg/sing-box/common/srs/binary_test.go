@@ -0,0 +1,38 @@
+package srs_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sagernet/sing-box/common/srs"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecoverRuleSet(t *testing.T) {
+	t.Parallel()
+	ruleSet := option.PlainRuleSet{
+		Rules: []option.HeadlessRule{
+			{
+				Type: C.RuleTypeDefault,
+				DefaultOptions: option.DefaultHeadlessRule{
+					Domain:       []string{"example.org", "www.example.net", "例子.测试"},
+					DomainSuffix: []string{"example.com", ".sub.example.net", "google.com"},
+					IPCIDR:       []string{"10.0.0.0/8", "2001:db8::/32"},
+				},
+			},
+		},
+	}
+	var buffer bytes.Buffer
+	require.NoError(t, srs.Write(&buffer, ruleSet))
+	recovered, err := srs.Read(bytes.NewReader(buffer.Bytes()), true)
+	require.NoError(t, err)
+	require.Len(t, recovered.Rules, 1)
+	rule := recovered.Rules[0].DefaultOptions
+	require.Equal(t, option.Listable[string]{"example.org", "www.example.net", "例子.测试"}, rule.Domain)
+	require.Equal(t, option.Listable[string]{".sub.example.net", "example.com", "google.com"}, rule.DomainSuffix)
+	require.Equal(t, option.Listable[string]{"10.0.0.0/8", "2001:db8::/32"}, rule.IPCIDR)
+	require.True(t, rule.DomainMatcher.Match("www.google.com"))
+}
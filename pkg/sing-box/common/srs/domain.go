@@ -0,0 +1,136 @@
+package srs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sagernet/sing/common/domain"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/rw"
+)
+
+const domainPrefixLabel = '\r'
+
+// readDomainMatcher reads a domain matcher and, in recovery mode,
+// also restores the domain and domain_suffix lists it was built from.
+func readDomainMatcher(reader io.Reader, recovery bool) (matcher *domain.Matcher, domains []string, domainSuffix []string, err error) {
+	if !recovery {
+		matcher, err = domain.ReadMatcher(reader)
+		return
+	}
+	var content bytes.Buffer
+	matcher, err = domain.ReadMatcher(io.TeeReader(reader, &content))
+	if err != nil {
+		return
+	}
+	domains, domainSuffix, err = dumpDomainMatcher(&content)
+	return
+}
+
+// dumpDomainMatcher walks the serialized succinct trie of a domain matcher.
+// Nodes are stored in breadth-first order: the label bitmap holds, for each
+// node, one zero bit per child followed by a one bit, and the n-th label
+// belongs to node n+1.
+func dumpDomainMatcher(reader io.Reader) (domains []string, domainSuffix []string, err error) {
+	var version uint8
+	err = binary.Read(reader, binary.BigEndian, &version)
+	if err != nil {
+		return
+	}
+	leaves, err := readUint64Slice(reader)
+	if err != nil {
+		return
+	}
+	labelBitmap, err := readUint64Slice(reader)
+	if err != nil {
+		return
+	}
+	labelsLength, err := rw.ReadUVariant(reader)
+	if err != nil {
+		return
+	}
+	labels := make([]byte, labelsLength)
+	_, err = io.ReadFull(reader, labels)
+	if err != nil {
+		return
+	}
+	getBit := func(bitmap []uint64, index int) bool {
+		return index>>6 < len(bitmap) && bitmap[index>>6]&(1<<uint(index&63)) != 0
+	}
+	keys := make([]string, len(labels)+1)
+	var reversedKeys []string
+	var bitmapIndex, labelIndex int
+	for node := 0; node < len(keys); node++ {
+		if getBit(leaves, node) {
+			reversedKeys = append(reversedKeys, keys[node])
+		}
+		for ; ; bitmapIndex++ {
+			if bitmapIndex>>6 >= len(labelBitmap) {
+				return nil, nil, E.New("bad domain matcher: truncated label bitmap")
+			}
+			if getBit(labelBitmap, bitmapIndex) {
+				bitmapIndex++
+				break
+			}
+			if labelIndex >= len(labels) {
+				return nil, nil, E.New("bad domain matcher: label out of range")
+			}
+			keys[labelIndex+1] = keys[node] + string(labels[labelIndex:labelIndex+1])
+			labelIndex++
+		}
+	}
+	exactDomains := make(map[string]bool)
+	var suffixes []string
+	for _, key := range reversedKeys {
+		if strings.HasSuffix(key, string(domainPrefixLabel)) {
+			suffixes = append(suffixes, reverseDomain(key[:len(key)-1]))
+		} else {
+			exactDomains[reverseDomain(key)] = true
+		}
+	}
+	// a root domain suffix "example.com" is stored as both the exact domain
+	// and the ".example.com" suffix
+	for _, suffix := range suffixes {
+		rootDomain := strings.TrimPrefix(suffix, ".")
+		if rootDomain != suffix && exactDomains[rootDomain] {
+			delete(exactDomains, rootDomain)
+			domainSuffix = append(domainSuffix, rootDomain)
+		} else {
+			domainSuffix = append(domainSuffix, suffix)
+		}
+	}
+	for exactDomain := range exactDomains {
+		domains = append(domains, exactDomain)
+	}
+	sort.Strings(domains)
+	sort.Strings(domainSuffix)
+	return
+}
+
+func readUint64Slice(reader io.Reader) ([]uint64, error) {
+	length, err := rw.ReadUVariant(reader)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]uint64, length)
+	err = binary.Read(reader, binary.BigEndian, values)
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func reverseDomain(domain string) string {
+	l := len(domain)
+	b := make([]byte, l)
+	for i := 0; i < l; {
+		r, n := utf8.DecodeRuneInString(domain[i:])
+		i += n
+		utf8.EncodeRune(b[l-i:], r)
+	}
+	return string(b)
+}
@@ -1,14 +1,20 @@
 package tls
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
 	"net"
 	"os"
+	"strings"
 
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/common/badtls"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
 	M "github.com/sagernet/sing/common/metadata"
 	N "github.com/sagernet/sing/common/network"
 	aTLS "github.com/sagernet/sing/common/tls"
@@ -40,6 +46,64 @@ func NewClient(ctx context.Context, serverAddress string, options option.Outboun
 	}
 }
 
+func checkPublicKeySHA256(options option.OutboundTLSOptions) error {
+	if len(options.Certificate) > 0 || options.CertificatePath != "" {
+		return E.New("certificate_public_key_sha256 is conflict with certificate or certificate_path")
+	}
+	for _, hashValue := range options.CertificatePublicKeySHA256 {
+		if len(hashValue) != sha256.Size {
+			return E.New("invalid certificate_public_key_sha256: ", base64.StdEncoding.EncodeToString(hashValue))
+		}
+	}
+	return nil
+}
+
+// verifyPublicKeySHA256 accepts the server if the SHA-256 hash of the public
+// key of its leaf certificate is pinned, in place of verifying the chain.
+func verifyPublicKeySHA256(knownHashValues [][]byte, rawCerts [][]byte) error {
+	if len(rawCerts) == 0 {
+		return E.New("missing server certificate")
+	}
+	leafCertificate, err := x509.ParseCertificate(rawCerts[0])
+	if err != nil {
+		return E.Cause(err, "parse server certificate")
+	}
+	hashValue := sha256.Sum256(leafCertificate.RawSubjectPublicKeyInfo)
+	for _, value := range knownHashValues {
+		if bytes.Equal(value, hashValue[:]) {
+			return nil
+		}
+	}
+	return E.New("unrecognized server public key: ", base64.StdEncoding.EncodeToString(hashValue[:]))
+}
+
+func loadClientCertificate(options option.OutboundTLSOptions) (certificate []byte, key []byte, err error) {
+	if len(options.ClientCertificate) > 0 {
+		certificate = []byte(strings.Join(options.ClientCertificate, "\n"))
+	} else if options.ClientCertificatePath != "" {
+		certificate, err = os.ReadFile(options.ClientCertificatePath)
+		if err != nil {
+			return nil, nil, E.Cause(err, "read client certificate")
+		}
+	}
+	if len(options.ClientKey) > 0 {
+		key = []byte(strings.Join(options.ClientKey, "\n"))
+	} else if options.ClientKeyPath != "" {
+		key, err = os.ReadFile(options.ClientKeyPath)
+		if err != nil {
+			return nil, nil, E.Cause(err, "read client key")
+		}
+	}
+	if certificate == nil && key == nil {
+		return nil, nil, nil
+	} else if certificate == nil {
+		return nil, nil, E.New("missing client_certificate")
+	} else if key == nil {
+		return nil, nil, E.New("missing client_key")
+	}
+	return certificate, key, nil
+}
+
 func ClientHandshake(ctx context.Context, conn net.Conn, config Config) (Conn, error) {
 	ctx, cancel := context.WithTimeout(ctx, C.TCPTimeout)
 	defer cancel()
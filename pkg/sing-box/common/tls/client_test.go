@@ -0,0 +1,99 @@
+package tls
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/option"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyPublicKeySHA256(t *testing.T) {
+	t.Parallel()
+	certificate, err := GenerateCertificate(time.Now, "example.com")
+	require.NoError(t, err)
+	otherCertificate, err := GenerateCertificate(time.Now, "example.com")
+	require.NoError(t, err)
+	leafCertificate, err := x509.ParseCertificate(certificate.Certificate[0])
+	require.NoError(t, err)
+	hashValue := sha256.Sum256(leafCertificate.RawSubjectPublicKeyInfo)
+
+	require.NoError(t, verifyPublicKeySHA256([][]byte{make([]byte, sha256.Size), hashValue[:]}, certificate.Certificate))
+	// only the leaf certificate is pinned
+	require.Error(t, verifyPublicKeySHA256([][]byte{hashValue[:]}, [][]byte{otherCertificate.Certificate[0], certificate.Certificate[0]}))
+	require.EqualError(t, verifyPublicKeySHA256([][]byte{hashValue[:]}, nil), "missing server certificate")
+	require.ErrorContains(t, verifyPublicKeySHA256([][]byte{hashValue[:]}, [][]byte{{0}}), "parse server certificate")
+}
+
+func TestCheckPublicKeySHA256(t *testing.T) {
+	t.Parallel()
+	hashValue := make([]byte, sha256.Size)
+	require.NoError(t, checkPublicKeySHA256(option.OutboundTLSOptions{
+		CertificatePublicKeySHA256: [][]byte{hashValue},
+	}))
+	require.ErrorContains(t, checkPublicKeySHA256(option.OutboundTLSOptions{
+		CertificatePublicKeySHA256: [][]byte{hashValue[1:]},
+	}), "invalid certificate_public_key_sha256")
+	require.ErrorContains(t, checkPublicKeySHA256(option.OutboundTLSOptions{
+		CertificatePath:            "ca.pem",
+		CertificatePublicKeySHA256: [][]byte{hashValue},
+	}), "conflict")
+	require.ErrorContains(t, checkPublicKeySHA256(option.OutboundTLSOptions{
+		Certificate:                []string{"-----BEGIN CERTIFICATE-----"},
+		CertificatePublicKeySHA256: [][]byte{hashValue},
+	}), "conflict")
+}
+
+func TestLoadClientCertificate(t *testing.T) {
+	t.Parallel()
+	keyPem, certificatePem, err := GenerateKeyPair(time.Now, "client", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	directory := t.TempDir()
+	certificatePath := filepath.Join(directory, "client.crt")
+	keyPath := filepath.Join(directory, "client.key")
+	require.NoError(t, os.WriteFile(certificatePath, certificatePem, 0o644))
+	require.NoError(t, os.WriteFile(keyPath, keyPem, 0o600))
+
+	certificate, key, err := loadClientCertificate(option.OutboundTLSOptions{})
+	require.NoError(t, err)
+	require.Nil(t, certificate)
+	require.Nil(t, key)
+
+	certificate, key, err = loadClientCertificate(option.OutboundTLSOptions{
+		ClientCertificatePath: certificatePath,
+		ClientKeyPath:         keyPath,
+	})
+	require.NoError(t, err)
+	require.Equal(t, certificatePem, certificate)
+	require.Equal(t, keyPem, key)
+
+	// inline content takes precedence over paths
+	certificate, key, err = loadClientCertificate(option.OutboundTLSOptions{
+		ClientCertificate:     []string{"certificate line 1", "certificate line 2"},
+		ClientCertificatePath: filepath.Join(directory, "missing.crt"),
+		ClientKey:             []string{"key"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "certificate line 1\ncertificate line 2", string(certificate))
+	require.Equal(t, "key", string(key))
+
+	_, _, err = loadClientCertificate(option.OutboundTLSOptions{ClientCertificatePath: certificatePath})
+	require.EqualError(t, err, "missing client_key")
+	_, _, err = loadClientCertificate(option.OutboundTLSOptions{ClientKey: []string{"key"}})
+	require.EqualError(t, err, "missing client_certificate")
+	_, _, err = loadClientCertificate(option.OutboundTLSOptions{
+		ClientCertificatePath: filepath.Join(directory, "missing.crt"),
+		ClientKeyPath:         keyPath,
+	})
+	require.ErrorContains(t, err, "read client certificate")
+	_, _, err = loadClientCertificate(option.OutboundTLSOptions{
+		ClientCertificatePath: certificatePath,
+		ClientKeyPath:         filepath.Join(directory, "missing.key"),
+	})
+	require.ErrorContains(t, err, "read client key")
+}
@@ -92,7 +92,7 @@ func NewECHClient(ctx context.Context, serverAddress string, options option.Outb
 			serverName = serverAddress
 		}
 	}
-	if serverName == "" && !options.Insecure {
+	if serverName == "" && !options.Insecure && len(options.CertificatePublicKeySHA256) == 0 {
 		return nil, E.New("missing server_name or insecure=true")
 	}
 
@@ -103,7 +103,16 @@ func NewECHClient(ctx context.Context, serverAddress string, options option.Outb
 	} else {
 		tlsConfig.ServerName = serverName
 	}
-	if options.Insecure {
+	if len(options.CertificatePublicKeySHA256) > 0 {
+		err := checkPublicKeySHA256(options)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyPeerCertificate = func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+			return verifyPublicKeySHA256(options.CertificatePublicKeySHA256, rawCerts)
+		}
+	} else if options.Insecure {
 		tlsConfig.InsecureSkipVerify = options.Insecure
 	} else if options.DisableSNI {
 		tlsConfig.InsecureSkipVerify = true
@@ -165,6 +174,17 @@ func NewECHClient(ctx context.Context, serverAddress string, options option.Outb
 		}
 		tlsConfig.RootCAs = certPool
 	}
+	clientCertificate, clientKey, err := loadClientCertificate(options)
+	if err != nil {
+		return nil, err
+	}
+	if clientCertificate != nil {
+		keyPair, err := cftls.X509KeyPair(clientCertificate, clientKey)
+		if err != nil {
+			return nil, E.Cause(err, "parse client x509 key pair")
+		}
+		tlsConfig.Certificates = []cftls.Certificate{keyPair}
+	}
 
 	// ECH Config
 
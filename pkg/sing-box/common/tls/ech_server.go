@@ -11,12 +11,12 @@ import (
 	"strings"
 
 	cftls "github.com/sagernet/cloudflare-tls"
+	"github.com/sagernet/sing-box/common/filewatcher"
 	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/atomic"
 	E "github.com/sagernet/sing/common/exceptions"
 	"github.com/sagernet/sing/common/ntp"
-
-	"github.com/fsnotify/fsnotify"
 )
 
 type echServerConfig struct {
@@ -26,9 +26,11 @@ type echServerConfig struct {
 	key             []byte
 	certificatePath string
 	keyPath         string
-	watcher         *fsnotify.Watcher
+	keyPair         atomic.Pointer[cftls.Certificate]
+	watcher         *filewatcher.Watcher
 	echKeyPath      string
-	echWatcher      *fsnotify.Watcher
+	echKeySet       *echKeySetProvider
+	echWatcher      *filewatcher.Watcher
 }
 
 func (c *echServerConfig) ServerName() string {
@@ -66,145 +68,63 @@ func (c *echServerConfig) Clone() Config {
 }
 
 func (c *echServerConfig) Start() error {
-	if c.certificatePath != "" && c.keyPath != "" {
-		err := c.startWatcher()
+	if c.certificatePath != "" || c.keyPath != "" {
+		c.watcher = filewatcher.New(c.logger, []string{c.certificatePath, c.keyPath}, c.reloadKeyPair)
+		err := c.watcher.Start()
 		if err != nil {
-			c.logger.Warn("create fsnotify watcher: ", err)
+			c.logger.Warn("watch certificate: ", err)
 		}
 	}
 	if c.echKeyPath != "" {
-		err := c.startECHWatcher()
-		if err != nil {
-			c.logger.Warn("create fsnotify watcher: ", err)
-		}
-	}
-	return nil
-}
-
-func (c *echServerConfig) startWatcher() error {
-	watcher, err := fsnotify.NewWatcher()
-	if err != nil {
-		return err
-	}
-	if c.certificatePath != "" {
-		err = watcher.Add(c.certificatePath)
+		c.echWatcher = filewatcher.New(c.logger, []string{c.echKeyPath}, c.reloadECHKey)
+		err := c.echWatcher.Start()
 		if err != nil {
-			return err
+			c.logger.Warn("watch ECH key: ", err)
 		}
 	}
-	if c.keyPath != "" {
-		err = watcher.Add(c.keyPath)
-		if err != nil {
-			return err
-		}
-	}
-	c.watcher = watcher
-	go c.loopUpdate()
 	return nil
 }
 
-func (c *echServerConfig) loopUpdate() {
-	for {
-		select {
-		case event, ok := <-c.watcher.Events:
-			if !ok {
-				return
-			}
-			if event.Op&fsnotify.Write != fsnotify.Write {
-				continue
-			}
-			err := c.reloadKeyPair()
-			if err != nil {
-				c.logger.Error(E.Cause(err, "reload TLS key pair"))
-			}
-		case err, ok := <-c.watcher.Errors:
-			if !ok {
-				return
-			}
-			c.logger.Error(E.Cause(err, "fsnotify error"))
-		}
-	}
+func (c *echServerConfig) getCertificate(*cftls.ClientHelloInfo) (*cftls.Certificate, error) {
+	return c.keyPair.Load(), nil
 }
 
 func (c *echServerConfig) reloadKeyPair() error {
+	certificate, key := c.certificate, c.key
 	if c.certificatePath != "" {
-		certificate, err := os.ReadFile(c.certificatePath)
+		content, err := os.ReadFile(c.certificatePath)
 		if err != nil {
 			return E.Cause(err, "reload certificate from ", c.certificatePath)
 		}
-		c.certificate = certificate
+		certificate = content
 	}
 	if c.keyPath != "" {
-		key, err := os.ReadFile(c.keyPath)
+		content, err := os.ReadFile(c.keyPath)
 		if err != nil {
 			return E.Cause(err, "reload key from ", c.keyPath)
 		}
-		c.key = key
+		key = content
 	}
-	keyPair, err := cftls.X509KeyPair(c.certificate, c.key)
+	keyPair, err := cftls.X509KeyPair(certificate, key)
 	if err != nil {
 		return E.Cause(err, "reload key pair")
 	}
-	c.config.Certificates = []cftls.Certificate{keyPair}
+	c.certificate, c.key = certificate, key
+	c.keyPair.Store(&keyPair)
 	c.logger.Info("reloaded TLS certificate")
 	return nil
 }
 
-func (c *echServerConfig) startECHWatcher() error {
-	watcher, err := fsnotify.NewWatcher()
-	if err != nil {
-		return err
-	}
-	err = watcher.Add(c.echKeyPath)
-	if err != nil {
-		return err
-	}
-	c.echWatcher = watcher
-	go c.loopECHUpdate()
-	return nil
-}
-
-func (c *echServerConfig) loopECHUpdate() {
-	for {
-		select {
-		case event, ok := <-c.echWatcher.Events:
-			if !ok {
-				return
-			}
-			if event.Op&fsnotify.Write != fsnotify.Write {
-				continue
-			}
-			err := c.reloadECHKey()
-			if err != nil {
-				c.logger.Error(E.Cause(err, "reload ECH key"))
-			}
-		case err, ok := <-c.echWatcher.Errors:
-			if !ok {
-				return
-			}
-			c.logger.Error(E.Cause(err, "fsnotify error"))
-		}
-	}
-}
-
 func (c *echServerConfig) reloadECHKey() error {
 	echKeyContent, err := os.ReadFile(c.echKeyPath)
 	if err != nil {
-		return err
-	}
-	block, rest := pem.Decode(echKeyContent)
-	if block == nil || block.Type != "ECH KEYS" || len(rest) > 0 {
-		return E.New("invalid ECH keys pem")
-	}
-	echKeys, err := cftls.EXP_UnmarshalECHKeys(block.Bytes)
-	if err != nil {
-		return E.Cause(err, "parse ECH keys")
+		return E.Cause(err, "reload ECH key from ", c.echKeyPath)
 	}
-	echKeySet, err := cftls.EXP_NewECHKeySet(echKeys)
+	echKeySet, err := parseECHKeys(echKeyContent)
 	if err != nil {
-		return E.Cause(err, "create ECH key set")
+		return E.Cause(err, "reload ECH key")
 	}
-	c.config.ServerECHProvider = echKeySet
+	c.echKeySet.Store(echKeySet)
 	c.logger.Info("reloaded ECH keys")
 	return nil
 }
@@ -224,6 +144,32 @@ func (c *echServerConfig) Close() error {
 	return err
 }
 
+// echKeySetProvider serves the current ECH key set, which is swapped when
+// the key file is reloaded.
+type echKeySetProvider struct {
+	atomic.Pointer[cftls.EXP_ECHKeySet]
+}
+
+func (p *echKeySetProvider) GetDecryptionContext(rawHandle []byte, version uint16) cftls.ECHProviderResult {
+	return p.Load().GetDecryptionContext(rawHandle, version)
+}
+
+func parseECHKeys(content []byte) (*cftls.EXP_ECHKeySet, error) {
+	block, rest := pem.Decode(content)
+	if block == nil || block.Type != "ECH KEYS" || len(rest) > 0 {
+		return nil, E.New("invalid ECH keys pem")
+	}
+	echKeys, err := cftls.EXP_UnmarshalECHKeys(block.Bytes)
+	if err != nil {
+		return nil, E.Cause(err, "parse ECH keys")
+	}
+	echKeySet, err := cftls.EXP_NewECHKeySet(echKeys)
+	if err != nil {
+		return nil, E.Cause(err, "create ECH key set")
+	}
+	return echKeySet, nil
+}
+
 func NewECHServer(ctx context.Context, logger log.Logger, options option.InboundTLSOptions) (ServerConfig, error) {
 	if !options.Enabled {
 		return nil, nil
@@ -296,12 +242,18 @@ func NewECHServer(ctx context.Context, logger log.Logger, options option.Inbound
 	if err != nil {
 		return nil, E.Cause(err, "parse x509 key pair")
 	}
-	tlsConfig.Certificates = []cftls.Certificate{keyPair}
+
+	clientAuthentication, clientCAs, err := parseClientAuthentication(options)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.ClientAuth = cftls.ClientAuthType(clientAuthentication)
+	tlsConfig.ClientCAs = clientCAs
 
 	var echKey []byte
 	if len(options.ECH.Key) > 0 {
 		echKey = []byte(strings.Join(options.ECH.Key, "\n"))
-	} else if options.KeyPath != "" {
+	} else if options.ECH.KeyPath != "" {
 		content, err := os.ReadFile(options.ECH.KeyPath)
 		if err != nil {
 			return nil, E.Cause(err, "read ECH key")
@@ -311,27 +263,16 @@ func NewECHServer(ctx context.Context, logger log.Logger, options option.Inbound
 		return nil, E.New("missing ECH key")
 	}
 
-	block, rest := pem.Decode(echKey)
-	if block == nil || block.Type != "ECH KEYS" || len(rest) > 0 {
-		return nil, E.New("invalid ECH keys pem")
-	}
-
-	echKeys, err := cftls.EXP_UnmarshalECHKeys(block.Bytes)
+	echKeySet, err := parseECHKeys(echKey)
 	if err != nil {
-		return nil, E.Cause(err, "parse ECH keys")
-	}
-
-	echKeySet, err := cftls.EXP_NewECHKeySet(echKeys)
-	if err != nil {
-		return nil, E.Cause(err, "create ECH key set")
+		return nil, err
 	}
 
 	tlsConfig.ECHEnabled = true
 	tlsConfig.PQSignatureSchemesEnabled = options.ECH.PQSignatureSchemesEnabled
 	tlsConfig.DynamicRecordSizingDisabled = options.ECH.DynamicRecordSizingDisabled
-	tlsConfig.ServerECHProvider = echKeySet
 
-	return &echServerConfig{
+	serverConfig := &echServerConfig{
 		config:          &tlsConfig,
 		logger:          logger,
 		certificate:     certificate,
@@ -339,5 +280,11 @@ func NewECHServer(ctx context.Context, logger log.Logger, options option.Inbound
 		certificatePath: options.CertificatePath,
 		keyPath:         options.KeyPath,
 		echKeyPath:      options.ECH.KeyPath,
-	}, nil
+		echKeySet:       &echKeySetProvider{},
+	}
+	serverConfig.keyPair.Store(&keyPair)
+	serverConfig.echKeySet.Store(echKeySet)
+	tlsConfig.GetCertificate = serverConfig.getCertificate
+	tlsConfig.ServerECHProvider = serverConfig.echKeySet
+	return serverConfig, nil
 }
@@ -49,6 +49,12 @@ func NewRealityClient(ctx context.Context, serverAddress string, options option.
 	if options.UTLS == nil || !options.UTLS.Enabled {
 		return nil, E.New("uTLS is required by reality client")
 	}
+	if len(options.CertificatePublicKeySHA256) > 0 {
+		return nil, E.New("certificate_public_key_sha256 is unsupported in reality")
+	}
+	if len(options.ClientCertificate) > 0 || options.ClientCertificatePath != "" {
+		return nil, E.New("client_certificate is unsupported in reality")
+	}
 
 	uClient, err := NewUTLSClient(ctx, serverAddress, options)
 	if err != nil {
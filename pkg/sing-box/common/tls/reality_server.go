@@ -73,6 +73,9 @@ func NewRealityServer(ctx context.Context, logger log.Logger, options option.Inb
 	if len(options.Key) > 0 || options.KeyPath != "" {
 		return nil, E.New("key is unavailable in reality")
 	}
+	if options.ClientAuthentication != "" || len(options.ClientCertificate) > 0 || options.ClientCertificatePath != "" {
+		return nil, E.New("client_authentication is unavailable in reality")
+	}
 
 	tlsConfig.SessionTicketsDisabled = true
 	tlsConfig.Type = N.NetworkTCP
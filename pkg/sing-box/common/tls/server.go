@@ -2,13 +2,17 @@ package tls
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"net"
 	"os"
+	"strings"
 
 	"github.com/sagernet/sing-box/common/badtls"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
 	aTLS "github.com/sagernet/sing/common/tls"
 )
 
@@ -40,3 +44,48 @@ func ServerHandshake(ctx context.Context, conn net.Conn, config ServerConfig) (C
 	}
 	return tlsConn, nil
 }
+
+// parseClientAuthentication returns the client authentication type and the
+// certificate pool to verify client certificates with.
+func parseClientAuthentication(options option.InboundTLSOptions) (tls.ClientAuthType, *x509.CertPool, error) {
+	var clientCertificate []byte
+	if len(options.ClientCertificate) > 0 {
+		clientCertificate = []byte(strings.Join(options.ClientCertificate, "\n"))
+	} else if options.ClientCertificatePath != "" {
+		content, err := os.ReadFile(options.ClientCertificatePath)
+		if err != nil {
+			return 0, nil, E.Cause(err, "read client certificate")
+		}
+		clientCertificate = content
+	}
+	var clientAuthentication tls.ClientAuthType
+	switch options.ClientAuthentication {
+	case "":
+		if clientCertificate != nil {
+			clientAuthentication = tls.RequireAndVerifyClientCert
+		}
+	case "no":
+		clientAuthentication = tls.NoClientCert
+	case "request":
+		clientAuthentication = tls.RequestClientCert
+	case "require-any":
+		clientAuthentication = tls.RequireAnyClientCert
+	case "verify-if-given":
+		clientAuthentication = tls.VerifyClientCertIfGiven
+	case "require-and-verify":
+		clientAuthentication = tls.RequireAndVerifyClientCert
+	default:
+		return 0, nil, E.New("unknown client_authentication: ", options.ClientAuthentication)
+	}
+	if clientCertificate == nil {
+		if clientAuthentication == tls.VerifyClientCertIfGiven || clientAuthentication == tls.RequireAndVerifyClientCert {
+			return 0, nil, E.New("missing client_certificate for client_authentication: ", options.ClientAuthentication)
+		}
+		return clientAuthentication, nil, nil
+	}
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(clientCertificate) {
+		return 0, nil, E.New("failed to parse client certificate:\n\n", clientCertificate)
+	}
+	return clientAuthentication, certPool, nil
+}
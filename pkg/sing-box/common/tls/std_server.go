@@ -8,13 +8,13 @@ import (
 	"strings"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/filewatcher"
 	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing-box/option"
 	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/atomic"
 	E "github.com/sagernet/sing/common/exceptions"
 	"github.com/sagernet/sing/common/ntp"
-
-	"github.com/fsnotify/fsnotify"
 )
 
 var errInsecureUnused = E.New("tls: insecure unused")
@@ -27,7 +27,8 @@ type STDServerConfig struct {
 	key             []byte
 	certificatePath string
 	keyPath         string
-	watcher         *fsnotify.Watcher
+	keyPair         atomic.Pointer[tls.Certificate]
+	watcher         *filewatcher.Watcher
 }
 
 func (c *STDServerConfig) ServerName() string {
@@ -79,79 +80,41 @@ func (c *STDServerConfig) Start() error {
 		if c.certificatePath == "" && c.keyPath == "" {
 			return nil
 		}
-		err := c.startWatcher()
+		c.watcher = filewatcher.New(c.logger, []string{c.certificatePath, c.keyPath}, c.reloadKeyPair)
+		err := c.watcher.Start()
 		if err != nil {
-			c.logger.Warn("create fsnotify watcher: ", err)
+			c.logger.Warn("watch certificate: ", err)
 		}
 		return nil
 	}
 }
 
-func (c *STDServerConfig) startWatcher() error {
-	watcher, err := fsnotify.NewWatcher()
-	if err != nil {
-		return err
-	}
-	if c.certificatePath != "" {
-		err = watcher.Add(c.certificatePath)
-		if err != nil {
-			return err
-		}
-	}
-	if c.keyPath != "" {
-		err = watcher.Add(c.keyPath)
-		if err != nil {
-			return err
-		}
-	}
-	c.watcher = watcher
-	go c.loopUpdate()
-	return nil
-}
-
-func (c *STDServerConfig) loopUpdate() {
-	for {
-		select {
-		case event, ok := <-c.watcher.Events:
-			if !ok {
-				return
-			}
-			if event.Op&fsnotify.Write != fsnotify.Write {
-				continue
-			}
-			err := c.reloadKeyPair()
-			if err != nil {
-				c.logger.Error(E.Cause(err, "reload TLS key pair"))
-			}
-		case err, ok := <-c.watcher.Errors:
-			if !ok {
-				return
-			}
-			c.logger.Error(E.Cause(err, "fsnotify error"))
-		}
-	}
+func (c *STDServerConfig) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return c.keyPair.Load(), nil
 }
 
 func (c *STDServerConfig) reloadKeyPair() error {
+	certificate, key := c.certificate, c.key
 	if c.certificatePath != "" {
-		certificate, err := os.ReadFile(c.certificatePath)
+		content, err := os.ReadFile(c.certificatePath)
 		if err != nil {
 			return E.Cause(err, "reload certificate from ", c.certificatePath)
 		}
-		c.certificate = certificate
+		certificate = content
 	}
 	if c.keyPath != "" {
-		key, err := os.ReadFile(c.keyPath)
+		content, err := os.ReadFile(c.keyPath)
 		if err != nil {
 			return E.Cause(err, "reload key from ", c.keyPath)
 		}
-		c.key = key
+		key = content
 	}
-	keyPair, err := tls.X509KeyPair(c.certificate, c.key)
+	keyPair, err := tls.X509KeyPair(certificate, key)
 	if err != nil {
 		return E.Cause(err, "reload key pair")
 	}
-	c.config.Certificates = []tls.Certificate{keyPair}
+	c.certificate, c.key = certificate, key
+	c.keyPair.Store(&keyPair)
 	c.logger.Info("reloaded TLS certificate")
 	return nil
 }
@@ -218,8 +181,15 @@ func NewSTDServer(ctx context.Context, logger log.Logger, options option.Inbound
 			return nil, E.New("unknown cipher_suite: ", cipherSuite)
 		}
 	}
+	clientAuthentication, clientCAs, err := parseClientAuthentication(options)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.ClientAuth = clientAuthentication
+	tlsConfig.ClientCAs = clientCAs
 	var certificate []byte
 	var key []byte
+	var keyPair *tls.Certificate
 	if acmeService == nil {
 		if len(options.Certificate) > 0 {
 			certificate = []byte(strings.Join(options.Certificate, "\n"))
@@ -250,14 +220,14 @@ func NewSTDServer(ctx context.Context, logger log.Logger, options option.Inbound
 				return nil, E.New("missing key")
 			}
 
-			keyPair, err := tls.X509KeyPair(certificate, key)
+			x509KeyPair, err := tls.X509KeyPair(certificate, key)
 			if err != nil {
 				return nil, E.Cause(err, "parse x509 key pair")
 			}
-			tlsConfig.Certificates = []tls.Certificate{keyPair}
+			keyPair = &x509KeyPair
 		}
 	}
-	return &STDServerConfig{
+	serverConfig := &STDServerConfig{
 		config:          tlsConfig,
 		logger:          logger,
 		acmeService:     acmeService,
@@ -265,5 +235,10 @@ func NewSTDServer(ctx context.Context, logger log.Logger, options option.Inbound
 		key:             key,
 		certificatePath: options.CertificatePath,
 		keyPath:         options.KeyPath,
-	}, nil
+	}
+	if keyPair != nil {
+		serverConfig.keyPair.Store(keyPair)
+		tlsConfig.GetCertificate = serverConfig.getCertificate
+	}
+	return serverConfig, nil
 }
@@ -123,7 +123,7 @@ func NewUTLSClient(ctx context.Context, serverAddress string, options option.Out
 			serverName = serverAddress
 		}
 	}
-	if serverName == "" && !options.Insecure {
+	if serverName == "" && !options.Insecure && len(options.CertificatePublicKeySHA256) == 0 {
 		return nil, E.New("missing server_name or insecure=true")
 	}
 
@@ -134,7 +134,16 @@ func NewUTLSClient(ctx context.Context, serverAddress string, options option.Out
 	} else {
 		tlsConfig.ServerName = serverName
 	}
-	if options.Insecure {
+	if len(options.CertificatePublicKeySHA256) > 0 {
+		err := checkPublicKeySHA256(options)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyPeerCertificate = func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+			return verifyPublicKeySHA256(options.CertificatePublicKeySHA256, rawCerts)
+		}
+	} else if options.Insecure {
 		tlsConfig.InsecureSkipVerify = options.Insecure
 	} else if options.DisableSNI {
 		return nil, E.New("disable_sni is unsupported in uTLS")
@@ -185,6 +194,17 @@ func NewUTLSClient(ctx context.Context, serverAddress string, options option.Out
 		}
 		tlsConfig.RootCAs = certPool
 	}
+	clientCertificate, clientKey, err := loadClientCertificate(options)
+	if err != nil {
+		return nil, err
+	}
+	if clientCertificate != nil {
+		keyPair, err := utls.X509KeyPair(clientCertificate, clientKey)
+		if err != nil {
+			return nil, E.Cause(err, "parse client x509 key pair")
+		}
+		tlsConfig.Certificates = []utls.Certificate{keyPair}
+	}
 	id, err := uTLSClientHelloID(options.UTLS.Fingerprint)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,395 @@
+package userlimit
+
+import (
+	"context"
+	"io"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/atomic"
+	"github.com/sagernet/sing/common/bufio"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/service"
+)
+
+// saveInterval is how often usage is saved to the cache file and
+// reset periods are checked.
+const saveInterval = time.Minute
+
+var _ adapter.UserLimiter = (*Limiter)(nil)
+
+// Limiter enforces the user_limits of inbounds on routed connections.
+// The state of a user is created on start, with the usage loaded from
+// the cache file if enabled.
+type Limiter struct {
+	ctx       context.Context
+	logger    logger.ContextLogger
+	inbounds  []option.Inbound
+	access    sync.Mutex
+	users     map[userKey]*userState
+	cacheFile adapter.CacheFile
+	ticker    *time.Ticker
+	done      chan struct{}
+}
+
+type userKey struct {
+	inbound string
+	user    string
+}
+
+type userState struct {
+	limiter        *Limiter
+	key            userKey
+	quota          uint64
+	maxConnections int
+	expireAt       time.Time
+	resetPeriod    string
+	resetDay       int
+	used           atomic.Uint64
+	expireTimer    *time.Timer
+
+	access      sync.Mutex
+	exceeded    bool
+	periodStart time.Time
+	savedUsed   uint64
+	conns       map[uint64]io.Closer
+	nextID      uint64
+}
+
+func NewLimiter(ctx context.Context, logger logger.ContextLogger, inbounds []option.Inbound) *Limiter {
+	return &Limiter{
+		ctx:      ctx,
+		logger:   logger,
+		inbounds: inbounds,
+		users:    make(map[userKey]*userState),
+		done:     make(chan struct{}),
+	}
+}
+
+func (l *Limiter) Start() error {
+	l.cacheFile = service.FromContext[adapter.CacheFile](l.ctx)
+	for _, inbound := range l.inbounds {
+		inboundOptions, loaded := limitedInboundOptions(inbound)
+		if !loaded {
+			continue
+		}
+		for _, userLimit := range inboundOptions.UserLimits {
+			l.loadState(adapter.InboundContext{
+				Inbound:        inbound.Tag,
+				User:           userLimit.Name,
+				InboundOptions: inboundOptions,
+			})
+		}
+	}
+	l.ticker = time.NewTicker(saveInterval)
+	go l.loopSave()
+	return nil
+}
+
+func (l *Limiter) Close() error {
+	if l.ticker == nil {
+		return nil
+	}
+	l.ticker.Stop()
+	close(l.done)
+	l.access.Lock()
+	for _, state := range l.users {
+		if state.expireTimer != nil {
+			state.expireTimer.Stop()
+		}
+	}
+	l.access.Unlock()
+	l.saveUsage(time.Now())
+	return nil
+}
+
+func (l *Limiter) loopSave() {
+	for {
+		select {
+		case now := <-l.ticker.C:
+			l.saveUsage(now)
+		case <-l.done:
+			return
+		}
+	}
+}
+
+// saveUsage resets usage of new periods, then writes changed usage
+// to the cache file in one transaction.
+func (l *Limiter) saveUsage(now time.Time) {
+	usages := make(map[string]map[string]*adapter.SavedUserUsage)
+	savedUsed := make(map[*userState]uint64)
+	for _, state := range l.states() {
+		state.access.Lock()
+		state.refresh(now)
+		used := state.used.Load()
+		if l.cacheFile != nil && used != state.savedUsed {
+			inboundUsages := usages[state.key.inbound]
+			if inboundUsages == nil {
+				inboundUsages = make(map[string]*adapter.SavedUserUsage)
+				usages[state.key.inbound] = inboundUsages
+			}
+			inboundUsages[state.key.user] = &adapter.SavedUserUsage{
+				Used:        used,
+				PeriodStart: state.periodStart,
+			}
+			savedUsed[state] = used
+		}
+		state.access.Unlock()
+	}
+	if len(savedUsed) == 0 {
+		return
+	}
+	err := l.cacheFile.SaveUserUsage(usages)
+	if err != nil {
+		l.logger.Warn("save user usage: ", err)
+		return
+	}
+	for state, used := range savedUsed {
+		state.access.Lock()
+		state.savedUsed = used
+		state.access.Unlock()
+	}
+}
+
+func (l *Limiter) states() []*userState {
+	l.access.Lock()
+	defer l.access.Unlock()
+	states := make([]*userState, 0, len(l.users))
+	for _, state := range l.users {
+		states = append(states, state)
+	}
+	return states
+}
+
+func (l *Limiter) RoutedConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) (net.Conn, func(), error) {
+	state := l.loadState(metadata)
+	if state == nil {
+		return conn, func() {}, nil
+	}
+	release, err := state.acquire(conn)
+	if err != nil {
+		return nil, nil, err
+	}
+	if state.quota > 0 {
+		conn = bufio.NewCounterConn(conn, []N.CountFunc{state.count}, []N.CountFunc{state.count})
+	}
+	return conn, release, nil
+}
+
+func (l *Limiter) RoutedPacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext) (N.PacketConn, func(), error) {
+	state := l.loadState(metadata)
+	if state == nil {
+		return conn, func() {}, nil
+	}
+	release, err := state.acquire(conn)
+	if err != nil {
+		return nil, nil, err
+	}
+	if state.quota > 0 {
+		conn = bufio.NewCounterPacketConn(conn, []N.CountFunc{state.count}, []N.CountFunc{state.count})
+	}
+	return conn, release, nil
+}
+
+func (l *Limiter) Usage() []adapter.UserUsage {
+	now := time.Now()
+	usage := common.Map(l.states(), func(state *userState) adapter.UserUsage {
+		state.access.Lock()
+		defer state.access.Unlock()
+		state.refresh(now)
+		userUsage := adapter.UserUsage{
+			Inbound:        state.key.inbound,
+			User:           state.key.user,
+			Used:           state.used.Load(),
+			Quota:          state.quota,
+			Connections:    len(state.conns),
+			MaxConnections: state.maxConnections,
+			ExpireAt:       state.expireAt,
+			Exceeded:       state.exceeded || state.expired(now),
+		}
+		if state.resetPeriod != "" {
+			userUsage.ResetAt = nextPeriodStart(state.periodStart, state.resetPeriod)
+		}
+		return userUsage
+	})
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Inbound != usage[j].Inbound {
+			return usage[i].Inbound < usage[j].Inbound
+		}
+		return usage[i].User < usage[j].User
+	})
+	return usage
+}
+
+func (l *Limiter) loadState(metadata adapter.InboundContext) *userState {
+	if metadata.User == "" || len(metadata.InboundOptions.UserLimits) == 0 {
+		return nil
+	}
+	key := userKey{metadata.Inbound, metadata.User}
+	l.access.Lock()
+	defer l.access.Unlock()
+	if state, loaded := l.users[key]; loaded {
+		return state
+	}
+	index := common.Index(metadata.InboundOptions.UserLimits, func(it option.UserLimitOptions) bool {
+		return it.Name == metadata.User
+	})
+	if index == -1 {
+		return nil
+	}
+	options := metadata.InboundOptions.UserLimits[index]
+	now := time.Now()
+	state := &userState{
+		limiter:        l,
+		key:            key,
+		quota:          uint64(options.QuotaBytes),
+		maxConnections: options.MaxConnections,
+		resetPeriod:    options.ResetPeriod,
+		resetDay:       options.ResetDay,
+		conns:          make(map[uint64]io.Closer),
+	}
+	if options.ExpireAt != nil {
+		state.expireAt = time.Time(*options.ExpireAt)
+		if now.Before(state.expireAt) {
+			state.expireTimer = time.AfterFunc(state.expireAt.Sub(now), func() {
+				state.closeAll("expired")
+			})
+		}
+	}
+	if state.resetPeriod != "" {
+		state.periodStart = periodStart(now, state.resetPeriod, state.resetDay)
+	}
+	if l.cacheFile != nil {
+		savedUsage := l.cacheFile.LoadUserUsage(key.inbound, key.user)
+		if savedUsage != nil && (state.resetPeriod == "" || savedUsage.PeriodStart.Equal(state.periodStart)) {
+			state.used.Store(savedUsage.Used)
+			state.savedUsed = savedUsage.Used
+		}
+	}
+	if state.quota > 0 && state.used.Load() >= state.quota {
+		state.exceeded = true
+	}
+	l.users[key] = state
+	return state
+}
+
+// limitedInboundOptions returns the options of an inbound with user limits.
+func limitedInboundOptions(inbound option.Inbound) (option.InboundOptions, bool) {
+	rawOptions, err := inbound.RawOptions()
+	if err != nil {
+		return option.InboundOptions{}, false
+	}
+	var inboundOptions option.InboundOptions
+	switch options := rawOptions.(type) {
+	case option.ListenOptionsWrapper:
+		inboundOptions = options.TakeListenOptions().InboundOptions
+	case *option.TunInboundOptions:
+		inboundOptions = options.InboundOptions
+	}
+	return inboundOptions, len(inboundOptions.UserLimits) > 0
+}
+
+func (s *userState) acquire(conn io.Closer) (func(), error) {
+	now := time.Now()
+	s.access.Lock()
+	defer s.access.Unlock()
+	s.refresh(now)
+	if s.expired(now) {
+		return nil, E.New("user ", s.key.user, " expired")
+	}
+	if s.exceeded {
+		return nil, E.New("user ", s.key.user, " exceeded quota")
+	}
+	if s.maxConnections > 0 && len(s.conns) >= s.maxConnections {
+		return nil, E.New("user ", s.key.user, " exceeded max connections")
+	}
+	id := s.nextID
+	s.nextID++
+	s.conns[id] = conn
+	return func() {
+		s.access.Lock()
+		delete(s.conns, id)
+		s.access.Unlock()
+	}, nil
+}
+
+func (s *userState) count(n int64) {
+	if s.used.Add(uint64(n)) < s.quota {
+		return
+	}
+	s.access.Lock()
+	if s.exceeded {
+		s.access.Unlock()
+		return
+	}
+	s.exceeded = true
+	s.access.Unlock()
+	s.closeAll("exceeded quota")
+}
+
+func (s *userState) closeAll(reason string) {
+	s.access.Lock()
+	conns := make([]io.Closer, 0, len(s.conns))
+	for _, conn := range s.conns {
+		conns = append(conns, conn)
+	}
+	s.access.Unlock()
+	s.limiter.logger.Info("user ", s.key.user, " on inbound ", s.key.inbound, " ", reason, ", closing ", len(conns), " connections")
+	for _, conn := range conns {
+		common.Close(conn)
+	}
+}
+
+func (s *userState) expired(now time.Time) bool {
+	return !s.expireAt.IsZero() && !now.Before(s.expireAt)
+}
+
+// refresh resets the usage if a new reset period has started.
+func (s *userState) refresh(now time.Time) {
+	if s.resetPeriod == "" {
+		return
+	}
+	start := periodStart(now, s.resetPeriod, s.resetDay)
+	if start.Equal(s.periodStart) {
+		return
+	}
+	s.periodStart = start
+	s.used.Store(0)
+	s.savedUsed = 0
+	s.exceeded = false
+	s.limiter.logger.Info("usage of user ", s.key.user, " on inbound ", s.key.inbound, " reset")
+}
+
+func periodStart(now time.Time, resetPeriod string, resetDay int) time.Time {
+	switch resetPeriod {
+	case C.UserLimitResetMonthly:
+		if resetDay == 0 {
+			resetDay = 1
+		}
+		start := time.Date(now.Year(), now.Month(), resetDay, 0, 0, 0, 0, now.Location())
+		if now.Before(start) {
+			start = start.AddDate(0, -1, 0)
+		}
+		return start
+	default:
+		return time.Time{}
+	}
+}
+
+func nextPeriodStart(start time.Time, resetPeriod string) time.Time {
+	switch resetPeriod {
+	case C.UserLimitResetMonthly:
+		return start.AddDate(0, 1, 0)
+	default:
+		return time.Time{}
+	}
+}
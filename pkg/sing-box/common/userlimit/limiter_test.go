@@ -0,0 +1,146 @@
+package userlimit
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/service"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testMetadata(limits ...option.UserLimitOptions) adapter.InboundContext {
+	return adapter.InboundContext{
+		Inbound: "in",
+		User:    "user",
+		InboundOptions: option.InboundOptions{
+			UserLimits: limits,
+		},
+	}
+}
+
+func TestLimiterQuota(t *testing.T) {
+	t.Parallel()
+	limiter := NewLimiter(context.Background(), log.NewNOPFactory().NewLogger("user-limit"), nil)
+	metadata := testMetadata(option.UserLimitOptions{Name: "user", QuotaBytes: 4})
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	conn, release, err := limiter.RoutedConnection(context.Background(), serverConn, metadata)
+	require.NoError(t, err)
+	defer release()
+	go clientConn.Read(make([]byte, 4))
+	_, err = conn.Write([]byte("test"))
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("test"))
+	require.Error(t, err)
+	_, _, err = limiter.RoutedConnection(context.Background(), serverConn, metadata)
+	require.Error(t, err)
+	usage := limiter.Usage()
+	require.Len(t, usage, 1)
+	require.True(t, usage[0].Exceeded)
+	require.Equal(t, uint64(4), usage[0].Used)
+}
+
+func TestLimiterMaxConnections(t *testing.T) {
+	t.Parallel()
+	limiter := NewLimiter(context.Background(), log.NewNOPFactory().NewLogger("user-limit"), nil)
+	metadata := testMetadata(option.UserLimitOptions{Name: "user", MaxConnections: 1})
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	_, release, err := limiter.RoutedConnection(context.Background(), serverConn, metadata)
+	require.NoError(t, err)
+	_, _, err = limiter.RoutedConnection(context.Background(), serverConn, metadata)
+	require.Error(t, err)
+	release()
+	_, _, err = limiter.RoutedConnection(context.Background(), serverConn, metadata)
+	require.NoError(t, err)
+}
+
+func TestLimiterExpired(t *testing.T) {
+	t.Parallel()
+	limiter := NewLimiter(context.Background(), log.NewNOPFactory().NewLogger("user-limit"), nil)
+	expireAt := option.Time(time.Now().Add(-time.Minute))
+	metadata := testMetadata(option.UserLimitOptions{Name: "user", ExpireAt: &expireAt})
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	_, _, err := limiter.RoutedConnection(context.Background(), serverConn, metadata)
+	require.Error(t, err)
+	metadata.User = "other"
+	_, _, err = limiter.RoutedConnection(context.Background(), serverConn, metadata)
+	require.NoError(t, err)
+}
+
+type testCacheFile struct {
+	adapter.CacheFile
+	usages map[string]map[string]*adapter.SavedUserUsage
+	saves  int
+}
+
+func (c *testCacheFile) LoadUserUsage(inbound string, user string) *adapter.SavedUserUsage {
+	return c.usages[inbound][user]
+}
+
+func (c *testCacheFile) SaveUserUsage(usages map[string]map[string]*adapter.SavedUserUsage) error {
+	c.saves++
+	for inbound, inboundUsages := range usages {
+		for user, usage := range inboundUsages {
+			c.usages[inbound][user] = usage
+		}
+	}
+	return nil
+}
+
+func TestLimiterCacheFile(t *testing.T) {
+	t.Parallel()
+	cacheFile := &testCacheFile{usages: map[string]map[string]*adapter.SavedUserUsage{
+		"in": {"user": {Used: 2}},
+	}}
+	ctx := service.ContextWith[adapter.CacheFile](context.Background(), cacheFile)
+	limiter := NewLimiter(ctx, log.NewNOPFactory().NewLogger("user-limit"), []option.Inbound{{
+		Type: C.TypeMixed,
+		Tag:  "in",
+		MixedOptions: option.HTTPMixedInboundOptions{ListenOptions: option.ListenOptions{
+			InboundOptions: option.InboundOptions{UserLimits: []option.UserLimitOptions{
+				{Name: "user", QuotaBytes: 8},
+				{Name: "other", QuotaBytes: 8},
+			}},
+		}},
+	}})
+	require.NoError(t, limiter.Start())
+
+	// users are reported before their first connection
+	usage := limiter.Usage()
+	require.Len(t, usage, 2)
+	require.Equal(t, "other", usage[0].User)
+	require.Zero(t, usage[0].Used)
+	require.Equal(t, "user", usage[1].User)
+	require.Equal(t, uint64(2), usage[1].Used)
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	conn, release, err := limiter.RoutedConnection(context.Background(), serverConn, testMetadata(option.UserLimitOptions{Name: "user", QuotaBytes: 8}))
+	require.NoError(t, err)
+	defer release()
+	go clientConn.Read(make([]byte, 4))
+	_, err = conn.Write([]byte("test"))
+	require.NoError(t, err)
+	require.NoError(t, limiter.Close())
+	require.Equal(t, 1, cacheFile.saves)
+	require.Equal(t, uint64(6), cacheFile.usages["in"]["user"].Used)
+	require.NotContains(t, cacheFile.usages["in"], "other")
+}
+
+func TestPeriodStart(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	require.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), periodStart(now, C.UserLimitResetMonthly, 0))
+	require.Equal(t, time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), periodStart(now, C.UserLimitResetMonthly, 10))
+	require.Equal(t, time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC), periodStart(now, C.UserLimitResetMonthly, 15))
+	require.Equal(t, time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC), nextPeriodStart(periodStart(now, C.UserLimitResetMonthly, 15), C.UserLimitResetMonthly))
+}
@@ -0,0 +1,187 @@
+package usermanager
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	"github.com/sagernet/sing/service"
+)
+
+var _ adapter.UserManager = (*Manager)(nil)
+
+// Manager changes the users of multi-user inbounds at runtime and persists
+// them to the cache file if store_users is enabled.
+type Manager struct {
+	ctx        context.Context
+	logger     logger.Logger
+	access     sync.Mutex
+	inbounds   []adapter.ManagedUserInbound
+	inboundMap map[string]adapter.ManagedUserInbound
+	cacheFile  adapter.CacheFile
+}
+
+func New(ctx context.Context, logger logger.Logger, inbounds []adapter.Inbound) *Manager {
+	manager := &Manager{
+		ctx:        ctx,
+		logger:     logger,
+		inboundMap: make(map[string]adapter.ManagedUserInbound),
+	}
+	for _, inbound := range inbounds {
+		managedInbound, isManaged := inbound.(adapter.ManagedUserInbound)
+		if !isManaged || inbound.Tag() == "" {
+			continue
+		}
+		manager.inbounds = append(manager.inbounds, managedInbound)
+		manager.inboundMap[inbound.Tag()] = managedInbound
+	}
+	return manager
+}
+
+func (m *Manager) Start() error {
+	cacheFile := service.FromContext[adapter.CacheFile](m.ctx)
+	if cacheFile == nil || !cacheFile.StoreUsers() {
+		return nil
+	}
+	m.cacheFile = cacheFile
+	for _, inbound := range m.inbounds {
+		savedUsers := cacheFile.LoadInboundUsers(inbound.Tag())
+		if savedUsers == nil {
+			continue
+		}
+		configuredUsers := inbound.Users()
+		err := inbound.UpdateUsers(savedUsers.Users)
+		if err != nil {
+			m.logger.Warn("load users for inbound/", inbound.Type(), "[", inbound.Tag(), "]: ", err)
+			continue
+		}
+		// stored users win over the configuration, which may have changed since
+		if !equalUsers(configuredUsers, savedUsers.Users) {
+			m.logger.Warn(len(configuredUsers), " configured users of inbound/", inbound.Type(), "[", inbound.Tag(), "] replaced by ", len(savedUsers.Users), " users stored in cache file")
+		} else {
+			m.logger.Info("loaded ", len(savedUsers.Users), " users for inbound/", inbound.Type(), "[", inbound.Tag(), "]")
+		}
+	}
+	return nil
+}
+
+func (m *Manager) Close() error {
+	return nil
+}
+
+func (m *Manager) Inbounds() []adapter.ManagedUserInbound {
+	return m.inbounds
+}
+
+func (m *Manager) Users(inbound string) ([]adapter.InboundUser, error) {
+	managedInbound, err := m.inbound(inbound)
+	if err != nil {
+		return nil, err
+	}
+	return managedInbound.Users(), nil
+}
+
+func (m *Manager) AddUser(inbound string, user adapter.InboundUser) error {
+	return m.update(inbound, user.Name, func(managedInbound adapter.ManagedUserInbound, users []adapter.InboundUser, index int) ([]adapter.InboundUser, error) {
+		if index != -1 {
+			return nil, E.New("user already exists: ", user.Name)
+		}
+		err := validateUser(managedInbound.Type(), user)
+		if err != nil {
+			return nil, err
+		}
+		return append(users, user), nil
+	})
+}
+
+func (m *Manager) UpdateUser(inbound string, user adapter.InboundUser) error {
+	return m.update(inbound, user.Name, func(managedInbound adapter.ManagedUserInbound, users []adapter.InboundUser, index int) ([]adapter.InboundUser, error) {
+		if index == -1 {
+			return nil, E.New("user not found: ", user.Name)
+		}
+		err := validateUser(managedInbound.Type(), user)
+		if err != nil {
+			return nil, err
+		}
+		users[index] = user
+		return users, nil
+	})
+}
+
+func (m *Manager) RemoveUser(inbound string, name string) error {
+	return m.update(inbound, name, func(managedInbound adapter.ManagedUserInbound, users []adapter.InboundUser, index int) ([]adapter.InboundUser, error) {
+		if index == -1 {
+			return nil, E.New("user not found: ", name)
+		}
+		return append(users[:index], users[index+1:]...), nil
+	})
+}
+
+func (m *Manager) inbound(tag string) (adapter.ManagedUserInbound, error) {
+	managedInbound, loaded := m.inboundMap[tag]
+	if !loaded {
+		return nil, E.New("inbound not found or does not support user management: ", tag)
+	}
+	return managedInbound, nil
+}
+
+func (m *Manager) update(inbound string, name string, modify func(managedInbound adapter.ManagedUserInbound, users []adapter.InboundUser, index int) ([]adapter.InboundUser, error)) error {
+	if name == "" {
+		return E.New("missing user name")
+	}
+	managedInbound, err := m.inbound(inbound)
+	if err != nil {
+		return err
+	}
+	m.access.Lock()
+	defer m.access.Unlock()
+	users := managedInbound.Users()
+	index := common.Index(users, func(it adapter.InboundUser) bool {
+		return it.Name == name
+	})
+	users, err = modify(managedInbound, users, index)
+	if err != nil {
+		return err
+	}
+	err = managedInbound.UpdateUsers(users)
+	if err != nil {
+		return err
+	}
+	if m.cacheFile != nil {
+		err = m.cacheFile.SaveInboundUsers(inbound, &adapter.SavedInboundUsers{Users: users})
+		if err != nil {
+			return E.Cause(err, "users updated, but not saved to cache file")
+		}
+	}
+	return nil
+}
+
+func equalUsers(users []adapter.InboundUser, otherUsers []adapter.InboundUser) bool {
+	if len(users) != len(otherUsers) {
+		return false
+	}
+	for index := range users {
+		if users[index] != otherUsers[index] {
+			return false
+		}
+	}
+	return true
+}
+
+func validateUser(inboundType string, user adapter.InboundUser) error {
+	switch inboundType {
+	case C.TypeVMess, C.TypeVLESS, C.TypeTUIC:
+		if user.UUID == "" {
+			return E.New("missing uuid")
+		}
+	case C.TypeShadowsocks, C.TypeTrojan, C.TypeHysteria2, C.TypeNaive:
+		if user.Password == "" {
+			return E.New("missing password")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package usermanager
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/experimental/cachefile"
+	"github.com/sagernet/sing-box/inbound"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/auth"
+	"github.com/sagernet/sing/service"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestInbounds(t *testing.T) []adapter.Inbound {
+	vmessInbound, err := inbound.NewVMess(context.Background(), nil, log.NewNOPFactory().Logger(), "vmess-in", option.VMessInboundOptions{
+		Users: []option.VMessUser{{Name: "alice", UUID: "b831381d-6324-4d53-ad4f-8cda48b30811"}},
+	})
+	require.NoError(t, err)
+	return []adapter.Inbound{
+		vmessInbound,
+		inbound.NewSocks(context.Background(), nil, log.NewNOPFactory().Logger(), "socks-in", option.SocksInboundOptions{
+			Users: []auth.User{{Username: "alice", Password: "a"}},
+		}),
+		// inbounds without a tag can not be managed
+		inbound.NewSocks(context.Background(), nil, log.NewNOPFactory().Logger(), "", option.SocksInboundOptions{}),
+	}
+}
+
+func TestManager(t *testing.T) {
+	t.Parallel()
+	manager := New(context.Background(), log.NewNOPFactory().Logger(), newTestInbounds(t))
+	require.NoError(t, manager.Start())
+	require.Len(t, manager.Inbounds(), 2)
+
+	bob := adapter.InboundUser{Name: "bob", UUID: "6a5ef32c-5cc7-4a8e-8a23-96c6e0a0d0a2", AlterID: 4}
+	require.NoError(t, manager.AddUser("vmess-in", bob))
+	require.Error(t, manager.AddUser("vmess-in", bob))
+	require.Error(t, manager.AddUser("vmess-in", adapter.InboundUser{Name: "carol"}))
+	require.Error(t, manager.AddUser("unknown", bob))
+	bob.AlterID = 0
+	require.NoError(t, manager.UpdateUser("vmess-in", bob))
+	require.Error(t, manager.UpdateUser("vmess-in", adapter.InboundUser{Name: "carol", UUID: bob.UUID}))
+	users, err := manager.Users("vmess-in")
+	require.NoError(t, err)
+	require.Equal(t, []adapter.InboundUser{{Name: "alice", UUID: "b831381d-6324-4d53-ad4f-8cda48b30811"}, bob}, users)
+
+	require.NoError(t, manager.RemoveUser("vmess-in", "alice"))
+	require.Error(t, manager.RemoveUser("vmess-in", "alice"))
+	// the last user of a username authenticated inbound is kept
+	require.Error(t, manager.RemoveUser("socks-in", "alice"))
+}
+
+func TestManagerCacheFile(t *testing.T) {
+	t.Parallel()
+	cacheFile := cachefile.New(context.Background(), option.CacheFileOptions{
+		Path:       filepath.Join(t.TempDir(), "cache.db"),
+		StoreUsers: true,
+	})
+	require.NoError(t, cacheFile.PreStart())
+	defer cacheFile.Close()
+	ctx := service.ContextWith[adapter.CacheFile](context.Background(), cacheFile)
+
+	manager := New(ctx, log.NewNOPFactory().Logger(), newTestInbounds(t))
+	require.NoError(t, manager.Start())
+	require.NoError(t, manager.AddUser("socks-in", adapter.InboundUser{Name: "bob", Password: "b"}))
+	require.NoError(t, manager.RemoveUser("socks-in", "alice"))
+	require.NoError(t, manager.UpdateUser("vmess-in", adapter.InboundUser{Name: "alice", UUID: "6a5ef32c-5cc7-4a8e-8a23-96c6e0a0d0a2", AlterID: 1}))
+
+	// stored users replace the configured ones on the next start
+	inbounds := newTestInbounds(t)
+	manager = New(ctx, log.NewNOPFactory().Logger(), inbounds)
+	require.NoError(t, manager.Start())
+	require.Equal(t, []adapter.InboundUser{{Name: "bob", Password: "b"}}, inbounds[1].(adapter.ManagedUserInbound).Users())
+	require.Equal(t, []adapter.InboundUser{{Name: "alice", UUID: "6a5ef32c-5cc7-4a8e-8a23-96c6e0a0d0a2", AlterID: 1}}, inbounds[0].(adapter.ManagedUserInbound).Users())
+}
@@ -0,0 +1,13 @@
+package constant
+
+const (
+	BalanceStrategyRoundRobin        = "round_robin"
+	BalanceStrategyConsistentHashing = "consistent_hashing"
+	BalanceStrategySourceIPHash      = "source_ip_hash"
+	BalanceStrategyLeastConnections  = "least_connections"
+)
+
+const (
+	BalanceHashKeyDomain      = "domain"
+	BalanceHashKeyETLDPlusOne = "etld_plus_one"
+)
@@ -4,3 +4,9 @@ const (
 	DNSProviderAliDNS     = "alidns"
 	DNSProviderCloudflare = "cloudflare"
 )
+
+const (
+	DNSGroupStrategyRace     = "race"
+	DNSGroupStrategyFastest  = "fastest"
+	DNSGroupStrategyFallback = "fallback"
+)
@@ -26,8 +26,11 @@ const (
 )
 
 const (
-	TypeSelector = "selector"
-	TypeURLTest  = "urltest"
+	TypeSelector    = "selector"
+	TypeURLTest     = "urltest"
+	TypeLoadBalance = "loadbalance"
+	TypeFallback    = "fallback"
+	TypeChain       = "chain"
 
 	TypeProvider = "provider"
 )
@@ -74,6 +77,12 @@ func ProxyDisplayName(proxyType string) string {
 		return "Selector"
 	case TypeURLTest:
 		return "URLTest"
+	case TypeLoadBalance:
+		return "LoadBalance"
+	case TypeFallback:
+		return "Fallback"
+	case TypeChain:
+		return "Chain"
 	case TypeProvider:
 		return "Provider"
 	default:
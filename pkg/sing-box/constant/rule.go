@@ -11,9 +11,17 @@ const (
 )
 
 const (
+	RuleSetTypeInline   = "inline"
 	RuleSetTypeLocal    = "local"
 	RuleSetTypeRemote   = "remote"
 	RuleSetVersion1     = 1
 	RuleSetFormatSource = "source"
 	RuleSetFormatBinary = "binary"
 )
+
+const (
+	RuleSetFormatClash      = "clash"
+	RuleSetFormatHosts      = "hosts"
+	RuleSetFormatAdBlock    = "adblock"
+	RuleSetFormatDomainList = "domain_list"
+)
@@ -30,6 +30,10 @@ var (
 		string(bucketMode),
 		string(bucketRuleSet),
 		string(bucketRDRC),
+		string(bucketDNSCache),
+		string(bucketInboundUsers),
+		string(bucketUserUsage),
+		string(bucketStatistics),
 	}
 
 	cacheIDDefault = []byte("default")
@@ -44,6 +48,8 @@ type CacheFile struct {
 	storeFakeIP       bool
 	storeRDRC         bool
 	rdrcTimeout       time.Duration
+	storeUsers        bool
+	storeStatistics   bool
 	DB                *bbolt.DB
 	saveMetadataTimer *time.Timer
 	saveFakeIPAccess  sync.RWMutex
@@ -79,16 +85,18 @@ func New(ctx context.Context, options option.CacheFileOptions) *CacheFile {
 		}
 	}
 	return &CacheFile{
-		ctx:          ctx,
-		path:         filemanager.BasePath(ctx, path),
-		cacheID:      cacheIDBytes,
-		storeFakeIP:  options.StoreFakeIP,
-		storeRDRC:    options.StoreRDRC,
-		rdrcTimeout:  rdrcTimeout,
-		saveDomain:   make(map[netip.Addr]string),
-		saveAddress4: make(map[string]netip.Addr),
-		saveAddress6: make(map[string]netip.Addr),
-		saveRDRC:     make(map[saveRDRCCacheKey]bool),
+		ctx:             ctx,
+		path:            filemanager.BasePath(ctx, path),
+		cacheID:         cacheIDBytes,
+		storeFakeIP:     options.StoreFakeIP,
+		storeRDRC:       options.StoreRDRC,
+		rdrcTimeout:     rdrcTimeout,
+		storeUsers:      options.StoreUsers,
+		storeStatistics: options.StoreStatistics,
+		saveDomain:      make(map[netip.Addr]string),
+		saveAddress4:    make(map[string]netip.Addr),
+		saveAddress6:    make(map[string]netip.Addr),
+		saveRDRC:        make(map[saveRDRCCacheKey]bool),
 	}
 }
 
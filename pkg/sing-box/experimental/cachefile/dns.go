@@ -0,0 +1,103 @@
+package cachefile
+
+import (
+	"time"
+
+	"github.com/sagernet/bbolt"
+	"github.com/sagernet/sing-box/adapter"
+)
+
+var bucketDNSCache = []byte("dns_cache")
+
+func (c *CacheFile) LoadDNSCache(transportName string, key string) *adapter.SavedDNSCache {
+	var savedCache adapter.SavedDNSCache
+	var loaded bool
+	err := c.DB.View(func(tx *bbolt.Tx) error {
+		bucket := c.bucket(tx, bucketDNSCache)
+		if bucket == nil {
+			return nil
+		}
+		bucket = bucket.Bucket([]byte(transportName))
+		if bucket == nil {
+			return nil
+		}
+		content := bucket.Get([]byte(key))
+		if content == nil {
+			return nil
+		}
+		loaded = true
+		return savedCache.UnmarshalBinary(content)
+	})
+	if err != nil || !loaded {
+		return nil
+	}
+	return &savedCache
+}
+
+// SaveDNSCache writes cached answers by transport name and key in one transaction.
+func (c *CacheFile) SaveDNSCache(caches map[string]map[string]*adapter.SavedDNSCache) error {
+	return c.DB.Batch(func(tx *bbolt.Tx) error {
+		bucket, err := c.createBucket(tx, bucketDNSCache)
+		if err != nil {
+			return err
+		}
+		for transportName, transportCaches := range caches {
+			transportBucket, err := bucket.CreateBucketIfNotExists([]byte(transportName))
+			if err != nil {
+				return err
+			}
+			for key, cache := range transportCaches {
+				content, err := cache.MarshalBinary()
+				if err != nil {
+					return err
+				}
+				err = transportBucket.Put([]byte(key), content)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (c *CacheFile) PurgeDNSCache(transportName string, expiredBefore time.Time) error {
+	return c.DB.Update(func(tx *bbolt.Tx) error {
+		bucket := c.bucket(tx, bucketDNSCache)
+		if bucket == nil {
+			return nil
+		}
+		bucket = bucket.Bucket([]byte(transportName))
+		if bucket == nil {
+			return nil
+		}
+		var expiredKeys [][]byte
+		err := bucket.ForEach(func(key, value []byte) error {
+			var savedCache adapter.SavedDNSCache
+			if savedCache.UnmarshalBinary(value) != nil || savedCache.ExpireAt.Before(expiredBefore) {
+				expiredKeys = append(expiredKeys, append([]byte(nil), key...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, key := range expiredKeys {
+			err = bucket.Delete(key)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (c *CacheFile) ClearDNSCache(transportName string) error {
+	return c.DB.Update(func(tx *bbolt.Tx) error {
+		bucket := c.bucket(tx, bucketDNSCache)
+		if bucket == nil || bucket.Bucket([]byte(transportName)) == nil {
+			return nil
+		}
+		return bucket.DeleteBucket([]byte(transportName))
+	})
+}
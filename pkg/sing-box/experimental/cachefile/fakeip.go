@@ -72,6 +72,7 @@ func (c *CacheFile) FakeIPStore(address netip.Addr, domain string) error {
 		if err != nil {
 			return err
 		}
+		oldDomain := string(bucket.Get(address.AsSlice()))
 		err = bucket.Put(address.AsSlice(), []byte(domain))
 		if err != nil {
 			return err
@@ -84,6 +85,12 @@ func (c *CacheFile) FakeIPStore(address netip.Addr, domain string) error {
 		if err != nil {
 			return err
 		}
+		if oldDomain != "" && oldDomain != domain && M.AddrFromIP(bucket.Get([]byte(oldDomain))) == address {
+			err = bucket.Delete([]byte(oldDomain))
+			if err != nil {
+				return err
+			}
+		}
 		return bucket.Put([]byte(domain), address.AsSlice())
 	})
 }
@@ -164,16 +171,30 @@ func (c *CacheFile) FakeIPLoadDomain(domain string, isIPv6 bool) (netip.Addr, bo
 	return address, address.IsValid()
 }
 
+func (c *CacheFile) FakeIPForEach(fn func(address netip.Addr, domain string)) error {
+	return c.DB.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(bucketFakeIP)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(key, value []byte) error {
+			if len(key) != 4 && len(key) != 16 {
+				return nil
+			}
+			fn(M.AddrFromIP(key), string(value))
+			return nil
+		})
+	})
+}
+
 func (c *CacheFile) FakeIPReset() error {
 	return c.DB.Batch(func(tx *bbolt.Tx) error {
-		err := tx.DeleteBucket(bucketFakeIP)
-		if err != nil {
-			return err
+		for _, bucketName := range [][]byte{bucketFakeIP, bucketFakeIPDomain4, bucketFakeIPDomain6} {
+			err := tx.DeleteBucket(bucketName)
+			if err != nil && err != bbolt.ErrBucketNotFound {
+				return err
+			}
 		}
-		err = tx.DeleteBucket(bucketFakeIPDomain4)
-		if err != nil {
-			return err
-		}
-		return tx.DeleteBucket(bucketFakeIPDomain6)
+		return nil
 	})
 }
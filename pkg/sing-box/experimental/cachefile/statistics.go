@@ -0,0 +1,50 @@
+package cachefile
+
+import (
+	"os"
+
+	"github.com/sagernet/bbolt"
+	"github.com/sagernet/sing-box/adapter"
+)
+
+var (
+	bucketStatistics = []byte("statistics")
+	keyTraffic       = []byte("traffic")
+)
+
+func (c *CacheFile) StoreStatistics() bool {
+	return c.storeStatistics
+}
+
+func (c *CacheFile) LoadStatistics() *adapter.SavedStatistics {
+	var savedStatistics adapter.SavedStatistics
+	err := c.DB.View(func(t *bbolt.Tx) error {
+		bucket := c.bucket(t, bucketStatistics)
+		if bucket == nil {
+			return os.ErrNotExist
+		}
+		statisticsBinary := bucket.Get(keyTraffic)
+		if len(statisticsBinary) == 0 {
+			return os.ErrInvalid
+		}
+		return savedStatistics.UnmarshalBinary(statisticsBinary)
+	})
+	if err != nil {
+		return nil
+	}
+	return &savedStatistics
+}
+
+func (c *CacheFile) SaveStatistics(statistics *adapter.SavedStatistics) error {
+	return c.DB.Batch(func(t *bbolt.Tx) error {
+		bucket, err := c.createBucket(t, bucketStatistics)
+		if err != nil {
+			return err
+		}
+		statisticsBinary, err := statistics.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		return bucket.Put(keyTraffic, statisticsBinary)
+	})
+}
@@ -0,0 +1,56 @@
+package cachefile
+
+import (
+	"os"
+
+	"github.com/sagernet/bbolt"
+	"github.com/sagernet/sing-box/adapter"
+)
+
+var bucketUserUsage = []byte("user_usage")
+
+func userUsageKey(inbound string, user string) []byte {
+	return []byte(inbound + "\x00" + user)
+}
+
+func (c *CacheFile) LoadUserUsage(inbound string, user string) *adapter.SavedUserUsage {
+	var savedUsage adapter.SavedUserUsage
+	err := c.DB.View(func(t *bbolt.Tx) error {
+		bucket := c.bucket(t, bucketUserUsage)
+		if bucket == nil {
+			return os.ErrNotExist
+		}
+		usageBinary := bucket.Get(userUsageKey(inbound, user))
+		if len(usageBinary) == 0 {
+			return os.ErrInvalid
+		}
+		return savedUsage.UnmarshalBinary(usageBinary)
+	})
+	if err != nil {
+		return nil
+	}
+	return &savedUsage
+}
+
+// SaveUserUsage writes usage by inbound and user in one transaction.
+func (c *CacheFile) SaveUserUsage(usages map[string]map[string]*adapter.SavedUserUsage) error {
+	return c.DB.Batch(func(t *bbolt.Tx) error {
+		bucket, err := c.createBucket(t, bucketUserUsage)
+		if err != nil {
+			return err
+		}
+		for inbound, inboundUsages := range usages {
+			for user, usage := range inboundUsages {
+				usageBinary, err := usage.MarshalBinary()
+				if err != nil {
+					return err
+				}
+				err = bucket.Put(userUsageKey(inbound, user), usageBinary)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,47 @@
+package cachefile
+
+import (
+	"os"
+
+	"github.com/sagernet/bbolt"
+	"github.com/sagernet/sing-box/adapter"
+)
+
+var bucketInboundUsers = []byte("inbound_users")
+
+func (c *CacheFile) StoreUsers() bool {
+	return c.storeUsers
+}
+
+func (c *CacheFile) LoadInboundUsers(inbound string) *adapter.SavedInboundUsers {
+	var savedUsers adapter.SavedInboundUsers
+	err := c.DB.View(func(t *bbolt.Tx) error {
+		bucket := c.bucket(t, bucketInboundUsers)
+		if bucket == nil {
+			return os.ErrNotExist
+		}
+		usersBinary := bucket.Get([]byte(inbound))
+		if len(usersBinary) == 0 {
+			return os.ErrInvalid
+		}
+		return savedUsers.UnmarshalBinary(usersBinary)
+	})
+	if err != nil {
+		return nil
+	}
+	return &savedUsers
+}
+
+func (c *CacheFile) SaveInboundUsers(inbound string, users *adapter.SavedInboundUsers) error {
+	return c.DB.Batch(func(t *bbolt.Tx) error {
+		bucket, err := c.createBucket(t, bucketInboundUsers)
+		if err != nil {
+			return err
+		}
+		usersBinary, err := users.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(inbound), usersBinary)
+	})
+}
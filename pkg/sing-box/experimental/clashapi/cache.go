@@ -3,6 +3,8 @@ package clashapi
 import (
 	"context"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing/service"
@@ -11,23 +13,135 @@ import (
 	"github.com/go-chi/render"
 )
 
-func cacheRouter(ctx context.Context) http.Handler {
+func cacheRouter(ctx context.Context, router adapter.Router) http.Handler {
 	r := chi.NewRouter()
-	r.Post("/fakeip/flush", flushFakeip(ctx))
+	r.Get("/fakeip", getFakeIPEntries(router))
+	r.Get("/fakeip/pools", getFakeIPPools(router))
+	r.Post("/fakeip/flush", flushFakeip(ctx, router))
+	r.Post("/dns/flush", flushDNS(router))
 	return r
 }
 
-func flushFakeip(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+type FakeIPEntry struct {
+	Pool     string `json:"pool,omitempty"`
+	Address  string `json:"address"`
+	Domain   string `json:"domain"`
+	LastUsed int64  `json:"last_used,omitempty"`
+}
+
+type FakeIPPool struct {
+	Tag           string `json:"tag,omitempty"`
+	Inet4Range    string `json:"inet4_range,omitempty"`
+	Inet6Range    string `json:"inet6_range,omitempty"`
+	Inet4Used     uint64 `json:"inet4_used"`
+	Inet4Capacity uint64 `json:"inet4_capacity"`
+	Inet6Used     uint64 `json:"inet6_used"`
+	Inet6Capacity uint64 `json:"inet6_capacity"`
+	Recycled      uint64 `json:"recycled"`
+}
+
+func getFakeIPEntries(router adapter.Router) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		cacheFile := service.FromContext[adapter.CacheFile](ctx)
-		if cacheFile != nil {
-			err := cacheFile.FakeIPReset()
+		fakeIPStore := router.FakeIPStore()
+		if fakeIPStore == nil {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, newError("fakeip not enabled"))
+			return
+		}
+		query := r.URL.Query()
+		var limit int
+		if limitStr := query.Get("limit"); limitStr != "" {
+			var err error
+			limit, err = strconv.Atoi(limitStr)
 			if err != nil {
-				render.Status(r, http.StatusInternalServerError)
-				render.JSON(w, r, newError(err.Error()))
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, ErrBadRequest)
 				return
 			}
 		}
+		keyword := strings.ToLower(query.Get("q"))
+		pool := query.Get("pool")
+		entries := []FakeIPEntry{}
+		for _, entry := range fakeIPStore.Entries() {
+			if query.Has("pool") && entry.Pool != pool {
+				continue
+			}
+			address := entry.Address.String()
+			if keyword != "" && !strings.Contains(entry.Domain, keyword) && !strings.Contains(address, keyword) {
+				continue
+			}
+			var lastUsed int64
+			if !entry.LastUsed.IsZero() {
+				lastUsed = entry.LastUsed.UnixMilli()
+			}
+			entries = append(entries, FakeIPEntry{
+				Pool:     entry.Pool,
+				Address:  address,
+				Domain:   entry.Domain,
+				LastUsed: lastUsed,
+			})
+			if limit > 0 && len(entries) == limit {
+				break
+			}
+		}
+		render.JSON(w, r, render.M{
+			"entries": entries,
+		})
+	}
+}
+
+func getFakeIPPools(router adapter.Router) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fakeIPStore := router.FakeIPStore()
+		if fakeIPStore == nil {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, newError("fakeip not enabled"))
+			return
+		}
+		pools := []FakeIPPool{}
+		for _, status := range fakeIPStore.Pools() {
+			pool := FakeIPPool{
+				Tag:           status.Tag,
+				Inet4Used:     status.Inet4Used,
+				Inet4Capacity: status.Inet4Capacity,
+				Inet6Used:     status.Inet6Used,
+				Inet6Capacity: status.Inet6Capacity,
+				Recycled:      status.Recycled,
+			}
+			if status.Inet4Range.IsValid() {
+				pool.Inet4Range = status.Inet4Range.String()
+			}
+			if status.Inet6Range.IsValid() {
+				pool.Inet6Range = status.Inet6Range.String()
+			}
+			pools = append(pools, pool)
+		}
+		render.JSON(w, r, render.M{
+			"pools": pools,
+		})
+	}
+}
+
+func flushFakeip(ctx context.Context, router adapter.Router) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		if fakeIPStore := router.FakeIPStore(); fakeIPStore != nil {
+			err = fakeIPStore.Reset()
+		} else if cacheFile := service.FromContext[adapter.CacheFile](ctx); cacheFile != nil {
+			err = cacheFile.FakeIPReset()
+		}
+		if err != nil {
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, newError(err.Error()))
+			return
+		}
+		render.NoContent(w, r)
+	}
+}
+
+func flushDNS(router adapter.Router) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		router.FlushDNSCache()
 		render.NoContent(w, r)
 	}
 }
@@ -0,0 +1,92 @@
+package clashapi
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/sagernet/sing-box/common/capture"
+	"github.com/sagernet/sing/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+func captureRouter(ctx context.Context) http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", getCapture(ctx))
+	r.Post("/", startCapture(ctx))
+	r.Delete("/", stopCapture(ctx))
+	r.Get("/file", getCaptureFile(ctx))
+	return r
+}
+
+func getCapture(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		captureManager := service.PtrFromContext[capture.Manager](ctx)
+		if captureManager == nil {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, ErrNotFound)
+			return
+		}
+		status := captureManager.Status()
+		if status == nil {
+			render.JSON(w, r, render.M{"running": false})
+			return
+		}
+		render.JSON(w, r, status)
+	}
+}
+
+func startCapture(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		captureManager := service.PtrFromContext[capture.Manager](ctx)
+		if captureManager == nil {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, ErrNotFound)
+			return
+		}
+		var options capture.Options
+		err := render.DecodeJSON(r.Body, &options)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, ErrBadRequest)
+			return
+		}
+		err = captureManager.Start(options)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, newError(err.Error()))
+			return
+		}
+		render.JSON(w, r, captureManager.Status())
+	}
+}
+
+func stopCapture(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if captureManager := service.PtrFromContext[capture.Manager](ctx); captureManager != nil {
+			captureManager.Stop()
+		}
+		render.NoContent(w, r)
+	}
+}
+
+func getCaptureFile(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var data []byte
+		if captureManager := service.PtrFromContext[capture.Manager](ctx); captureManager != nil {
+			data = captureManager.Data()
+		}
+		if data == nil {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, ErrNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", `attachment; filename="sing-box.pcapng"`)
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.WriteHeader(http.StatusOK)
+		w.Write(data)
+	}
+}
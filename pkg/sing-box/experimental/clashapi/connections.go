@@ -20,6 +20,8 @@ func connectionRouter(router adapter.Router, trafficManager *trafficontrol.Manag
 	r := chi.NewRouter()
 	r.Get("/", getConnections(trafficManager))
 	r.Delete("/", closeAllConnections(router, trafficManager))
+	r.Get("/closed", getClosedConnections(trafficManager))
+	r.Delete("/closed", clearClosedConnections(trafficManager))
 	r.Delete("/{id}", closeConnection(trafficManager))
 	return r
 }
@@ -80,7 +82,7 @@ func closeConnection(trafficManager *trafficontrol.Manager) func(w http.Response
 		snapshot := trafficManager.Snapshot()
 		for _, c := range snapshot.Connections {
 			if id == c.ID() {
-				c.Close()
+				c.CloseWithReason("closed by API")
 				break
 			}
 		}
@@ -92,9 +94,24 @@ func closeAllConnections(router adapter.Router, trafficManager *trafficontrol.Ma
 	return func(w http.ResponseWriter, r *http.Request) {
 		snapshot := trafficManager.Snapshot()
 		for _, c := range snapshot.Connections {
-			c.Close()
+			c.CloseWithReason("closed by API")
 		}
 		router.ResetNetwork()
 		render.NoContent(w, r)
 	}
 }
+
+func getClosedConnections(trafficManager *trafficontrol.Manager) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, r, render.M{
+			"connections": trafficManager.ClosedConnections(),
+		})
+	}
+}
+
+func clearClosedConnections(trafficManager *trafficontrol.Manager) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		trafficManager.ClearClosedConnections()
+		render.NoContent(w, r)
+	}
+}
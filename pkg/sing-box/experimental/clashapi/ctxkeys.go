@@ -5,6 +5,8 @@ var (
 	CtxKeyProviderName = contextKey("provider name")
 	CtxKeyProxy        = contextKey("proxy")
 	CtxKeyProvider     = contextKey("provider")
+	CtxKeyInbound      = contextKey("inbound")
+	CtxKeyUserManager  = contextKey("user manager")
 )
 
 type contextKey string
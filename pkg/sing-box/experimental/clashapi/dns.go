@@ -1,24 +1,105 @@
 package clashapi
 
 import (
+	"bytes"
 	"context"
 	"net/http"
+	"strconv"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/dnsquery"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/json"
+	"github.com/sagernet/ws"
+	"github.com/sagernet/ws/wsutil"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
 	"github.com/miekg/dns"
 )
 
-func dnsRouter(router adapter.Router) http.Handler {
+func dnsRouter(router adapter.Router, recorder *dnsquery.Recorder) http.Handler {
 	r := chi.NewRouter()
 	r.Get("/query", queryDNS(router))
+	r.Get("/queries", getDNSQueries(recorder))
+	r.Delete("/queries", resetDNSQueries(recorder))
+	r.Get("/stats", getDNSStats(recorder))
 	return r
 }
 
+func getDNSQueries(recorder *dnsquery.Recorder) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "websocket" {
+			var limit int
+			if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+				var err error
+				limit, err = strconv.Atoi(limitStr)
+				if err != nil {
+					render.Status(r, http.StatusBadRequest)
+					render.JSON(w, r, ErrBadRequest)
+					return
+				}
+			}
+			records := recorder.Records(limit)
+			if records == nil {
+				records = []adapter.DNSQueryRecord{}
+			}
+			render.JSON(w, r, render.M{
+				"queries": records,
+			})
+			return
+		}
+
+		subscription, done, err := recorder.Subscribe()
+		if err != nil {
+			render.Status(r, http.StatusNoContent)
+			return
+		}
+		defer recorder.UnSubscribe(subscription)
+
+		conn, _, _, err := ws.UpgradeHTTP(r, w)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		buf := &bytes.Buffer{}
+		var record adapter.DNSQueryRecord
+		for {
+			select {
+			case <-done:
+				return
+			case record = <-subscription:
+			}
+			buf.Reset()
+			err = json.NewEncoder(buf).Encode(record)
+			if err != nil {
+				return
+			}
+			err = wsutil.WriteServerText(conn, buf.Bytes())
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+func resetDNSQueries(recorder *dnsquery.Recorder) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		recorder.Reset()
+		render.NoContent(w, r)
+	}
+}
+
+func getDNSStats(recorder *dnsquery.Recorder) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, r, render.M{
+			"upstreams": recorder.Stats(),
+		})
+	}
+}
+
 func queryDNS(router adapter.Router) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		name := r.URL.Query().Get("name")
@@ -0,0 +1,62 @@
+package clashapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/dnsquery"
+	"github.com/sagernet/sing/common/json"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDNSQueriesEndpoint(t *testing.T) {
+	t.Parallel()
+	recorder := dnsquery.NewRecorder(dnsquery.DefaultSize)
+	defer recorder.Close()
+	handler := dnsRouter(nil, recorder)
+	request := func(method string, target string) *httptest.ResponseRecorder {
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, httptest.NewRequest(method, target, nil))
+		return response
+	}
+	var queries struct {
+		Queries []adapter.DNSQueryRecord `json:"queries"`
+	}
+	response := request(http.MethodGet, "/queries")
+	require.Equal(t, http.StatusOK, response.Code)
+	require.JSONEq(t, `{"queries":[]}`, response.Body.String())
+
+	recorder.RecordDNSQuery(adapter.DNSQueryRecord{Domain: "a.example", QueryType: "A", Upstream: "remote", RCode: "NOERROR", Answers: []string{"1.1.1.1"}})
+	recorder.RecordDNSQuery(adapter.DNSQueryRecord{Domain: "b.example", QueryType: "AAAA", Cached: true})
+	response = request(http.MethodGet, "/queries")
+	require.Equal(t, http.StatusOK, response.Code)
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &queries))
+	require.Len(t, queries.Queries, 2)
+	require.Equal(t, "a.example", queries.Queries[0].Domain)
+	require.Equal(t, []string{"1.1.1.1"}, queries.Queries[0].Answers)
+	require.True(t, queries.Queries[1].Cached)
+
+	response = request(http.MethodGet, "/queries?limit=1")
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &queries))
+	require.Len(t, queries.Queries, 1)
+	require.Equal(t, "b.example", queries.Queries[0].Domain)
+
+	response = request(http.MethodGet, "/queries?limit=x")
+	require.Equal(t, http.StatusBadRequest, response.Code)
+
+	var stats struct {
+		Upstreams []dnsquery.UpstreamStats `json:"upstreams"`
+	}
+	response = request(http.MethodGet, "/stats")
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &stats))
+	require.Len(t, stats.Upstreams, 1)
+	require.Equal(t, "remote", stats.Upstreams[0].Upstream)
+	require.Equal(t, uint64(1), stats.Upstreams[0].Queries)
+
+	response = request(http.MethodDelete, "/queries")
+	require.Equal(t, http.StatusNoContent, response.Code)
+	require.Empty(t, recorder.Records(0))
+}
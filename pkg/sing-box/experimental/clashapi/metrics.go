@@ -0,0 +1,218 @@
+package clashapi
+
+import (
+	"bytes"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/dnsquery"
+	"github.com/sagernet/sing-box/common/urltest"
+	"github.com/sagernet/sing-box/experimental/clashapi/trafficontrol"
+	N "github.com/sagernet/sing/common/network"
+)
+
+func metrics(server *Server, router adapter.Router, trafficManager *trafficontrol.Manager) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var writer metricsWriter
+		writeStatisticMetrics(&writer, router, trafficManager)
+		writeTrafficMetrics(&writer, trafficManager)
+		writeURLTestMetrics(&writer, router, server.urlTestHistory)
+		writeDNSMetrics(&writer, server.dnsQueries)
+		writeUpdateMetrics(&writer, router)
+		writeRuntimeMetrics(&writer, server.startedAt)
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Write(writer.Bytes())
+	}
+}
+
+// writeStatisticMetrics writes the counters of the traffic manager, including
+// the totals restored from the cache file.
+func writeStatisticMetrics(writer *metricsWriter, router adapter.Router, trafficManager *trafficontrol.Manager) {
+	statistics := trafficManager.Statistics()
+	writeTrafficCounters(writer, "inbound", statistics.Inbounds, func(key string) []string {
+		return []string{"inbound", key}
+	})
+	writeTrafficCounters(writer, "outbound", statistics.Outbounds, func(key string) []string {
+		return []string{"outbound", key}
+	})
+	writeTrafficCounters(writer, "user", trafficManager.UserStatistics(), func(key trafficontrol.UserKey) []string {
+		return []string{"inbound", key.Inbound, "user", key.User}
+	})
+	ruleHits := trafficManager.RuleHits()
+	writer.family("sing_box_rule_hits_total", "counter", "Routed connections by matched rule.")
+	for i, rule := range router.Rules() {
+		if hits, loaded := ruleHits[rule]; loaded {
+			writer.sample("sing_box_rule_hits_total", float64(hits), "rule", strconv.Itoa(i), "outbound", rule.Outbound())
+		}
+	}
+	if hits, loaded := ruleHits[nil]; loaded {
+		var defaultTag string
+		if defaultOutbound, err := router.DefaultOutbound(N.NetworkTCP); err == nil {
+			defaultTag = defaultOutbound.Tag()
+		}
+		writer.sample("sing_box_rule_hits_total", float64(hits), "rule", "final", "outbound", defaultTag)
+	}
+}
+
+func writeTrafficCounters[K comparable](writer *metricsWriter, scope string, statistics map[K]*trafficontrol.Statistic, labels func(key K) []string) {
+	type entry struct {
+		labels    []string
+		statistic *trafficontrol.Statistic
+	}
+	entries := make([]entry, 0, len(statistics))
+	for key, statistic := range statistics {
+		entries = append(entries, entry{labels(key), statistic})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return strings.Join(entries[i].labels, "\x00") < strings.Join(entries[j].labels, "\x00")
+	})
+	bytesName := "sing_box_" + scope + "_bytes_total"
+	writer.family(bytesName, "counter", "Traffic of routed connections by "+scope+".")
+	for _, entry := range entries {
+		writer.sample(bytesName, float64(entry.statistic.Upload.Load()), append(entry.labels, "direction", "upload")...)
+		writer.sample(bytesName, float64(entry.statistic.Download.Load()), append(entry.labels, "direction", "download")...)
+	}
+	connectionsName := "sing_box_" + scope + "_connections_total"
+	writer.family(connectionsName, "counter", "Routed connections by "+scope+".")
+	for _, entry := range entries {
+		writer.sample(connectionsName, float64(entry.statistic.Connections.Load()), entry.labels...)
+	}
+}
+
+func writeTrafficMetrics(writer *metricsWriter, trafficManager *trafficontrol.Manager) {
+	upload, download := trafficManager.Total()
+	writer.family("sing_box_bytes_total", "counter", "Traffic of all routed connections.")
+	writer.sample("sing_box_bytes_total", float64(upload), "direction", "upload")
+	writer.sample("sing_box_bytes_total", float64(download), "direction", "download")
+	writer.family("sing_box_active_connections", "gauge", "Routed connections currently open.")
+	writer.sample("sing_box_active_connections", float64(trafficManager.Connections()))
+}
+
+func writeURLTestMetrics(writer *metricsWriter, router adapter.Router, historyStorage *urltest.HistoryStorage) {
+	var tags []string
+	for _, detour := range router.Outbounds() {
+		tags = append(tags, detour.Tag())
+		if provider, isProvider := detour.(adapter.ProxyProvider); isProvider {
+			for tag := range provider.AllOutbound() {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	histories := make(map[string]*urltest.History)
+	for _, tag := range tags {
+		if history := historyStorage.LoadURLTestHistory(tag); history != nil {
+			histories[tag] = history
+		}
+	}
+	writer.family("sing_box_urltest_delay_milliseconds", "gauge", "Delay of outbounds in the last URL test.")
+	for _, tag := range tags {
+		if history := histories[tag]; history != nil {
+			writer.sample("sing_box_urltest_delay_milliseconds", float64(history.Delay), "outbound", tag)
+		}
+	}
+	writer.family("sing_box_urltest_last_tested_timestamp_seconds", "gauge", "Time of the last URL test of outbounds.")
+	for _, tag := range tags {
+		if history := histories[tag]; history != nil {
+			writer.sample("sing_box_urltest_last_tested_timestamp_seconds", float64(history.Time.Unix()), "outbound", tag)
+		}
+	}
+}
+
+func writeDNSMetrics(writer *metricsWriter, recorder *dnsquery.Recorder) {
+	queries, cacheHits := recorder.Totals()
+	writer.family("sing_box_dns_queries_total", "counter", "DNS queries.")
+	writer.sample("sing_box_dns_queries_total", float64(queries))
+	writer.family("sing_box_dns_cache_hits_total", "counter", "DNS queries answered from cache.")
+	writer.sample("sing_box_dns_cache_hits_total", float64(cacheHits))
+	upstreamStats := recorder.Stats()
+	writer.family("sing_box_dns_upstream_queries_total", "counter", "DNS queries sent by upstream.")
+	for _, stats := range upstreamStats {
+		writer.sample("sing_box_dns_upstream_queries_total", float64(stats.Queries), "upstream", stats.Upstream)
+	}
+	writer.family("sing_box_dns_upstream_failures_total", "counter", "Failed DNS queries by upstream.")
+	for _, stats := range upstreamStats {
+		writer.sample("sing_box_dns_upstream_failures_total", float64(stats.Failures), "upstream", stats.Upstream)
+	}
+}
+
+func writeUpdateMetrics(writer *metricsWriter, router adapter.Router) {
+	ruleSets := router.RuleSets()
+	ruleSetTags := make([]string, 0, len(ruleSets))
+	for tag := range ruleSets {
+		ruleSetTags = append(ruleSetTags, tag)
+	}
+	sort.Strings(ruleSetTags)
+	writer.family("sing_box_rule_set_last_updated_timestamp_seconds", "gauge", "Last update time of rule-sets.")
+	for _, tag := range ruleSetTags {
+		if lastUpdated := ruleSets[tag].LastUpdated(); !lastUpdated.IsZero() {
+			writer.sample("sing_box_rule_set_last_updated_timestamp_seconds", float64(lastUpdated.Unix()), "rule_set", tag)
+		}
+	}
+	writer.family("sing_box_provider_last_updated_timestamp_seconds", "gauge", "Last update time of outbound providers.")
+	for _, detour := range router.Outbounds() {
+		if provider, isProvider := detour.(adapter.ProxyProvider); isProvider {
+			if updatedAt := provider.UpdatedAt(); !updatedAt.IsZero() {
+				writer.sample("sing_box_provider_last_updated_timestamp_seconds", float64(updatedAt.Unix()), "provider", detour.Tag())
+			}
+		}
+	}
+}
+
+func writeRuntimeMetrics(writer *metricsWriter, startedAt time.Time) {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	writer.family("process_start_time_seconds", "gauge", "Start time of the process.")
+	writer.sample("process_start_time_seconds", float64(startedAt.Unix()))
+	writer.family("go_goroutines", "gauge", "Number of goroutines.")
+	writer.sample("go_goroutines", float64(runtime.NumGoroutine()))
+	writer.family("go_memstats_alloc_bytes", "gauge", "Bytes of allocated heap objects.")
+	writer.sample("go_memstats_alloc_bytes", float64(memStats.Alloc))
+	writer.family("go_memstats_alloc_bytes_total", "counter", "Bytes allocated for heap objects.")
+	writer.sample("go_memstats_alloc_bytes_total", float64(memStats.TotalAlloc))
+	writer.family("go_memstats_sys_bytes", "gauge", "Bytes of memory obtained from the OS.")
+	writer.sample("go_memstats_sys_bytes", float64(memStats.Sys))
+	writer.family("go_memstats_heap_inuse_bytes", "gauge", "Bytes in in-use heap spans.")
+	writer.sample("go_memstats_heap_inuse_bytes", float64(memStats.HeapInuse))
+	writer.family("go_memstats_heap_objects", "gauge", "Number of allocated heap objects.")
+	writer.sample("go_memstats_heap_objects", float64(memStats.HeapObjects))
+	writer.family("go_gc_cycles_total", "counter", "Completed GC cycles.")
+	writer.sample("go_gc_cycles_total", float64(memStats.NumGC))
+	writer.family("go_gc_pause_seconds_total", "counter", "Total GC pause time.")
+	writer.sample("go_gc_pause_seconds_total", float64(memStats.PauseTotalNs)/float64(time.Second))
+}
+
+// metricsWriter writes the Prometheus text exposition format.
+type metricsWriter struct {
+	bytes.Buffer
+}
+
+func (w *metricsWriter) family(name string, metricType string, help string) {
+	w.WriteString("# HELP " + name + " " + help + "\n")
+	w.WriteString("# TYPE " + name + " " + metricType + "\n")
+}
+
+// sample writes a sample with labels given as name and value pairs.
+func (w *metricsWriter) sample(name string, value float64, labels ...string) {
+	w.WriteString(name)
+	if len(labels) > 0 {
+		w.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(labels[i] + "=\"" + labelEscaper.Replace(labels[i+1]) + "\"")
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	w.WriteByte('\n')
+}
+
+var labelEscaper = strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n")
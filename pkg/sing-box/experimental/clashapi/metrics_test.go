@@ -0,0 +1,120 @@
+package clashapi
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/experimental/clashapi/trafficontrol"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testRouter struct {
+	adapter.Router
+	outbounds map[string]adapter.Outbound
+	rules     []adapter.Rule
+}
+
+func (r *testRouter) Outbound(tag string) (adapter.Outbound, bool) {
+	outbound, loaded := r.outbounds[tag]
+	return outbound, loaded
+}
+
+func (r *testRouter) DefaultOutbound(network string) (adapter.Outbound, error) {
+	return r.outbounds["direct"], nil
+}
+
+func (r *testRouter) Rules() []adapter.Rule {
+	return r.rules
+}
+
+type testOutbound struct {
+	adapter.Outbound
+	tag string
+}
+
+func (o *testOutbound) Tag() string {
+	return o.tag
+}
+
+type testRule struct {
+	adapter.Rule
+	outbound string
+}
+
+func (r *testRule) Outbound() string {
+	return r.outbound
+}
+
+func (r *testRule) String() string {
+	return "test"
+}
+
+func TestStatisticMetrics(t *testing.T) {
+	t.Parallel()
+	router := &testRouter{
+		outbounds: map[string]adapter.Outbound{
+			"direct": &testOutbound{tag: "direct"},
+			"proxy":  &testOutbound{tag: "proxy"},
+		},
+		rules: []adapter.Rule{&testRule{outbound: "proxy"}, &testRule{outbound: "block"}},
+	}
+	manager := trafficontrol.NewManager(router, 0)
+	manager.LoadStatistics(&adapter.SavedStatistics{
+		Outbounds: map[string]adapter.SavedTraffic{"proxy": {Upload: 100}},
+	})
+	metadata := adapter.InboundContext{
+		Inbound:     "in",
+		Network:     N.NetworkTCP,
+		User:        `al"ice`,
+		Destination: M.ParseSocksaddr("example.com:443"),
+	}
+	client, server := net.Pipe()
+	defer server.Close()
+	conn, tracker := manager.RoutedConnection(context.Background(), client, metadata, router.rules[0])
+	go server.Write([]byte("hello"))
+	_, err := io.ReadFull(conn, make([]byte, 5))
+	require.NoError(t, err)
+	conn.Close()
+	tracker.Leave(nil)
+	metadata.User = ""
+	_, tracker = manager.RoutedConnection(context.Background(), &net.TCPConn{}, metadata, nil)
+	tracker.Leave(nil)
+
+	var writer metricsWriter
+	writeStatisticMetrics(&writer, router, manager)
+	require.Equal(t, `# HELP sing_box_inbound_bytes_total Traffic of routed connections by inbound.
+# TYPE sing_box_inbound_bytes_total counter
+sing_box_inbound_bytes_total{inbound="in",direction="upload"} 5
+sing_box_inbound_bytes_total{inbound="in",direction="download"} 0
+# HELP sing_box_inbound_connections_total Routed connections by inbound.
+# TYPE sing_box_inbound_connections_total counter
+sing_box_inbound_connections_total{inbound="in"} 2
+# HELP sing_box_outbound_bytes_total Traffic of routed connections by outbound.
+# TYPE sing_box_outbound_bytes_total counter
+sing_box_outbound_bytes_total{outbound="direct",direction="upload"} 0
+sing_box_outbound_bytes_total{outbound="direct",direction="download"} 0
+sing_box_outbound_bytes_total{outbound="proxy",direction="upload"} 105
+sing_box_outbound_bytes_total{outbound="proxy",direction="download"} 0
+# HELP sing_box_outbound_connections_total Routed connections by outbound.
+# TYPE sing_box_outbound_connections_total counter
+sing_box_outbound_connections_total{outbound="direct"} 1
+sing_box_outbound_connections_total{outbound="proxy"} 1
+# HELP sing_box_user_bytes_total Traffic of routed connections by user.
+# TYPE sing_box_user_bytes_total counter
+sing_box_user_bytes_total{inbound="in",user="al\"ice",direction="upload"} 5
+sing_box_user_bytes_total{inbound="in",user="al\"ice",direction="download"} 0
+# HELP sing_box_user_connections_total Routed connections by user.
+# TYPE sing_box_user_connections_total counter
+sing_box_user_connections_total{inbound="in",user="al\"ice"} 1
+# HELP sing_box_rule_hits_total Routed connections by matched rule.
+# TYPE sing_box_rule_hits_total counter
+sing_box_rule_hits_total{rule="0",outbound="proxy"} 1
+sing_box_rule_hits_total{rule="final",outbound="direct"} 1
+`, writer.String())
+}
@@ -57,17 +57,19 @@ func providerInfo(server *Server, detour adapter.Outbound) *badjson.JSONObject {
 	info.Put("name", detour.Tag())
 
 	var proxies []*badjson.JSONObject
+	updatedAt := time.Now()
 	if d, ok := detour.(adapter.ProxyProvider); ok {
 		all := d.AllOutbound()
 		for _, v := range all {
 			proxies = append(proxies, proxyInfo(server, v))
 		}
+		updatedAt = d.UpdatedAt()
 	}
 
 	info.Put("proxies", proxies)
 	info.Put("type", "Proxy")
 	info.Put("vehicleType", "HTTP")
-	info.Put("updatedAt", time.Now())
+	info.Put("updatedAt", updatedAt)
 	return &info
 }
 
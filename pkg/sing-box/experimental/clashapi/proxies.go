@@ -85,6 +85,16 @@ func proxyInfo(server *Server, detour adapter.Outbound) *badjson.JSONObject {
 		info.Put("now", group.Now())
 		info.Put("all", group.All())
 	}
+	if failoverGroup, isFailoverGroup := detour.(adapter.FailoverGroup); isFailoverGroup {
+		info.Put("failover", failoverGroup.FailoverCounts())
+	}
+	if statistic := server.trafficManager.OutboundStatistic(detour.Tag()); statistic != nil {
+		info.Put("upload", statistic.Upload.Load())
+		info.Put("download", statistic.Download.Load())
+	} else {
+		info.Put("upload", 0)
+		info.Put("download", 0)
+	}
 	return &info
 }
 
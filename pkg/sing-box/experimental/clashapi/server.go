@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/dnsquery"
 	"github.com/sagernet/sing-box/common/urltest"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/experimental"
@@ -19,9 +20,7 @@ import (
 	"github.com/sagernet/sing-box/option"
 	"github.com/sagernet/sing/common"
 	E "github.com/sagernet/sing/common/exceptions"
-	F "github.com/sagernet/sing/common/format"
 	"github.com/sagernet/sing/common/json"
-	N "github.com/sagernet/sing/common/network"
 	"github.com/sagernet/sing/service"
 	"github.com/sagernet/sing/service/filemanager"
 	"github.com/sagernet/ws"
@@ -32,6 +31,8 @@ import (
 	"github.com/go-chi/render"
 )
 
+const statisticsSaveInterval = time.Minute
+
 func init() {
 	experimental.RegisterClashServerConstructor(NewServer)
 }
@@ -45,6 +46,11 @@ type Server struct {
 	httpServer     *http.Server
 	trafficManager *trafficontrol.Manager
 	urlTestHistory *urltest.HistoryStorage
+	startedAt      time.Time
+	dnsQueries     *dnsquery.Recorder
+	cacheFile      adapter.CacheFile
+	saveTicker     *time.Ticker
+	done           chan struct{}
 	mode           string
 	modeList       []string
 	modeUpdateHook chan<- struct{}
@@ -56,7 +62,10 @@ type Server struct {
 }
 
 func NewServer(ctx context.Context, router adapter.Router, logFactory log.ObservableFactory, options option.ClashAPIOptions) (adapter.ClashServer, error) {
-	trafficManager := trafficontrol.NewManager()
+	trafficManager := service.PtrFromContext[trafficontrol.Manager](ctx)
+	if trafficManager == nil {
+		return nil, E.New("missing traffic manager")
+	}
 	chiRouter := chi.NewRouter()
 	server := &Server{
 		ctx:    ctx,
@@ -67,6 +76,9 @@ func NewServer(ctx context.Context, router adapter.Router, logFactory log.Observ
 			Handler: chiRouter,
 		},
 		trafficManager:           trafficManager,
+		startedAt:                time.Now(),
+		dnsQueries:               dnsquery.NewRecorder(dnsquery.DefaultSize),
+		done:                     make(chan struct{}),
 		modeList:                 options.ModeList,
 		externalController:       options.ExternalController != "",
 		externalUIDownloadURL:    options.ExternalUIDownloadURL,
@@ -84,6 +96,7 @@ func NewServer(ctx context.Context, router adapter.Router, logFactory log.Observ
 		server.modeList = append([]string{defaultMode}, server.modeList...)
 	}
 	server.mode = defaultMode
+	router.SetDNSQueryRecorder(server.dnsQueries)
 	//goland:noinspection GoDeprecation
 	//nolint:staticcheck
 	if options.StoreMode || options.StoreSelected || options.StoreFakeIP || options.CacheFile != "" || options.CacheID != "" {
@@ -102,16 +115,21 @@ func NewServer(ctx context.Context, router adapter.Router, logFactory log.Observ
 		r.Get("/logs", getLogs(logFactory))
 		r.Get("/traffic", traffic(trafficManager))
 		r.Get("/version", version)
+		r.Get("/metrics", metrics(server, router, trafficManager))
 		r.Mount("/configs", configRouter(server, logFactory))
 		r.Mount("/proxies", proxyRouter(server, router))
 		r.Mount("/rules", ruleRouter(router))
 		r.Mount("/connections", connectionRouter(router, trafficManager))
+		r.Mount("/statistics", statisticsRouter(trafficManager))
 		r.Mount("/providers/proxies", proxyProviderRouter(server, router))
 		r.Mount("/providers/rules", ruleProviderRouter())
 		r.Mount("/script", scriptRouter())
 		r.Mount("/profile", profileRouter())
-		r.Mount("/cache", cacheRouter(ctx))
-		r.Mount("/dns", dnsRouter(router))
+		r.Mount("/cache", cacheRouter(ctx, router))
+		r.Mount("/dns", dnsRouter(router, server.dnsQueries))
+		r.Mount("/users", userRouter(ctx))
+		r.Mount("/capture", captureRouter(ctx))
+		r.Mount("/wireguard", wireGuardRouter(router))
 
 		server.setupMetaAPI(r)
 	})
@@ -137,11 +155,22 @@ func (s *Server) PreStart() error {
 		}) {
 			s.mode = mode
 		}
+		if cacheFile.StoreStatistics() {
+			s.cacheFile = cacheFile
+			savedStatistics := cacheFile.LoadStatistics()
+			if savedStatistics != nil {
+				s.trafficManager.LoadStatistics(savedStatistics)
+			}
+		}
 	}
 	return nil
 }
 
 func (s *Server) Start() error {
+	if s.cacheFile != nil {
+		s.saveTicker = time.NewTicker(statisticsSaveInterval)
+		go s.loopSaveStatistics()
+	}
 	if s.externalController {
 		s.checkAndDownloadExternalUI()
 		listener, err := net.Listen("tcp", s.httpServer.Addr)
@@ -159,11 +188,34 @@ func (s *Server) Start() error {
 	return nil
 }
 
+func (s *Server) loopSaveStatistics() {
+	for {
+		select {
+		case <-s.saveTicker.C:
+			s.saveStatistics()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *Server) saveStatistics() {
+	err := s.cacheFile.SaveStatistics(s.trafficManager.SaveStatistics())
+	if err != nil {
+		s.logger.Warn(E.Cause(err, "save statistics"))
+	}
+}
+
 func (s *Server) Close() error {
+	if s.saveTicker != nil {
+		s.saveTicker.Stop()
+		close(s.done)
+		s.saveStatistics()
+	}
 	return common.Close(
 		common.PtrOrNil(s.httpServer),
-		s.trafficManager,
 		s.urlTestHistory,
+		s.dnsQueries,
 	)
 }
 
@@ -217,59 +269,6 @@ func (s *Server) TrafficManager() *trafficontrol.Manager {
 	return s.trafficManager
 }
 
-func (s *Server) RoutedConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, matchedRule adapter.Rule) (net.Conn, adapter.Tracker) {
-	tracker := trafficontrol.NewTCPTracker(conn, s.trafficManager, castMetadata(metadata), s.router, matchedRule)
-	return tracker, tracker
-}
-
-func (s *Server) RoutedPacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext, matchedRule adapter.Rule) (N.PacketConn, adapter.Tracker) {
-	tracker := trafficontrol.NewUDPTracker(conn, s.trafficManager, castMetadata(metadata), s.router, matchedRule)
-	return tracker, tracker
-}
-
-func castMetadata(metadata adapter.InboundContext) trafficontrol.Metadata {
-	var inbound string
-	if metadata.Inbound != "" {
-		inbound = metadata.InboundType + "/" + metadata.Inbound
-	} else {
-		inbound = metadata.InboundType
-	}
-	var domain string
-	if metadata.Domain != "" {
-		domain = metadata.Domain
-	} else {
-		domain = metadata.Destination.Fqdn
-	}
-	var processPath string
-	if metadata.ProcessInfo != nil {
-		if metadata.ProcessInfo.ProcessPath != "" {
-			processPath = metadata.ProcessInfo.ProcessPath
-		} else if metadata.ProcessInfo.PackageName != "" {
-			processPath = metadata.ProcessInfo.PackageName
-		}
-		if processPath == "" {
-			if metadata.ProcessInfo.UserId != -1 {
-				processPath = F.ToString(metadata.ProcessInfo.UserId)
-			}
-		} else if metadata.ProcessInfo.User != "" {
-			processPath = F.ToString(processPath, " (", metadata.ProcessInfo.User, ")")
-		} else if metadata.ProcessInfo.UserId != -1 {
-			processPath = F.ToString(processPath, " (", metadata.ProcessInfo.UserId, ")")
-		}
-	}
-	return trafficontrol.Metadata{
-		NetWork:     metadata.Network,
-		Type:        inbound,
-		SrcIP:       metadata.Source.Addr,
-		DstIP:       metadata.Destination.Addr,
-		SrcPort:     F.ToString(metadata.Source.Port),
-		DstPort:     F.ToString(metadata.Destination.Port),
-		Host:        domain,
-		DNSMode:     "normal",
-		ProcessPath: processPath,
-	}
-}
-
 func authentication(serverSecret string) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,30 @@
+package clashapi
+
+import (
+	"net/http"
+
+	"github.com/sagernet/sing-box/experimental/clashapi/trafficontrol"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+func statisticsRouter(trafficManager *trafficontrol.Manager) http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", getStatistics(trafficManager))
+	r.Delete("/", resetStatistics(trafficManager))
+	return r
+}
+
+func getStatistics(trafficManager *trafficontrol.Manager) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, r, trafficManager.Statistics())
+	}
+}
+
+func resetStatistics(trafficManager *trafficontrol.Manager) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		trafficManager.ResetStatistic()
+		render.NoContent(w, r)
+	}
+}
@@ -1,14 +1,34 @@
 package trafficontrol
 
 import (
+	"context"
+	"net"
 	"runtime"
+	"sync"
 	"time"
 
+	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/experimental/clashapi/compatible"
 	"github.com/sagernet/sing/common/atomic"
+	N "github.com/sagernet/sing/common/network"
 )
 
+const DefaultClosedConnections = 100
+
+var (
+	_ adapter.Service           = (*Manager)(nil)
+	_ adapter.ConnectionTracker = (*Manager)(nil)
+)
+
+// CloseListener is notified of every tracked connection once routing of it has finished.
+type CloseListener interface {
+	ConnectionClosed(connection *ClosedConnection, metadata adapter.InboundContext, matchedRule adapter.Rule, err error)
+}
+
+// Manager tracks routed connections for the Clash API and the access log.
 type Manager struct {
+	router adapter.Router
+
 	uploadTemp    atomic.Int64
 	downloadTemp  atomic.Int64
 	uploadBlip    atomic.Int64
@@ -21,16 +41,55 @@ type Manager struct {
 	done        chan struct{}
 	// process     *process.Process
 	memory uint64
+
+	closedAccess sync.Mutex
+	closed       []*ClosedConnection
+	closedIndex  int
+	closedLimit  int
+
+	statisticAccess sync.Mutex
+	outbounds       map[string]*Statistic
+	inbounds        map[string]*Statistic
+	processes       map[string]*Statistic
+	users           map[UserKey]*Statistic
+	ruleHits        map[adapter.Rule]*atomic.Int64
+
+	listeners []CloseListener
 }
 
-func NewManager() *Manager {
-	manager := &Manager{
+func NewManager(router adapter.Router, closedLimit int) *Manager {
+	return &Manager{
+		router: router,
 		ticker: time.NewTicker(time.Second),
 		done:   make(chan struct{}),
 		// process: &process.Process{Pid: int32(os.Getpid())},
+		closedLimit: closedLimit,
+		outbounds:   make(map[string]*Statistic),
+		inbounds:    make(map[string]*Statistic),
+		processes:   make(map[string]*Statistic),
+		users:       make(map[UserKey]*Statistic),
+		ruleHits:    make(map[adapter.Rule]*atomic.Int64),
 	}
-	go manager.handle()
-	return manager
+}
+
+func (m *Manager) Start() error {
+	go m.handle()
+	return nil
+}
+
+// AddCloseListener registers a listener, it must be called before any connection is routed.
+func (m *Manager) AddCloseListener(listener CloseListener) {
+	m.listeners = append(m.listeners, listener)
+}
+
+func (m *Manager) RoutedConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, matchedRule adapter.Rule) (net.Conn, adapter.Tracker) {
+	tracker := NewTCPTracker(conn, m, metadata, matchedRule)
+	return tracker, tracker
+}
+
+func (m *Manager) RoutedPacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext, matchedRule adapter.Rule) (N.PacketConn, adapter.Tracker) {
+	tracker := NewUDPTracker(conn, m, metadata, matchedRule)
+	return tracker, tracker
 }
 
 func (m *Manager) Join(c tracker) {
@@ -41,6 +100,45 @@ func (m *Manager) Leave(c tracker) {
 	m.connections.Delete(c.ID())
 }
 
+// pushClosed adds a connection to the ring of recently closed connections,
+// replacing the oldest one if the ring is full.
+func (m *Manager) pushClosed(connection *ClosedConnection) {
+	if m.closedLimit <= 0 {
+		return
+	}
+	m.closedAccess.Lock()
+	defer m.closedAccess.Unlock()
+	if len(m.closed) < m.closedLimit {
+		m.closed = append(m.closed, connection)
+		return
+	}
+	m.closed[m.closedIndex] = connection
+	m.closedIndex = (m.closedIndex + 1) % m.closedLimit
+}
+
+func (m *Manager) notifyClosed(connection *ClosedConnection, metadata adapter.InboundContext, matchedRule adapter.Rule, err error) {
+	for _, listener := range m.listeners {
+		listener.ConnectionClosed(connection, metadata, matchedRule, err)
+	}
+}
+
+// ClosedConnections returns the recently closed connections, oldest first.
+func (m *Manager) ClosedConnections() []*ClosedConnection {
+	m.closedAccess.Lock()
+	defer m.closedAccess.Unlock()
+	connections := make([]*ClosedConnection, 0, len(m.closed))
+	connections = append(connections, m.closed[m.closedIndex:]...)
+	connections = append(connections, m.closed[:m.closedIndex]...)
+	return connections
+}
+
+func (m *Manager) ClearClosedConnections() {
+	m.closedAccess.Lock()
+	defer m.closedAccess.Unlock()
+	m.closed = nil
+	m.closedIndex = 0
+}
+
 func (m *Manager) PushUploaded(size int64) {
 	m.uploadTemp.Add(size)
 	m.uploadTotal.Add(size)
@@ -89,6 +187,19 @@ func (m *Manager) ResetStatistic() {
 	m.downloadTemp.Store(0)
 	m.downloadBlip.Store(0)
 	m.downloadTotal.Store(0)
+	m.statisticAccess.Lock()
+	defer m.statisticAccess.Unlock()
+	for _, statistics := range []map[string]*Statistic{m.outbounds, m.inbounds, m.processes} {
+		for _, statistic := range statistics {
+			statistic.reset()
+		}
+	}
+	for _, statistic := range m.users {
+		statistic.reset()
+	}
+	for _, hits := range m.ruleHits {
+		hits.Store(0)
+	}
 }
 
 func (m *Manager) handle() {
@@ -0,0 +1,49 @@
+package trafficontrol
+
+import (
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClosedConnections(t *testing.T) {
+	t.Parallel()
+	manager := NewManager(nil, 2)
+	defer manager.Close()
+	for _, id := range []string{"a", "b", "c"} {
+		manager.pushClosed(&ClosedConnection{ID: id})
+	}
+	closed := manager.ClosedConnections()
+	require.Len(t, closed, 2)
+	require.Equal(t, "b", closed[0].ID)
+	require.Equal(t, "c", closed[1].ID)
+	manager.ClearClosedConnections()
+	require.Empty(t, manager.ClosedConnections())
+}
+
+func TestSavedStatistics(t *testing.T) {
+	t.Parallel()
+	manager := NewManager(nil, 0)
+	defer manager.Close()
+	manager.PushUploaded(1)
+	manager.loadStatistic(manager.outbounds, "direct").Download.Add(2)
+	manager.loadStatistic(manager.outbounds, "direct").Connections.Add(4)
+	manager.loadStatistic(manager.processes, "/usr/bin/curl").Upload.Add(3)
+	manager.loadUserStatistic(UserKey{"vmess-in", "alice"}).Upload.Add(5)
+	content, err := manager.SaveStatistics().MarshalBinary()
+	require.NoError(t, err)
+	var saved adapter.SavedStatistics
+	require.NoError(t, saved.UnmarshalBinary(content))
+	restored := NewManager(nil, 0)
+	defer restored.Close()
+	restored.LoadStatistics(&saved)
+	statistics := restored.Statistics()
+	require.Equal(t, int64(1), statistics.UploadTotal)
+	require.Equal(t, int64(2), statistics.Outbounds["direct"].Download.Load())
+	require.Equal(t, int64(4), statistics.Outbounds["direct"].Connections.Load())
+	require.Equal(t, int64(3), statistics.Processes["/usr/bin/curl"].Upload.Load())
+	require.Empty(t, statistics.Inbounds)
+	require.Equal(t, int64(5), restored.UserStatistics()[UserKey{"vmess-in", "alice"}].Upload.Load())
+}
@@ -0,0 +1,199 @@
+package trafficontrol
+
+import (
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing/common/atomic"
+	"github.com/sagernet/sing/common/json"
+)
+
+type Statistic struct {
+	Upload      atomic.Int64
+	Download    atomic.Int64
+	Connections atomic.Int64
+}
+
+func (s *Statistic) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"upload":      s.Upload.Load(),
+		"download":    s.Download.Load(),
+		"connections": s.Connections.Load(),
+	})
+}
+
+func (s *Statistic) reset() {
+	s.Upload.Store(0)
+	s.Download.Store(0)
+	s.Connections.Store(0)
+}
+
+// UserKey identifies an authenticated user of an inbound.
+type UserKey struct {
+	Inbound string
+	User    string
+}
+
+type Statistics struct {
+	UploadTotal   int64                 `json:"uploadTotal"`
+	DownloadTotal int64                 `json:"downloadTotal"`
+	Outbounds     map[string]*Statistic `json:"outbounds"`
+	Inbounds      map[string]*Statistic `json:"inbounds"`
+	Processes     map[string]*Statistic `json:"processes"`
+}
+
+func (m *Manager) loadStatistic(statistics map[string]*Statistic, name string) *Statistic {
+	m.statisticAccess.Lock()
+	defer m.statisticAccess.Unlock()
+	statistic, loaded := statistics[name]
+	if !loaded {
+		statistic = new(Statistic)
+		statistics[name] = statistic
+	}
+	return statistic
+}
+
+func (m *Manager) loadUserStatistic(key UserKey) *Statistic {
+	m.statisticAccess.Lock()
+	defer m.statisticAccess.Unlock()
+	statistic, loaded := m.users[key]
+	if !loaded {
+		statistic = new(Statistic)
+		m.users[key] = statistic
+	}
+	return statistic
+}
+
+// hitRule counts a routed connection for the matched rule, nil for the final outbound.
+func (m *Manager) hitRule(rule adapter.Rule) {
+	m.statisticAccess.Lock()
+	defer m.statisticAccess.Unlock()
+	hits, loaded := m.ruleHits[rule]
+	if !loaded {
+		hits = new(atomic.Int64)
+		m.ruleHits[rule] = hits
+	}
+	hits.Add(1)
+}
+
+// UserStatistics returns the traffic of authenticated users.
+func (m *Manager) UserStatistics() map[UserKey]*Statistic {
+	m.statisticAccess.Lock()
+	defer m.statisticAccess.Unlock()
+	statistics := make(map[UserKey]*Statistic, len(m.users))
+	for key, statistic := range m.users {
+		statistics[key] = statistic
+	}
+	return statistics
+}
+
+// RuleHits returns the routed connections by matched rule, nil being the final outbound.
+func (m *Manager) RuleHits() map[adapter.Rule]int64 {
+	m.statisticAccess.Lock()
+	defer m.statisticAccess.Unlock()
+	ruleHits := make(map[adapter.Rule]int64, len(m.ruleHits))
+	for rule, hits := range m.ruleHits {
+		ruleHits[rule] = hits.Load()
+	}
+	return ruleHits
+}
+
+// OutboundStatistic returns the cumulative traffic of an outbound,
+// or nil if no connection has used it.
+func (m *Manager) OutboundStatistic(tag string) *Statistic {
+	m.statisticAccess.Lock()
+	defer m.statisticAccess.Unlock()
+	return m.outbounds[tag]
+}
+
+func (m *Manager) Statistics() *Statistics {
+	m.statisticAccess.Lock()
+	defer m.statisticAccess.Unlock()
+	return &Statistics{
+		UploadTotal:   m.uploadTotal.Load(),
+		DownloadTotal: m.downloadTotal.Load(),
+		Outbounds:     copyStatistics(m.outbounds),
+		Inbounds:      copyStatistics(m.inbounds),
+		Processes:     copyStatistics(m.processes),
+	}
+}
+
+func copyStatistics(statistics map[string]*Statistic) map[string]*Statistic {
+	statisticsCopy := make(map[string]*Statistic, len(statistics))
+	for name, statistic := range statistics {
+		statisticsCopy[name] = statistic
+	}
+	return statisticsCopy
+}
+
+// SaveStatistics returns the cumulative totals for the cache file.
+func (m *Manager) SaveStatistics() *adapter.SavedStatistics {
+	m.statisticAccess.Lock()
+	defer m.statisticAccess.Unlock()
+	savedStatistics := &adapter.SavedStatistics{
+		Upload:    m.uploadTotal.Load(),
+		Download:  m.downloadTotal.Load(),
+		Outbounds: saveStatistics(m.outbounds),
+		Inbounds:  saveStatistics(m.inbounds),
+		Processes: saveStatistics(m.processes),
+	}
+	for key, statistic := range m.users {
+		savedStatistics.Users = append(savedStatistics.Users, adapter.SavedUserTraffic{
+			Inbound:      key.Inbound,
+			User:         key.User,
+			SavedTraffic: statistic.save(),
+		})
+	}
+	return savedStatistics
+}
+
+// LoadStatistics adds totals restored from the cache file.
+func (m *Manager) LoadStatistics(saved *adapter.SavedStatistics) {
+	m.uploadTotal.Add(saved.Upload)
+	m.downloadTotal.Add(saved.Download)
+	m.statisticAccess.Lock()
+	defer m.statisticAccess.Unlock()
+	loadStatistics(m.outbounds, saved.Outbounds)
+	loadStatistics(m.inbounds, saved.Inbounds)
+	loadStatistics(m.processes, saved.Processes)
+	for _, savedUser := range saved.Users {
+		key := UserKey{savedUser.Inbound, savedUser.User}
+		statistic, loaded := m.users[key]
+		if !loaded {
+			statistic = new(Statistic)
+			m.users[key] = statistic
+		}
+		statistic.load(savedUser.SavedTraffic)
+	}
+}
+
+func (s *Statistic) save() adapter.SavedTraffic {
+	return adapter.SavedTraffic{
+		Upload:      s.Upload.Load(),
+		Download:    s.Download.Load(),
+		Connections: s.Connections.Load(),
+	}
+}
+
+func (s *Statistic) load(savedTraffic adapter.SavedTraffic) {
+	s.Upload.Add(savedTraffic.Upload)
+	s.Download.Add(savedTraffic.Download)
+	s.Connections.Add(savedTraffic.Connections)
+}
+
+func saveStatistics(statistics map[string]*Statistic) map[string]adapter.SavedTraffic {
+	savedStatistics := make(map[string]adapter.SavedTraffic, len(statistics))
+	for name, statistic := range statistics {
+		savedStatistics[name] = statistic.save()
+	}
+	return savedStatistics
+}
+
+func loadStatistics(statistics map[string]*Statistic, savedStatistics map[string]adapter.SavedTraffic) {
+	for name, savedTraffic := range savedStatistics {
+		statistic, loaded := statistics[name]
+		if !loaded {
+			statistic = new(Statistic)
+			statistics[name] = statistic
+		}
+		statistic.load(savedTraffic)
+	}
+}
@@ -3,12 +3,14 @@ package trafficontrol
 import (
 	"net"
 	"net/netip"
+	"sync"
 	"time"
 
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing/common"
 	"github.com/sagernet/sing/common/atomic"
 	"github.com/sagernet/sing/common/bufio"
+	F "github.com/sagernet/sing/common/format"
 	"github.com/sagernet/sing/common/json"
 	N "github.com/sagernet/sing/common/network"
 
@@ -25,12 +27,14 @@ type Metadata struct {
 	Host        string     `json:"host"`
 	DNSMode     string     `json:"dnsMode"`
 	ProcessPath string     `json:"processPath"`
+	InboundName string     `json:"inboundName"`
 }
 
 type tracker interface {
 	ID() string
 	Close() error
-	Leave()
+	CloseWithReason(reason string) error
+	Leave(err error)
 }
 
 type trackerInfo struct {
@@ -42,9 +46,16 @@ type trackerInfo struct {
 	Chain         []string      `json:"chains"`
 	Rule          string        `json:"rule"`
 	RulePayload   string        `json:"rulePayload"`
+
+	access      sync.Mutex
+	closeReason string
+	left        bool
+
+	inboundContext adapter.InboundContext
+	matchedRule    adapter.Rule
 }
 
-func (t trackerInfo) MarshalJSON() ([]byte, error) {
+func (t *trackerInfo) MarshalJSON() ([]byte, error) {
 	return json.Marshal(map[string]any{
 		"id":          t.UUID.String(),
 		"metadata":    t.Metadata,
@@ -57,6 +68,65 @@ func (t trackerInfo) MarshalJSON() ([]byte, error) {
 	})
 }
 
+func (t *trackerInfo) setCloseReason(reason string) {
+	t.access.Lock()
+	defer t.access.Unlock()
+	if t.closeReason == "" {
+		t.closeReason = reason
+	}
+}
+
+// leave moves the connection to the closed connections of the manager.
+// The first close reason set wins, otherwise err or "closed" is used.
+func (t *trackerInfo) leave(manager *Manager, c tracker, err error) {
+	t.access.Lock()
+	if t.left {
+		t.access.Unlock()
+		return
+	}
+	t.left = true
+	if t.closeReason == "" {
+		if err != nil {
+			t.closeReason = err.Error()
+		} else {
+			t.closeReason = "closed"
+		}
+	}
+	reason := t.closeReason
+	t.access.Unlock()
+	manager.Leave(c)
+	end := time.Now()
+	connection := &ClosedConnection{
+		ID:          t.UUID.String(),
+		Metadata:    t.Metadata,
+		Upload:      t.UploadTotal.Load(),
+		Download:    t.DownloadTotal.Load(),
+		Start:       t.Start,
+		End:         end,
+		Duration:    end.Sub(t.Start).Milliseconds(),
+		Chain:       t.Chain,
+		Rule:        t.Rule,
+		RulePayload: t.RulePayload,
+		Reason:      reason,
+	}
+	manager.pushClosed(connection)
+	manager.notifyClosed(connection, t.inboundContext, t.matchedRule, err)
+}
+
+type ClosedConnection struct {
+	ID          string    `json:"id"`
+	Metadata    Metadata  `json:"metadata"`
+	Upload      int64     `json:"upload"`
+	Download    int64     `json:"download"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	Duration    int64     `json:"duration"`
+	Chain       []string  `json:"chains"`
+	Rule        string    `json:"rule"`
+	RulePayload string    `json:"rulePayload"`
+	Reason      string    `json:"reason"`
+}
+
 type tcpTracker struct {
 	N.ExtendedConn `json:"-"`
 	*trackerInfo
@@ -72,8 +142,13 @@ func (tt *tcpTracker) Close() error {
 	return tt.ExtendedConn.Close()
 }
 
-func (tt *tcpTracker) Leave() {
-	tt.manager.Leave(tt)
+func (tt *tcpTracker) CloseWithReason(reason string) error {
+	tt.setCloseReason(reason)
+	return tt.Close()
+}
+
+func (tt *tcpTracker) Leave(err error) {
+	tt.leave(tt.manager, tt, err)
 }
 
 func (tt *tcpTracker) Upstream() any {
@@ -88,60 +163,13 @@ func (tt *tcpTracker) WriterReplaceable() bool {
 	return true
 }
 
-func NewTCPTracker(conn net.Conn, manager *Manager, metadata Metadata, router adapter.Router, rule adapter.Rule) *tcpTracker {
-	uuid, _ := uuid.NewV4()
-
-	var chain []string
-	var next string
-	if rule == nil {
-		if defaultOutbound, err := router.DefaultOutbound(N.NetworkTCP); err == nil {
-			next = defaultOutbound.Tag()
-		}
-	} else {
-		next = rule.Outbound()
-	}
-	for {
-		chain = append(chain, next)
-		detour, loaded := router.Outbound(next)
-		if !loaded {
-			break
-		}
-		group, isGroup := detour.(adapter.OutboundGroup)
-		if !isGroup {
-			break
-		}
-		next = group.Now()
-	}
-
-	upload := new(atomic.Int64)
-	download := new(atomic.Int64)
-
+func NewTCPTracker(conn net.Conn, manager *Manager, metadata adapter.InboundContext, rule adapter.Rule) *tcpTracker {
+	info, uploadFunc, downloadFunc := newTrackerInfo(manager, metadata, rule, N.NetworkTCP)
 	t := &tcpTracker{
-		ExtendedConn: bufio.NewCounterConn(conn, []N.CountFunc{func(n int64) {
-			upload.Add(n)
-			manager.PushUploaded(n)
-		}}, []N.CountFunc{func(n int64) {
-			download.Add(n)
-			manager.PushDownloaded(n)
-		}}),
-		manager: manager,
-		trackerInfo: &trackerInfo{
-			UUID:          uuid,
-			Start:         time.Now(),
-			Metadata:      metadata,
-			Chain:         common.Reverse(chain),
-			Rule:          "",
-			UploadTotal:   upload,
-			DownloadTotal: download,
-		},
-	}
-
-	if rule != nil {
-		t.trackerInfo.Rule = rule.String() + " => " + rule.Outbound()
-	} else {
-		t.trackerInfo.Rule = "final"
+		ExtendedConn: bufio.NewCounterConn(conn, []N.CountFunc{uploadFunc}, []N.CountFunc{downloadFunc}),
+		manager:      manager,
+		trackerInfo:  info,
 	}
-
 	manager.Join(t)
 	return t
 }
@@ -161,8 +189,13 @@ func (ut *udpTracker) Close() error {
 	return ut.PacketConn.Close()
 }
 
-func (ut *udpTracker) Leave() {
-	ut.manager.Leave(ut)
+func (ut *udpTracker) CloseWithReason(reason string) error {
+	ut.setCloseReason(reason)
+	return ut.Close()
+}
+
+func (ut *udpTracker) Leave(err error) {
+	ut.leave(ut.manager, ut, err)
 }
 
 func (ut *udpTracker) Upstream() any {
@@ -177,60 +210,123 @@ func (ut *udpTracker) WriterReplaceable() bool {
 	return true
 }
 
-func NewUDPTracker(conn N.PacketConn, manager *Manager, metadata Metadata, router adapter.Router, rule adapter.Rule) *udpTracker {
+func NewUDPTracker(conn N.PacketConn, manager *Manager, metadata adapter.InboundContext, rule adapter.Rule) *udpTracker {
+	info, uploadFunc, downloadFunc := newTrackerInfo(manager, metadata, rule, N.NetworkUDP)
+	ut := &udpTracker{
+		PacketConn:  bufio.NewCounterPacketConn(conn, []N.CountFunc{uploadFunc}, []N.CountFunc{downloadFunc}),
+		manager:     manager,
+		trackerInfo: info,
+	}
+	manager.Join(ut)
+	return ut
+}
+
+func newTrackerInfo(manager *Manager, inboundContext adapter.InboundContext, rule adapter.Rule, network string) (*trackerInfo, N.CountFunc, N.CountFunc) {
 	uuid, _ := uuid.NewV4()
+	metadata := castMetadata(inboundContext)
 
-	var chain []string
 	var next string
 	if rule == nil {
-		if defaultOutbound, err := router.DefaultOutbound(N.NetworkUDP); err == nil {
+		if defaultOutbound, err := manager.router.DefaultOutbound(network); err == nil {
 			next = defaultOutbound.Tag()
 		}
 	} else {
 		next = rule.Outbound()
 	}
-	for {
-		chain = append(chain, next)
-		detour, loaded := router.Outbound(next)
-		if !loaded {
-			break
-		}
-		group, isGroup := detour.(adapter.OutboundGroup)
-		if !isGroup {
-			break
-		}
-		next = group.Now()
+	chain := adapter.AppendOutboundChain(nil, manager.router, next)
+
+	var statistics []*Statistic
+	for _, tag := range common.Uniq(chain) {
+		statistics = append(statistics, manager.loadStatistic(manager.outbounds, tag))
+	}
+	if metadata.InboundName != "" {
+		statistics = append(statistics, manager.loadStatistic(manager.inbounds, metadata.InboundName))
+	}
+	if metadata.ProcessPath != "" {
+		statistics = append(statistics, manager.loadStatistic(manager.processes, metadata.ProcessPath))
+	}
+	if inboundContext.User != "" {
+		statistics = append(statistics, manager.loadUserStatistic(UserKey{inboundContext.Inbound, inboundContext.User}))
+	}
+	for _, statistic := range statistics {
+		statistic.Connections.Add(1)
 	}
+	manager.hitRule(rule)
 
 	upload := new(atomic.Int64)
 	download := new(atomic.Int64)
-
-	ut := &udpTracker{
-		PacketConn: bufio.NewCounterPacketConn(conn, []N.CountFunc{func(n int64) {
+	info := &trackerInfo{
+		UUID:          uuid,
+		Start:         time.Now(),
+		Metadata:      metadata,
+		Chain:         common.Reverse(chain),
+		Rule:          "",
+		UploadTotal:   upload,
+		DownloadTotal: download,
+
+		inboundContext: inboundContext,
+		matchedRule:    rule,
+	}
+	if rule != nil {
+		info.Rule = rule.String() + " => " + rule.Outbound()
+	} else {
+		info.Rule = "final"
+	}
+	return info, func(n int64) {
 			upload.Add(n)
 			manager.PushUploaded(n)
-		}}, []N.CountFunc{func(n int64) {
+			for _, statistic := range statistics {
+				statistic.Upload.Add(n)
+			}
+		}, func(n int64) {
 			download.Add(n)
 			manager.PushDownloaded(n)
-		}}),
-		manager: manager,
-		trackerInfo: &trackerInfo{
-			UUID:          uuid,
-			Start:         time.Now(),
-			Metadata:      metadata,
-			Chain:         common.Reverse(chain),
-			Rule:          "",
-			UploadTotal:   upload,
-			DownloadTotal: download,
-		},
-	}
+			for _, statistic := range statistics {
+				statistic.Download.Add(n)
+			}
+		}
+}
 
-	if rule != nil {
-		ut.trackerInfo.Rule = rule.String() + " => " + rule.Outbound()
+func castMetadata(metadata adapter.InboundContext) Metadata {
+	var inbound string
+	if metadata.Inbound != "" {
+		inbound = metadata.InboundType + "/" + metadata.Inbound
 	} else {
-		ut.trackerInfo.Rule = "final"
+		inbound = metadata.InboundType
+	}
+	var domain string
+	if metadata.Domain != "" {
+		domain = metadata.Domain
+	} else {
+		domain = metadata.Destination.Fqdn
+	}
+	var processPath string
+	if metadata.ProcessInfo != nil {
+		if metadata.ProcessInfo.ProcessPath != "" {
+			processPath = metadata.ProcessInfo.ProcessPath
+		} else if metadata.ProcessInfo.PackageName != "" {
+			processPath = metadata.ProcessInfo.PackageName
+		}
+		if processPath == "" {
+			if metadata.ProcessInfo.UserId != -1 {
+				processPath = F.ToString(metadata.ProcessInfo.UserId)
+			}
+		} else if metadata.ProcessInfo.User != "" {
+			processPath = F.ToString(processPath, " (", metadata.ProcessInfo.User, ")")
+		} else if metadata.ProcessInfo.UserId != -1 {
+			processPath = F.ToString(processPath, " (", metadata.ProcessInfo.UserId, ")")
+		}
+	}
+	return Metadata{
+		NetWork:     metadata.Network,
+		Type:        inbound,
+		SrcIP:       metadata.Source.Addr,
+		DstIP:       metadata.Destination.Addr,
+		SrcPort:     F.ToString(metadata.Source.Port),
+		DstPort:     F.ToString(metadata.Destination.Port),
+		Host:        domain,
+		DNSMode:     "normal",
+		ProcessPath: processPath,
+		InboundName: metadata.Inbound,
 	}
-
-	manager.Join(ut)
-	return ut
 }
@@ -0,0 +1,162 @@
+package clashapi
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+func userRouter(ctx context.Context) http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", getUserInbounds(ctx))
+	r.Route("/{inbound}", func(r chi.Router) {
+		r.Use(findUserInbound(ctx))
+		r.Get("/", getUsers)
+		r.Post("/", addUser)
+		r.Put("/{name}", updateUser)
+		r.Delete("/{name}", removeUser)
+	})
+	return r
+}
+
+type UserInbound struct {
+	Tag   string `json:"tag"`
+	Type  string `json:"type"`
+	Users int    `json:"users"`
+}
+
+type InboundUser struct {
+	Name     string `json:"name"`
+	Password string `json:"password,omitempty"`
+	UUID     string `json:"uuid,omitempty"`
+	AlterID  int    `json:"alter_id,omitempty"`
+	Flow     string `json:"flow,omitempty"`
+}
+
+func getUserInbounds(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		inbounds := []UserInbound{}
+		if userManager := service.FromContext[adapter.UserManager](ctx); userManager != nil {
+			for _, inbound := range userManager.Inbounds() {
+				inbounds = append(inbounds, UserInbound{
+					Tag:   inbound.Tag(),
+					Type:  inbound.Type(),
+					Users: len(inbound.Users()),
+				})
+			}
+		}
+		render.JSON(w, r, render.M{
+			"inbounds": inbounds,
+		})
+	}
+}
+
+func findUserInbound(ctx context.Context) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tag := getEscapeParam(r, "inbound")
+			userManager := service.FromContext[adapter.UserManager](ctx)
+			if userManager == nil || !common.Any(userManager.Inbounds(), func(it adapter.ManagedUserInbound) bool {
+				return it.Tag() == tag
+			}) {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, ErrNotFound)
+				return
+			}
+			ctx := context.WithValue(r.Context(), CtxKeyUserManager, userManager)
+			ctx = context.WithValue(ctx, CtxKeyInbound, tag)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func getUsers(w http.ResponseWriter, r *http.Request) {
+	userManager := r.Context().Value(CtxKeyUserManager).(adapter.UserManager)
+	inbound := r.Context().Value(CtxKeyInbound).(string)
+	users, err := userManager.Users(inbound)
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, newError(err.Error()))
+		return
+	}
+	render.JSON(w, r, render.M{
+		"users": common.Map(users, func(it adapter.InboundUser) InboundUser {
+			return InboundUser(it)
+		}),
+	})
+}
+
+func addUser(w http.ResponseWriter, r *http.Request) {
+	userManager := r.Context().Value(CtxKeyUserManager).(adapter.UserManager)
+	inbound := r.Context().Value(CtxKeyInbound).(string)
+	var user InboundUser
+	err := render.DecodeJSON(r.Body, &user)
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, ErrBadRequest)
+		return
+	}
+	err = userManager.AddUser(inbound, adapter.InboundUser(user))
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, newError(err.Error()))
+		return
+	}
+	render.NoContent(w, r)
+}
+
+func updateUser(w http.ResponseWriter, r *http.Request) {
+	userManager := r.Context().Value(CtxKeyUserManager).(adapter.UserManager)
+	inbound := r.Context().Value(CtxKeyInbound).(string)
+	var user InboundUser
+	err := render.DecodeJSON(r.Body, &user)
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, ErrBadRequest)
+		return
+	}
+	user.Name = getEscapeParam(r, "name")
+	if !userExists(userManager, inbound, user.Name) {
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, ErrNotFound)
+		return
+	}
+	err = userManager.UpdateUser(inbound, adapter.InboundUser(user))
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, newError(err.Error()))
+		return
+	}
+	render.NoContent(w, r)
+}
+
+func removeUser(w http.ResponseWriter, r *http.Request) {
+	userManager := r.Context().Value(CtxKeyUserManager).(adapter.UserManager)
+	inbound := r.Context().Value(CtxKeyInbound).(string)
+	name := getEscapeParam(r, "name")
+	if !userExists(userManager, inbound, name) {
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, ErrNotFound)
+		return
+	}
+	err := userManager.RemoveUser(inbound, name)
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, newError(err.Error()))
+		return
+	}
+	render.NoContent(w, r)
+}
+
+func userExists(userManager adapter.UserManager, inbound string, name string) bool {
+	users, _ := userManager.Users(inbound)
+	return common.Any(users, func(it adapter.InboundUser) bool {
+		return it.Name == name
+	})
+}
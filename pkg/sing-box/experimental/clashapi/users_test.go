@@ -0,0 +1,63 @@
+package clashapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/usermanager"
+	"github.com/sagernet/sing-box/inbound"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/auth"
+	"github.com/sagernet/sing/service"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsersEndpoint(t *testing.T) {
+	t.Parallel()
+	userManager := usermanager.New(context.Background(), log.NewNOPFactory().Logger(), []adapter.Inbound{
+		inbound.NewSocks(context.Background(), nil, log.NewNOPFactory().Logger(), "socks-in", option.SocksInboundOptions{
+			Users: []auth.User{{Username: "alice", Password: "a"}},
+		}),
+	})
+	require.NoError(t, userManager.Start())
+	handler := userRouter(service.ContextWith[adapter.UserManager](context.Background(), userManager))
+	request := func(method string, target string, body string) *httptest.ResponseRecorder {
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return response
+	}
+
+	response := request(http.MethodGet, "/", "")
+	require.Equal(t, http.StatusOK, response.Code)
+	require.JSONEq(t, `{"inbounds":[{"tag":"socks-in","type":"socks","users":1}]}`, response.Body.String())
+
+	response = request(http.MethodPost, "/socks-in", `{"name":"bob","password":"b"}`)
+	require.Equal(t, http.StatusNoContent, response.Code)
+	response = request(http.MethodPost, "/socks-in", `{"name":"bob","password":"b"}`)
+	require.Equal(t, http.StatusBadRequest, response.Code)
+	response = request(http.MethodPost, "/socks-in", `{`)
+	require.Equal(t, http.StatusBadRequest, response.Code)
+	response = request(http.MethodPut, "/socks-in/bob", `{"password":"c"}`)
+	require.Equal(t, http.StatusNoContent, response.Code)
+	response = request(http.MethodPut, "/socks-in/carol", `{"password":"c"}`)
+	require.Equal(t, http.StatusNotFound, response.Code)
+	response = request(http.MethodDelete, "/socks-in/alice", "")
+	require.Equal(t, http.StatusNoContent, response.Code)
+	response = request(http.MethodDelete, "/socks-in/alice", "")
+	require.Equal(t, http.StatusNotFound, response.Code)
+	// the last user is kept
+	response = request(http.MethodDelete, "/socks-in/bob", "")
+	require.Equal(t, http.StatusBadRequest, response.Code)
+
+	response = request(http.MethodGet, "/socks-in", "")
+	require.Equal(t, http.StatusOK, response.Code)
+	require.JSONEq(t, `{"users":[{"name":"bob","password":"c"}]}`, response.Body.String())
+	response = request(http.MethodGet, "/unknown", "")
+	require.Equal(t, http.StatusNotFound, response.Code)
+}
@@ -0,0 +1,86 @@
+package clashapi
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing/common"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+func wireGuardRouter(router adapter.Router) http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", getWireGuardInbounds(router))
+	r.Get("/{inbound}", getWireGuardInbound(router))
+	return r
+}
+
+type WireGuardInbound struct {
+	Tag   string          `json:"tag"`
+	Peers []WireGuardPeer `json:"peers"`
+}
+
+type WireGuardPeer struct {
+	Name          string     `json:"name,omitempty"`
+	PublicKey     string     `json:"public_key"`
+	Endpoint      string     `json:"endpoint,omitempty"`
+	LastHandshake *time.Time `json:"last_handshake,omitempty"`
+	RxBytes       uint64     `json:"rx_bytes"`
+	TxBytes       uint64     `json:"tx_bytes"`
+}
+
+func newWireGuardInbound(inbound adapter.WireGuardInbound) WireGuardInbound {
+	return WireGuardInbound{
+		Tag: inbound.Tag(),
+		Peers: common.Map(inbound.Peers(), func(it adapter.WireGuardPeer) WireGuardPeer {
+			peer := WireGuardPeer{
+				Name:      it.Name,
+				PublicKey: it.PublicKey,
+				Endpoint:  it.Endpoint,
+				RxBytes:   it.RxBytes,
+				TxBytes:   it.TxBytes,
+			}
+			if !it.LastHandshake.IsZero() {
+				peer.LastHandshake = common.Ptr(it.LastHandshake)
+			}
+			return peer
+		}),
+	}
+}
+
+func getWireGuardInbounds(router adapter.Router) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		inbounds := []WireGuardInbound{}
+		for _, inbound := range router.Inbounds() {
+			wireGuardInbound, isWireGuard := inbound.(adapter.WireGuardInbound)
+			if !isWireGuard {
+				continue
+			}
+			inbounds = append(inbounds, newWireGuardInbound(wireGuardInbound))
+		}
+		render.JSON(w, r, render.M{
+			"inbounds": inbounds,
+		})
+	}
+}
+
+func getWireGuardInbound(router adapter.Router) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		inbound, loaded := router.Inbound(getEscapeParam(r, "inbound"))
+		if !loaded {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, ErrNotFound)
+			return
+		}
+		wireGuardInbound, isWireGuard := inbound.(adapter.WireGuardInbound)
+		if !isWireGuard {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, ErrNotFound)
+			return
+		}
+		render.JSON(w, r, newWireGuardInbound(wireGuardInbound))
+	}
+}
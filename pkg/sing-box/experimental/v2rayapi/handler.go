@@ -0,0 +1,219 @@
+package v2rayapi
+
+import (
+	"context"
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/service"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+var _ HandlerServiceServer = (*HandlerService)(nil)
+
+// xrayHandlerServiceDesc serves HandlerService under the service name of Xray.
+var xrayHandlerServiceDesc = func() grpc.ServiceDesc {
+	serviceDesc := HandlerService_ServiceDesc
+	serviceDesc.ServiceName = "xray.app.proxyman.command.HandlerService"
+	return serviceDesc
+}()
+
+// HandlerService manages inbound users with the V2Ray and Xray HandlerService API.
+// Messages are matched by their unqualified names, so both v2ray.core.* and
+// xray.* type names are accepted.
+type HandlerService struct {
+	ctx context.Context
+}
+
+func NewHandlerService(ctx context.Context, options option.V2RayHandlerServiceOptions) *HandlerService {
+	if !options.Enabled {
+		return nil
+	}
+	return &HandlerService{ctx: ctx}
+}
+
+func (s *HandlerService) userManager() (adapter.UserManager, error) {
+	userManager := service.FromContext[adapter.UserManager](s.ctx)
+	if userManager == nil {
+		return nil, E.New("user manager not available")
+	}
+	return userManager, nil
+}
+
+func (s *HandlerService) AlterInbound(ctx context.Context, request *AlterInboundRequest) (*AlterInboundResponse, error) {
+	userManager, err := s.userManager()
+	if err != nil {
+		return nil, err
+	}
+	if request.Operation == nil {
+		return nil, E.New("missing operation")
+	}
+	switch messageName(request.Operation.Type, 1) {
+	case "AddUserOperation":
+		var operation AddUserOperation
+		err = proto.Unmarshal(request.Operation.Value, &operation)
+		if err != nil {
+			return nil, E.Cause(err, "decode operation")
+		}
+		if operation.User == nil {
+			return nil, E.New("missing user")
+		}
+		var user adapter.InboundUser
+		user, err = userFromMessage(operation.User)
+		if err != nil {
+			return nil, err
+		}
+		err = userManager.AddUser(request.Tag, user)
+	case "RemoveUserOperation":
+		var operation RemoveUserOperation
+		err = proto.Unmarshal(request.Operation.Value, &operation)
+		if err != nil {
+			return nil, E.Cause(err, "decode operation")
+		}
+		err = userManager.RemoveUser(request.Tag, operation.Email)
+	default:
+		return nil, E.New("unsupported operation: ", request.Operation.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &AlterInboundResponse{}, nil
+}
+
+func (s *HandlerService) GetInboundUsers(ctx context.Context, request *GetInboundUserRequest) (*GetInboundUserResponse, error) {
+	userManager, err := s.userManager()
+	if err != nil {
+		return nil, err
+	}
+	var inboundType string
+	for _, inbound := range userManager.Inbounds() {
+		if inbound.Tag() == request.Tag {
+			inboundType = inbound.Type()
+			break
+		}
+	}
+	users, err := userManager.Users(request.Tag)
+	if err != nil {
+		return nil, err
+	}
+	var response GetInboundUserResponse
+	for _, user := range users {
+		if request.Email != "" && user.Name != request.Email {
+			continue
+		}
+		userMessage, err := userToMessage(inboundType, user)
+		if err != nil {
+			return nil, err
+		}
+		response.Users = append(response.Users, userMessage)
+	}
+	return &response, nil
+}
+
+func (s *HandlerService) mustEmbedUnimplementedHandlerServiceServer() {
+}
+
+// messageName returns the last n components of a message type name.
+func messageName(typeName string, n int) string {
+	components := strings.Split(typeName, ".")
+	if len(components) > n {
+		components = components[len(components)-n:]
+	}
+	return strings.Join(components, ".")
+}
+
+func userFromMessage(message *User) (adapter.InboundUser, error) {
+	user := adapter.InboundUser{Name: message.Email}
+	if message.Account == nil {
+		return user, E.New("missing account")
+	}
+	var err error
+	switch accountType := messageName(message.Account.Type, 2); accountType {
+	case "vmess.Account", "v2rayapi.VMessAccount":
+		var account VMessAccount
+		err = proto.Unmarshal(message.Account.Value, &account)
+		user.UUID = account.Id
+		user.AlterID = int(account.AlterId)
+	case "vless.Account", "v2rayapi.VLESSAccount":
+		var account VLESSAccount
+		err = proto.Unmarshal(message.Account.Value, &account)
+		user.UUID = account.Id
+		user.Flow = account.Flow
+	case "trojan.Account", "shadowsocks.Account", "shadowsocks_2022.Account", "v2rayapi.PasswordAccount":
+		var account PasswordAccount
+		err = proto.Unmarshal(message.Account.Value, &account)
+		user.Password = account.Password
+	case "socks.Account", "http.Account", "v2rayapi.UsernamePasswordAccount":
+		var account UsernamePasswordAccount
+		err = proto.Unmarshal(message.Account.Value, &account)
+		if user.Name == "" {
+			user.Name = account.Username
+		} else if account.Username != "" && account.Username != user.Name {
+			return user, E.New("username must match email")
+		}
+		user.Password = account.Password
+	case "v2rayapi.TUICAccount":
+		var account TUICAccount
+		err = proto.Unmarshal(message.Account.Value, &account)
+		user.UUID = account.Uuid
+		user.Password = account.Password
+	default:
+		return user, E.New("unsupported account type: ", message.Account.Type)
+	}
+	if err != nil {
+		return user, E.Cause(err, "decode account")
+	}
+	return user, nil
+}
+
+// userToMessage returns user with the V2Ray account of inboundType, or an
+// account of handler.proto for inbounds V2Ray does not have.
+func userToMessage(inboundType string, user adapter.InboundUser) (*User, error) {
+	var (
+		account     proto.Message
+		accountType string
+	)
+	switch inboundType {
+	case C.TypeVMess:
+		account = &VMessAccount{Id: user.UUID, AlterId: uint32(user.AlterID)}
+		accountType = "v2ray.core.proxy.vmess.Account"
+	case C.TypeVLESS:
+		account = &VLESSAccount{Id: user.UUID, Flow: user.Flow}
+		accountType = "v2ray.core.proxy.vless.Account"
+	case C.TypeTrojan:
+		account = &PasswordAccount{Password: user.Password}
+		accountType = "v2ray.core.proxy.trojan.Account"
+	case C.TypeShadowsocks:
+		account = &PasswordAccount{Password: user.Password}
+		accountType = "v2ray.core.proxy.shadowsocks.Account"
+	case C.TypeSOCKS, C.TypeMixed:
+		account = &UsernamePasswordAccount{Username: user.Name, Password: user.Password}
+		accountType = "v2ray.core.proxy.socks.Account"
+	case C.TypeHTTP, C.TypeNaive:
+		account = &UsernamePasswordAccount{Username: user.Name, Password: user.Password}
+		accountType = "v2ray.core.proxy.http.Account"
+	case C.TypeTUIC:
+		account = &TUICAccount{Uuid: user.UUID, Password: user.Password}
+	default:
+		account = &PasswordAccount{Password: user.Password}
+	}
+	if accountType == "" {
+		accountType = string(account.ProtoReflect().Descriptor().FullName())
+	}
+	accountBinary, err := proto.Marshal(account)
+	if err != nil {
+		return nil, err
+	}
+	return &User{
+		Email: user.Name,
+		Account: &TypedMessage{
+			Type:  accountType,
+			Value: accountBinary,
+		},
+	}, nil
+}
@@ -0,0 +1,90 @@
+package v2rayapi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/usermanager"
+	"github.com/sagernet/sing-box/inbound"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/service"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+)
+
+func typedMessage(t *testing.T, typeName string, message proto.Message) *TypedMessage {
+	value, err := proto.Marshal(message)
+	require.NoError(t, err)
+	return &TypedMessage{Type: typeName, Value: value}
+}
+
+func TestHandlerService(t *testing.T) {
+	t.Parallel()
+	vmessInbound, err := inbound.NewVMess(context.Background(), nil, log.NewNOPFactory().Logger(), "vmess-in", option.VMessInboundOptions{
+		Users: []option.VMessUser{{Name: "alice", UUID: "b831381d-6324-4d53-ad4f-8cda48b30811"}},
+	})
+	require.NoError(t, err)
+	userManager := usermanager.New(context.Background(), log.NewNOPFactory().Logger(), []adapter.Inbound{vmessInbound})
+	require.NoError(t, userManager.Start())
+	server, err := NewServer(service.ContextWith[adapter.UserManager](context.Background(), userManager), log.NewNOPFactory().Logger(), option.V2RayAPIOptions{
+		Listen:  "127.0.0.1:0",
+		Handler: &option.V2RayHandlerServiceOptions{Enabled: true},
+	})
+	require.NoError(t, err)
+	require.NoError(t, server.Start())
+	defer server.Close()
+	conn, err := grpc.Dial(server.(*Server).tcpListener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	handlerService := NewHandlerServiceClient(conn)
+
+	// xray type names are accepted as well as v2ray ones
+	_, err = handlerService.AlterInbound(context.Background(), &AlterInboundRequest{
+		Tag: "vmess-in",
+		Operation: typedMessage(t, "xray.app.proxyman.command.AddUserOperation", &AddUserOperation{
+			User: &User{
+				Email:   "bob",
+				Account: typedMessage(t, "xray.proxy.vmess.Account", &VMessAccount{Id: "6a5ef32c-5cc7-4a8e-8a23-96c6e0a0d0a2"}),
+			},
+		}),
+	})
+	require.NoError(t, err)
+	_, err = handlerService.AlterInbound(context.Background(), &AlterInboundRequest{
+		Tag:       "vmess-in",
+		Operation: typedMessage(t, "v2ray.core.app.proxyman.command.RemoveUserOperation", &RemoveUserOperation{Email: "alice"}),
+	})
+	require.NoError(t, err)
+	_, err = handlerService.AlterInbound(context.Background(), &AlterInboundRequest{
+		Tag:       "vmess-in",
+		Operation: typedMessage(t, "xray.app.proxyman.command.AddInboundOperation", &RemoveUserOperation{}),
+	})
+	require.Error(t, err)
+	_, err = handlerService.AlterInbound(context.Background(), &AlterInboundRequest{
+		Tag: "vmess-in",
+		Operation: typedMessage(t, "xray.app.proxyman.command.AddUserOperation", &AddUserOperation{
+			User: &User{Email: "carol"},
+		}),
+	})
+	require.Error(t, err)
+
+	response, err := handlerService.GetInboundUsers(context.Background(), &GetInboundUserRequest{Tag: "vmess-in"})
+	require.NoError(t, err)
+	require.Len(t, response.Users, 1)
+	require.Equal(t, "bob", response.Users[0].Email)
+	var account VMessAccount
+	require.Equal(t, "v2ray.core.proxy.vmess.Account", response.Users[0].Account.Type)
+	require.NoError(t, proto.Unmarshal(response.Users[0].Account.Value, &account))
+	require.Equal(t, "6a5ef32c-5cc7-4a8e-8a23-96c6e0a0d0a2", account.Id)
+	_, err = handlerService.GetInboundUsers(context.Background(), &GetInboundUserRequest{Tag: "unknown"})
+	require.Error(t, err)
+
+	// Xray clients call the same service under their package
+	response = &GetInboundUserResponse{}
+	require.NoError(t, conn.Invoke(context.Background(), "/xray.app.proxyman.command.HandlerService/GetInboundUsers", &GetInboundUserRequest{Tag: "vmess-in"}, response))
+	require.Len(t, response.Users, 1)
+}
@@ -1,6 +1,7 @@
 package v2rayapi
 
 import (
+	"context"
 	"errors"
 	"net"
 	"net/http"
@@ -29,12 +30,17 @@ type Server struct {
 	statsService *StatsService
 }
 
-func NewServer(logger log.Logger, options option.V2RayAPIOptions) (adapter.V2RayServer, error) {
+func NewServer(ctx context.Context, logger log.Logger, options option.V2RayAPIOptions) (adapter.V2RayServer, error) {
 	grpcServer := grpc.NewServer(grpc.Creds(insecure.NewCredentials()))
-	statsService := NewStatsService(common.PtrValueOrDefault(options.Stats))
+	statsService := NewStatsService(ctx, common.PtrValueOrDefault(options.Stats))
 	if statsService != nil {
 		RegisterStatsServiceServer(grpcServer, statsService)
 	}
+	handlerService := NewHandlerService(ctx, common.PtrValueOrDefault(options.Handler))
+	if handlerService != nil {
+		RegisterHandlerServiceServer(grpcServer, handlerService)
+		grpcServer.RegisterService(&xrayHandlerServiceDesc, handlerService)
+	}
 	server := &Server{
 		logger:       logger,
 		listen:       options.Listen,
@@ -71,5 +77,8 @@ func (s *Server) Close() error {
 }
 
 func (s *Server) StatsService() adapter.V2RayStatsService {
+	if s.statsService == nil {
+		return nil
+	}
 	return s.statsService
 }
@@ -15,6 +15,7 @@ import (
 	"github.com/sagernet/sing/common/bufio"
 	E "github.com/sagernet/sing/common/exceptions"
 	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/service"
 )
 
 func init() {
@@ -27,6 +28,7 @@ var (
 )
 
 type StatsService struct {
+	ctx       context.Context
 	createdAt time.Time
 	inbounds  map[string]bool
 	outbounds map[string]bool
@@ -35,7 +37,7 @@ type StatsService struct {
 	counters  map[string]*atomic.Int64
 }
 
-func NewStatsService(options option.V2RayStatsServiceOptions) *StatsService {
+func NewStatsService(ctx context.Context, options option.V2RayStatsServiceOptions) *StatsService {
 	if !options.Enabled {
 		return nil
 	}
@@ -52,6 +54,7 @@ func NewStatsService(options option.V2RayStatsServiceOptions) *StatsService {
 		users[user] = true
 	}
 	return &StatsService{
+		ctx:       ctx,
 		createdAt: time.Now(),
 		inbounds:  inbounds,
 		outbounds: outbounds,
@@ -117,6 +120,11 @@ func (s *StatsService) GetStats(ctx context.Context, request *GetStatsRequest) (
 	counter, loaded := s.counters[request.Name]
 	s.access.Unlock()
 	if !loaded {
+		for _, stat := range s.userUsageStats() {
+			if stat.Name == request.Name {
+				return &GetStatsResponse{Stat: stat}, nil
+			}
+		}
 		return nil, E.New(request.Name, " not found.")
 	}
 	var value int64
@@ -179,9 +187,63 @@ func (s *StatsService) QueryStats(ctx context.Context, request *QueryStatsReques
 			}
 		}
 	}
+	for _, stat := range s.userUsageStats() {
+		matched, err := matchStat(request, stat.Name)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			response.Stat = append(response.Stat, stat)
+		}
+	}
 	return &response, nil
 }
 
+// userUsageStats returns the usage of users with user_limits as read-only
+// stats, which are not affected by reset.
+func (s *StatsService) userUsageStats() []*Stat {
+	userLimiter := service.FromContext[adapter.UserLimiter](s.ctx)
+	if userLimiter == nil {
+		return nil
+	}
+	var stats []*Stat
+	for _, usage := range userLimiter.Usage() {
+		prefix := "inbound>>>" + usage.Inbound + ">>>user>>>" + usage.User + ">>>quota>>>"
+		stats = append(stats,
+			&Stat{Name: prefix + "used", Value: int64(usage.Used)},
+			&Stat{Name: prefix + "limit", Value: int64(usage.Quota)},
+			&Stat{Name: prefix + "connections", Value: int64(usage.Connections)},
+		)
+		if !usage.ExpireAt.IsZero() {
+			stats = append(stats, &Stat{Name: prefix + "expire", Value: usage.ExpireAt.Unix()})
+		}
+		if !usage.ResetAt.IsZero() {
+			stats = append(stats, &Stat{Name: prefix + "reset", Value: usage.ResetAt.Unix()})
+		}
+	}
+	return stats
+}
+
+func matchStat(request *QueryStatsRequest, name string) (bool, error) {
+	if len(request.Patterns) == 0 {
+		return true, nil
+	}
+	for _, pattern := range request.Patterns {
+		if request.Regexp {
+			matched, err := regexp.MatchString(pattern, name)
+			if err != nil {
+				return false, err
+			}
+			if matched {
+				return true, nil
+			}
+		} else if strings.Contains(name, pattern) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func (s *StatsService) GetSysStats(ctx context.Context, request *SysStatsRequest) (*SysStatsResponse, error) {
 	var rtm runtime.MemStats
 	runtime.ReadMemStats(&rtm)
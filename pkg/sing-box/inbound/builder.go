@@ -48,6 +48,10 @@ func New(ctx context.Context, router adapter.Router, logger log.ContextLogger, o
 		return NewTUIC(ctx, router, logger, options.Tag, options.TUICOptions)
 	case C.TypeHysteria2:
 		return NewHysteria2(ctx, router, logger, options.Tag, options.Hysteria2Options)
+	case C.TypeDNS:
+		return NewDNS(ctx, router, logger, options.Tag, options.DNSOptions)
+	case C.TypeWireGuard:
+		return NewWireGuard(ctx, router, logger, options.Tag, options.WireGuardOptions)
 	default:
 		return nil, E.New("unknown inbound type: ", options.Type)
 	}
@@ -0,0 +1,394 @@
+package inbound
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/tls"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-dns"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/buf"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+
+	mDNS "github.com/miekg/dns"
+	"go4.org/netipx"
+)
+
+var (
+	_ adapter.Inbound       = (*DNS)(nil)
+	_ adapter.PacketHandler = (*DNS)(nil)
+)
+
+const (
+	defaultDoHPath = "/dns-query"
+
+	// dnsStreamIdleTimeout closes idle TCP and TLS connections.
+	dnsStreamIdleTimeout = 2 * time.Minute
+
+	// dnsStreamMaxQueries bounds the pipelined queries of a TCP or TLS
+	// connection answered at the same time, further queries are not read
+	// until one of them is answered.
+	dnsStreamMaxQueries = 16
+)
+
+type DNS struct {
+	myInboundAdapter
+	dnsRouter  adapter.Router
+	tlsConfig  tls.ServerConfig
+	doh        bool
+	dohPath    string
+	allowed    *netipx.IPSet
+	denied     *netipx.IPSet
+	httpServer *http.Server
+}
+
+func NewDNS(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.DNSInboundOptions) (*DNS, error) {
+	inbound := &DNS{
+		myInboundAdapter: myInboundAdapter{
+			protocol:      C.TypeDNS,
+			network:       options.Network.Build(),
+			ctx:           ctx,
+			router:        router,
+			logger:        logger,
+			tag:           tag,
+			listenOptions: options.ListenOptions,
+		},
+		dnsRouter: router,
+		doh:       options.DoH,
+		dohPath:   options.DoHPath,
+	}
+	if options.TLS != nil && options.TLS.Enabled {
+		tlsConfig, err := tls.NewServer(ctx, logger, common.PtrValueOrDefault(options.TLS))
+		if err != nil {
+			return nil, err
+		}
+		inbound.tlsConfig = tlsConfig
+	}
+	if inbound.doh {
+		if inbound.dohPath == "" {
+			inbound.dohPath = defaultDoHPath
+		}
+	} else if inbound.dohPath != "" {
+		return nil, E.New("doh_path requires doh enabled")
+	}
+	if inbound.tlsConfig != nil || inbound.doh {
+		if len(options.Network) > 0 && common.Contains(inbound.network, N.NetworkUDP) {
+			return nil, E.New("UDP is not available for DNS over TLS or HTTPS")
+		}
+		inbound.network = []string{N.NetworkTCP}
+	}
+	var err error
+	inbound.allowed, err = newClientIPSet(options.AllowedClients)
+	if err != nil {
+		return nil, E.Cause(err, "parse allowed_clients")
+	}
+	inbound.denied, err = newClientIPSet(options.DeniedClients)
+	if err != nil {
+		return nil, E.Cause(err, "parse denied_clients")
+	}
+	inbound.connHandler = inbound
+	inbound.packetHandler = inbound
+	return inbound, nil
+}
+
+func newClientIPSet(cidrList []string) (*netipx.IPSet, error) {
+	if len(cidrList) == 0 {
+		return nil, nil
+	}
+	var builder netipx.IPSetBuilder
+	for i, cidr := range cidrList {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err == nil {
+			builder.AddPrefix(prefix)
+			continue
+		}
+		address, addrErr := netip.ParseAddr(cidr)
+		if addrErr == nil {
+			builder.Add(address)
+			continue
+		}
+		return nil, E.Cause(err, "[", i, "]")
+	}
+	return builder.IPSet()
+}
+
+func (d *DNS) Start() error {
+	if d.tlsConfig != nil {
+		err := d.tlsConfig.Start()
+		if err != nil {
+			return E.Cause(err, "create TLS config")
+		}
+	}
+	if !d.doh {
+		return d.myInboundAdapter.Start()
+	}
+	var tlsConfig *tls.STDConfig
+	if d.tlsConfig != nil {
+		var err error
+		tlsConfig, err = d.tlsConfig.Config()
+		if err != nil {
+			return err
+		}
+	}
+	tcpListener, err := d.ListenTCP()
+	if err != nil {
+		return err
+	}
+	d.httpServer = &http.Server{
+		Handler:   d,
+		TLSConfig: tlsConfig,
+		BaseContext: func(listener net.Listener) context.Context {
+			return d.ctx
+		},
+	}
+	go func() {
+		var sErr error
+		if tlsConfig != nil {
+			sErr = d.httpServer.ServeTLS(tcpListener, "", "")
+		} else {
+			sErr = d.httpServer.Serve(tcpListener)
+		}
+		if sErr != nil && !E.IsClosedOrCanceled(sErr) {
+			d.logger.Error("http server serve error: ", sErr)
+		}
+	}()
+	return nil
+}
+
+func (d *DNS) Close() error {
+	return common.Close(
+		&d.myInboundAdapter,
+		common.PtrOrNil(d.httpServer),
+		d.tlsConfig,
+	)
+}
+
+func (d *DNS) isAllowed(source netip.Addr) bool {
+	source = source.Unmap()
+	if d.denied != nil && d.denied.Contains(source) {
+		return false
+	}
+	return d.allowed == nil || d.allowed.Contains(source)
+}
+
+// exchange answers message for the client in metadata, refusing clients
+// not permitted by the access lists.
+func (d *DNS) exchange(ctx context.Context, message *mDNS.Msg, metadata adapter.InboundContext) *mDNS.Msg {
+	if !d.isAllowed(metadata.Source.Addr) {
+		d.logger.DebugContext(ctx, "refused query from ", metadata.Source)
+		return dnsErrorResponse(message, mDNS.RcodeRefused)
+	}
+	response, err := d.dnsRouter.Exchange(adapter.WithContext(ctx, &metadata), message)
+	if err != nil {
+		if rcodeError, isRCodeError := err.(dns.RCodeError); isRCodeError {
+			return dnsErrorResponse(message, int(rcodeError))
+		}
+		return dnsErrorResponse(message, mDNS.RcodeServerFailure)
+	}
+	response.Id = message.Id
+	return response
+}
+
+func dnsErrorResponse(message *mDNS.Msg, rcode int) *mDNS.Msg {
+	response := new(mDNS.Msg)
+	response.SetRcode(message, rcode)
+	response.RecursionAvailable = true
+	return response
+}
+
+func (d *DNS) NewConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
+	if d.tlsConfig != nil {
+		tlsConn, err := tls.ServerHandshake(ctx, conn, d.tlsConfig)
+		if err != nil {
+			return err
+		}
+		conn = tlsConn
+	}
+	defer conn.Close()
+	var (
+		writeAccess sync.Mutex
+		queries     = make(chan struct{}, dnsStreamMaxQueries)
+		inflight    sync.WaitGroup
+	)
+	// answer queries already read before closing the connection
+	defer inflight.Wait()
+	for {
+		err := conn.SetReadDeadline(time.Now().Add(dnsStreamIdleTimeout))
+		if err != nil {
+			return err
+		}
+		var queryLength uint16
+		err = binary.Read(conn, binary.BigEndian, &queryLength)
+		if err != nil {
+			if E.IsClosedOrCanceled(err) || err == io.EOF || E.IsTimeout(err) {
+				return nil
+			}
+			return err
+		}
+		if queryLength == 0 {
+			return dns.RCodeFormatError
+		}
+		buffer := buf.NewSize(int(queryLength))
+		_, err = buffer.ReadFullFrom(conn, int(queryLength))
+		if err != nil {
+			buffer.Release()
+			return err
+		}
+		var message mDNS.Msg
+		err = message.Unpack(buffer.Bytes())
+		buffer.Release()
+		if err != nil {
+			return err
+		}
+		queries <- struct{}{}
+		inflight.Add(1)
+		go func() {
+			defer func() {
+				<-queries
+				inflight.Done()
+			}()
+			response := d.exchange(ctx, &message, metadata)
+			responseBuffer := buf.NewPacket()
+			defer responseBuffer.Release()
+			responseBuffer.Resize(2, 0)
+			rawResponse, err := response.PackBuffer(responseBuffer.FreeBytes())
+			if err != nil {
+				d.logger.ErrorContext(ctx, E.Cause(err, "pack response"))
+				return
+			}
+			responseBuffer.Truncate(len(rawResponse))
+			binary.BigEndian.PutUint16(responseBuffer.ExtendHeader(2), uint16(len(rawResponse)))
+			writeAccess.Lock()
+			_, err = conn.Write(responseBuffer.Bytes())
+			writeAccess.Unlock()
+			if err != nil {
+				d.logger.DebugContext(ctx, E.Cause(err, "write response"))
+			}
+		}()
+	}
+}
+
+func (d *DNS) NewPacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext) error {
+	return E.New("unsupported")
+}
+
+func (d *DNS) NewPacket(ctx context.Context, conn N.PacketConn, buffer *buf.Buffer, metadata adapter.InboundContext) error {
+	var message mDNS.Msg
+	err := message.Unpack(buffer.Bytes())
+	if err != nil {
+		return err
+	}
+	go func() {
+		response := d.exchange(log.ContextWithNewID(ctx), &message, metadata)
+		maxSize := mDNS.MinMsgSize
+		if edns0 := message.IsEdns0(); edns0 != nil {
+			maxSize = int(edns0.UDPSize())
+		}
+		response.Truncate(maxSize)
+		responseBuffer := buf.NewPacket()
+		rawResponse, err := response.PackBuffer(responseBuffer.FreeBytes())
+		if err != nil {
+			responseBuffer.Release()
+			d.logger.ErrorContext(ctx, E.Cause(err, "pack response"))
+			return
+		}
+		responseBuffer.Truncate(len(rawResponse))
+		err = conn.WritePacket(responseBuffer, metadata.Source)
+		if err != nil {
+			responseBuffer.Release()
+			d.logger.DebugContext(ctx, E.Cause(err, "write response"))
+		}
+	}()
+	return nil
+}
+
+func (d *DNS) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.URL.Path != d.dohPath {
+		writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+	var rawMessage []byte
+	switch request.Method {
+	case http.MethodGet:
+		var err error
+		rawMessage, err = base64.RawURLEncoding.DecodeString(request.URL.Query().Get("dns"))
+		if err != nil || len(rawMessage) == 0 {
+			writer.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	case http.MethodPost:
+		if request.Header.Get("Content-Type") != "application/dns-message" {
+			writer.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		var err error
+		rawMessage, err = io.ReadAll(io.LimitReader(request.Body, mDNS.MaxMsgSize))
+		if err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	default:
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var message mDNS.Msg
+	err := message.Unpack(rawMessage)
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	ctx := log.ContextWithNewID(request.Context())
+	source := M.ParseSocksaddr(request.RemoteAddr).Unwrap()
+	var metadata adapter.InboundContext
+	metadata.Inbound = d.tag
+	metadata.InboundType = d.protocol
+	metadata.InboundOptions = d.listenOptions.InboundOptions
+	metadata.Source = source
+	if !d.isAllowed(source.Addr) {
+		d.logger.DebugContext(ctx, "refused query from ", source)
+		writer.WriteHeader(http.StatusForbidden)
+		return
+	}
+	response := d.exchange(ctx, &message, metadata)
+	rawResponse, err := response.Pack()
+	if err != nil {
+		d.logger.ErrorContext(ctx, E.Cause(err, "pack response"))
+		writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	writer.Header().Set("Content-Type", "application/dns-message")
+	if timeToLive, loaded := responseMinTTL(response); loaded {
+		writer.Header().Set("Cache-Control", "max-age="+strconv.FormatUint(uint64(timeToLive), 10))
+	}
+	writer.Write(rawResponse)
+}
+
+func responseMinTTL(response *mDNS.Msg) (uint32, bool) {
+	var (
+		timeToLive uint32
+		loaded     bool
+	)
+	for _, recordList := range [][]mDNS.RR{response.Answer, response.Ns} {
+		for _, record := range recordList {
+			if !loaded || record.Header().Ttl < timeToLive {
+				timeToLive = record.Header().Ttl
+				loaded = true
+			}
+		}
+	}
+	return timeToLive, loaded
+}
@@ -0,0 +1,255 @@
+package inbound
+
+import (
+	"context"
+	stdTLS "crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/tls"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/buf"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+
+	mDNS "github.com/miekg/dns"
+	"github.com/stretchr/testify/require"
+)
+
+type testDNSRouter struct {
+	adapter.Router
+	delay    time.Duration
+	inflight atomic.Int32
+	maximum  atomic.Int32
+}
+
+func (r *testDNSRouter) Exchange(ctx context.Context, message *mDNS.Msg) (*mDNS.Msg, error) {
+	inflight := r.inflight.Add(1)
+	defer r.inflight.Add(-1)
+	for {
+		maximum := r.maximum.Load()
+		if inflight <= maximum || r.maximum.CompareAndSwap(maximum, inflight) {
+			break
+		}
+	}
+	time.Sleep(r.delay)
+	response := new(mDNS.Msg)
+	response.SetReply(message)
+	response.Answer = append(response.Answer, &mDNS.A{
+		Hdr: mDNS.RR_Header{Name: message.Question[0].Name, Rrtype: mDNS.TypeA, Class: mDNS.ClassINET, Ttl: 60},
+		A:   net.IPv4(1, 2, 3, 4),
+	})
+	return response, nil
+}
+
+type testDNSPacketConn struct {
+	N.PacketConn
+	packets chan *buf.Buffer
+}
+
+func (c *testDNSPacketConn) WritePacket(buffer *buf.Buffer, destination M.Socksaddr) error {
+	c.packets <- buffer
+	return nil
+}
+
+func newTestDNS(t *testing.T, router adapter.Router, options option.DNSInboundOptions) *DNS {
+	inbound, err := NewDNS(context.Background(), router, log.NewNOPFactory().Logger(), "dns-in", options)
+	require.NoError(t, err)
+	return inbound
+}
+
+func packQuery(t *testing.T, id uint16, name string) []byte {
+	message := new(mDNS.Msg)
+	message.SetQuestion(name, mDNS.TypeA)
+	message.Id = id
+	rawMessage, err := message.Pack()
+	require.NoError(t, err)
+	return rawMessage
+}
+
+func requireAnswer(t *testing.T, rawResponse []byte) *mDNS.Msg {
+	var response mDNS.Msg
+	require.NoError(t, response.Unpack(rawResponse))
+	require.Equal(t, mDNS.RcodeSuccess, response.Rcode)
+	require.Len(t, response.Answer, 1)
+	require.Equal(t, "1.2.3.4", response.Answer[0].(*mDNS.A).A.String())
+	return &response
+}
+
+func writeStreamQuery(t *testing.T, conn net.Conn, id uint16, name string) {
+	rawQuery := packQuery(t, id, name)
+	_, err := conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(rawQuery))), rawQuery...))
+	require.NoError(t, err)
+}
+
+func readStreamResponse(t *testing.T, conn net.Conn) *mDNS.Msg {
+	var length uint16
+	require.NoError(t, binary.Read(conn, binary.BigEndian, &length))
+	rawResponse := make([]byte, length)
+	_, err := io.ReadFull(conn, rawResponse)
+	require.NoError(t, err)
+	return requireAnswer(t, rawResponse)
+}
+
+// serveStream accepts one connection of a loopback listener for the inbound.
+func serveStream(t *testing.T, inbound *DNS) (net.Conn, chan error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		listener.Close()
+	})
+	done := make(chan error, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			done <- err
+			return
+		}
+		done <- inbound.NewConnection(context.Background(), conn, adapter.InboundContext{
+			Source: M.SocksaddrFromNet(conn.RemoteAddr()),
+		})
+	}()
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		conn.Close()
+	})
+	return conn, done
+}
+
+func TestDNSUDP(t *testing.T) {
+	t.Parallel()
+	inbound := newTestDNS(t, &testDNSRouter{}, option.DNSInboundOptions{DeniedClients: []string{"10.0.0.0/8"}})
+	conn := &testDNSPacketConn{packets: make(chan *buf.Buffer, 1)}
+	for _, source := range []string{"127.0.0.1:5353", "10.0.0.1:5353"} {
+		err := inbound.NewPacket(context.Background(), conn, buf.As(packQuery(t, 1, "example.com.")), adapter.InboundContext{
+			Source: M.ParseSocksaddr(source),
+		})
+		require.NoError(t, err)
+		buffer := <-conn.packets
+		var response mDNS.Msg
+		require.NoError(t, response.Unpack(buffer.Bytes()))
+		require.Equal(t, uint16(1), response.Id)
+		if source == "127.0.0.1:5353" {
+			requireAnswer(t, buffer.Bytes())
+		} else {
+			require.Equal(t, mDNS.RcodeRefused, response.Rcode)
+		}
+		buffer.Release()
+	}
+}
+
+func TestDNSTCPPipelined(t *testing.T) {
+	t.Parallel()
+	router := &testDNSRouter{delay: 50 * time.Millisecond}
+	inbound := newTestDNS(t, router, option.DNSInboundOptions{})
+	conn, done := serveStream(t, inbound)
+	const queries = dnsStreamMaxQueries * 2
+	for i := 0; i < queries; i++ {
+		writeStreamQuery(t, conn, uint16(i), "example.com.")
+	}
+	// queries already read are answered after the client stops sending
+	require.NoError(t, conn.(*net.TCPConn).CloseWrite())
+	ids := make(map[uint16]bool)
+	for i := 0; i < queries; i++ {
+		ids[readStreamResponse(t, conn).Id] = true
+	}
+	require.Len(t, ids, queries)
+	require.NoError(t, <-done)
+	require.LessOrEqual(t, router.maximum.Load(), int32(dnsStreamMaxQueries))
+}
+
+func TestDNSTLS(t *testing.T) {
+	t.Parallel()
+	privateKey, certificate, err := tls.GenerateKeyPair(time.Now, "dns.example", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	inbound := newTestDNS(t, &testDNSRouter{}, option.DNSInboundOptions{
+		InboundTLSOptionsContainer: option.InboundTLSOptionsContainer{TLS: &option.InboundTLSOptions{
+			Enabled:     true,
+			Certificate: []string{string(certificate)},
+			Key:         []string{string(privateKey)},
+		}},
+	})
+	require.Equal(t, []string{N.NetworkTCP}, inbound.network)
+	require.NoError(t, inbound.tlsConfig.Start())
+	rawConn, done := serveStream(t, inbound)
+	conn := stdTLS.Client(rawConn, &stdTLS.Config{ServerName: "dns.example", InsecureSkipVerify: true})
+	writeStreamQuery(t, conn, 1, "example.com.")
+	require.Equal(t, uint16(1), readStreamResponse(t, conn).Id)
+	conn.Close()
+	require.NoError(t, <-done)
+}
+
+func TestDNSHTTPS(t *testing.T) {
+	t.Parallel()
+	inbound := newTestDNS(t, &testDNSRouter{}, option.DNSInboundOptions{
+		DoH:            true,
+		AllowedClients: []string{"127.0.0.0/8"},
+		DeniedClients:  []string{"127.0.0.2"},
+	})
+	rawQuery := packQuery(t, 0, "example.com.")
+	for _, testCase := range []struct {
+		request *http.Request
+		status  int
+	}{
+		{httptest.NewRequest(http.MethodGet, "/dns-query?dns="+base64.RawURLEncoding.EncodeToString(rawQuery), nil), http.StatusOK},
+		{httptest.NewRequest(http.MethodPost, "/dns-query", strings.NewReader(string(rawQuery))), http.StatusOK},
+		{httptest.NewRequest(http.MethodPost, "/dns-query", strings.NewReader(string(rawQuery))), http.StatusUnsupportedMediaType},
+		{httptest.NewRequest(http.MethodGet, "/other?dns="+base64.RawURLEncoding.EncodeToString(rawQuery), nil), http.StatusNotFound},
+		{httptest.NewRequest(http.MethodGet, "/dns-query?dns=", nil), http.StatusBadRequest},
+	} {
+		if testCase.request.Method == http.MethodPost && testCase.status == http.StatusOK {
+			testCase.request.Header.Set("Content-Type", "application/dns-message")
+		}
+		testCase.request.RemoteAddr = "127.0.0.1:50000"
+		recorder := httptest.NewRecorder()
+		inbound.ServeHTTP(recorder, testCase.request)
+		require.Equal(t, testCase.status, recorder.Code, testCase.request.URL.String())
+		if testCase.status == http.StatusOK {
+			require.Equal(t, "application/dns-message", recorder.Header().Get("Content-Type"))
+			require.Equal(t, "max-age=60", recorder.Header().Get("Cache-Control"))
+			requireAnswer(t, recorder.Body.Bytes())
+		}
+	}
+	for _, remoteAddr := range []string{"127.0.0.2:50000", "192.168.1.1:50000"} {
+		request := httptest.NewRequest(http.MethodGet, "/dns-query?dns="+base64.RawURLEncoding.EncodeToString(rawQuery), nil)
+		request.RemoteAddr = remoteAddr
+		recorder := httptest.NewRecorder()
+		inbound.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusForbidden, recorder.Code, remoteAddr)
+	}
+}
+
+func TestDNSClientACL(t *testing.T) {
+	t.Parallel()
+	inbound := newTestDNS(t, &testDNSRouter{}, option.DNSInboundOptions{
+		AllowedClients: []string{"192.168.0.0/16", "::1"},
+		DeniedClients:  []string{"192.168.1.0/24"},
+	})
+	for address, allowed := range map[string]bool{
+		"192.168.0.1":        true,
+		"192.168.1.1":        false,
+		"::ffff:192.168.0.1": true,
+		"::1":                true,
+		"10.0.0.1":           false,
+	} {
+		require.Equal(t, allowed, inbound.isAllowed(netip.MustParseAddr(address)), address)
+	}
+	message := new(mDNS.Msg)
+	message.SetQuestion("example.com.", mDNS.TypeA)
+	response := inbound.exchange(context.Background(), message, adapter.InboundContext{Source: M.ParseSocksaddr("10.0.0.1:53")})
+	require.Equal(t, mDNS.RcodeRefused, response.Rcode)
+	_, err := newClientIPSet([]string{"not an address"})
+	require.Error(t, err)
+}
@@ -20,14 +20,15 @@ import (
 )
 
 var (
-	_ adapter.Inbound           = (*HTTP)(nil)
-	_ adapter.InjectableInbound = (*HTTP)(nil)
+	_ adapter.Inbound            = (*HTTP)(nil)
+	_ adapter.InjectableInbound  = (*HTTP)(nil)
+	_ adapter.ManagedUserInbound = (*HTTP)(nil)
 )
 
 type HTTP struct {
 	myInboundAdapter
-	authenticator *auth.Authenticator
-	tlsConfig     tls.ServerConfig
+	users     *authUsers
+	tlsConfig tls.ServerConfig
 }
 
 func NewHTTP(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.HTTPMixedInboundOptions) (*HTTP, error) {
@@ -42,7 +43,7 @@ func NewHTTP(ctx context.Context, router adapter.Router, logger log.ContextLogge
 			listenOptions:  options.ListenOptions,
 			setSystemProxy: options.SetSystemProxy,
 		},
-		authenticator: auth.NewAuthenticator(options.Users),
+		users: newAuthUsers(options.Users),
 	}
 	if options.TLS != nil {
 		tlsConfig, err := tls.NewServer(ctx, logger, common.PtrValueOrDefault(options.TLS))
@@ -80,35 +81,61 @@ func (h *HTTP) NewConnection(ctx context.Context, conn net.Conn, metadata adapte
 			return err
 		}
 	}
-	return http.HandleConnection(ctx, conn, std_bufio.NewReader(conn), h.authenticator, h.upstreamUserHandler(metadata), adapter.UpstreamMetadata(metadata))
+	authenticator, handler := h.upstreamUserHandler(h.users, metadata)
+	return http.HandleConnection(ctx, conn, std_bufio.NewReader(conn), authenticator, handler, adapter.UpstreamMetadata(metadata))
 }
 
 func (h *HTTP) NewPacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext) error {
 	return os.ErrInvalid
 }
 
-func (a *myInboundAdapter) upstreamUserHandler(metadata adapter.InboundContext) adapter.UpstreamHandlerAdapter {
-	return adapter.NewUpstreamHandler(metadata, a.newUserConnection, a.streamUserPacketConnection, a)
+// upstreamUserHandler returns the authenticator for a new connection and a
+// handler recording the connections it authenticates to users.
+func (a *myInboundAdapter) upstreamUserHandler(users *authUsers, metadata adapter.InboundContext) (*auth.Authenticator, adapter.UpstreamHandlerAdapter) {
+	state := users.load()
+	return state.authenticator, adapter.NewUpstreamHandler(metadata, func(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
+		return a.newUserConnection(ctx, users, state, conn, metadata)
+	}, func(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext) error {
+		return a.streamUserPacketConnection(ctx, users, state, conn, metadata)
+	}, a)
 }
 
-func (a *myInboundAdapter) newUserConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
+func (a *myInboundAdapter) newUserConnection(ctx context.Context, users *authUsers, state *authState, conn net.Conn, metadata adapter.InboundContext) error {
 	user, loaded := auth.UserFromContext[string](ctx)
 	if !loaded {
 		a.logger.InfoContext(ctx, "inbound connection to ", metadata.Destination)
 		return a.router.RouteConnection(ctx, conn, metadata)
 	}
+	done, err := users.track(state, user, conn)
+	if err != nil {
+		return err
+	}
+	defer done()
 	metadata.User = user
 	a.logger.InfoContext(ctx, "[", user, "] inbound connection to ", metadata.Destination)
 	return a.router.RouteConnection(ctx, conn, metadata)
 }
 
-func (a *myInboundAdapter) streamUserPacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext) error {
+func (a *myInboundAdapter) streamUserPacketConnection(ctx context.Context, users *authUsers, state *authState, conn N.PacketConn, metadata adapter.InboundContext) error {
 	user, loaded := auth.UserFromContext[string](ctx)
 	if !loaded {
 		a.logger.InfoContext(ctx, "inbound packet connection to ", metadata.Destination)
 		return a.router.RoutePacketConnection(ctx, conn, metadata)
 	}
+	done, err := users.track(state, user, conn)
+	if err != nil {
+		return err
+	}
+	defer done()
 	metadata.User = user
 	a.logger.InfoContext(ctx, "[", user, "] inbound packet connection to ", metadata.Destination)
 	return a.router.RoutePacketConnection(ctx, conn, metadata)
 }
+
+func (h *HTTP) Users() []adapter.InboundUser {
+	return h.users.list()
+}
+
+func (h *HTTP) UpdateUsers(users []adapter.InboundUser) error {
+	return h.users.update(users)
+}
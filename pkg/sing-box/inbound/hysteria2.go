@@ -23,13 +23,16 @@ import (
 	N "github.com/sagernet/sing/common/network"
 )
 
-var _ adapter.Inbound = (*Hysteria2)(nil)
+var (
+	_ adapter.Inbound            = (*Hysteria2)(nil)
+	_ adapter.ManagedUserInbound = (*Hysteria2)(nil)
+)
 
 type Hysteria2 struct {
 	myInboundAdapter
-	tlsConfig    tls.ServerConfig
-	service      *hysteria2.Service[int]
-	userNameList []string
+	tlsConfig tls.ServerConfig
+	service   *hysteria2.Service[int]
+	users     userTable
 }
 
 func NewHysteria2(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.Hysteria2InboundOptions) (*Hysteria2, error) {
@@ -110,25 +113,39 @@ func NewHysteria2(ctx context.Context, router adapter.Router, logger log.Context
 	if err != nil {
 		return nil, err
 	}
-	userList := make([]int, 0, len(options.Users))
-	userNameList := make([]string, 0, len(options.Users))
-	userPasswordList := make([]string, 0, len(options.Users))
-	for index, user := range options.Users {
-		userList = append(userList, index)
-		userNameList = append(userNameList, user.Name)
-		userPasswordList = append(userPasswordList, user.Password)
-	}
-	service.UpdateUsers(userList, userPasswordList)
 	inbound.service = service
-	inbound.userNameList = userNameList
+	err = inbound.UpdateUsers(common.Map(options.Users, func(it option.Hysteria2User) adapter.InboundUser {
+		return adapter.InboundUser{Name: it.Name, Password: it.Password}
+	}))
+	if err != nil {
+		return nil, err
+	}
 	return inbound, nil
 }
 
+func (h *Hysteria2) Users() []adapter.InboundUser {
+	return h.users.list()
+}
+
+func (h *Hysteria2) UpdateUsers(users []adapter.InboundUser) error {
+	return h.users.update(users, func(keys []int, users []adapter.InboundUser) error {
+		h.service.UpdateUsers(keys, common.Map(users, func(it adapter.InboundUser) string {
+			return it.Password
+		}))
+		return nil
+	})
+}
+
 func (h *Hysteria2) newConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
 	ctx = log.ContextWithNewID(ctx)
 	metadata = h.createMetadata(conn, metadata)
 	userID, _ := auth.UserFromContext[int](ctx)
-	if userName := h.userNameList[userID]; userName != "" {
+	userName, done, err := h.users.track(userID, conn)
+	if err != nil {
+		return err
+	}
+	defer done()
+	if userName != "" {
 		metadata.User = userName
 		h.logger.InfoContext(ctx, "[", userName, "] inbound connection to ", metadata.Destination)
 	} else {
@@ -141,7 +158,12 @@ func (h *Hysteria2) newPacketConnection(ctx context.Context, conn N.PacketConn,
 	ctx = log.ContextWithNewID(ctx)
 	metadata = h.createPacketMetadata(conn, metadata)
 	userID, _ := auth.UserFromContext[int](ctx)
-	if userName := h.userNameList[userID]; userName != "" {
+	userName, done, err := h.users.track(userID, conn)
+	if err != nil {
+		return err
+	}
+	defer done()
+	if userName != "" {
 		metadata.User = userName
 		h.logger.InfoContext(ctx, "[", userName, "] inbound packet connection to ", metadata.Destination)
 	} else {
@@ -151,6 +173,8 @@ func (h *Hysteria2) newPacketConnection(ctx context.Context, conn N.PacketConn,
 }
 
 func (h *Hysteria2) Start() error {
+	// the service replaces its users without synchronization
+	h.users.seal(E.New("users of hysteria2 inbounds can not be changed after start"))
 	if h.tlsConfig != nil {
 		err := h.tlsConfig.Start()
 		if err != nil {
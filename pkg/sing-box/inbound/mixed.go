@@ -11,7 +11,6 @@ import (
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing-box/option"
-	"github.com/sagernet/sing/common/auth"
 	"github.com/sagernet/sing/common/buf"
 	"github.com/sagernet/sing/common/bufio"
 	N "github.com/sagernet/sing/common/network"
@@ -23,13 +22,14 @@ import (
 )
 
 var (
-	_ adapter.Inbound           = (*Mixed)(nil)
-	_ adapter.InjectableInbound = (*Mixed)(nil)
+	_ adapter.Inbound            = (*Mixed)(nil)
+	_ adapter.InjectableInbound  = (*Mixed)(nil)
+	_ adapter.ManagedUserInbound = (*Mixed)(nil)
 )
 
 type Mixed struct {
 	myInboundAdapter
-	authenticator *auth.Authenticator
+	users *authUsers
 }
 
 func NewMixed(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.HTTPMixedInboundOptions) *Mixed {
@@ -44,7 +44,7 @@ func NewMixed(ctx context.Context, router adapter.Router, logger log.ContextLogg
 			listenOptions:  options.ListenOptions,
 			setSystemProxy: options.SetSystemProxy,
 		},
-		auth.NewAuthenticator(options.Users),
+		newAuthUsers(options.Users),
 	}
 	inbound.connHandler = inbound
 	return inbound
@@ -55,14 +55,23 @@ func (h *Mixed) NewConnection(ctx context.Context, conn net.Conn, metadata adapt
 	if err != nil {
 		return err
 	}
+	authenticator, handler := h.upstreamUserHandler(h.users, metadata)
 	switch headerType {
 	case socks4.Version, socks5.Version:
-		return socks.HandleConnection0(ctx, conn, headerType, h.authenticator, h.upstreamUserHandler(metadata), adapter.UpstreamMetadata(metadata))
+		return socks.HandleConnection0(ctx, conn, headerType, authenticator, handler, adapter.UpstreamMetadata(metadata))
 	}
 	reader := std_bufio.NewReader(bufio.NewCachedReader(conn, buf.As([]byte{headerType})))
-	return http.HandleConnection(ctx, conn, reader, h.authenticator, h.upstreamUserHandler(metadata), adapter.UpstreamMetadata(metadata))
+	return http.HandleConnection(ctx, conn, reader, authenticator, handler, adapter.UpstreamMetadata(metadata))
 }
 
 func (h *Mixed) NewPacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext) error {
 	return os.ErrInvalid
 }
+
+func (h *Mixed) Users() []adapter.InboundUser {
+	return h.users.list()
+}
+
+func (h *Mixed) UpdateUsers(users []adapter.InboundUser) error {
+	return h.users.update(users)
+}
@@ -18,7 +18,6 @@ import (
 	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing-box/option"
 	"github.com/sagernet/sing/common"
-	"github.com/sagernet/sing/common/auth"
 	"github.com/sagernet/sing/common/buf"
 	E "github.com/sagernet/sing/common/exceptions"
 	M "github.com/sagernet/sing/common/metadata"
@@ -27,14 +26,17 @@ import (
 	sHttp "github.com/sagernet/sing/protocol/http"
 )
 
-var _ adapter.Inbound = (*Naive)(nil)
+var (
+	_ adapter.Inbound            = (*Naive)(nil)
+	_ adapter.ManagedUserInbound = (*Naive)(nil)
+)
 
 type Naive struct {
 	myInboundAdapter
-	authenticator *auth.Authenticator
-	tlsConfig     tls.ServerConfig
-	httpServer    *http.Server
-	h3Server      any
+	users      *authUsers
+	tlsConfig  tls.ServerConfig
+	httpServer *http.Server
+	h3Server   any
 }
 
 func NewNaive(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.NaiveInboundOptions) (*Naive, error) {
@@ -48,7 +50,7 @@ func NewNaive(ctx context.Context, router adapter.Router, logger log.ContextLogg
 			tag:           tag,
 			listenOptions: options.ListenOptions,
 		},
-		authenticator: auth.NewAuthenticator(options.Users),
+		users: newAuthUsers(options.Users),
 	}
 	if common.Contains(inbound.network, N.NetworkUDP) {
 		if options.TLS == nil || !options.TLS.Enabled {
@@ -127,6 +129,14 @@ func (n *Naive) Close() error {
 	)
 }
 
+func (n *Naive) Users() []adapter.InboundUser {
+	return n.users.list()
+}
+
+func (n *Naive) UpdateUsers(users []adapter.InboundUser) error {
+	return n.users.update(users)
+}
+
 func (n *Naive) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
 	ctx := log.ContextWithNewID(request.Context())
 	if request.Method != "CONNECT" {
@@ -138,9 +148,10 @@ func (n *Naive) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
 		n.badRequest(ctx, request, E.New("missing naive padding"))
 		return
 	}
+	userState := n.users.load()
 	userName, password, authOk := sHttp.ParseBasicAuth(request.Header.Get("Proxy-Authorization"))
 	if authOk {
-		authOk = n.authenticator.Verify(userName, password)
+		authOk = userState.authenticator.Verify(userName, password)
 	}
 	if !authOk {
 		rejectHTTP(writer, http.StatusProxyAuthRequired)
@@ -164,13 +175,22 @@ func (n *Naive) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
 			n.badRequest(ctx, request, E.New("hijack failed"))
 			return
 		}
-		n.newConnection(ctx, &naiveH1Conn{Conn: conn}, userName, source, destination)
+		n.newConnection(ctx, &naiveH1Conn{Conn: conn}, userState, userName, source, destination)
 	} else {
-		n.newConnection(ctx, &naiveH2Conn{reader: request.Body, writer: writer, flusher: writer.(http.Flusher)}, userName, source, destination)
+		n.newConnection(ctx, &naiveH2Conn{reader: request.Body, writer: writer, flusher: writer.(http.Flusher)}, userState, userName, source, destination)
 	}
 }
 
-func (n *Naive) newConnection(ctx context.Context, conn net.Conn, userName string, source, destination M.Socksaddr) {
+func (n *Naive) newConnection(ctx context.Context, conn net.Conn, userState *authState, userName string, source, destination M.Socksaddr) {
+	if len(userState.users) > 0 {
+		done, err := n.users.track(userState, userName, conn)
+		if err != nil {
+			conn.Close()
+			n.NewError(ctx, E.Cause(err, "process connection from ", source))
+			return
+		}
+		defer done()
+	}
 	if userName != "" {
 		n.logger.InfoContext(ctx, "[", userName, "] inbound connection from ", source)
 		n.logger.InfoContext(ctx, "[", userName, "] inbound connection to ", destination)
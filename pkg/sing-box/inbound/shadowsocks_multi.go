@@ -25,14 +25,16 @@ import (
 )
 
 var (
-	_ adapter.Inbound           = (*ShadowsocksMulti)(nil)
-	_ adapter.InjectableInbound = (*ShadowsocksMulti)(nil)
+	_ adapter.Inbound            = (*ShadowsocksMulti)(nil)
+	_ adapter.InjectableInbound  = (*ShadowsocksMulti)(nil)
+	_ adapter.ManagedUserInbound = (*ShadowsocksMulti)(nil)
 )
 
 type ShadowsocksMulti struct {
 	myInboundAdapter
-	service shadowsocks.MultiService[int]
-	users   []option.ShadowsocksUser
+	service    servicePublisher[shadowsocks.MultiService[int]]
+	newService func() (shadowsocks.MultiService[int], error)
+	users      userTable
 }
 
 func newShadowsocksMulti(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.ShadowsocksInboundOptions) (*ShadowsocksMulti, error) {
@@ -60,58 +62,79 @@ func newShadowsocksMulti(ctx context.Context, router adapter.Router, logger log.
 	} else {
 		udpTimeout = C.UDPTimeout
 	}
-	var service shadowsocks.MultiService[int]
 	if common.Contains(shadowaead_2022.List, options.Method) {
-		service, err = shadowaead_2022.NewMultiServiceWithPassword[int](
-			options.Method,
-			options.Password,
-			int64(udpTimeout.Seconds()),
-			adapter.NewUpstreamContextHandler(inbound.newConnection, inbound.newPacketConnection, inbound),
-			ntp.TimeFuncFromContext(ctx),
-		)
+		inbound.newService = func() (shadowsocks.MultiService[int], error) {
+			return shadowaead_2022.NewMultiServiceWithPassword[int](
+				options.Method,
+				options.Password,
+				int64(udpTimeout.Seconds()),
+				adapter.NewUpstreamContextHandler(inbound.newConnection, inbound.newPacketConnection, inbound),
+				ntp.TimeFuncFromContext(ctx),
+			)
+		}
 	} else if common.Contains(shadowaead.List, options.Method) {
-		service, err = shadowaead.NewMultiService[int](
-			options.Method,
-			int64(udpTimeout.Seconds()),
-			adapter.NewUpstreamContextHandler(inbound.newConnection, inbound.newPacketConnection, inbound))
+		inbound.newService = func() (shadowsocks.MultiService[int], error) {
+			return shadowaead.NewMultiService[int](
+				options.Method,
+				int64(udpTimeout.Seconds()),
+				adapter.NewUpstreamContextHandler(inbound.newConnection, inbound.newPacketConnection, inbound))
+		}
 	} else {
 		return nil, E.New("unsupported method: " + options.Method)
 	}
-	if err != nil {
-		return nil, err
-	}
-	err = service.UpdateUsersWithPasswords(common.MapIndexed(options.Users, func(index int, user option.ShadowsocksUser) int {
-		return index
-	}), common.Map(options.Users, func(user option.ShadowsocksUser) string {
-		return user.Password
+	err = inbound.UpdateUsers(common.Map(options.Users, func(it option.ShadowsocksUser) adapter.InboundUser {
+		return adapter.InboundUser{Name: it.Name, Password: it.Password}
 	}))
 	if err != nil {
 		return nil, err
 	}
-	inbound.service = service
-	inbound.packetUpstream = service
-	inbound.users = options.Users
-	return inbound, err
+	inbound.packetUpstream = inbound.service.load()
+	return inbound, nil
 }
 
 func (h *ShadowsocksMulti) NewConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
-	return h.service.NewConnection(adapter.WithContext(log.ContextWithNewID(ctx), &metadata), conn, adapter.UpstreamMetadata(metadata))
+	return h.service.load().NewConnection(adapter.WithContext(log.ContextWithNewID(ctx), &metadata), conn, adapter.UpstreamMetadata(metadata))
 }
 
 func (h *ShadowsocksMulti) NewPacket(ctx context.Context, conn N.PacketConn, buffer *buf.Buffer, metadata adapter.InboundContext) error {
-	return h.service.NewPacket(adapter.WithContext(ctx, &metadata), conn, buffer, adapter.UpstreamMetadata(metadata))
+	return h.service.load().NewPacket(adapter.WithContext(ctx, &metadata), conn, buffer, adapter.UpstreamMetadata(metadata))
 }
 
 func (h *ShadowsocksMulti) NewPacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext) error {
 	return os.ErrInvalid
 }
 
+func (h *ShadowsocksMulti) Users() []adapter.InboundUser {
+	return h.users.list()
+}
+
+func (h *ShadowsocksMulti) UpdateUsers(users []adapter.InboundUser) error {
+	return h.users.update(users, func(keys []int, users []adapter.InboundUser) error {
+		// the service replaces its users without synchronization
+		service, err := h.newService()
+		if err != nil {
+			return err
+		}
+		err = service.UpdateUsersWithPasswords(keys, common.Map(users, func(it adapter.InboundUser) string {
+			return it.Password
+		}))
+		if err != nil {
+			return err
+		}
+		return h.service.publish(service)
+	})
+}
+
 func (h *ShadowsocksMulti) newConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
 	userIndex, loaded := auth.UserFromContext[int](ctx)
 	if !loaded {
 		return os.ErrInvalid
 	}
-	user := h.users[userIndex].Name
+	user, done, err := h.users.track(userIndex, conn)
+	if err != nil {
+		return err
+	}
+	defer done()
 	if user == "" {
 		user = F.ToString(userIndex)
 	} else {
@@ -126,7 +149,11 @@ func (h *ShadowsocksMulti) newPacketConnection(ctx context.Context, conn N.Packe
 	if !loaded {
 		return os.ErrInvalid
 	}
-	user := h.users[userIndex].Name
+	user, done, err := h.users.track(userIndex, conn)
+	if err != nil {
+		return err
+	}
+	defer done()
 	if user == "" {
 		user = F.ToString(userIndex)
 	} else {
@@ -10,19 +10,19 @@ import (
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing-box/option"
-	"github.com/sagernet/sing/common/auth"
 	N "github.com/sagernet/sing/common/network"
 	"github.com/sagernet/sing/protocol/socks"
 )
 
 var (
-	_ adapter.Inbound           = (*Socks)(nil)
-	_ adapter.InjectableInbound = (*Socks)(nil)
+	_ adapter.Inbound            = (*Socks)(nil)
+	_ adapter.InjectableInbound  = (*Socks)(nil)
+	_ adapter.ManagedUserInbound = (*Socks)(nil)
 )
 
 type Socks struct {
 	myInboundAdapter
-	authenticator *auth.Authenticator
+	users *authUsers
 }
 
 func NewSocks(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.SocksInboundOptions) *Socks {
@@ -36,16 +36,25 @@ func NewSocks(ctx context.Context, router adapter.Router, logger log.ContextLogg
 			tag:           tag,
 			listenOptions: options.ListenOptions,
 		},
-		auth.NewAuthenticator(options.Users),
+		newAuthUsers(options.Users),
 	}
 	inbound.connHandler = inbound
 	return inbound
 }
 
 func (h *Socks) NewConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
-	return socks.HandleConnection(ctx, conn, h.authenticator, h.upstreamUserHandler(metadata), adapter.UpstreamMetadata(metadata))
+	authenticator, handler := h.upstreamUserHandler(h.users, metadata)
+	return socks.HandleConnection(ctx, conn, authenticator, handler, adapter.UpstreamMetadata(metadata))
 }
 
 func (h *Socks) NewPacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext) error {
 	return os.ErrInvalid
 }
+
+func (h *Socks) Users() []adapter.InboundUser {
+	return h.users.list()
+}
+
+func (h *Socks) UpdateUsers(users []adapter.InboundUser) error {
+	return h.users.update(users)
+}
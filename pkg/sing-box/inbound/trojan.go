@@ -22,14 +22,15 @@ import (
 )
 
 var (
-	_ adapter.Inbound           = (*Trojan)(nil)
-	_ adapter.InjectableInbound = (*Trojan)(nil)
+	_ adapter.Inbound            = (*Trojan)(nil)
+	_ adapter.InjectableInbound  = (*Trojan)(nil)
+	_ adapter.ManagedUserInbound = (*Trojan)(nil)
 )
 
 type Trojan struct {
 	myInboundAdapter
 	service                  *trojan.Service[int]
-	users                    []option.TrojanUser
+	users                    userTable
 	tlsConfig                tls.ServerConfig
 	fallbackAddr             M.Socksaddr
 	fallbackAddrTLSNextProto map[string]M.Socksaddr
@@ -47,7 +48,6 @@ func NewTrojan(ctx context.Context, router adapter.Router, logger log.ContextLog
 			tag:           tag,
 			listenOptions: options.ListenOptions,
 		},
-		users: options.Users,
 	}
 	if options.TLS != nil {
 		tlsConfig, err := tls.NewServer(ctx, logger, common.PtrValueOrDefault(options.TLS))
@@ -81,10 +81,9 @@ func NewTrojan(ctx context.Context, router adapter.Router, logger log.ContextLog
 		fallbackHandler = adapter.NewUpstreamContextHandler(inbound.fallbackConnection, nil, nil)
 	}
 	service := trojan.NewService[int](adapter.NewUpstreamContextHandler(inbound.newConnection, inbound.newPacketConnection, inbound), fallbackHandler)
-	err := service.UpdateUsers(common.MapIndexed(options.Users, func(index int, it option.TrojanUser) int {
-		return index
-	}), common.Map(options.Users, func(it option.TrojanUser) string {
-		return it.Password
+	inbound.service = service
+	err := inbound.UpdateUsers(common.Map(options.Users, func(it option.TrojanUser) adapter.InboundUser {
+		return adapter.InboundUser{Name: it.Name, Password: it.Password}
 	}))
 	if err != nil {
 		return nil, err
@@ -99,7 +98,6 @@ func NewTrojan(ctx context.Context, router adapter.Router, logger log.ContextLog
 	if err != nil {
 		return nil, err
 	}
-	inbound.service = service
 	inbound.connHandler = inbound
 	return inbound, nil
 }
@@ -169,12 +167,28 @@ func (h *Trojan) NewPacketConnection(ctx context.Context, conn N.PacketConn, met
 	return os.ErrInvalid
 }
 
+func (h *Trojan) Users() []adapter.InboundUser {
+	return h.users.list()
+}
+
+func (h *Trojan) UpdateUsers(users []adapter.InboundUser) error {
+	return h.users.update(users, func(keys []int, users []adapter.InboundUser) error {
+		return h.service.UpdateUsers(keys, common.Map(users, func(it adapter.InboundUser) string {
+			return it.Password
+		}))
+	})
+}
+
 func (h *Trojan) newConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
 	userIndex, loaded := auth.UserFromContext[int](ctx)
 	if !loaded {
 		return os.ErrInvalid
 	}
-	user := h.users[userIndex].Name
+	user, done, err := h.users.track(userIndex, conn)
+	if err != nil {
+		return err
+	}
+	defer done()
 	if user == "" {
 		user = F.ToString(userIndex)
 	} else {
@@ -212,7 +226,11 @@ func (h *Trojan) newPacketConnection(ctx context.Context, conn N.PacketConn, met
 	if !loaded {
 		return os.ErrInvalid
 	}
-	user := h.users[userIndex].Name
+	user, done, err := h.users.track(userIndex, conn)
+	if err != nil {
+		return err
+	}
+	defer done()
 	if user == "" {
 		user = F.ToString(userIndex)
 	} else {
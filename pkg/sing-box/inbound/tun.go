@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/capture"
 	"github.com/sagernet/sing-box/common/taskmonitor"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/experimental/libbox/platform"
@@ -19,6 +20,7 @@ import (
 	M "github.com/sagernet/sing/common/metadata"
 	N "github.com/sagernet/sing/common/network"
 	"github.com/sagernet/sing/common/ranges"
+	"github.com/sagernet/sing/service"
 )
 
 var _ adapter.Inbound = (*Tun)(nil)
@@ -164,6 +166,13 @@ func (t *Tun) Start() error {
 	if err != nil {
 		return E.Cause(err, "configure tun interface")
 	}
+	if captureManager := service.PtrFromContext[capture.Manager](t.ctx); captureManager != nil {
+		if t.stack == "gvisor" {
+			captureManager.AddUnsupportedTun(t.tag)
+		} else {
+			tunInterface = captureManager.WrapTun(tunInterface)
+		}
+	}
 	t.logger.Trace("creating stack")
 	t.tunIf = tunInterface
 	t.tunStack, err = tun.NewStack(t.stack, tun.StackOptions{
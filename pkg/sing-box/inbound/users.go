@@ -0,0 +1,287 @@
+package inbound
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/auth"
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+// userConns holds the open connections of each user, so they can be closed
+// when the user is removed or its credentials change.
+type userConns[K comparable] map[K]map[io.Closer]struct{}
+
+func (c userConns[K]) add(key K, conn io.Closer) {
+	conns := c[key]
+	if conns == nil {
+		conns = make(map[io.Closer]struct{})
+		c[key] = conns
+	}
+	conns[conn] = struct{}{}
+}
+
+func (c userConns[K]) remove(key K, conn io.Closer) {
+	delete(c[key], conn)
+	if len(c[key]) == 0 {
+		delete(c, key)
+	}
+}
+
+// drop removes the connections of key and returns them to be closed
+// outside of the lock.
+func (c userConns[K]) drop(key K) []io.Closer {
+	var conns []io.Closer
+	for conn := range c[key] {
+		conns = append(conns, conn)
+	}
+	delete(c, key)
+	return conns
+}
+
+func closeConns(conns []io.Closer) {
+	for _, conn := range conns {
+		conn.Close()
+	}
+}
+
+// userTable tracks the users of a multi-user inbound by the keys handed to
+// the protocol service. Keys are never reused, so a connection authenticated
+// before an update can not be attributed to another user.
+type userTable struct {
+	access  sync.RWMutex
+	users   []adapter.InboundUser
+	keys    []int
+	userMap map[int]adapter.InboundUser
+	nextKey int
+	conns   userConns[int]
+	sealed  error
+}
+
+func (t *userTable) list() []adapter.InboundUser {
+	t.access.RLock()
+	defer t.access.RUnlock()
+	return append([]adapter.InboundUser(nil), t.users...)
+}
+
+// seal makes later updates fail with err, for protocol services which
+// can not replace their users while serving.
+func (t *userTable) seal(err error) {
+	t.access.Lock()
+	defer t.access.Unlock()
+	t.sealed = err
+}
+
+// update replaces the users and calls apply with their keys. Unchanged users
+// keep their keys, connections of removed or changed users are closed.
+// The table is left untouched if apply fails.
+func (t *userTable) update(users []adapter.InboundUser, apply func(keys []int, users []adapter.InboundUser) error) error {
+	t.access.Lock()
+	if t.sealed != nil {
+		t.access.Unlock()
+		return t.sealed
+	}
+	unusedKeys := make(map[adapter.InboundUser][]int)
+	for index, user := range t.users {
+		unusedKeys[user] = append(unusedKeys[user], t.keys[index])
+	}
+	nextKey := t.nextKey
+	keys := make([]int, len(users))
+	userMap := make(map[int]adapter.InboundUser, len(users))
+	for index, user := range users {
+		if existsKeys := unusedKeys[user]; len(existsKeys) > 0 {
+			keys[index] = existsKeys[0]
+			unusedKeys[user] = existsKeys[1:]
+		} else {
+			keys[index] = nextKey
+			nextKey++
+		}
+		userMap[keys[index]] = user
+	}
+	err := apply(keys, users)
+	if err != nil {
+		t.access.Unlock()
+		return err
+	}
+	var removedConns []io.Closer
+	for _, removedKeys := range unusedKeys {
+		for _, key := range removedKeys {
+			removedConns = append(removedConns, t.conns.drop(key)...)
+		}
+	}
+	t.users = users
+	t.keys = keys
+	t.userMap = userMap
+	t.nextKey = nextKey
+	t.access.Unlock()
+	closeConns(removedConns)
+	return nil
+}
+
+// track returns the name of the user with key and records conn until done
+// is called. It fails if the user has been removed.
+func (t *userTable) track(key int, conn io.Closer) (name string, done func(), err error) {
+	t.access.Lock()
+	defer t.access.Unlock()
+	user, loaded := t.userMap[key]
+	if !loaded {
+		return "", nil, E.New("user ", key, " removed")
+	}
+	if t.conns == nil {
+		t.conns = make(userConns[int])
+	}
+	t.conns.add(key, conn)
+	return user.Name, func() {
+		t.access.Lock()
+		defer t.access.Unlock()
+		t.conns.remove(key, conn)
+	}, nil
+}
+
+// servicePublisher holds a protocol service which can not replace its users
+// safely while serving, so a new one is built for each update and published
+// as a whole. The previous one keeps serving the connections it accepted.
+type servicePublisher[S any] struct {
+	access  sync.Mutex
+	service atomic.Pointer[S]
+	started bool
+}
+
+func (p *servicePublisher[S]) load() S {
+	return *p.service.Load()
+}
+
+// publish starts service if the inbound has been started and replaces
+// the previous one.
+func (p *servicePublisher[S]) publish(service S) error {
+	p.access.Lock()
+	defer p.access.Unlock()
+	if p.started {
+		err := common.Start(service)
+		if err != nil {
+			return err
+		}
+	}
+	oldService := p.service.Swap(&service)
+	if p.started && oldService != nil {
+		common.Close(*oldService)
+	}
+	return nil
+}
+
+func (p *servicePublisher[S]) Start() error {
+	p.access.Lock()
+	defer p.access.Unlock()
+	p.started = true
+	return common.Start(p.load())
+}
+
+func (p *servicePublisher[S]) Close() error {
+	p.access.Lock()
+	defer p.access.Unlock()
+	p.started = false
+	return common.Close(p.load())
+}
+
+// authUsers holds the users of an inbound authenticating with username and
+// password, where the user name is the username.
+type authUsers struct {
+	access sync.Mutex
+	state  atomic.Pointer[authState]
+	conns  userConns[string]
+}
+
+// authState is the authenticator of one update. Each user records the
+// update which last changed it, so connections authenticated before can
+// be told apart.
+type authState struct {
+	users         []auth.User
+	authenticator *auth.Authenticator
+	updates       map[string]int
+	update        int
+}
+
+func newAuthUsers(users []auth.User) *authUsers {
+	a := &authUsers{conns: make(userConns[string])}
+	updates := make(map[string]int)
+	for _, user := range users {
+		updates[user.Username] = 0
+	}
+	a.state.Store(&authState{
+		users:         users,
+		authenticator: auth.NewAuthenticator(users),
+		updates:       updates,
+	})
+	return a
+}
+
+// load returns the current state, whose authenticator verifies
+// new connections.
+func (a *authUsers) load() *authState {
+	return a.state.Load()
+}
+
+func (a *authUsers) list() []adapter.InboundUser {
+	return common.Map(a.load().users, func(it auth.User) adapter.InboundUser {
+		return adapter.InboundUser{Name: it.Username, Password: it.Password}
+	})
+}
+
+// update replaces the users and closes connections of removed users
+// and users whose password changed.
+func (a *authUsers) update(users []adapter.InboundUser) error {
+	a.access.Lock()
+	state := a.load()
+	if len(users) == 0 && len(state.users) > 0 {
+		a.access.Unlock()
+		return E.New("removing all users would disable authentication")
+	}
+	newState := &authState{
+		users: common.Map(users, func(it adapter.InboundUser) auth.User {
+			return auth.User{Username: it.Name, Password: it.Password}
+		}),
+		updates: make(map[string]int, len(users)),
+		update:  state.update + 1,
+	}
+	newState.authenticator = auth.NewAuthenticator(newState.users)
+	oldPasswords := make(map[string]string, len(state.users))
+	for _, user := range state.users {
+		oldPasswords[user.Username] = user.Password
+	}
+	for _, user := range newState.users {
+		if oldPassword, loaded := oldPasswords[user.Username]; loaded && oldPassword == user.Password {
+			newState.updates[user.Username] = state.updates[user.Username]
+			delete(oldPasswords, user.Username)
+		} else {
+			newState.updates[user.Username] = newState.update
+		}
+	}
+	a.state.Store(newState)
+	var removedConns []io.Closer
+	for name := range oldPasswords {
+		removedConns = append(removedConns, a.conns.drop(name)...)
+	}
+	a.access.Unlock()
+	closeConns(removedConns)
+	return nil
+}
+
+// track records conn of the user authenticated with state until done is
+// called. It fails if the user has been removed or changed since.
+func (a *authUsers) track(state *authState, name string, conn io.Closer) (done func(), err error) {
+	a.access.Lock()
+	defer a.access.Unlock()
+	update, loaded := a.load().updates[name]
+	if !loaded || update > state.update {
+		return nil, E.New("user ", name, " removed")
+	}
+	a.conns.add(name, conn)
+	return func() {
+		a.access.Lock()
+		defer a.access.Unlock()
+		a.conns.remove(name, conn)
+	}, nil
+}
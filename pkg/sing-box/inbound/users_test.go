@@ -0,0 +1,181 @@
+package inbound
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/auth"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	"github.com/sagernet/sing/protocol/socks"
+	"github.com/sagernet/sing/protocol/socks/socks5"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testUserRouter struct {
+	adapter.Router
+	users chan string
+}
+
+func (r *testUserRouter) RouteConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
+	r.users <- metadata.User
+	_, err := io.Copy(io.Discard, conn)
+	return err
+}
+
+type testCloser struct {
+	closed bool
+}
+
+func (c *testCloser) Close() error {
+	c.closed = true
+	return nil
+}
+
+type testService struct {
+	started bool
+	closed  bool
+}
+
+func (s *testService) Start() error {
+	s.started = true
+	return nil
+}
+
+func (s *testService) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestUserTable(t *testing.T) {
+	t.Parallel()
+	var table userTable
+	var appliedKeys []int
+	apply := func(keys []int, users []adapter.InboundUser) error {
+		appliedKeys = keys
+		return nil
+	}
+	alice := adapter.InboundUser{Name: "alice", Password: "a"}
+	bob := adapter.InboundUser{Name: "bob", Password: "b"}
+	require.NoError(t, table.update([]adapter.InboundUser{alice, bob}, apply))
+	require.Equal(t, []int{0, 1}, appliedKeys)
+	aliceConn, bobConn := &testCloser{}, &testCloser{}
+	name, aliceDone, err := table.track(0, aliceConn)
+	require.NoError(t, err)
+	require.Equal(t, "alice", name)
+	_, _, err = table.track(1, bobConn)
+	require.NoError(t, err)
+
+	// unchanged users keep their keys and connections, changed users get a new key
+	bob.Password = "c"
+	require.NoError(t, table.update([]adapter.InboundUser{alice, bob}, apply))
+	require.Equal(t, []int{0, 2}, appliedKeys)
+	require.False(t, aliceConn.closed)
+	require.True(t, bobConn.closed)
+	_, _, err = table.track(1, &testCloser{})
+	require.Error(t, err)
+
+	// failed updates leave the table untouched
+	require.Error(t, table.update(nil, func(keys []int, users []adapter.InboundUser) error {
+		return E.New("rejected")
+	}))
+	require.Equal(t, []adapter.InboundUser{alice, bob}, table.list())
+	require.False(t, aliceConn.closed)
+
+	// finished connections are not closed again
+	aliceDone()
+	require.NoError(t, table.update([]adapter.InboundUser{bob}, apply))
+	require.Equal(t, []int{2}, appliedKeys)
+	require.False(t, aliceConn.closed)
+
+	table.seal(E.New("sealed"))
+	require.EqualError(t, table.update(nil, apply), "sealed")
+	require.Equal(t, []adapter.InboundUser{bob}, table.list())
+}
+
+func TestAuthUsers(t *testing.T) {
+	t.Parallel()
+	users := newAuthUsers([]auth.User{{Username: "alice", Password: "a"}, {Username: "bob", Password: "b"}})
+	state := users.load()
+	require.True(t, state.authenticator.Verify("alice", "a"))
+	aliceConn, bobConn := &testCloser{}, &testCloser{}
+	_, err := users.track(state, "alice", aliceConn)
+	require.NoError(t, err)
+	_, err = users.track(state, "bob", bobConn)
+	require.NoError(t, err)
+
+	require.NoError(t, users.update([]adapter.InboundUser{{Name: "alice", Password: "a"}, {Name: "bob", Password: "c"}}))
+	require.False(t, aliceConn.closed)
+	require.True(t, bobConn.closed)
+	// connections authenticated with the previous password are rejected
+	_, err = users.track(state, "bob", &testCloser{})
+	require.Error(t, err)
+	_, err = users.track(users.load(), "bob", &testCloser{})
+	require.NoError(t, err)
+	require.False(t, users.load().authenticator.Verify("bob", "b"))
+
+	require.Error(t, users.update(nil))
+	require.Len(t, users.list(), 2)
+}
+
+func TestServicePublisher(t *testing.T) {
+	t.Parallel()
+	var publisher servicePublisher[*testService]
+	first := &testService{}
+	require.NoError(t, publisher.publish(first))
+	require.False(t, first.started)
+	require.NoError(t, publisher.Start())
+	require.True(t, first.started)
+
+	// services published after start are started and replace the previous one
+	second := &testService{}
+	require.NoError(t, publisher.publish(second))
+	require.True(t, second.started)
+	require.True(t, first.closed)
+	require.Equal(t, second, publisher.load())
+	require.NoError(t, publisher.Close())
+	require.True(t, second.closed)
+}
+
+func TestSocksRemoveUser(t *testing.T) {
+	t.Parallel()
+	router := &testUserRouter{users: make(chan string, 1)}
+	inbound := NewSocks(context.Background(), router, log.NewNOPFactory().Logger(), "socks-in", option.SocksInboundOptions{
+		Users: []auth.User{{Username: "alice", Password: "a"}, {Username: "bob", Password: "b"}},
+	})
+	connect := func(username string, password string) (net.Conn, chan error) {
+		client, server := net.Pipe()
+		t.Cleanup(func() {
+			client.Close()
+		})
+		done := make(chan error, 1)
+		go func() {
+			done <- inbound.NewConnection(context.Background(), server, adapter.InboundContext{})
+		}()
+		go socks.ClientHandshake5(client, socks5.CommandConnect, M.ParseSocksaddr("example.com:443"), username, password)
+		require.Equal(t, username, <-router.users)
+		return client, done
+	}
+	_, aliceDone := connect("alice", "a")
+	_, bobDone := connect("bob", "b")
+
+	require.NoError(t, inbound.UpdateUsers([]adapter.InboundUser{{Name: "bob", Password: "b"}}))
+	select {
+	case <-aliceDone:
+	case <-time.After(time.Second):
+		t.Fatal("connection of removed user not closed")
+	}
+	select {
+	case <-bobDone:
+		t.Fatal("connection of remaining user closed")
+	case <-time.After(100 * time.Millisecond):
+	}
+	require.Equal(t, []adapter.InboundUser{{Name: "bob", Password: "b"}}, inbound.Users())
+}
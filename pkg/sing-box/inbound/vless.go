@@ -25,14 +25,15 @@ import (
 )
 
 var (
-	_ adapter.Inbound           = (*VLESS)(nil)
-	_ adapter.InjectableInbound = (*VLESS)(nil)
+	_ adapter.Inbound            = (*VLESS)(nil)
+	_ adapter.InjectableInbound  = (*VLESS)(nil)
+	_ adapter.ManagedUserInbound = (*VLESS)(nil)
 )
 
 type VLESS struct {
 	myInboundAdapter
 	ctx       context.Context
-	users     []option.VLESSUser
+	users     userTable
 	service   *vless.Service[int]
 	tlsConfig tls.ServerConfig
 	transport adapter.V2RayServerTransport
@@ -49,8 +50,7 @@ func NewVLESS(ctx context.Context, router adapter.Router, logger log.ContextLogg
 			tag:           tag,
 			listenOptions: options.ListenOptions,
 		},
-		ctx:   ctx,
-		users: options.Users,
+		ctx: ctx,
 	}
 	var err error
 	inbound.router, err = mux.NewRouterWithOptions(inbound.router, logger, common.PtrValueOrDefault(options.Multiplex))
@@ -58,14 +58,13 @@ func NewVLESS(ctx context.Context, router adapter.Router, logger log.ContextLogg
 		return nil, err
 	}
 	service := vless.NewService[int](logger, adapter.NewUpstreamContextHandler(inbound.newConnection, inbound.newPacketConnection, inbound))
-	service.UpdateUsers(common.MapIndexed(inbound.users, func(index int, _ option.VLESSUser) int {
-		return index
-	}), common.Map(inbound.users, func(it option.VLESSUser) string {
-		return it.UUID
-	}), common.Map(inbound.users, func(it option.VLESSUser) string {
-		return it.Flow
-	}))
 	inbound.service = service
+	err = inbound.UpdateUsers(common.Map(options.Users, func(it option.VLESSUser) adapter.InboundUser {
+		return adapter.InboundUser{Name: it.Name, UUID: it.UUID, Flow: it.Flow}
+	}))
+	if err != nil {
+		return nil, err
+	}
 	if options.TLS != nil {
 		inbound.tlsConfig, err = tls.NewServer(ctx, logger, common.PtrValueOrDefault(options.TLS))
 		if err != nil {
@@ -149,12 +148,31 @@ func (h *VLESS) NewPacketConnection(ctx context.Context, conn N.PacketConn, meta
 	return os.ErrInvalid
 }
 
+func (h *VLESS) Users() []adapter.InboundUser {
+	return h.users.list()
+}
+
+func (h *VLESS) UpdateUsers(users []adapter.InboundUser) error {
+	return h.users.update(users, func(keys []int, users []adapter.InboundUser) error {
+		h.service.UpdateUsers(keys, common.Map(users, func(it adapter.InboundUser) string {
+			return it.UUID
+		}), common.Map(users, func(it adapter.InboundUser) string {
+			return it.Flow
+		}))
+		return nil
+	})
+}
+
 func (h *VLESS) newConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
 	userIndex, loaded := auth.UserFromContext[int](ctx)
 	if !loaded {
 		return os.ErrInvalid
 	}
-	user := h.users[userIndex].Name
+	user, done, err := h.users.track(userIndex, conn)
+	if err != nil {
+		return err
+	}
+	defer done()
 	if user == "" {
 		user = F.ToString(userIndex)
 	} else {
@@ -169,7 +187,11 @@ func (h *VLESS) newPacketConnection(ctx context.Context, conn N.PacketConn, meta
 	if !loaded {
 		return os.ErrInvalid
 	}
-	user := h.users[userIndex].Name
+	user, done, err := h.users.track(userIndex, conn)
+	if err != nil {
+		return err
+	}
+	defer done()
 	if user == "" {
 		user = F.ToString(userIndex)
 	} else {
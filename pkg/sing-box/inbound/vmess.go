@@ -25,17 +25,19 @@ import (
 )
 
 var (
-	_ adapter.Inbound           = (*VMess)(nil)
-	_ adapter.InjectableInbound = (*VMess)(nil)
+	_ adapter.Inbound            = (*VMess)(nil)
+	_ adapter.InjectableInbound  = (*VMess)(nil)
+	_ adapter.ManagedUserInbound = (*VMess)(nil)
 )
 
 type VMess struct {
 	myInboundAdapter
-	ctx       context.Context
-	service   *vmess.Service[int]
-	users     []option.VMessUser
-	tlsConfig tls.ServerConfig
-	transport adapter.V2RayServerTransport
+	ctx        context.Context
+	service    servicePublisher[*vmess.Service[int]]
+	newService func() *vmess.Service[int]
+	users      userTable
+	tlsConfig  tls.ServerConfig
+	transport  adapter.V2RayServerTransport
 }
 
 func NewVMess(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.VMessInboundOptions) (*VMess, error) {
@@ -49,8 +51,7 @@ func NewVMess(ctx context.Context, router adapter.Router, logger log.ContextLogg
 			tag:           tag,
 			listenOptions: options.ListenOptions,
 		},
-		ctx:   ctx,
-		users: options.Users,
+		ctx: ctx,
 	}
 	var err error
 	inbound.router, err = mux.NewRouterWithOptions(inbound.router, logger, common.PtrValueOrDefault(options.Multiplex))
@@ -64,14 +65,11 @@ func NewVMess(ctx context.Context, router adapter.Router, logger log.ContextLogg
 	if options.Transport != nil && options.Transport.Type != "" {
 		serviceOptions = append(serviceOptions, vmess.ServiceWithDisableHeaderProtection())
 	}
-	service := vmess.NewService[int](adapter.NewUpstreamContextHandler(inbound.newConnection, inbound.newPacketConnection, inbound), serviceOptions...)
-	inbound.service = service
-	err = service.UpdateUsers(common.MapIndexed(options.Users, func(index int, it option.VMessUser) int {
-		return index
-	}), common.Map(options.Users, func(it option.VMessUser) string {
-		return it.UUID
-	}), common.Map(options.Users, func(it option.VMessUser) int {
-		return it.AlterId
+	inbound.newService = func() *vmess.Service[int] {
+		return vmess.NewService[int](adapter.NewUpstreamContextHandler(inbound.newConnection, inbound.newPacketConnection, inbound), serviceOptions...)
+	}
+	err = inbound.UpdateUsers(common.Map(options.Users, func(it option.VMessUser) adapter.InboundUser {
+		return adapter.InboundUser{Name: it.Name, UUID: it.UUID, AlterID: it.AlterId}
 	}))
 	if err != nil {
 		return nil, err
@@ -94,7 +92,7 @@ func NewVMess(ctx context.Context, router adapter.Router, logger log.ContextLogg
 
 func (h *VMess) Start() error {
 	err := common.Start(
-		h.service,
+		&h.service,
 		h.tlsConfig,
 	)
 	if err != nil {
@@ -132,7 +130,7 @@ func (h *VMess) Start() error {
 
 func (h *VMess) Close() error {
 	return common.Close(
-		h.service,
+		&h.service,
 		&h.myInboundAdapter,
 		h.tlsConfig,
 		h.transport,
@@ -152,19 +150,44 @@ func (h *VMess) NewConnection(ctx context.Context, conn net.Conn, metadata adapt
 			return err
 		}
 	}
-	return h.service.NewConnection(adapter.WithContext(log.ContextWithNewID(ctx), &metadata), conn, adapter.UpstreamMetadata(metadata))
+	return h.service.load().NewConnection(adapter.WithContext(log.ContextWithNewID(ctx), &metadata), conn, adapter.UpstreamMetadata(metadata))
 }
 
 func (h *VMess) NewPacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext) error {
 	return os.ErrInvalid
 }
 
+func (h *VMess) Users() []adapter.InboundUser {
+	return h.users.list()
+}
+
+func (h *VMess) UpdateUsers(users []adapter.InboundUser) error {
+	return h.users.update(users, func(keys []int, users []adapter.InboundUser) error {
+		// the service replaces its users without synchronization, and only
+		// refreshes legacy keys of alterId users present when it is started
+		service := h.newService()
+		err := service.UpdateUsers(keys, common.Map(users, func(it adapter.InboundUser) string {
+			return it.UUID
+		}), common.Map(users, func(it adapter.InboundUser) int {
+			return it.AlterID
+		}))
+		if err != nil {
+			return err
+		}
+		return h.service.publish(service)
+	})
+}
+
 func (h *VMess) newConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
 	userIndex, loaded := auth.UserFromContext[int](ctx)
 	if !loaded {
 		return os.ErrInvalid
 	}
-	user := h.users[userIndex].Name
+	user, done, err := h.users.track(userIndex, conn)
+	if err != nil {
+		return err
+	}
+	defer done()
 	if user == "" {
 		user = F.ToString(userIndex)
 	} else {
@@ -179,7 +202,11 @@ func (h *VMess) newPacketConnection(ctx context.Context, conn N.PacketConn, meta
 	if !loaded {
 		return os.ErrInvalid
 	}
-	user := h.users[userIndex].Name
+	user, done, err := h.users.track(userIndex, conn)
+	if err != nil {
+		return err
+	}
+	defer done()
 	if user == "" {
 		user = F.ToString(userIndex)
 	} else {
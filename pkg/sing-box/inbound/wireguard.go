@@ -0,0 +1,225 @@
+//go:build with_wireguard
+
+package inbound
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/transport/wireguard"
+	"github.com/sagernet/sing-tun"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/wireguard-go/device"
+)
+
+var (
+	_ adapter.Inbound          = (*WireGuard)(nil)
+	_ adapter.WireGuardInbound = (*WireGuard)(nil)
+)
+
+type WireGuard struct {
+	myInboundAdapter
+	workers                int
+	udpTimeout             int64
+	endpointIndependentNat bool
+	ipcConf                string
+	peers                  wireGuardPeers
+	tunDevice              *wireguard.ServerDevice
+	tunStack               tun.Stack
+	device                 *device.Device
+}
+
+func NewWireGuard(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.WireGuardInboundOptions) (*WireGuard, error) {
+	inbound := &WireGuard{
+		myInboundAdapter: myInboundAdapter{
+			protocol:      C.TypeWireGuard,
+			network:       []string{N.NetworkUDP},
+			ctx:           ctx,
+			router:        router,
+			logger:        logger,
+			tag:           tag,
+			listenOptions: options.ListenOptions,
+		},
+		workers:                options.Workers,
+		endpointIndependentNat: options.EndpointIndependentNat,
+	}
+	if !tun.WithGVisor {
+		return nil, E.New("gVisor is required by WireGuard inbound, rebuild with -tags with_gvisor")
+	}
+	if len(options.Peers) == 0 {
+		return nil, E.New("missing peers")
+	}
+	privateKey, err := decodeWireGuardKey(options.PrivateKey)
+	if err != nil {
+		return nil, E.Cause(err, "decode private key")
+	}
+	ipcConf := "private_key=" + privateKey
+	for peerIndex, rawPeer := range options.Peers {
+		publicKey, err := decodeWireGuardKey(rawPeer.PublicKey)
+		if err != nil {
+			return nil, E.Cause(err, "decode public key for peer ", peerIndex)
+		}
+		if len(rawPeer.AllowedIPs) == 0 {
+			return nil, E.New("missing allowed_ips for peer ", peerIndex)
+		}
+		ipcConf += "\npublic_key=" + publicKey
+		if rawPeer.PreSharedKey != "" {
+			preSharedKey, err := decodeWireGuardKey(rawPeer.PreSharedKey)
+			if err != nil {
+				return nil, E.Cause(err, "decode pre shared key for peer ", peerIndex)
+			}
+			ipcConf += "\npreshared_key=" + preSharedKey
+		}
+		if rawPeer.PersistentKeepaliveInterval > 0 {
+			ipcConf += "\npersistent_keepalive_interval=" + F.ToString(rawPeer.PersistentKeepaliveInterval)
+		}
+		for _, allowedIP := range rawPeer.AllowedIPs {
+			ipcConf += "\nallowed_ip=" + allowedIP.String()
+		}
+		inbound.peers = append(inbound.peers, wireGuardPeer{
+			name:       rawPeer.Name,
+			publicKey:  rawPeer.PublicKey,
+			hexKey:     publicKey,
+			allowedIPs: rawPeer.AllowedIPs,
+		})
+	}
+	err = inbound.peers.checkAllowedIPs()
+	if err != nil {
+		return nil, err
+	}
+	inbound.ipcConf = ipcConf
+	var udpTimeout time.Duration
+	if options.UDPTimeout != 0 {
+		udpTimeout = time.Duration(options.UDPTimeout)
+	} else {
+		udpTimeout = C.UDPTimeout
+	}
+	inbound.udpTimeout = int64(udpTimeout.Seconds())
+	mtu := options.MTU
+	if mtu == 0 {
+		mtu = 1408
+	}
+	inbound.tunDevice, err = wireguard.NewServerDevice(mtu)
+	if err != nil {
+		return nil, E.Cause(err, "create WireGuard device")
+	}
+	return inbound, nil
+}
+
+func decodeWireGuardKey(key string) (string, error) {
+	bytes, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+func (w *WireGuard) Start() error {
+	tunStack, err := tun.NewStack("gvisor", tun.StackOptions{
+		Context:                w.ctx,
+		Tun:                    w.tunDevice.Tun(),
+		EndpointIndependentNat: w.endpointIndependentNat,
+		UDPTimeout:             w.udpTimeout,
+		Handler:                w,
+		Logger:                 w.logger,
+	})
+	if err != nil {
+		return err
+	}
+	err = tunStack.Start()
+	if err != nil {
+		return err
+	}
+	w.tunStack = tunStack
+	wgDevice := device.NewDevice(w.tunDevice, wireguard.NewServerBind(w.ListenUDP), &device.Logger{
+		Verbosef: func(format string, args ...interface{}) {
+			w.logger.Debug(fmt.Sprintf(strings.ToLower(format), args...))
+		},
+		Errorf: func(format string, args ...interface{}) {
+			w.logger.Error(fmt.Sprintf(strings.ToLower(format), args...))
+		},
+	}, w.workers)
+	w.device = wgDevice
+	err = wgDevice.IpcSet(w.ipcConf)
+	if err != nil {
+		return E.Cause(err, "setup wireguard")
+	}
+	return w.tunDevice.Start()
+}
+
+func (w *WireGuard) Close() error {
+	if w.device != nil {
+		w.device.Close()
+	}
+	return common.Close(
+		w.tunStack,
+		common.PtrOrNil(w.tunDevice),
+	)
+}
+
+func (w *WireGuard) NewConnection(ctx context.Context, conn net.Conn, upstreamMetadata M.Metadata) error {
+	ctx = log.ContextWithNewID(ctx)
+	metadata := w.createPeerMetadata(upstreamMetadata)
+	if metadata.User != "" {
+		w.logger.InfoContext(ctx, "[", metadata.User, "] inbound connection from ", metadata.Source)
+	} else {
+		w.logger.InfoContext(ctx, "inbound connection from ", metadata.Source)
+	}
+	w.logger.InfoContext(ctx, "inbound connection to ", metadata.Destination)
+	err := w.router.RouteConnection(ctx, conn, metadata)
+	if err != nil {
+		w.NewError(ctx, err)
+	}
+	return nil
+}
+
+func (w *WireGuard) NewPacketConnection(ctx context.Context, conn N.PacketConn, upstreamMetadata M.Metadata) error {
+	ctx = log.ContextWithNewID(ctx)
+	metadata := w.createPeerMetadata(upstreamMetadata)
+	if metadata.User != "" {
+		w.logger.InfoContext(ctx, "[", metadata.User, "] inbound packet connection from ", metadata.Source)
+	} else {
+		w.logger.InfoContext(ctx, "inbound packet connection from ", metadata.Source)
+	}
+	w.logger.InfoContext(ctx, "inbound packet connection to ", metadata.Destination)
+	err := w.router.RoutePacketConnection(ctx, conn, metadata)
+	if err != nil {
+		w.NewError(ctx, err)
+	}
+	return nil
+}
+
+func (w *WireGuard) createPeerMetadata(upstreamMetadata M.Metadata) adapter.InboundContext {
+	var metadata adapter.InboundContext
+	metadata.Inbound = w.tag
+	metadata.InboundType = C.TypeWireGuard
+	metadata.Source = upstreamMetadata.Source
+	metadata.Destination = upstreamMetadata.Destination
+	metadata.InboundOptions = w.listenOptions.InboundOptions
+	metadata.User = w.peers.lookup(metadata.Source.Addr.Unmap())
+	return metadata
+}
+
+func (w *WireGuard) Peers() []adapter.WireGuardPeer {
+	if w.device == nil {
+		return w.peers.status("")
+	}
+	ipcConf, err := w.device.IpcGet()
+	if err != nil {
+		w.logger.Error(E.Cause(err, "get wireguard status"))
+	}
+	return w.peers.status(ipcConf)
+}
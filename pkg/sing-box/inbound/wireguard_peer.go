@@ -0,0 +1,100 @@
+package inbound
+
+import (
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+type wireGuardPeer struct {
+	name       string
+	publicKey  string
+	hexKey     string
+	allowedIPs []netip.Prefix
+}
+
+// wireGuardPeers are the peers of a WireGuard inbound in configuration order.
+type wireGuardPeers []wireGuardPeer
+
+// checkAllowedIPs rejects prefixes listed by several peers,
+// as the device routes such a prefix to the last of them only.
+func (p wireGuardPeers) checkAllowedIPs() error {
+	peerByPrefix := make(map[netip.Prefix]int)
+	for peerIndex, peer := range p {
+		for _, prefix := range peer.allowedIPs {
+			prefix = prefix.Masked()
+			if otherIndex, loaded := peerByPrefix[prefix]; loaded && otherIndex != peerIndex {
+				return E.New("allowed_ips ", prefix, " of peer ", peerIndex, " is already used by peer ", otherIndex)
+			}
+			peerByPrefix[prefix] = peerIndex
+		}
+	}
+	return nil
+}
+
+// lookup returns the name of the peer routed to the address, matching
+// the allowed IPs with the longest prefix as the device does.
+func (p wireGuardPeers) lookup(addr netip.Addr) string {
+	var (
+		name string
+		bits = -1
+	)
+	for _, peer := range p {
+		for _, prefix := range peer.allowedIPs {
+			if prefix.Bits() > bits && prefix.Contains(addr) {
+				name = peer.name
+				bits = prefix.Bits()
+			}
+		}
+	}
+	return name
+}
+
+// status returns the peers with the state read from the IpcGet output of
+// the device, which lists the keys of each peer after its public_key.
+func (p wireGuardPeers) status(ipcConf string) []adapter.WireGuardPeer {
+	peers := make([]adapter.WireGuardPeer, len(p))
+	peerByKey := make(map[string]*adapter.WireGuardPeer)
+	for i, peer := range p {
+		peers[i].Name = peer.name
+		peers[i].PublicKey = peer.publicKey
+		peerByKey[peer.hexKey] = &peers[i]
+	}
+	var (
+		currentPeer   *adapter.WireGuardPeer
+		handshakeSecs int64
+	)
+	for _, line := range strings.Split(ipcConf, "\n") {
+		key, value, loaded := strings.Cut(line, "=")
+		if !loaded {
+			continue
+		}
+		if key == "public_key" {
+			currentPeer = peerByKey[value]
+			continue
+		}
+		if currentPeer == nil {
+			continue
+		}
+		switch key {
+		case "endpoint":
+			currentPeer.Endpoint = value
+		case "last_handshake_time_sec":
+			handshakeSecs, _ = strconv.ParseInt(value, 10, 64)
+		case "last_handshake_time_nsec":
+			handshakeNanos, _ := strconv.ParseInt(value, 10, 64)
+			if handshakeSecs != 0 || handshakeNanos != 0 {
+				currentPeer.LastHandshake = time.Unix(handshakeSecs, handshakeNanos)
+			}
+		case "rx_bytes":
+			currentPeer.RxBytes, _ = strconv.ParseUint(value, 10, 64)
+		case "tx_bytes":
+			currentPeer.TxBytes, _ = strconv.ParseUint(value, 10, 64)
+		}
+	}
+	return peers
+}
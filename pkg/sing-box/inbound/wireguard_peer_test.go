@@ -0,0 +1,68 @@
+package inbound
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testWireGuardPeers = wireGuardPeers{
+	{
+		name:       "alice",
+		publicKey:  "alice-key",
+		hexKey:     "a1",
+		allowedIPs: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/24"), netip.MustParsePrefix("fd00::/64")},
+	},
+	{
+		name:       "bob",
+		publicKey:  "bob-key",
+		hexKey:     "b2",
+		allowedIPs: []netip.Prefix{netip.MustParsePrefix("10.0.0.8/29")},
+	},
+}
+
+func TestWireGuardPeerLookup(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, "alice", testWireGuardPeers.lookup(netip.MustParseAddr("10.0.0.1")))
+	// the longest prefix wins regardless of the peer order
+	require.Equal(t, "bob", testWireGuardPeers.lookup(netip.MustParseAddr("10.0.0.9")))
+	require.Equal(t, "alice", testWireGuardPeers.lookup(netip.MustParseAddr("fd00::1")))
+	require.Empty(t, testWireGuardPeers.lookup(netip.MustParseAddr("10.0.1.1")))
+}
+
+func TestWireGuardPeerAllowedIPs(t *testing.T) {
+	t.Parallel()
+	require.NoError(t, testWireGuardPeers.checkAllowedIPs())
+	peers := append(wireGuardPeers{}, testWireGuardPeers...)
+	peers = append(peers, wireGuardPeer{
+		name:       "carol",
+		allowedIPs: []netip.Prefix{netip.MustParsePrefix("10.0.0.9/29")},
+	})
+	require.EqualError(t, peers.checkAllowedIPs(), "allowed_ips 10.0.0.8/29 of peer 2 is already used by peer 1")
+}
+
+func TestWireGuardPeerStatus(t *testing.T) {
+	t.Parallel()
+	ipcConf := "private_key=ff\nlisten_port=51820\n" +
+		"public_key=b2\nendpoint=192.0.2.1:51820\nlast_handshake_time_sec=1700000000\nlast_handshake_time_nsec=5\nrx_bytes=100\ntx_bytes=200\nallowed_ip=10.0.0.8/29\n" +
+		"public_key=c3\nendpoint=192.0.2.3:51820\nrx_bytes=1\n" +
+		"public_key=a1\nlast_handshake_time_sec=0\nlast_handshake_time_nsec=0\nrx_bytes=0\ntx_bytes=0\n" +
+		"errno=0\n"
+	require.Equal(t, []adapter.WireGuardPeer{
+		{Name: "alice", PublicKey: "alice-key"},
+		{
+			Name:          "bob",
+			PublicKey:     "bob-key",
+			Endpoint:      "192.0.2.1:51820",
+			LastHandshake: time.Unix(1700000000, 5),
+			RxBytes:       100,
+			TxBytes:       200,
+		},
+	}, testWireGuardPeers.status(ipcConf))
+	// peers are listed without a device
+	require.Len(t, testWireGuardPeers.status(""), 2)
+}
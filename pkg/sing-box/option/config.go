@@ -32,9 +32,17 @@ func (o *Options) UnmarshalJSON(content []byte) error {
 }
 
 type LogOptions struct {
-	Disabled     bool   `json:"disabled,omitempty"`
-	Level        string `json:"level,omitempty"`
-	Output       string `json:"output,omitempty"`
-	Timestamp    bool   `json:"timestamp,omitempty"`
-	DisableColor bool   `json:"-"`
+	Disabled     bool              `json:"disabled,omitempty"`
+	Level        string            `json:"level,omitempty"`
+	Output       string            `json:"output,omitempty"`
+	Timestamp    bool              `json:"timestamp,omitempty"`
+	Access       *AccessLogOptions `json:"access,omitempty"`
+	DisableColor bool              `json:"-"`
+}
+
+type AccessLogOptions struct {
+	Enabled    bool        `json:"enabled,omitempty"`
+	Output     string      `json:"output,omitempty"`
+	MaxSize    MemoryBytes `json:"max_size,omitempty"`
+	MaxBackups int         `json:"max_backups,omitempty"`
 }
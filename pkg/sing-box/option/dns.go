@@ -12,14 +12,31 @@ type DNSOptions struct {
 }
 
 type DNSServerOptions struct {
-	Tag                  string         `json:"tag,omitempty"`
-	Address              string         `json:"address"`
-	AddressResolver      string         `json:"address_resolver,omitempty"`
-	AddressStrategy      DomainStrategy `json:"address_strategy,omitempty"`
-	AddressFallbackDelay Duration       `json:"address_fallback_delay,omitempty"`
-	Strategy             DomainStrategy `json:"strategy,omitempty"`
-	Detour               string         `json:"detour,omitempty"`
-	ClientSubnet         *ListenAddress `json:"client_subnet,omitempty"`
+	Tag                  string           `json:"tag,omitempty"`
+	Address              string           `json:"address"`
+	AddressResolver      string           `json:"address_resolver,omitempty"`
+	AddressStrategy      DomainStrategy   `json:"address_strategy,omitempty"`
+	AddressFallbackDelay Duration         `json:"address_fallback_delay,omitempty"`
+	Strategy             DomainStrategy   `json:"strategy,omitempty"`
+	Detour               string           `json:"detour,omitempty"`
+	ClientSubnet         *ListenAddress   `json:"client_subnet,omitempty"`
+	Hosts                *DNSHostsOptions `json:"hosts,omitempty"`
+	Group                *DNSGroupOptions `json:"group,omitempty"`
+	FakeIPPool           string           `json:"fakeip_pool,omitempty"`
+}
+
+type DNSHostsOptions struct {
+	Path       Listable[string]            `json:"path,omitempty"`
+	Predefined map[string]Listable[string] `json:"predefined,omitempty"`
+	TTL        uint32                      `json:"ttl,omitempty"`
+}
+
+type DNSGroupOptions struct {
+	Servers     Listable[string] `json:"servers"`
+	Strategy    string           `json:"strategy,omitempty"`
+	Timeout     Duration         `json:"timeout,omitempty"`
+	RejectBogus bool             `json:"reject_bogus,omitempty"`
+	BogusIPCIDR Listable[string] `json:"bogus_ip_cidr,omitempty"`
 }
 
 type DNSClientOptions struct {
@@ -28,10 +45,23 @@ type DNSClientOptions struct {
 	DisableExpire    bool           `json:"disable_expire,omitempty"`
 	IndependentCache bool           `json:"independent_cache,omitempty"`
 	ClientSubnet     *ListenAddress `json:"client_subnet,omitempty"`
+	ServeStale       bool           `json:"serve_stale,omitempty"`
+	StaleTimeout     Duration       `json:"stale_timeout,omitempty"`
+	Prefetch         bool           `json:"prefetch,omitempty"`
+	StoreCache       bool           `json:"store_cache,omitempty"`
 }
 
 type DNSFakeIPOptions struct {
-	Enabled    bool          `json:"enabled,omitempty"`
+	Enabled             bool                   `json:"enabled,omitempty"`
+	Inet4Range          *netip.Prefix          `json:"inet4_range,omitempty"`
+	Inet6Range          *netip.Prefix          `json:"inet6_range,omitempty"`
+	Pools               []DNSFakeIPPoolOptions `json:"pools,omitempty"`
+	ExcludeDomain       Listable[string]       `json:"exclude_domain,omitempty"`
+	ExcludeDomainSuffix Listable[string]       `json:"exclude_domain_suffix,omitempty"`
+}
+
+type DNSFakeIPPoolOptions struct {
+	Tag        string        `json:"tag"`
 	Inet4Range *netip.Prefix `json:"inet4_range,omitempty"`
 	Inet6Range *netip.Prefix `json:"inet6_range,omitempty"`
 }
@@ -0,0 +1,11 @@
+package option
+
+type DNSInboundOptions struct {
+	ListenOptions
+	Network NetworkList `json:"network,omitempty"`
+	InboundTLSOptionsContainer
+	DoH            bool             `json:"doh,omitempty"`
+	DoHPath        string           `json:"doh_path,omitempty"`
+	AllowedClients Listable[string] `json:"allowed_clients,omitempty"`
+	DeniedClients  Listable[string] `json:"denied_clients,omitempty"`
+}
@@ -8,12 +8,14 @@ type ExperimentalOptions struct {
 }
 
 type CacheFileOptions struct {
-	Enabled     bool     `json:"enabled,omitempty"`
-	Path        string   `json:"path,omitempty"`
-	CacheID     string   `json:"cache_id,omitempty"`
-	StoreFakeIP bool     `json:"store_fakeip,omitempty"`
-	StoreRDRC   bool     `json:"store_rdrc,omitempty"`
-	RDRCTimeout Duration `json:"rdrc_timeout,omitempty"`
+	Enabled         bool     `json:"enabled,omitempty"`
+	Path            string   `json:"path,omitempty"`
+	CacheID         string   `json:"cache_id,omitempty"`
+	StoreFakeIP     bool     `json:"store_fakeip,omitempty"`
+	StoreRDRC       bool     `json:"store_rdrc,omitempty"`
+	RDRCTimeout     Duration `json:"rdrc_timeout,omitempty"`
+	StoreUsers      bool     `json:"store_users,omitempty"`
+	StoreStatistics bool     `json:"store_statistics,omitempty"`
 }
 
 type ClashAPIOptions struct {
@@ -23,6 +25,7 @@ type ClashAPIOptions struct {
 	ExternalUIDownloadDetour string   `json:"external_ui_download_detour,omitempty"`
 	Secret                   string   `json:"secret,omitempty"`
 	DefaultMode              string   `json:"default_mode,omitempty"`
+	ClosedConnections        int      `json:"closed_connections,omitempty"`
 	ModeList                 []string `json:"-"`
 
 	// Deprecated: migrated to global cache file
@@ -38,8 +41,9 @@ type ClashAPIOptions struct {
 }
 
 type V2RayAPIOptions struct {
-	Listen string                    `json:"listen,omitempty"`
-	Stats  *V2RayStatsServiceOptions `json:"stats,omitempty"`
+	Listen  string                      `json:"listen,omitempty"`
+	Stats   *V2RayStatsServiceOptions   `json:"stats,omitempty"`
+	Handler *V2RayHandlerServiceOptions `json:"handler,omitempty"`
 }
 
 type V2RayStatsServiceOptions struct {
@@ -48,3 +52,7 @@ type V2RayStatsServiceOptions struct {
 	Outbounds []string `json:"outbounds,omitempty"`
 	Users     []string `json:"users,omitempty"`
 }
+
+type V2RayHandlerServiceOptions struct {
+	Enabled bool `json:"enabled,omitempty"`
+}
@@ -14,3 +14,26 @@ type URLTestOutboundOptions struct {
 	IdleTimeout               Duration `json:"idle_timeout,omitempty"`
 	InterruptExistConnections bool     `json:"interrupt_exist_connections,omitempty"`
 }
+
+type LoadBalanceOutboundOptions struct {
+	Outbounds   []string `json:"outbounds"`
+	Strategy    string   `json:"strategy,omitempty"`
+	HashKey     string   `json:"hash_key,omitempty"`
+	URL         string   `json:"url,omitempty"`
+	Interval    Duration `json:"interval,omitempty"`
+	IdleTimeout Duration `json:"idle_timeout,omitempty"`
+}
+
+type FallbackOutboundOptions struct {
+	Outbounds                 []string `json:"outbounds"`
+	URL                       string   `json:"url,omitempty"`
+	Interval                  Duration `json:"interval,omitempty"`
+	IdleTimeout               Duration `json:"idle_timeout,omitempty"`
+	Backoff                   Duration `json:"backoff,omitempty"`
+	MaxBackoff                Duration `json:"max_backoff,omitempty"`
+	InterruptExistConnections bool     `json:"interrupt_exist_connections,omitempty"`
+}
+
+type ChainOutboundOptions struct {
+	Outbounds []string `json:"outbounds"`
+}
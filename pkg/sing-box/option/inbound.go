@@ -27,6 +27,8 @@ type _Inbound struct {
 	VLESSOptions       VLESSInboundOptions       `json:"-"`
 	TUICOptions        TUICInboundOptions        `json:"-"`
 	Hysteria2Options   Hysteria2InboundOptions   `json:"-"`
+	DNSOptions         DNSInboundOptions         `json:"-"`
+	WireGuardOptions   WireGuardInboundOptions   `json:"-"`
 }
 
 type Inbound _Inbound
@@ -66,6 +68,10 @@ func (h *Inbound) RawOptions() (any, error) {
 		rawOptionsPtr = &h.TUICOptions
 	case C.TypeHysteria2:
 		rawOptionsPtr = &h.Hysteria2Options
+	case C.TypeDNS:
+		rawOptionsPtr = &h.DNSOptions
+	case C.TypeWireGuard:
+		rawOptionsPtr = &h.WireGuardOptions
 	case "":
 		return nil, E.New("missing inbound type")
 	default:
@@ -99,11 +105,13 @@ func (h *Inbound) UnmarshalJSON(bytes []byte) error {
 }
 
 type InboundOptions struct {
-	SniffEnabled              bool           `json:"sniff,omitempty"`
-	SniffOverrideDestination  bool           `json:"sniff_override_destination,omitempty"`
-	SniffTimeout              Duration       `json:"sniff_timeout,omitempty"`
-	DomainStrategy            DomainStrategy `json:"domain_strategy,omitempty"`
-	UDPDisableDomainUnmapping bool           `json:"udp_disable_domain_unmapping,omitempty"`
+	SniffEnabled              bool               `json:"sniff,omitempty"`
+	SniffOverrideDestination  bool               `json:"sniff_override_destination,omitempty"`
+	SniffTimeout              Duration           `json:"sniff_timeout,omitempty"`
+	DomainStrategy            DomainStrategy     `json:"domain_strategy,omitempty"`
+	UDPDisableDomainUnmapping bool               `json:"udp_disable_domain_unmapping,omitempty"`
+	UserLimits                []UserLimitOptions `json:"user_limits,omitempty"`
+	RateLimit                 *RateLimitOptions  `json:"rate_limit,omitempty"`
 }
 
 type ListenOptions struct {
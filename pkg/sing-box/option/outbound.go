@@ -10,6 +10,7 @@ import (
 type _Outbound struct {
 	Type                string                      `json:"type"`
 	Tag                 string                      `json:"tag,omitempty"`
+	RateLimit           *RateLimitOptions           `json:"rate_limit,omitempty"`
 	DirectOptions       DirectOutboundOptions       `json:"-"`
 	SocksOptions        SocksOutboundOptions        `json:"-"`
 	HTTPOptions         HTTPOutboundOptions         `json:"-"`
@@ -27,6 +28,9 @@ type _Outbound struct {
 	Hysteria2Options    Hysteria2OutboundOptions    `json:"-"`
 	SelectorOptions     SelectorOutboundOptions     `json:"-"`
 	URLTestOptions      URLTestOutboundOptions      `json:"-"`
+	LoadBalanceOptions  LoadBalanceOutboundOptions  `json:"-"`
+	FallbackOptions     FallbackOutboundOptions     `json:"-"`
+	ChainOptions        ChainOutboundOptions        `json:"-"`
 	ProviderOptions     ProviderOutboundOptions     `json:"-"`
 }
 
@@ -71,6 +75,12 @@ func (h *Outbound) RawOptions() (any, error) {
 		rawOptionsPtr = &h.SelectorOptions
 	case C.TypeURLTest:
 		rawOptionsPtr = &h.URLTestOptions
+	case C.TypeLoadBalance:
+		rawOptionsPtr = &h.LoadBalanceOptions
+	case C.TypeFallback:
+		rawOptionsPtr = &h.FallbackOptions
+	case C.TypeChain:
+		rawOptionsPtr = &h.ChainOptions
 	case "":
 		return nil, E.New("missing outbound type")
 	case C.TypeProvider:
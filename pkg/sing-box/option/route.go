@@ -11,6 +11,7 @@ type RouteOptions struct {
 	OverrideAndroidVPN  bool            `json:"override_android_vpn,omitempty"`
 	DefaultInterface    string          `json:"default_interface,omitempty"`
 	DefaultMark         int             `json:"default_mark,omitempty"`
+	Schedule            []Schedule      `json:"schedule,omitempty"`
 }
 
 type GeoIPOptions struct {
@@ -24,3 +25,11 @@ type GeositeOptions struct {
 	DownloadURL    string `json:"download_url,omitempty"`
 	DownloadDetour string `json:"download_detour,omitempty"`
 }
+
+type Schedule struct {
+	Cron      string `json:"cron"`
+	TimeZone  string `json:"time_zone,omitempty"`
+	ClashMode string `json:"clash_mode,omitempty"`
+	Selector  string `json:"selector,omitempty"`
+	Outbound  string `json:"outbound,omitempty"`
+}
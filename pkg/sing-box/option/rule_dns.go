@@ -95,6 +95,9 @@ type DefaultDNSRule struct {
 	ClashMode                string                 `json:"clash_mode,omitempty"`
 	WIFISSID                 Listable[string]       `json:"wifi_ssid,omitempty"`
 	WIFIBSSID                Listable[string]       `json:"wifi_bssid,omitempty"`
+	TimeRange                Listable[string]       `json:"time_range,omitempty"`
+	Weekday                  Listable[string]       `json:"weekday,omitempty"`
+	TimeZone                 string                 `json:"time_zone,omitempty"`
 	RuleSet                  Listable[string]       `json:"rule_set,omitempty"`
 	RuleSetIPCIDRMatchSource bool                   `json:"rule_set_ipcidr_match_source,omitempty"`
 	Invert                   bool                   `json:"invert,omitempty"`
@@ -111,6 +114,7 @@ func (r DefaultDNSRule) IsValid() bool {
 	defaultValue.DisableCache = r.DisableCache
 	defaultValue.RewriteTTL = r.RewriteTTL
 	defaultValue.ClientSubnet = r.ClientSubnet
+	defaultValue.TimeZone = r.TimeZone
 	return !reflect.DeepEqual(r, defaultValue)
 }
 
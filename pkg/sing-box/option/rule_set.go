@@ -16,7 +16,8 @@ import (
 type _RuleSet struct {
 	Type          string        `json:"type"`
 	Tag           string        `json:"tag"`
-	Format        string        `json:"format"`
+	Format        string        `json:"format,omitempty"`
+	InlineOptions PlainRuleSet  `json:"-"`
 	LocalOptions  LocalRuleSet  `json:"-"`
 	RemoteOptions RemoteRuleSet `json:"-"`
 }
@@ -26,6 +27,9 @@ type RuleSet _RuleSet
 func (r RuleSet) MarshalJSON() ([]byte, error) {
 	var v any
 	switch r.Type {
+	case C.RuleSetTypeInline:
+		r.Format = ""
+		v = r.InlineOptions
 	case C.RuleSetTypeLocal:
 		v = r.LocalOptions
 	case C.RuleSetTypeRemote:
@@ -46,13 +50,21 @@ func (r *RuleSet) UnmarshalJSON(bytes []byte) error {
 	}
 	switch r.Format {
 	case "":
-		return E.New("missing format")
+		if r.Type != C.RuleSetTypeInline {
+			return E.New("missing format")
+		}
 	case C.RuleSetFormatSource, C.RuleSetFormatBinary:
+	case C.RuleSetFormatClash, C.RuleSetFormatHosts, C.RuleSetFormatAdBlock, C.RuleSetFormatDomainList:
 	default:
 		return E.New("unknown rule set format: " + r.Format)
 	}
 	var v any
 	switch r.Type {
+	case C.RuleSetTypeInline:
+		if r.Format != "" {
+			return E.New("format is not allowed for inline rule set")
+		}
+		v = &r.InlineOptions
 	case C.RuleSetTypeLocal:
 		v = &r.LocalOptions
 	case C.RuleSetTypeRemote:
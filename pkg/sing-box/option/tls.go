@@ -1,20 +1,23 @@
 package option
 
 type InboundTLSOptions struct {
-	Enabled         bool                   `json:"enabled,omitempty"`
-	ServerName      string                 `json:"server_name,omitempty"`
-	Insecure        bool                   `json:"insecure,omitempty"`
-	ALPN            Listable[string]       `json:"alpn,omitempty"`
-	MinVersion      string                 `json:"min_version,omitempty"`
-	MaxVersion      string                 `json:"max_version,omitempty"`
-	CipherSuites    Listable[string]       `json:"cipher_suites,omitempty"`
-	Certificate     Listable[string]       `json:"certificate,omitempty"`
-	CertificatePath string                 `json:"certificate_path,omitempty"`
-	Key             Listable[string]       `json:"key,omitempty"`
-	KeyPath         string                 `json:"key_path,omitempty"`
-	ACME            *InboundACMEOptions    `json:"acme,omitempty"`
-	ECH             *InboundECHOptions     `json:"ech,omitempty"`
-	Reality         *InboundRealityOptions `json:"reality,omitempty"`
+	Enabled               bool                   `json:"enabled,omitempty"`
+	ServerName            string                 `json:"server_name,omitempty"`
+	Insecure              bool                   `json:"insecure,omitempty"`
+	ALPN                  Listable[string]       `json:"alpn,omitempty"`
+	MinVersion            string                 `json:"min_version,omitempty"`
+	MaxVersion            string                 `json:"max_version,omitempty"`
+	CipherSuites          Listable[string]       `json:"cipher_suites,omitempty"`
+	Certificate           Listable[string]       `json:"certificate,omitempty"`
+	CertificatePath       string                 `json:"certificate_path,omitempty"`
+	Key                   Listable[string]       `json:"key,omitempty"`
+	KeyPath               string                 `json:"key_path,omitempty"`
+	ClientAuthentication  string                 `json:"client_authentication,omitempty"`
+	ClientCertificate     Listable[string]       `json:"client_certificate,omitempty"`
+	ClientCertificatePath string                 `json:"client_certificate_path,omitempty"`
+	ACME                  *InboundACMEOptions    `json:"acme,omitempty"`
+	ECH                   *InboundECHOptions     `json:"ech,omitempty"`
+	Reality               *InboundRealityOptions `json:"reality,omitempty"`
 }
 
 type InboundTLSOptionsContainer struct {
@@ -35,19 +38,24 @@ func (o *InboundTLSOptionsContainer) ReplaceInboundTLSOptions(options *InboundTL
 }
 
 type OutboundTLSOptions struct {
-	Enabled         bool                    `json:"enabled,omitempty"`
-	DisableSNI      bool                    `json:"disable_sni,omitempty"`
-	ServerName      string                  `json:"server_name,omitempty"`
-	Insecure        bool                    `json:"insecure,omitempty"`
-	ALPN            Listable[string]        `json:"alpn,omitempty"`
-	MinVersion      string                  `json:"min_version,omitempty"`
-	MaxVersion      string                  `json:"max_version,omitempty"`
-	CipherSuites    Listable[string]        `json:"cipher_suites,omitempty"`
-	Certificate     Listable[string]        `json:"certificate,omitempty"`
-	CertificatePath string                  `json:"certificate_path,omitempty"`
-	ECH             *OutboundECHOptions     `json:"ech,omitempty"`
-	UTLS            *OutboundUTLSOptions    `json:"utls,omitempty"`
-	Reality         *OutboundRealityOptions `json:"reality,omitempty"`
+	Enabled                    bool                    `json:"enabled,omitempty"`
+	DisableSNI                 bool                    `json:"disable_sni,omitempty"`
+	ServerName                 string                  `json:"server_name,omitempty"`
+	Insecure                   bool                    `json:"insecure,omitempty"`
+	ALPN                       Listable[string]        `json:"alpn,omitempty"`
+	MinVersion                 string                  `json:"min_version,omitempty"`
+	MaxVersion                 string                  `json:"max_version,omitempty"`
+	CipherSuites               Listable[string]        `json:"cipher_suites,omitempty"`
+	Certificate                Listable[string]        `json:"certificate,omitempty"`
+	CertificatePath            string                  `json:"certificate_path,omitempty"`
+	CertificatePublicKeySHA256 Listable[[]byte]        `json:"certificate_public_key_sha256,omitempty"`
+	ClientCertificate          Listable[string]        `json:"client_certificate,omitempty"`
+	ClientCertificatePath      string                  `json:"client_certificate_path,omitempty"`
+	ClientKey                  Listable[string]        `json:"client_key,omitempty"`
+	ClientKeyPath              string                  `json:"client_key_path,omitempty"`
+	ECH                        *OutboundECHOptions     `json:"ech,omitempty"`
+	UTLS                       *OutboundUTLSOptions    `json:"utls,omitempty"`
+	Reality                    *OutboundRealityOptions `json:"reality,omitempty"`
 }
 
 type OutboundTLSOptionsContainer struct {
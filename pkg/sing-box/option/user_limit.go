@@ -0,0 +1,69 @@
+package option
+
+import (
+	"time"
+
+	C "github.com/sagernet/sing-box/constant"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/json"
+)
+
+type _UserLimitOptions struct {
+	Name           string            `json:"name"`
+	QuotaBytes     MemoryBytes       `json:"quota_bytes,omitempty"`
+	ExpireAt       *Time             `json:"expire_at,omitempty"`
+	MaxConnections int               `json:"max_connections,omitempty"`
+	ResetPeriod    string            `json:"reset_period,omitempty"`
+	ResetDay       int               `json:"reset_day,omitempty"`
+	RateLimit      *RateLimitOptions `json:"rate_limit,omitempty"`
+}
+
+type UserLimitOptions _UserLimitOptions
+
+func (o *UserLimitOptions) UnmarshalJSON(bytes []byte) error {
+	err := json.Unmarshal(bytes, (*_UserLimitOptions)(o))
+	if err != nil {
+		return err
+	}
+	if o.Name == "" {
+		return E.New("missing user name")
+	}
+	switch o.ResetPeriod {
+	case "":
+		if o.ResetDay != 0 {
+			return E.New("reset_day requires reset_period")
+		}
+	case C.UserLimitResetMonthly:
+		if o.ResetDay < 0 || o.ResetDay > 28 {
+			return E.New("reset_day must be between 1 and 28")
+		}
+	default:
+		return E.New("unknown reset period: ", o.ResetPeriod)
+	}
+	return nil
+}
+
+// Time is an RFC 3339 timestamp, or a date in local time.
+type Time time.Time
+
+func (t Time) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Time(t).Format(time.RFC3339))
+}
+
+func (t *Time) UnmarshalJSON(bytes []byte) error {
+	var value string
+	err := json.Unmarshal(bytes, &value)
+	if err != nil {
+		return err
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		var dateErr error
+		parsed, dateErr = time.ParseInLocation(time.DateOnly, value, time.Local)
+		if dateErr != nil {
+			return E.Cause(err, "parse time")
+		}
+	}
+	*t = Time(parsed)
+	return nil
+}
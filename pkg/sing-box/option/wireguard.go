@@ -26,3 +26,20 @@ type WireGuardPeer struct {
 	AllowedIPs   Listable[string] `json:"allowed_ips,omitempty"`
 	Reserved     []uint8          `json:"reserved,omitempty"`
 }
+
+type WireGuardInboundOptions struct {
+	ListenOptions
+	PrivateKey             string                 `json:"private_key"`
+	Peers                  []WireGuardInboundPeer `json:"peers"`
+	Workers                int                    `json:"workers,omitempty"`
+	MTU                    uint32                 `json:"mtu,omitempty"`
+	EndpointIndependentNat bool                   `json:"endpoint_independent_nat,omitempty"`
+}
+
+type WireGuardInboundPeer struct {
+	Name                        string                 `json:"name,omitempty"`
+	PublicKey                   string                 `json:"public_key"`
+	PreSharedKey                string                 `json:"pre_shared_key,omitempty"`
+	AllowedIPs                  Listable[netip.Prefix] `json:"allowed_ips"`
+	PersistentKeepaliveInterval uint16                 `json:"persistent_keepalive_interval,omitempty"`
+}
@@ -59,6 +59,12 @@ func New(ctx context.Context, router adapter.Router, logger log.ContextLogger, t
 		return NewSelector(ctx, router, logger, tag, options.SelectorOptions)
 	case C.TypeURLTest:
 		return NewURLTest(ctx, router, logger, tag, options.URLTestOptions)
+	case C.TypeLoadBalance:
+		return NewLoadBalance(ctx, router, logger, tag, options.LoadBalanceOptions)
+	case C.TypeFallback:
+		return NewFallback(ctx, router, logger, tag, options.FallbackOptions)
+	case C.TypeChain:
+		return NewChain(router, logger, tag, options.ChainOptions)
 	case C.TypeProvider:
 		return NewProvider(ctx, router, logger, tag, options.ProviderOptions)
 	default:
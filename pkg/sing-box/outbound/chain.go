@@ -0,0 +1,235 @@
+package outbound
+
+import (
+	"context"
+	"net"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/dialer"
+	"github.com/sagernet/sing-box/common/mux"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/transport/sip003"
+	"github.com/sagernet/sing-box/transport/v2rayhttpupgrade"
+	"github.com/sagernet/sing-box/transport/v2raywebsocket"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+var (
+	_ adapter.Outbound      = (*Chain)(nil)
+	_ adapter.OutboundChain = (*Chain)(nil)
+)
+
+type Chain struct {
+	myOutboundAdapter
+	tags []string
+	hops []adapter.Outbound
+}
+
+func NewChain(router adapter.Router, logger log.ContextLogger, tag string, options option.ChainOutboundOptions) (*Chain, error) {
+	outbound := &Chain{
+		myOutboundAdapter: myOutboundAdapter{
+			protocol:     C.TypeChain,
+			router:       router,
+			logger:       logger,
+			tag:          tag,
+			dependencies: options.Outbounds,
+		},
+		tags: options.Outbounds,
+	}
+	if len(outbound.tags) < 2 {
+		return nil, E.New("chain requires at least two outbounds")
+	}
+	return outbound, nil
+}
+
+func (s *Chain) Start() error {
+	hops := make([]adapter.Outbound, 0, len(s.tags))
+	for i, tag := range s.tags {
+		detour, loaded := s.router.Outbound(tag)
+		if !loaded {
+			return E.New("outbound ", i, " not found: ", tag)
+		}
+		hops = append(hops, detour)
+	}
+	err := s.checkLoop(hops, make(map[string]bool))
+	if err != nil {
+		return err
+	}
+	err = s.checkHops(hops)
+	if err != nil {
+		return err
+	}
+	s.hops = hops
+	return nil
+}
+
+// checkHops rejects hops after the first one which can not dial through the
+// previous hop. Members of providers change on update, so it is checked again
+// for each connection.
+func (s *Chain) checkHops(hops []adapter.Outbound) error {
+	visited := make(map[string]bool)
+	for _, hop := range hops[1:] {
+		err := s.checkChainable(hop, visited)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkChainable checks an outbound dials each connection with the dialer of
+// its context. Outbounds that keep their own connections, such as QUIC based
+// protocols, WireGuard, SSH or multiplexed ones, would bypass the previous hops.
+func (s *Chain) checkChainable(hop adapter.Outbound, visited map[string]bool) error {
+	if visited[hop.Tag()] {
+		return nil
+	}
+	visited[hop.Tag()] = true
+	switch detour := hop.(type) {
+	case adapter.OutboundChain:
+		return E.New("chain outbound ", detour.Tag(), " can only be the first hop")
+	case adapter.OutboundGroup:
+		for _, tag := range detour.All() {
+			member, loaded := s.router.Outbound(tag)
+			if !loaded {
+				continue
+			}
+			err := s.checkChainable(member, visited)
+			if err != nil {
+				return E.Cause(err, "group ", detour.Tag())
+			}
+		}
+		return nil
+	case *Direct, *Block, *Socks, *HTTP, *ShadowTLS:
+		return nil
+	case *Shadowsocks:
+		if detour.multiplexDialer != nil {
+			return E.New("multiplexed outbound ", detour.Tag(), " can not be chained")
+		}
+		if _, isObfs := detour.plugin.(*sip003.ObfsLocal); detour.plugin != nil && !isObfs {
+			return E.New("plugin of outbound ", detour.Tag(), " can not be chained")
+		}
+		return nil
+	case *VMess:
+		return checkChainableV2Ray(detour.Tag(), detour.multiplexDialer, detour.transport)
+	case *Trojan:
+		return checkChainableV2Ray(detour.Tag(), detour.multiplexDialer, detour.transport)
+	case *VLESS:
+		return checkChainableV2Ray(detour.Tag(), detour.multiplexDialer, detour.transport)
+	default:
+		return E.New(hop.Type(), " outbound ", hop.Tag(), " can not be chained")
+	}
+}
+
+func checkChainableV2Ray(tag string, multiplexDialer *mux.Client, transport adapter.V2RayClientTransport) error {
+	if multiplexDialer != nil {
+		return E.New("multiplexed outbound ", tag, " can not be chained")
+	}
+	switch transport.(type) {
+	case nil, *v2raywebsocket.Client, *v2rayhttpupgrade.Client:
+		return nil
+	default:
+		return E.New("transport of outbound ", tag, " shares connections and can not be chained")
+	}
+}
+
+// checkLoop rejects chains that would dial through themselves,
+// directly or via nested chains and groups.
+func (s *Chain) checkLoop(hops []adapter.Outbound, visited map[string]bool) error {
+	for _, hop := range hops {
+		if hop.Tag() == s.tag {
+			return E.New("chain loop on outbound: ", s.tag)
+		}
+		if visited[hop.Tag()] {
+			continue
+		}
+		visited[hop.Tag()] = true
+		var nextTags []string
+		switch detour := hop.(type) {
+		case adapter.OutboundChain:
+			nextTags = detour.Hops()
+		case adapter.OutboundGroup:
+			nextTags = detour.All()
+		}
+		var next []adapter.Outbound
+		for _, tag := range nextTags {
+			if nextHop, loaded := s.router.Outbound(tag); loaded {
+				next = append(next, nextHop)
+			}
+		}
+		err := s.checkLoop(next, visited)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Chain) Network() []string {
+	if len(s.hops) == 0 {
+		return []string{N.NetworkTCP, N.NetworkUDP}
+	}
+	return s.hops[len(s.hops)-1].Network()
+}
+
+func (s *Chain) Hops() []string {
+	return s.tags
+}
+
+func (s *Chain) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	err := s.checkHops(s.hops)
+	if err != nil {
+		return nil, err
+	}
+	last := len(s.hops) - 1
+	ctx = dialer.ContextWithChainDialer(ctx, newChainHop(s.hops[:last]))
+	return s.hops[last].DialContext(ctx, network, destination)
+}
+
+func (s *Chain) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
+	err := s.checkHops(s.hops)
+	if err != nil {
+		return nil, err
+	}
+	last := len(s.hops) - 1
+	ctx = dialer.ContextWithChainDialer(ctx, newChainHop(s.hops[:last]))
+	return s.hops[last].ListenPacket(ctx, destination)
+}
+
+func (s *Chain) NewConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
+	return NewConnection(ctx, s, conn, metadata)
+}
+
+func (s *Chain) NewPacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext) error {
+	return NewPacketConnection(ctx, s, conn, metadata)
+}
+
+// chainHop dials through the last outbound of hops,
+// which in turn dials through the ones before it.
+type chainHop struct {
+	outbound adapter.Outbound
+	upstream N.Dialer
+}
+
+func newChainHop(hops []adapter.Outbound) N.Dialer {
+	if len(hops) == 0 {
+		return nil
+	}
+	last := len(hops) - 1
+	return &chainHop{
+		outbound: hops[last],
+		upstream: newChainHop(hops[:last]),
+	}
+}
+
+func (h *chainHop) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	return h.outbound.DialContext(dialer.ContextWithChainDialer(ctx, h.upstream), network, destination)
+}
+
+func (h *chainHop) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
+	return h.outbound.ListenPacket(dialer.ContextWithChainDialer(ctx, h.upstream), destination)
+}
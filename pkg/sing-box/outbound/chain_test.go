@@ -0,0 +1,205 @@
+package outbound
+
+import (
+	std_bufio "bufio"
+	"context"
+	"io"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/bufio"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/protocol/http"
+	"github.com/sagernet/sing/protocol/socks"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testChainRouter struct {
+	adapter.Router
+	outbounds map[string]adapter.Outbound
+}
+
+func (r *testChainRouter) Outbound(tag string) (adapter.Outbound, bool) {
+	outbound, loaded := r.outbounds[tag]
+	return outbound, loaded
+}
+
+func (r *testChainRouter) AutoDetectInterface() bool {
+	return false
+}
+
+func (r *testChainRouter) DefaultInterface() string {
+	return ""
+}
+
+func (r *testChainRouter) DefaultMark() int {
+	return 0
+}
+
+// testProxyServer is a local SOCKS or HTTP proxy recording the destinations it connects to.
+type testProxyServer struct {
+	listener     net.Listener
+	access       sync.Mutex
+	destinations []string
+}
+
+func newTestProxyServer(t *testing.T, protocol string) *testProxyServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		listener.Close()
+	})
+	server := &testProxyServer{listener: listener}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				if protocol == C.TypeHTTP {
+					http.HandleConnection(context.Background(), conn, std_bufio.NewReader(conn), nil, server, M.Metadata{})
+				} else {
+					socks.HandleConnection(context.Background(), conn, nil, server, M.Metadata{})
+				}
+			}()
+		}
+	}()
+	return server
+}
+
+func (s *testProxyServer) serverOptions() option.ServerOptions {
+	address := M.SocksaddrFromNet(s.listener.Addr())
+	return option.ServerOptions{Server: address.AddrString(), ServerPort: address.Port}
+}
+
+func (s *testProxyServer) NewConnection(ctx context.Context, conn net.Conn, metadata M.Metadata) error {
+	s.access.Lock()
+	s.destinations = append(s.destinations, metadata.Destination.String())
+	s.access.Unlock()
+	remoteConn, err := net.Dial("tcp", metadata.Destination.String())
+	if err != nil {
+		return err
+	}
+	return bufio.CopyConn(ctx, conn, remoteConn)
+}
+
+func (s *testProxyServer) NewPacketConnection(ctx context.Context, conn N.PacketConn, metadata M.Metadata) error {
+	return conn.Close()
+}
+
+func (s *testProxyServer) Destinations() []string {
+	s.access.Lock()
+	defer s.access.Unlock()
+	return s.destinations
+}
+
+func newTestEchoServer(t *testing.T) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		listener.Close()
+	})
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return listener
+}
+
+func newTestChain(t *testing.T, router *testChainRouter, outbounds ...string) (*Chain, error) {
+	chain, err := NewChain(router, log.NewNOPFactory().Logger(), "chain", option.ChainOutboundOptions{Outbounds: outbounds})
+	require.NoError(t, err)
+	router.outbounds[chain.Tag()] = chain
+	return chain, chain.Start()
+}
+
+func TestChainDialsThroughHops(t *testing.T) {
+	t.Parallel()
+	entry := newTestProxyServer(t, C.TypeSOCKS)
+	relay := newTestProxyServer(t, C.TypeSOCKS)
+	exit := newTestProxyServer(t, C.TypeHTTP)
+	target := newTestEchoServer(t)
+	router := &testChainRouter{outbounds: make(map[string]adapter.Outbound)}
+	for tag, server := range map[string]*testProxyServer{"entry": entry, "relay": relay} {
+		outbound, err := NewSocks(router, log.NewNOPFactory().Logger(), tag, option.SocksOutboundOptions{
+			ServerOptions: server.serverOptions(),
+		})
+		require.NoError(t, err)
+		router.outbounds[tag] = outbound
+	}
+	exitOutbound, err := NewHTTP(context.Background(), router, log.NewNOPFactory().Logger(), "exit", option.HTTPOutboundOptions{
+		ServerOptions: exit.serverOptions(),
+	})
+	require.NoError(t, err)
+	router.outbounds["exit"] = exitOutbound
+	chain, err := newTestChain(t, router, "entry", "relay", "exit")
+	require.NoError(t, err)
+
+	conn, err := chain.DialContext(context.Background(), N.NetworkTCP, M.SocksaddrFromNet(target.Addr()))
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("hello"))
+	require.NoError(t, err)
+	response := make([]byte, 5)
+	_, err = io.ReadFull(conn, response)
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(response))
+
+	// each hop connects to the server of the next one
+	require.Equal(t, []string{relay.listener.Addr().String()}, entry.Destinations())
+	require.Equal(t, []string{exit.listener.Addr().String()}, relay.Destinations())
+	require.Equal(t, []string{target.Addr().String()}, exit.Destinations())
+}
+
+func TestChainRejectsUnchainableHops(t *testing.T) {
+	t.Parallel()
+	router := &testChainRouter{outbounds: make(map[string]adapter.Outbound)}
+	for _, outbound := range []adapter.Outbound{
+		newTestOutbound(C.TypeHysteria2, "hysteria2", nil),
+		newTestOutbound(C.TypeSOCKS, "test", nil),
+		NewBlock(log.NewNOPFactory().Logger(), "block"),
+	} {
+		router.outbounds[outbound.Tag()] = outbound
+	}
+	selector, err := NewSelector(context.Background(), router, log.NewNOPFactory().Logger(), "select", option.SelectorOutboundOptions{
+		Outbounds: []string{"block", "hysteria2"},
+	})
+	require.NoError(t, err)
+	router.outbounds["select"] = selector
+	other, err := NewChain(router, log.NewNOPFactory().Logger(), "other", option.ChainOutboundOptions{Outbounds: []string{"block", "block"}})
+	require.NoError(t, err)
+	router.outbounds["other"] = other
+
+	// the first hop dials directly and may be of any type
+	_, err = newTestChain(t, router, "hysteria2", "block")
+	require.NoError(t, err)
+	_, err = newTestChain(t, router, "other", "block")
+	require.NoError(t, err)
+
+	for _, hops := range [][]string{
+		{"block", "hysteria2"},
+		{"block", "test"},
+		{"block", "select"},
+		{"block", "other"},
+	} {
+		_, err = newTestChain(t, router, hops...)
+		require.Error(t, err, hops)
+	}
+}
@@ -0,0 +1,354 @@
+package outbound
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/interrupt"
+	"github.com/sagernet/sing-box/common/urltest"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-dns"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/atomic"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+var (
+	_ adapter.Outbound                = (*Fallback)(nil)
+	_ adapter.OutboundGroup           = (*Fallback)(nil)
+	_ adapter.URLTestGroup            = (*Fallback)(nil)
+	_ adapter.FailoverGroup           = (*Fallback)(nil)
+	_ adapter.InterfaceUpdateListener = (*Fallback)(nil)
+)
+
+const (
+	defaultFallbackBackoff    = 10 * time.Second
+	defaultFallbackMaxBackoff = 10 * time.Minute
+)
+
+type Fallback struct {
+	myOutboundAdapter
+	ctx                          context.Context
+	tags                         []string
+	link                         string
+	interval                     time.Duration
+	idleTimeout                  time.Duration
+	backoff                      time.Duration
+	maxBackoff                   time.Duration
+	members                      []*fallbackMember
+	group                        *URLTestGroup
+	interruptGroup               *interrupt.Group
+	interruptExternalConnections bool
+	selected                     atomic.TypedValue[*fallbackMember]
+}
+
+type fallbackMember struct {
+	outbound  adapter.Outbound
+	access    sync.Mutex
+	failedAt  time.Time
+	retryAt   time.Time
+	backoff   time.Duration
+	checking  atomic.Bool
+	failovers atomic.Uint64
+}
+
+func NewFallback(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.FallbackOutboundOptions) (*Fallback, error) {
+	outbound := &Fallback{
+		myOutboundAdapter: myOutboundAdapter{
+			protocol:     C.TypeFallback,
+			network:      []string{N.NetworkTCP, N.NetworkUDP},
+			router:       router,
+			logger:       logger,
+			tag:          tag,
+			dependencies: options.Outbounds,
+		},
+		ctx:                          ctx,
+		tags:                         options.Outbounds,
+		link:                         options.URL,
+		interval:                     time.Duration(options.Interval),
+		idleTimeout:                  time.Duration(options.IdleTimeout),
+		backoff:                      time.Duration(options.Backoff),
+		maxBackoff:                   time.Duration(options.MaxBackoff),
+		interruptGroup:               interrupt.NewGroup(),
+		interruptExternalConnections: options.InterruptExistConnections,
+	}
+	if len(outbound.tags) == 0 {
+		return nil, E.New("missing tags")
+	}
+	if outbound.backoff == 0 {
+		outbound.backoff = defaultFallbackBackoff
+	}
+	if outbound.maxBackoff == 0 {
+		outbound.maxBackoff = defaultFallbackMaxBackoff
+	}
+	if outbound.maxBackoff < outbound.backoff {
+		return nil, E.New("max_backoff must be greater or equal than backoff")
+	}
+	return outbound, nil
+}
+
+func (s *Fallback) Start() error {
+	outbounds := make([]adapter.Outbound, 0, len(s.tags))
+	for i, tag := range s.tags {
+		detour, loaded := s.router.Outbound(tag)
+		if !loaded {
+			return E.New("outbound ", i, " not found: ", tag)
+		}
+		outbounds = append(outbounds, detour)
+		s.members = append(s.members, &fallbackMember{outbound: detour})
+	}
+	group, err := NewURLTestGroup(
+		s.ctx,
+		s.router,
+		s.logger,
+		outbounds,
+		s.link,
+		s.interval,
+		0,
+		s.idleTimeout,
+		false,
+	)
+	if err != nil {
+		return err
+	}
+	s.group = group
+	return nil
+}
+
+func (s *Fallback) PostStart() error {
+	s.group.PostStart()
+	return nil
+}
+
+func (s *Fallback) Close() error {
+	return common.Close(
+		common.PtrOrNil(s.group),
+	)
+}
+
+func (s *Fallback) Now() string {
+	if selected := s.selected.Load(); selected != nil {
+		return selected.outbound.Tag()
+	}
+	return s.tags[0]
+}
+
+func (s *Fallback) All() []string {
+	return s.tags
+}
+
+func (s *Fallback) URLTest(ctx context.Context) (map[string]uint16, error) {
+	return s.group.URLTest(ctx)
+}
+
+func (s *Fallback) CheckOutbounds() {
+	s.group.CheckOutbounds(true)
+}
+
+func (s *Fallback) FailoverCounts() map[string]uint64 {
+	counts := make(map[string]uint64)
+	for _, member := range s.members {
+		counts[member.outbound.Tag()] = member.failovers.Load()
+	}
+	return counts
+}
+
+func (s *Fallback) InterfaceUpdated() {
+	go s.group.CheckOutbounds(true)
+}
+
+func (s *Fallback) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	s.group.Touch()
+	var dialErrors []error
+	for _, member := range s.candidates(N.NetworkName(network)) {
+		conn, err := member.outbound.DialContext(ctx, network, destination)
+		if err == nil {
+			s.updateSelected(member)
+			return s.interruptGroup.NewConn(conn, interrupt.IsExternalConnectionFromContext(ctx)), nil
+		}
+		if ctx.Err() != nil || isDestinationError(member.outbound, destination, err) {
+			return nil, err
+		}
+		s.markFailed(ctx, member, err)
+		dialErrors = append(dialErrors, err)
+	}
+	if len(dialErrors) == 0 {
+		return nil, E.New("missing supported outbound")
+	}
+	return nil, E.Errors(dialErrors...)
+}
+
+func (s *Fallback) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
+	s.group.Touch()
+	var dialErrors []error
+	for _, member := range s.candidates(N.NetworkUDP) {
+		conn, err := member.outbound.ListenPacket(ctx, destination)
+		if err == nil {
+			s.updateSelected(member)
+			return s.interruptGroup.NewPacketConn(conn, interrupt.IsExternalConnectionFromContext(ctx)), nil
+		}
+		if ctx.Err() != nil || isDestinationError(member.outbound, destination, err) {
+			return nil, err
+		}
+		s.markFailed(ctx, member, err)
+		dialErrors = append(dialErrors, err)
+	}
+	if len(dialErrors) == 0 {
+		return nil, E.New("missing supported outbound")
+	}
+	return nil, E.Errors(dialErrors...)
+}
+
+// isDestinationError reports whether err is caused by the destination rather
+// than the outbound, so that the destination would fail with any member.
+// Errors reaching the destination are only known for direct outbounds,
+// as other outbounds connect to their servers first.
+func isDestinationError(outbound adapter.Outbound, destination M.Socksaddr, err error) bool {
+	var rcodeError dns.RCodeError
+	if errors.As(err, &rcodeError) && rcodeError == dns.RCodeNameError {
+		return true
+	}
+	if !errors.Is(err, syscall.ECONNREFUSED) && !errors.Is(err, syscall.EHOSTUNREACH) && !errors.Is(err, syscall.ENETUNREACH) {
+		return false
+	}
+	if outbound.Type() == C.TypeDirect {
+		return true
+	}
+	var opError *net.OpError
+	return errors.As(err, &opError) && opError.Addr != nil && M.SocksaddrFromNet(opError.Addr) == destination
+}
+
+func (s *Fallback) NewConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
+	ctx = interrupt.ContextWithIsExternalConnection(ctx)
+	return NewConnection(ctx, s, conn, metadata)
+}
+
+func (s *Fallback) NewPacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext) error {
+	ctx = interrupt.ContextWithIsExternalConnection(ctx)
+	return NewPacketConnection(ctx, s, conn, metadata)
+}
+
+// candidates returns healthy members supporting the network in priority order,
+// followed by unhealthy ones as a last resort.
+func (s *Fallback) candidates(network string) []*fallbackMember {
+	var healthy, unhealthy []*fallbackMember
+	for _, member := range s.members {
+		if !common.Contains(member.outbound.Network(), network) {
+			continue
+		}
+		if s.isHealthy(member) {
+			healthy = append(healthy, member)
+		} else {
+			unhealthy = append(unhealthy, member)
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+
+func (s *Fallback) isHealthy(member *fallbackMember) bool {
+	member.access.Lock()
+	failedAt, retryAt := member.failedAt, member.retryAt
+	member.access.Unlock()
+	if failedAt.IsZero() {
+		return true
+	}
+	history := s.group.history.LoadURLTestHistory(RealTag(member.outbound))
+	if history != nil && history.Time.After(failedAt) {
+		s.markRecovered(member)
+		return true
+	}
+	if time.Now().After(retryAt) && !member.checking.Swap(true) {
+		go s.checkMember(member)
+	}
+	return false
+}
+
+func (s *Fallback) markFailed(ctx context.Context, member *fallbackMember, err error) {
+	member.failovers.Add(1)
+	member.access.Lock()
+	if member.backoff == 0 {
+		member.backoff = s.backoff
+	} else if !member.failedAt.IsZero() {
+		member.backoff = s.nextBackoff(member.backoff)
+	}
+	member.failedAt = time.Now()
+	member.retryAt = member.failedAt.Add(member.backoff)
+	backoff := member.backoff
+	member.access.Unlock()
+	s.group.history.DeleteURLTestHistory(RealTag(member.outbound))
+	s.logger.ErrorContext(ctx, E.Cause(err, "outbound ", member.outbound.Tag(), " failed, retry after ", backoff))
+}
+
+func (s *Fallback) markRecovered(member *fallbackMember) {
+	member.access.Lock()
+	defer member.access.Unlock()
+	if member.failedAt.IsZero() {
+		return
+	}
+	member.failedAt = time.Time{}
+	member.retryAt = time.Time{}
+	member.backoff = 0
+	s.logger.Info("outbound ", member.outbound.Tag(), " recovered")
+}
+
+func (s *Fallback) checkMember(member *fallbackMember) {
+	defer member.checking.Store(false)
+	ctx, cancel := context.WithTimeout(s.ctx, C.TCPTimeout)
+	defer cancel()
+	realTag := RealTag(member.outbound)
+	t, err := urltest.URLTest(ctx, s.link, member.outbound)
+	if err != nil {
+		s.logger.Debug("outbound ", member.outbound.Tag(), " still unavailable: ", err)
+		member.access.Lock()
+		member.backoff = s.nextBackoff(member.backoff)
+		member.retryAt = time.Now().Add(member.backoff)
+		member.access.Unlock()
+		return
+	}
+	s.group.history.StoreURLTestHistory(realTag, &urltest.History{
+		Time:  time.Now(),
+		Delay: t,
+	})
+	s.markRecovered(member)
+	if selected := s.selected.Load(); selected != nil && s.indexOf(member) < s.indexOf(selected) {
+		s.updateSelected(member)
+	}
+}
+
+func (s *Fallback) nextBackoff(backoff time.Duration) time.Duration {
+	backoff *= 2
+	if backoff > s.maxBackoff {
+		backoff = s.maxBackoff
+	}
+	return backoff
+}
+
+func (s *Fallback) updateSelected(member *fallbackMember) {
+	previous := s.selected.Swap(member)
+	if previous == nil || previous == member {
+		return
+	}
+	s.logger.Info("switched from ", previous.outbound.Tag(), " to ", member.outbound.Tag())
+	if s.indexOf(member) < s.indexOf(previous) {
+		// switching back to a preferred member
+		s.interruptGroup.Interrupt(s.interruptExternalConnections)
+	}
+}
+
+func (s *Fallback) indexOf(member *fallbackMember) int {
+	for i, it := range s.members {
+		if it == member {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,148 @@
+package outbound
+
+import (
+	"context"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/interrupt"
+	"github.com/sagernet/sing-box/common/urltest"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-dns"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/service"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testOutbound struct {
+	myOutboundAdapter
+	dialErr error
+	dials   int
+}
+
+func newTestOutbound(protocol string, tag string, dialErr error) *testOutbound {
+	return &testOutbound{
+		myOutboundAdapter: myOutboundAdapter{
+			protocol: protocol,
+			network:  []string{N.NetworkTCP, N.NetworkUDP},
+			logger:   log.NewNOPFactory().Logger(),
+			tag:      tag,
+		},
+		dialErr: dialErr,
+	}
+}
+
+func (o *testOutbound) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	o.dials++
+	if o.dialErr != nil {
+		return nil, o.dialErr
+	}
+	conn, _ := net.Pipe()
+	return conn, nil
+}
+
+func (o *testOutbound) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
+	return nil, o.dialErr
+}
+
+func (o *testOutbound) NewConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
+	return NewConnection(ctx, o, conn, metadata)
+}
+
+func (o *testOutbound) NewPacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext) error {
+	return NewPacketConnection(ctx, o, conn, metadata)
+}
+
+func newTestFallback(t *testing.T, outbounds ...adapter.Outbound) *Fallback {
+	ctx := service.ContextWithPtr(context.Background(), urltest.NewHistoryStorage())
+	fallback := &Fallback{
+		myOutboundAdapter: myOutboundAdapter{
+			protocol: C.TypeFallback,
+			logger:   log.NewNOPFactory().Logger(),
+			tag:      "fallback",
+		},
+		ctx:            ctx,
+		backoff:        defaultFallbackBackoff,
+		maxBackoff:     defaultFallbackMaxBackoff,
+		interruptGroup: interrupt.NewGroup(),
+	}
+	for _, outbound := range outbounds {
+		fallback.tags = append(fallback.tags, outbound.Tag())
+		fallback.members = append(fallback.members, &fallbackMember{outbound: outbound})
+	}
+	group, err := NewURLTestGroup(ctx, nil, fallback.logger, outbounds, "", 0, 0, 0, false)
+	require.NoError(t, err)
+	fallback.group = group
+	return fallback
+}
+
+func TestFallbackServerFailure(t *testing.T) {
+	t.Parallel()
+	destination := M.ParseSocksaddr("203.0.113.1:443")
+	serverErr := &net.OpError{Op: "dial", Net: "tcp", Addr: M.ParseSocksaddr("198.51.100.1:1080").TCPAddr(), Err: syscall.ECONNREFUSED}
+	primary := newTestOutbound(C.TypeSOCKS, "primary", E.Cause(serverErr, "dial server"))
+	backup := newTestOutbound(C.TypeSOCKS, "backup", nil)
+	fallback := newTestFallback(t, primary, backup)
+	conn, err := fallback.DialContext(context.Background(), N.NetworkTCP, destination)
+	require.NoError(t, err)
+	conn.Close()
+	require.Equal(t, "backup", fallback.Now())
+	require.Equal(t, map[string]uint64{"primary": 1, "backup": 0}, fallback.FailoverCounts())
+	require.False(t, fallback.isHealthy(fallback.members[0]))
+}
+
+func TestFallbackDestinationFailure(t *testing.T) {
+	t.Parallel()
+	destination := M.ParseSocksaddr("203.0.113.1:443")
+	for _, testCase := range []struct {
+		name    string
+		primary *testOutbound
+	}{
+		{
+			name:    "direct refused",
+			primary: newTestOutbound(C.TypeDirect, "primary", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}),
+		},
+		{
+			name:    "direct unreachable",
+			primary: newTestOutbound(C.TypeDirect, "primary", E.Errors(&net.OpError{Op: "dial", Net: "tcp", Err: syscall.EHOSTUNREACH})),
+		},
+		{
+			name:    "proxy reports destination",
+			primary: newTestOutbound(C.TypeSOCKS, "primary", &net.OpError{Op: "dial", Net: "tcp", Addr: destination.TCPAddr(), Err: syscall.ECONNREFUSED}),
+		},
+		{
+			name:    "name error",
+			primary: newTestOutbound(C.TypeDirect, "primary", E.Cause(dns.RCodeNameError, "lookup example.invalid")),
+		},
+	} {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			backup := newTestOutbound(C.TypeSOCKS, "backup", nil)
+			fallback := newTestFallback(t, testCase.primary, backup)
+			_, err := fallback.DialContext(context.Background(), N.NetworkTCP, destination)
+			require.Error(t, err)
+			require.Zero(t, backup.dials)
+			require.Equal(t, map[string]uint64{"primary": 0, "backup": 0}, fallback.FailoverCounts())
+			require.True(t, fallback.isHealthy(fallback.members[0]))
+		})
+	}
+}
+
+func TestFallbackAllFailed(t *testing.T) {
+	t.Parallel()
+	primary := newTestOutbound(C.TypeSOCKS, "primary", E.New("handshake failed"))
+	backup := newTestOutbound(C.TypeSOCKS, "backup", E.New("handshake failed"))
+	fallback := newTestFallback(t, primary, backup)
+	_, err := fallback.DialContext(context.Background(), N.NetworkTCP, M.ParseSocksaddr("203.0.113.1:443"))
+	require.Error(t, err)
+	require.Equal(t, map[string]uint64{"primary": 1, "backup": 1}, fallback.FailoverCounts())
+	require.Equal(t, 1, primary.dials)
+	require.Equal(t, 1, backup.dials)
+}
@@ -0,0 +1,346 @@
+package outbound
+
+import (
+	"context"
+	"hash/fnv"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/atomic"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+var (
+	_ adapter.Outbound                = (*LoadBalance)(nil)
+	_ adapter.OutboundGroup           = (*LoadBalance)(nil)
+	_ adapter.URLTestGroup            = (*LoadBalance)(nil)
+	_ adapter.InterfaceUpdateListener = (*LoadBalance)(nil)
+)
+
+type LoadBalance struct {
+	myOutboundAdapter
+	ctx               context.Context
+	tags              []string
+	strategy          string
+	hashKey           string
+	link              string
+	interval          time.Duration
+	idleTimeout       time.Duration
+	outbounds         []adapter.Outbound
+	group             *URLTestGroup
+	roundRobinIndex   atomic.Uint32
+	connectionsAccess sync.Mutex
+	connections       map[string]int
+	lastSelected      atomic.TypedValue[string]
+}
+
+func NewLoadBalance(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.LoadBalanceOutboundOptions) (*LoadBalance, error) {
+	outbound := &LoadBalance{
+		myOutboundAdapter: myOutboundAdapter{
+			protocol:     C.TypeLoadBalance,
+			network:      []string{N.NetworkTCP, N.NetworkUDP},
+			router:       router,
+			logger:       logger,
+			tag:          tag,
+			dependencies: options.Outbounds,
+		},
+		ctx:         ctx,
+		tags:        options.Outbounds,
+		strategy:    options.Strategy,
+		hashKey:     options.HashKey,
+		link:        options.URL,
+		interval:    time.Duration(options.Interval),
+		idleTimeout: time.Duration(options.IdleTimeout),
+		connections: make(map[string]int),
+	}
+	if len(outbound.tags) == 0 {
+		return nil, E.New("missing tags")
+	}
+	switch outbound.strategy {
+	case "":
+		outbound.strategy = C.BalanceStrategyRoundRobin
+	case C.BalanceStrategyRoundRobin, C.BalanceStrategyConsistentHashing, C.BalanceStrategySourceIPHash, C.BalanceStrategyLeastConnections:
+	default:
+		return nil, E.New("unknown load balance strategy: ", outbound.strategy)
+	}
+	switch outbound.hashKey {
+	case "":
+		outbound.hashKey = C.BalanceHashKeyETLDPlusOne
+	case C.BalanceHashKeyDomain, C.BalanceHashKeyETLDPlusOne:
+	default:
+		return nil, E.New("unknown load balance hash key: ", outbound.hashKey)
+	}
+	return outbound, nil
+}
+
+func (s *LoadBalance) Start() error {
+	outbounds := make([]adapter.Outbound, 0, len(s.tags))
+	for i, tag := range s.tags {
+		detour, loaded := s.router.Outbound(tag)
+		if !loaded {
+			return E.New("outbound ", i, " not found: ", tag)
+		}
+		outbounds = append(outbounds, detour)
+	}
+	group, err := NewURLTestGroup(
+		s.ctx,
+		s.router,
+		s.logger,
+		outbounds,
+		s.link,
+		s.interval,
+		0,
+		s.idleTimeout,
+		false,
+	)
+	if err != nil {
+		return err
+	}
+	s.outbounds = outbounds
+	s.group = group
+	return nil
+}
+
+func (s *LoadBalance) PostStart() error {
+	s.group.PostStart()
+	return nil
+}
+
+func (s *LoadBalance) Close() error {
+	return common.Close(
+		common.PtrOrNil(s.group),
+	)
+}
+
+func (s *LoadBalance) Now() string {
+	if selected := s.lastSelected.Load(); selected != "" {
+		return selected
+	}
+	return s.tags[0]
+}
+
+func (s *LoadBalance) All() []string {
+	return s.tags
+}
+
+func (s *LoadBalance) URLTest(ctx context.Context) (map[string]uint16, error) {
+	return s.group.URLTest(ctx)
+}
+
+func (s *LoadBalance) CheckOutbounds() {
+	s.group.CheckOutbounds(true)
+}
+
+func (s *LoadBalance) InterfaceUpdated() {
+	go s.group.CheckOutbounds(true)
+}
+
+func (s *LoadBalance) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	s.group.Touch()
+	outbound, release := s.pick(ctx, N.NetworkName(network), destination)
+	if outbound == nil {
+		return nil, E.New("missing supported outbound")
+	}
+	conn, err := outbound.DialContext(ctx, network, destination)
+	if err != nil {
+		release()
+		s.logger.ErrorContext(ctx, err)
+		s.group.history.DeleteURLTestHistory(RealTag(outbound))
+		return nil, err
+	}
+	if s.strategy == C.BalanceStrategyLeastConnections {
+		conn = &loadBalanceConn{Conn: conn, release: release}
+	}
+	return conn, nil
+}
+
+func (s *LoadBalance) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
+	s.group.Touch()
+	outbound, release := s.pick(ctx, N.NetworkUDP, destination)
+	if outbound == nil {
+		return nil, E.New("missing supported outbound")
+	}
+	conn, err := outbound.ListenPacket(ctx, destination)
+	if err != nil {
+		release()
+		s.logger.ErrorContext(ctx, err)
+		s.group.history.DeleteURLTestHistory(RealTag(outbound))
+		return nil, err
+	}
+	if s.strategy == C.BalanceStrategyLeastConnections {
+		conn = &loadBalancePacketConn{PacketConn: conn, release: release}
+	}
+	return conn, nil
+}
+
+func (s *LoadBalance) NewConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
+	return NewConnection(ctx, s, conn, metadata)
+}
+
+func (s *LoadBalance) NewPacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext) error {
+	return NewPacketConnection(ctx, s, conn, metadata)
+}
+
+// available returns members supporting the network that passed the latest
+// health check, or every member supporting the network if none did.
+func (s *LoadBalance) available(network string) []adapter.Outbound {
+	var candidates, alive []adapter.Outbound
+	for _, detour := range s.outbounds {
+		if !common.Contains(detour.Network(), network) {
+			continue
+		}
+		candidates = append(candidates, detour)
+		if s.group.history.LoadURLTestHistory(RealTag(detour)) != nil {
+			alive = append(alive, detour)
+		}
+	}
+	if len(alive) > 0 {
+		return alive
+	}
+	return candidates
+}
+
+// pick selects a member for a new connection. The returned release function
+// must be called if the connection fails or once it is closed.
+func (s *LoadBalance) pick(ctx context.Context, network string, destination M.Socksaddr) (adapter.Outbound, func()) {
+	candidates := s.available(network)
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	var selected adapter.Outbound
+	release := func() {}
+	switch s.strategy {
+	case C.BalanceStrategyConsistentHashing:
+		selected = rendezvousHash(candidates, s.destinationKey(ctx, destination))
+	case C.BalanceStrategySourceIPHash:
+		var key string
+		if metadata := adapter.ContextFrom(ctx); metadata != nil && metadata.Source.Addr.IsValid() {
+			key = metadata.Source.Addr.String()
+		}
+		selected = rendezvousHash(candidates, key)
+	case C.BalanceStrategyLeastConnections:
+		selected, release = s.leastConnections(candidates)
+	default:
+		selected = candidates[int(s.roundRobinIndex.Add(1)-1)%len(candidates)]
+	}
+	s.lastSelected.Store(selected.Tag())
+	return selected, release
+}
+
+func (s *LoadBalance) destinationKey(ctx context.Context, destination M.Socksaddr) string {
+	var domain string
+	if metadata := adapter.ContextFrom(ctx); metadata != nil && metadata.Domain != "" {
+		domain = metadata.Domain
+	} else if destination.IsFqdn() {
+		domain = destination.Fqdn
+	} else {
+		return destination.Addr.String()
+	}
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	if s.hashKey == C.BalanceHashKeyETLDPlusOne {
+		if etldPlusOne, err := publicsuffix.EffectiveTLDPlusOne(domain); err == nil {
+			return etldPlusOne
+		}
+	}
+	return domain
+}
+
+// leastConnections selects the member with the fewest connections of this
+// group and counts the new connection before it is dialed, so that a burst
+// of dials is spread across members. The connections of the Clash API
+// tracker are not used, as they exist only with the Clash API enabled and
+// are registered after the dial has completed.
+func (s *LoadBalance) leastConnections(candidates []adapter.Outbound) (adapter.Outbound, func()) {
+	s.connectionsAccess.Lock()
+	defer s.connectionsAccess.Unlock()
+	selected := candidates[0]
+	for _, detour := range candidates[1:] {
+		if s.connections[detour.Tag()] < s.connections[selected.Tag()] {
+			selected = detour
+		}
+	}
+	tag := selected.Tag()
+	s.connections[tag]++
+	var once sync.Once
+	return selected, func() {
+		once.Do(func() {
+			s.connectionsAccess.Lock()
+			s.connections[tag]--
+			s.connectionsAccess.Unlock()
+		})
+	}
+}
+
+// rendezvousHash picks the member with the highest hash weight for key,
+// so only keys of a removed member move when the candidate set changes.
+func rendezvousHash(candidates []adapter.Outbound, key string) adapter.Outbound {
+	var selected adapter.Outbound
+	var maxWeight uint64
+	for _, detour := range candidates {
+		hash := fnv.New64a()
+		hash.Write([]byte(key))
+		hash.Write([]byte{0})
+		hash.Write([]byte(detour.Tag()))
+		weight := hash.Sum64()
+		if selected == nil || weight > maxWeight {
+			selected = detour
+			maxWeight = weight
+		}
+	}
+	return selected
+}
+
+type loadBalanceConn struct {
+	net.Conn
+	release func()
+}
+
+func (c *loadBalanceConn) Close() error {
+	c.release()
+	return c.Conn.Close()
+}
+
+func (c *loadBalanceConn) ReaderReplaceable() bool {
+	return true
+}
+
+func (c *loadBalanceConn) WriterReplaceable() bool {
+	return true
+}
+
+func (c *loadBalanceConn) Upstream() any {
+	return c.Conn
+}
+
+type loadBalancePacketConn struct {
+	net.PacketConn
+	release func()
+}
+
+func (c *loadBalancePacketConn) Close() error {
+	c.release()
+	return c.PacketConn.Close()
+}
+
+func (c *loadBalancePacketConn) ReaderReplaceable() bool {
+	return true
+}
+
+func (c *loadBalancePacketConn) WriterReplaceable() bool {
+	return true
+}
+
+func (c *loadBalancePacketConn) Upstream() any {
+	return c.PacketConn
+}
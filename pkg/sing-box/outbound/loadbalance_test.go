@@ -0,0 +1,167 @@
+package outbound
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/urltest"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/service"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLoadBalance(t *testing.T, options option.LoadBalanceOutboundOptions, outbounds ...adapter.Outbound) *LoadBalance {
+	ctx := service.ContextWithPtr(context.Background(), urltest.NewHistoryStorage())
+	for _, outbound := range outbounds {
+		options.Outbounds = append(options.Outbounds, outbound.Tag())
+	}
+	loadBalance, err := NewLoadBalance(ctx, nil, log.NewNOPFactory().Logger(), "load-balance", options)
+	require.NoError(t, err)
+	group, err := NewURLTestGroup(ctx, nil, loadBalance.logger, outbounds, "", 0, 0, 0, false)
+	require.NoError(t, err)
+	loadBalance.outbounds = outbounds
+	loadBalance.group = group
+	return loadBalance
+}
+
+func dialTag(t *testing.T, loadBalance *LoadBalance, ctx context.Context, destination string) (string, net.Conn) {
+	conn, err := loadBalance.DialContext(ctx, N.NetworkTCP, M.ParseSocksaddr(destination))
+	require.NoError(t, err)
+	return loadBalance.Now(), conn
+}
+
+func TestLoadBalanceRoundRobin(t *testing.T) {
+	t.Parallel()
+	loadBalance := newTestLoadBalance(t, option.LoadBalanceOutboundOptions{},
+		newTestOutbound(C.TypeSOCKS, "a", nil),
+		newTestOutbound(C.TypeSOCKS, "b", nil),
+		newTestOutbound(C.TypeSOCKS, "c", nil),
+	)
+	var tags []string
+	for i := 0; i < 6; i++ {
+		tag, conn := dialTag(t, loadBalance, context.Background(), "example.com:443")
+		conn.Close()
+		tags = append(tags, tag)
+	}
+	require.Equal(t, []string{"a", "b", "c", "a", "b", "c"}, tags)
+}
+
+func TestLoadBalanceHealthy(t *testing.T) {
+	t.Parallel()
+	loadBalance := newTestLoadBalance(t, option.LoadBalanceOutboundOptions{},
+		newTestOutbound(C.TypeSOCKS, "a", nil),
+		newTestOutbound(C.TypeSOCKS, "b", nil),
+	)
+	loadBalance.group.history.StoreURLTestHistory("b", &urltest.History{Time: time.Now(), Delay: 10})
+	for i := 0; i < 3; i++ {
+		tag, conn := dialTag(t, loadBalance, context.Background(), "example.com:443")
+		conn.Close()
+		require.Equal(t, "b", tag)
+	}
+}
+
+func TestLoadBalanceConsistentHashing(t *testing.T) {
+	t.Parallel()
+	members := []adapter.Outbound{
+		newTestOutbound(C.TypeSOCKS, "a", nil),
+		newTestOutbound(C.TypeSOCKS, "b", nil),
+		newTestOutbound(C.TypeSOCKS, "c", nil),
+	}
+	options := option.LoadBalanceOutboundOptions{Strategy: C.BalanceStrategyConsistentHashing}
+	loadBalance := newTestLoadBalance(t, options, members...)
+	tag, conn := dialTag(t, loadBalance, context.Background(), "www.example.com:443")
+	conn.Close()
+	for _, destination := range []string{"api.example.com:443", "example.com:80", "cdn.www.example.com:443"} {
+		other, conn := dialTag(t, loadBalance, context.Background(), destination)
+		conn.Close()
+		require.Equal(t, tag, other, destination)
+	}
+	options.HashKey = C.BalanceHashKeyDomain
+	loadBalance = newTestLoadBalance(t, options, members...)
+	require.Equal(t, "www.example.com", loadBalance.destinationKey(context.Background(), M.ParseSocksaddr("WWW.example.com.:443")))
+	ctx := adapter.WithContext(context.Background(), &adapter.InboundContext{Domain: "sniffed.example.org"})
+	require.Equal(t, "sniffed.example.org", loadBalance.destinationKey(ctx, M.ParseSocksaddr("1.1.1.1:443")))
+
+	// only keys of a removed member move
+	keys := make(map[string]adapter.Outbound)
+	for i := 0; i < 100; i++ {
+		key := F.ToString("key", i)
+		keys[key] = rendezvousHash(members, key)
+	}
+	for key, selected := range keys {
+		remaining := rendezvousHash([]adapter.Outbound{members[0], members[2]}, key)
+		if selected != members[1] {
+			require.Equal(t, selected, remaining, key)
+		}
+	}
+}
+
+func TestLoadBalanceSourceIPHash(t *testing.T) {
+	t.Parallel()
+	loadBalance := newTestLoadBalance(t, option.LoadBalanceOutboundOptions{Strategy: C.BalanceStrategySourceIPHash},
+		newTestOutbound(C.TypeSOCKS, "a", nil),
+		newTestOutbound(C.TypeSOCKS, "b", nil),
+		newTestOutbound(C.TypeSOCKS, "c", nil),
+	)
+	selectedTags := make(map[string]bool)
+	for i := 0; i < 32; i++ {
+		source := M.SocksaddrFrom(netip.AddrFrom4([4]byte{192, 168, 0, byte(i)}), 10000)
+		ctx := adapter.WithContext(context.Background(), &adapter.InboundContext{Source: source})
+		tag, conn := dialTag(t, loadBalance, ctx, "example.com:443")
+		conn.Close()
+		for _, destination := range []string{"example.org:443", "1.1.1.1:53"} {
+			source.Port++
+			ctx = adapter.WithContext(context.Background(), &adapter.InboundContext{Source: source})
+			other, conn := dialTag(t, loadBalance, ctx, destination)
+			conn.Close()
+			require.Equal(t, tag, other)
+		}
+		selectedTags[tag] = true
+	}
+	require.Len(t, selectedTags, 3)
+}
+
+func TestLoadBalanceLeastConnections(t *testing.T) {
+	t.Parallel()
+	failed := newTestOutbound(C.TypeSOCKS, "c", E.New("handshake failed"))
+	loadBalance := newTestLoadBalance(t, option.LoadBalanceOutboundOptions{Strategy: C.BalanceStrategyLeastConnections},
+		newTestOutbound(C.TypeSOCKS, "a", nil),
+		newTestOutbound(C.TypeSOCKS, "b", nil),
+		failed,
+	)
+	var conns []net.Conn
+	for i := 0; i < 2; i++ {
+		tag, conn := dialTag(t, loadBalance, context.Background(), "example.com:443")
+		require.NotEqual(t, "c", tag)
+		conns = append(conns, conn)
+	}
+	// the failed dial to c is not counted
+	_, err := loadBalance.DialContext(context.Background(), N.NetworkTCP, M.ParseSocksaddr("example.com:443"))
+	require.Error(t, err)
+	require.Equal(t, 0, loadBalance.connections["c"])
+	failed.dialErr = nil
+	tag, conn := dialTag(t, loadBalance, context.Background(), "example.com:443")
+	require.Equal(t, "c", tag)
+	conns = append(conns, conn)
+	require.Equal(t, map[string]int{"a": 1, "b": 1, "c": 1}, loadBalance.connections)
+	conns[1].Close()
+	conns[1].Close()
+	require.Equal(t, map[string]int{"a": 1, "b": 0, "c": 1}, loadBalance.connections)
+	tag, conn = dialTag(t, loadBalance, context.Background(), "example.com:443")
+	require.Equal(t, "b", tag)
+	conn.Close()
+	conns[0].Close()
+	conns[2].Close()
+	require.Equal(t, map[string]int{"a": 0, "b": 0, "c": 0}, loadBalance.connections)
+}
@@ -20,6 +20,7 @@ import (
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/atomic"
 	E "github.com/sagernet/sing/common/exceptions"
 	"github.com/sagernet/sing/common/json"
 	M "github.com/sagernet/sing/common/metadata"
@@ -54,6 +55,7 @@ type Provider struct {
 	includeKeyWords option.Listable[string]
 	excludeKeyWords option.Listable[string]
 	ctx             context.Context
+	updatedAt       atomic.TypedValue[time.Time]
 }
 
 func NewProvider(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.ProviderOutboundOptions) (*Provider, error) {
@@ -202,6 +204,10 @@ func (s *Provider) AllOutbound() map[string]adapter.Outbound {
 	return s.outbounds
 }
 
+func (s *Provider) UpdatedAt() time.Time {
+	return s.updatedAt.Load()
+}
+
 func (s *Provider) SelectOutbound(tag string) bool {
 	detour, loaded := s.outbounds[tag]
 	if !loaded {
@@ -340,6 +346,7 @@ func (s *Provider) parseProvider(ctx context.Context, content []byte) error {
 	if err != nil {
 		s.logger.Error("updateSelected err ", err)
 	}
+	s.updatedAt.Store(time.Now())
 
 	//if s.selected == nil {
 	//	s.selected = s.outbounds[s.tags[0]]
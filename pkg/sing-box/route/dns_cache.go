@@ -0,0 +1,497 @@
+package route
+
+import (
+	"context"
+	"net/netip"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-dns"
+	"github.com/sagernet/sing/common/cache"
+	E "github.com/sagernet/sing/common/exceptions"
+
+	mDNS "github.com/miekg/dns"
+)
+
+const (
+	dnsCacheMaxSize         = 65536
+	defaultDNSStaleTimeout  = 24 * time.Hour
+	dnsStaleResponseTTL     = 30
+	dnsPrefetchMinHits      = 2
+	dnsPrefetchMinTTL       = 10
+	dnsPrefetchRemainingDiv = 10
+	dnsCacheSaveInterval    = 10 * time.Second
+)
+
+// dnsCache holds settings shared by all cached transports.
+// The cache file is only known after the router starts.
+type dnsCache struct {
+	logger        log.ContextLogger
+	disableExpire bool
+	serveStale    bool
+	staleTimeout  time.Duration
+	prefetch      bool
+	storeCache    bool
+	cacheFile     adapter.CacheFile
+
+	// answers waiting to be written to the cache file, by transport name and key
+	saveAccess  sync.Mutex
+	saves       map[string]map[string]*adapter.SavedDNSCache
+	writeAccess sync.Mutex
+	done        chan struct{}
+	closed      chan struct{}
+}
+
+// start persists answers to cacheFile, writing them in batches.
+func (c *dnsCache) start(cacheFile adapter.CacheFile) {
+	c.cacheFile = cacheFile
+	c.done = make(chan struct{})
+	c.closed = make(chan struct{})
+	go c.loopSave()
+}
+
+func (c *dnsCache) loopSave() {
+	defer close(c.closed)
+	ticker := time.NewTicker(dnsCacheSaveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.flush()
+		case <-c.done:
+			c.flush()
+			return
+		}
+	}
+}
+
+// close writes pending answers and stops saving.
+func (c *dnsCache) close() {
+	if c.done == nil {
+		return
+	}
+	close(c.done)
+	<-c.closed
+}
+
+func (c *dnsCache) queueSave(transportName string, key string, cache *adapter.SavedDNSCache) {
+	c.saveAccess.Lock()
+	defer c.saveAccess.Unlock()
+	if c.saves == nil {
+		c.saves = make(map[string]map[string]*adapter.SavedDNSCache)
+	}
+	transportSaves := c.saves[transportName]
+	if transportSaves == nil {
+		transportSaves = make(map[string]*adapter.SavedDNSCache)
+		c.saves[transportName] = transportSaves
+	}
+	transportSaves[key] = cache
+}
+
+func (c *dnsCache) flush() {
+	c.writeAccess.Lock()
+	defer c.writeAccess.Unlock()
+	c.saveAccess.Lock()
+	saves := c.saves
+	c.saves = nil
+	c.saveAccess.Unlock()
+	if len(saves) == 0 {
+		return
+	}
+	err := c.cacheFile.SaveDNSCache(saves)
+	if err != nil {
+		c.logger.Warn("save DNS cache: ", err)
+	}
+}
+
+// clear drops pending and stored answers of a transport.
+func (c *dnsCache) clear(transportName string) {
+	c.writeAccess.Lock()
+	defer c.writeAccess.Unlock()
+	c.saveAccess.Lock()
+	delete(c.saves, transportName)
+	c.saveAccess.Unlock()
+	err := c.cacheFile.ClearDNSCache(transportName)
+	if err != nil {
+		c.logger.Warn("clear DNS cache for ", transportName, ": ", err)
+	}
+}
+
+type dnsCacheEntry struct {
+	access     sync.Mutex
+	message    *mDNS.Msg
+	request    *mDNS.Msg
+	ttl        uint32
+	rewriteTTL bool
+	expireAt   time.Time
+	storedAt   time.Time
+	hits       int
+	refreshing bool
+}
+
+// cachedDNSTransport replaces the DNS client cache for a transport
+// when stale answers, prefetching or persistence are requested.
+type cachedDNSTransport struct {
+	dns.Transport
+	ctx     context.Context
+	options *dnsCache
+	entries *cache.LruCache[mDNS.Question, *dnsCacheEntry]
+	// answers stored before are expired, in Unix nanoseconds
+	expiredAt atomic.Int64
+}
+
+func newCachedDNSTransport(ctx context.Context, transport dns.Transport, options *dnsCache) *cachedDNSTransport {
+	return &cachedDNSTransport{
+		Transport: transport,
+		ctx:       ctx,
+		options:   options,
+		entries:   cache.New[mDNS.Question, *dnsCacheEntry](cache.WithSize[mDNS.Question, *dnsCacheEntry](dnsCacheMaxSize)),
+	}
+}
+
+// wrapCachedDNSTransport leaves transports without upstream answers
+// (fakeip, hosts and rcode) uncached. Groups must be given unwrapped members,
+// so their answers are cached once.
+func wrapCachedDNSTransport(ctx context.Context, transport dns.Transport, options *dnsCache) dns.Transport {
+	switch transport.(type) {
+	case adapter.FakeIPTransport, adapter.HostsTransport, *dns.RCodeTransport:
+		return transport
+	}
+	return newCachedDNSTransport(ctx, transport, options)
+}
+
+func (t *cachedDNSTransport) Start() error {
+	err := t.Transport.Start()
+	if err != nil {
+		return err
+	}
+	if cacheFile := t.options.cacheFile; cacheFile != nil {
+		expiredBefore := time.Now()
+		if t.options.serveStale {
+			expiredBefore = expiredBefore.Add(-t.options.staleTimeout)
+		}
+		go func() {
+			err := cacheFile.PurgeDNSCache(t.Name(), expiredBefore)
+			if err != nil {
+				t.options.logger.Warn("purge DNS cache for ", t.Name(), ": ", err)
+			}
+		}()
+	}
+	return nil
+}
+
+func (t *cachedDNSTransport) Upstream() any {
+	return t.Transport
+}
+
+func (t *cachedDNSTransport) Exchange(ctx context.Context, message *mDNS.Msg) (*mDNS.Msg, error) {
+	if len(message.Question) != 1 || dns.DisableCacheFromContext(ctx) {
+		return t.Transport.Exchange(ctx, message)
+	}
+	question := message.Question[0]
+	if entry := t.loadEntry(question); entry != nil {
+		response := t.cachedResponse(entry, question)
+		if response != nil {
+			markDNSQueryCached(ctx)
+			response.Id = message.Id
+			return response, nil
+		}
+	}
+	response, err := t.Transport.Exchange(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+	rewriteTTL, loaded := dns.RewriteTTLFromContext(ctx)
+	t.storeResponse(question, message, response, rewriteTTL, loaded)
+	return response, nil
+}
+
+func (t *cachedDNSTransport) Lookup(ctx context.Context, domain string, strategy dns.DomainStrategy) ([]netip.Addr, error) {
+	if dns.DisableCacheFromContext(ctx) {
+		return t.Transport.Lookup(ctx, domain, strategy)
+	}
+	questions := lookupQuestions(domain, strategy)
+	var response4, response6 []netip.Addr
+	cached := true
+	for _, question := range questions {
+		entry := t.loadEntry(question)
+		if entry == nil {
+			cached = false
+			break
+		}
+		response := t.cachedResponse(entry, question)
+		if response == nil {
+			cached = false
+			break
+		}
+		addresses, _ := dns.MessageToAddresses(response)
+		for _, address := range addresses {
+			if address.Is4() {
+				response4 = append(response4, address)
+			} else {
+				response6 = append(response6, address)
+			}
+		}
+	}
+	if cached {
+		markDNSQueryCached(ctx)
+		if len(response4) == 0 && len(response6) == 0 {
+			return nil, dns.RCodeNameError
+		}
+		if strategy == dns.DomainStrategyPreferIPv6 {
+			return append(response6, response4...), nil
+		}
+		return append(response4, response6...), nil
+	}
+	addresses, err := t.Transport.Lookup(ctx, domain, strategy)
+	if err != nil {
+		return nil, err
+	}
+	timeToLive := uint32(dns.DefaultTTL)
+	if rewriteTTL, loaded := dns.RewriteTTLFromContext(ctx); loaded {
+		timeToLive = rewriteTTL
+	}
+	for _, question := range questions {
+		t.storeResponse(question, nil, lookupResponse(question, addresses, timeToLive), timeToLive, true)
+	}
+	return addresses, nil
+}
+
+// Expire marks answers cached until now as expired, so they are queried
+// again, or served stale while refreshed. Stored answers are kept.
+func (t *cachedDNSTransport) Expire() {
+	t.expiredAt.Store(time.Now().UnixNano())
+}
+
+// Clear drops cached answers, including stale and stored ones.
+func (t *cachedDNSTransport) Clear() {
+	t.entries.Clear()
+	if t.options.cacheFile != nil {
+		t.options.clear(t.Name())
+	}
+}
+
+func (t *cachedDNSTransport) loadEntry(question mDNS.Question) *dnsCacheEntry {
+	entry, loaded := t.entries.Load(question)
+	if loaded {
+		return entry
+	}
+	cacheFile := t.options.cacheFile
+	if cacheFile == nil {
+		return nil
+	}
+	savedCache := cacheFile.LoadDNSCache(t.Name(), dnsCacheKey(question))
+	if savedCache == nil {
+		return nil
+	}
+	deadline := savedCache.ExpireAt
+	if t.options.serveStale {
+		deadline = deadline.Add(t.options.staleTimeout)
+	}
+	if !t.options.disableExpire && time.Now().After(deadline) {
+		return nil
+	}
+	var message mDNS.Msg
+	err := message.Unpack(savedCache.Message)
+	if err != nil {
+		return nil
+	}
+	entry = &dnsCacheEntry{
+		message:  &message,
+		ttl:      savedCache.TTL,
+		expireAt: savedCache.ExpireAt,
+		storedAt: savedCache.ExpireAt.Add(-time.Duration(savedCache.TTL) * time.Second),
+	}
+	t.entries.Store(question, entry)
+	return entry
+}
+
+func (t *cachedDNSTransport) cachedResponse(entry *dnsCacheEntry, question mDNS.Question) *mDNS.Msg {
+	entry.access.Lock()
+	defer entry.access.Unlock()
+	expired := entry.storedAt.UnixNano() < t.expiredAt.Load()
+	if t.options.disableExpire && !expired {
+		return entry.message.Copy()
+	}
+	now := time.Now()
+	if !expired && now.Before(entry.expireAt) {
+		entry.hits++
+		remaining := uint32(entry.expireAt.Sub(now) / time.Second)
+		if t.options.prefetch && !entry.refreshing && entry.hits >= dnsPrefetchMinHits &&
+			entry.ttl >= dnsPrefetchMinTTL && remaining < entry.ttl/dnsPrefetchRemainingDiv {
+			entry.refreshing = true
+			go t.refresh(question, entry)
+		}
+		if remaining == 0 {
+			remaining = 1
+		}
+		return copyWithTTL(entry.message, remaining)
+	}
+	if t.options.serveStale && (t.options.disableExpire || now.Before(entry.expireAt.Add(t.options.staleTimeout))) {
+		if !entry.refreshing {
+			entry.refreshing = true
+			go t.refresh(question, entry)
+		}
+		return copyWithTTL(entry.message, dnsStaleResponseTTL)
+	}
+	return nil
+}
+
+func (t *cachedDNSTransport) refresh(question mDNS.Question, entry *dnsCacheEntry) {
+	ctx, cancel := context.WithTimeout(t.ctx, C.DNSTimeout)
+	defer cancel()
+	entry.access.Lock()
+	request, rewriteTTL, ttl := entry.request, entry.rewriteTTL, entry.ttl
+	entry.access.Unlock()
+	var (
+		response *mDNS.Msg
+		err      error
+	)
+	if t.Transport.Raw() {
+		if request == nil {
+			request = &mDNS.Msg{
+				MsgHdr: mDNS.MsgHdr{
+					RecursionDesired: true,
+				},
+				Question: []mDNS.Question{question},
+			}
+		}
+		request = request.Copy()
+		request.Id = mDNS.Id()
+		response, err = t.Transport.Exchange(ctx, request)
+	} else {
+		strategy := dns.DomainStrategyUseIPv4
+		if question.Qtype == mDNS.TypeAAAA {
+			strategy = dns.DomainStrategyUseIPv6
+		}
+		var addresses []netip.Addr
+		addresses, err = t.Transport.Lookup(ctx, question.Name, strategy)
+		if err == nil {
+			response = lookupResponse(question, addresses, ttl)
+			rewriteTTL = true
+		}
+	}
+	if err != nil {
+		entry.access.Lock()
+		entry.refreshing = false
+		entry.access.Unlock()
+		t.options.logger.Debug(E.Cause(err, "refresh cached ", question.Name, " on ", t.Name()))
+		return
+	}
+	t.storeResponse(question, request, response, ttl, rewriteTTL)
+}
+
+func (t *cachedDNSTransport) storeResponse(question mDNS.Question, request *mDNS.Msg, response *mDNS.Msg, rewriteTTL uint32, rewrite bool) {
+	if response.Rcode != mDNS.RcodeSuccess && response.Rcode != mDNS.RcodeNameError {
+		return
+	}
+	var timeToLive uint32
+	if rewrite {
+		timeToLive = rewriteTTL
+	} else {
+		timeToLive = messageTTL(response)
+	}
+	if timeToLive == 0 {
+		return
+	}
+	now := time.Now()
+	entry := &dnsCacheEntry{
+		message:    response.Copy(),
+		ttl:        timeToLive,
+		rewriteTTL: rewrite,
+		expireAt:   now.Add(time.Duration(timeToLive) * time.Second),
+		storedAt:   now,
+	}
+	if request != nil {
+		entry.request = request.Copy()
+	}
+	t.entries.Store(question, entry)
+	if t.options.cacheFile != nil {
+		content, err := response.Pack()
+		if err != nil {
+			return
+		}
+		t.options.queueSave(t.Name(), dnsCacheKey(question), &adapter.SavedDNSCache{
+			Message:  content,
+			TTL:      timeToLive,
+			ExpireAt: entry.expireAt,
+		})
+	}
+}
+
+func dnsCacheKey(question mDNS.Question) string {
+	return question.Name + "/" + strconv.Itoa(int(question.Qtype)) + "/" + strconv.Itoa(int(question.Qclass))
+}
+
+// messageTTL returns the lowest non-zero TTL of all records in message.
+func messageTTL(message *mDNS.Msg) uint32 {
+	var timeToLive uint32
+	for _, recordList := range [][]mDNS.RR{message.Answer, message.Ns, message.Extra} {
+		for _, record := range recordList {
+			if record.Header().Rrtype == mDNS.TypeOPT {
+				continue
+			}
+			if ttl := record.Header().Ttl; ttl > 0 && (timeToLive == 0 || ttl < timeToLive) {
+				timeToLive = ttl
+			}
+		}
+	}
+	return timeToLive
+}
+
+func copyWithTTL(message *mDNS.Msg, timeToLive uint32) *mDNS.Msg {
+	response := message.Copy()
+	for _, recordList := range [][]mDNS.RR{response.Answer, response.Ns, response.Extra} {
+		for _, record := range recordList {
+			if record.Header().Rrtype == mDNS.TypeOPT {
+				continue
+			}
+			record.Header().Ttl = timeToLive
+		}
+	}
+	return response
+}
+
+func lookupQuestions(domain string, strategy dns.DomainStrategy) []mDNS.Question {
+	dnsName := mDNS.Fqdn(domain)
+	var questions []mDNS.Question
+	if strategy != dns.DomainStrategyUseIPv6 {
+		questions = append(questions, mDNS.Question{Name: dnsName, Qtype: mDNS.TypeA, Qclass: mDNS.ClassINET})
+	}
+	if strategy != dns.DomainStrategyUseIPv4 {
+		questions = append(questions, mDNS.Question{Name: dnsName, Qtype: mDNS.TypeAAAA, Qclass: mDNS.ClassINET})
+	}
+	return questions
+}
+
+// lookupResponse wraps addresses returned by a non-raw transport into
+// a message answering question, so they can be cached like raw responses.
+func lookupResponse(question mDNS.Question, addresses []netip.Addr, timeToLive uint32) *mDNS.Msg {
+	response := &mDNS.Msg{
+		MsgHdr: mDNS.MsgHdr{
+			Response: true,
+			Rcode:    mDNS.RcodeSuccess,
+		},
+		Question: []mDNS.Question{question},
+	}
+	for _, address := range addresses {
+		address = address.Unmap()
+		header := mDNS.RR_Header{
+			Name:   question.Name,
+			Rrtype: question.Qtype,
+			Class:  mDNS.ClassINET,
+			Ttl:    timeToLive,
+		}
+		if question.Qtype == mDNS.TypeA && address.Is4() {
+			response.Answer = append(response.Answer, &mDNS.A{Hdr: header, A: address.AsSlice()})
+		} else if question.Qtype == mDNS.TypeAAAA && address.Is6() {
+			response.Answer = append(response.Answer, &mDNS.AAAA{Hdr: header, AAAA: address.AsSlice()})
+		}
+	}
+	return response
+}
@@ -0,0 +1,174 @@
+package route
+
+import (
+	"context"
+	"net/netip"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/experimental/cachefile"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-dns"
+
+	mDNS "github.com/miekg/dns"
+	"github.com/stretchr/testify/require"
+)
+
+// testCacheUpstream answers 1.1.1.1 to the first query, 1.1.1.2 to the second and so on.
+type testCacheUpstream struct {
+	dns.Transport
+	queries atomic.Int32
+}
+
+func (u *testCacheUpstream) Name() string {
+	return "upstream"
+}
+
+func (u *testCacheUpstream) Raw() bool {
+	return true
+}
+
+func (u *testCacheUpstream) Exchange(ctx context.Context, message *mDNS.Msg) (*mDNS.Msg, error) {
+	queries := u.queries.Add(1)
+	response := new(mDNS.Msg)
+	response.SetReply(message)
+	response.Answer = append(response.Answer, &mDNS.A{
+		Hdr: mDNS.RR_Header{Name: message.Question[0].Name, Rrtype: mDNS.TypeA, Class: mDNS.ClassINET, Ttl: 60},
+		A:   netip.AddrFrom4([4]byte{1, 1, 1, byte(queries)}).AsSlice(),
+	})
+	return response, nil
+}
+
+func newTestDNSCache() *dnsCache {
+	return &dnsCache{
+		logger:       log.NewNOPFactory().Logger(),
+		staleTimeout: time.Hour,
+	}
+}
+
+func exchangeCached(t *testing.T, transport *cachedDNSTransport, id uint16) (address string, ttl uint32) {
+	message := new(mDNS.Msg)
+	message.SetQuestion("example.com.", mDNS.TypeA)
+	message.Id = id
+	response, err := transport.Exchange(context.Background(), message)
+	require.NoError(t, err)
+	require.Equal(t, id, response.Id)
+	require.Len(t, response.Answer, 1)
+	return response.Answer[0].(*mDNS.A).A.String(), response.Answer[0].Header().Ttl
+}
+
+func expireCached(transport *cachedDNSTransport) {
+	transport.entries.Range(func(_ mDNS.Question, entry *dnsCacheEntry) {
+		entry.access.Lock()
+		entry.expireAt = time.Now().Add(-time.Second)
+		entry.access.Unlock()
+	})
+}
+
+func TestDNSCacheHit(t *testing.T) {
+	t.Parallel()
+	upstream := &testCacheUpstream{}
+	transport := newCachedDNSTransport(context.Background(), upstream, newTestDNSCache())
+	address, ttl := exchangeCached(t, transport, 1)
+	require.Equal(t, "1.1.1.1", address)
+	require.Equal(t, uint32(60), ttl)
+	address, ttl = exchangeCached(t, transport, 2)
+	require.Equal(t, "1.1.1.1", address)
+	require.LessOrEqual(t, ttl, uint32(60))
+	require.Equal(t, int32(1), upstream.queries.Load())
+}
+
+func TestDNSCacheExpire(t *testing.T) {
+	t.Parallel()
+	upstream := &testCacheUpstream{}
+	transport := newCachedDNSTransport(context.Background(), upstream, newTestDNSCache())
+	exchangeCached(t, transport, 1)
+	expireCached(transport)
+	address, _ := exchangeCached(t, transport, 2)
+	require.Equal(t, "1.1.1.2", address)
+	require.Equal(t, int32(2), upstream.queries.Load())
+}
+
+func TestDNSCacheServeStale(t *testing.T) {
+	t.Parallel()
+	options := newTestDNSCache()
+	options.serveStale = true
+	upstream := &testCacheUpstream{}
+	transport := newCachedDNSTransport(context.Background(), upstream, options)
+	exchangeCached(t, transport, 1)
+	expireCached(transport)
+	address, ttl := exchangeCached(t, transport, 2)
+	require.Equal(t, "1.1.1.1", address)
+	require.Equal(t, uint32(dnsStaleResponseTTL), ttl)
+	require.Eventually(t, func() bool {
+		address, _ = exchangeCached(t, transport, 3)
+		return address == "1.1.1.2"
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, int32(2), upstream.queries.Load())
+
+	// clearing drops stale answers as well
+	expireCached(transport)
+	transport.Clear()
+	address, _ = exchangeCached(t, transport, 4)
+	require.Equal(t, "1.1.1.3", address)
+}
+
+func TestDNSCachePersistence(t *testing.T) {
+	t.Parallel()
+	cacheFile := cachefile.New(context.Background(), option.CacheFileOptions{
+		Path: filepath.Join(t.TempDir(), "cache.db"),
+	})
+	require.NoError(t, cacheFile.PreStart())
+	defer cacheFile.Close()
+	question := mDNS.Question{Name: "example.com.", Qtype: mDNS.TypeA, Qclass: mDNS.ClassINET}
+
+	options := newTestDNSCache()
+	options.start(cacheFile)
+	transport := newCachedDNSTransport(context.Background(), &testCacheUpstream{}, options)
+	exchangeCached(t, transport, 1)
+	// answers are written in batches
+	require.Nil(t, cacheFile.LoadDNSCache("upstream", dnsCacheKey(question)))
+	options.close()
+	require.NotNil(t, cacheFile.LoadDNSCache("upstream", dnsCacheKey(question)))
+
+	options = newTestDNSCache()
+	options.start(cacheFile)
+	defer options.close()
+	upstream := &testCacheUpstream{}
+	transport = newCachedDNSTransport(context.Background(), upstream, options)
+	address, ttl := exchangeCached(t, transport, 2)
+	require.Equal(t, "1.1.1.1", address)
+	require.LessOrEqual(t, ttl, uint32(60))
+	require.Zero(t, upstream.queries.Load())
+
+	// expiring keeps stored answers, which are expired as well when loaded again
+	transport.Expire()
+	transport.entries.Clear()
+	exchangeCached(t, transport, 3)
+	require.Equal(t, int32(1), upstream.queries.Load())
+	require.NotNil(t, cacheFile.LoadDNSCache("upstream", dnsCacheKey(question)))
+
+	transport.Clear()
+	require.Nil(t, cacheFile.LoadDNSCache("upstream", dnsCacheKey(question)))
+}
+
+func TestDNSCacheExpireAll(t *testing.T) {
+	t.Parallel()
+	for _, disableExpire := range []bool{false, true} {
+		options := newTestDNSCache()
+		options.disableExpire = disableExpire
+		upstream := &testCacheUpstream{}
+		transport := newCachedDNSTransport(context.Background(), upstream, options)
+		exchangeCached(t, transport, 1)
+		transport.Expire()
+		address, _ := exchangeCached(t, transport, 2)
+		require.Equal(t, "1.1.1.2", address)
+		// answers cached after are kept
+		address, _ = exchangeCached(t, transport, 3)
+		require.Equal(t, "1.1.1.2", address)
+		require.Equal(t, int32(2), upstream.queries.Load())
+	}
+}
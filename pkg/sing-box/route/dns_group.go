@@ -0,0 +1,265 @@
+package route
+
+import (
+	"context"
+	"net/netip"
+	"os"
+	"strings"
+	"time"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-dns"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+
+	mDNS "github.com/miekg/dns"
+	"go4.org/netipx"
+)
+
+const defaultDNSGroupTimeout = 3 * time.Second
+
+// defaultBogusIPCIDR lists ranges a public domain should never resolve to,
+// which is typical for poisoned answers.
+var defaultBogusIPCIDR = []string{
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"::/128",
+	"::1/128",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+}
+
+var _ dns.Transport = (*DNSGroup)(nil)
+
+// DNSGroup queries several DNS servers for a single query.
+type DNSGroup struct {
+	name       string
+	logger     logger.ContextLogger
+	members    []dns.Transport
+	strategy   string
+	timeout    time.Duration
+	bogusIPSet *netipx.IPSet
+}
+
+type dnsGroupResult struct {
+	member   dns.Transport
+	response *mDNS.Msg
+	err      error
+}
+
+func NewDNSGroup(name string, logger logger.ContextLogger, members []dns.Transport, options option.DNSGroupOptions) (*DNSGroup, error) {
+	group := &DNSGroup{
+		name:     name,
+		logger:   logger,
+		members:  members,
+		strategy: options.Strategy,
+		timeout:  time.Duration(options.Timeout),
+	}
+	if len(members) == 0 {
+		return nil, E.New("missing servers")
+	}
+	switch group.strategy {
+	case "":
+		group.strategy = C.DNSGroupStrategyRace
+	case C.DNSGroupStrategyRace, C.DNSGroupStrategyFastest, C.DNSGroupStrategyFallback:
+	default:
+		return nil, E.New("unknown DNS group strategy: ", group.strategy)
+	}
+	if group.timeout == 0 {
+		group.timeout = defaultDNSGroupTimeout
+	}
+	var bogusIPCIDR []string
+	if options.RejectBogus {
+		bogusIPCIDR = append(bogusIPCIDR, defaultBogusIPCIDR...)
+	}
+	bogusIPCIDR = append(bogusIPCIDR, options.BogusIPCIDR...)
+	if len(bogusIPCIDR) > 0 {
+		var builder netipx.IPSetBuilder
+		for i, cidr := range bogusIPCIDR {
+			prefix, err := netip.ParsePrefix(cidr)
+			if err == nil {
+				builder.AddPrefix(prefix)
+				continue
+			}
+			address, addrErr := netip.ParseAddr(cidr)
+			if addrErr == nil {
+				builder.Add(address)
+				continue
+			}
+			return nil, E.Cause(err, "parse bogus_ip_cidr [", i, "]")
+		}
+		bogusIPSet, err := builder.IPSet()
+		if err != nil {
+			return nil, err
+		}
+		group.bogusIPSet = bogusIPSet
+	}
+	return group, nil
+}
+
+func (g *DNSGroup) Name() string {
+	return g.name
+}
+
+func (g *DNSGroup) Start() error {
+	return nil
+}
+
+func (g *DNSGroup) Reset() {
+}
+
+func (g *DNSGroup) Close() error {
+	return nil
+}
+
+func (g *DNSGroup) Raw() bool {
+	return true
+}
+
+func (g *DNSGroup) Lookup(ctx context.Context, domain string, strategy dns.DomainStrategy) ([]netip.Addr, error) {
+	return nil, os.ErrInvalid
+}
+
+func (g *DNSGroup) Exchange(ctx context.Context, message *mDNS.Msg) (*mDNS.Msg, error) {
+	if len(message.Question) != 1 {
+		return g.exchange(ctx, g.members[0], message)
+	}
+	if g.strategy == C.DNSGroupStrategyFallback {
+		return g.exchangeFallback(ctx, message)
+	}
+	return g.exchangeParallel(ctx, message)
+}
+
+func (g *DNSGroup) exchangeFallback(ctx context.Context, message *mDNS.Msg) (*mDNS.Msg, error) {
+	var (
+		candidate *mDNS.Msg
+		errors    []error
+	)
+	for _, member := range g.members {
+		memberCtx, cancel := context.WithTimeout(ctx, g.timeout)
+		response, err := g.exchange(memberCtx, member, message)
+		cancel()
+		accepted, err := g.check(message.Question[0], member, response, err, false)
+		if accepted {
+			return response, nil
+		}
+		if err != nil {
+			errors = append(errors, err)
+		} else {
+			candidate = preferredResponse(candidate, response)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if candidate != nil {
+		return candidate, nil
+	}
+	return nil, E.Errors(errors...)
+}
+
+// exchangeParallel queries all members at once. The race strategy takes the
+// first usable answer, the fastest strategy waits for one containing addresses.
+func (g *DNSGroup) exchangeParallel(ctx context.Context, message *mDNS.Msg) (*mDNS.Msg, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan dnsGroupResult, len(g.members))
+	for _, member := range g.members {
+		go func(member dns.Transport) {
+			response, err := g.exchange(ctx, member, message)
+			results <- dnsGroupResult{member, response, err}
+		}(member)
+	}
+	question := message.Question[0]
+	requireAddresses := g.strategy == C.DNSGroupStrategyFastest && (question.Qtype == mDNS.TypeA || question.Qtype == mDNS.TypeAAAA)
+	var (
+		candidate *mDNS.Msg
+		errors    []error
+	)
+	for range g.members {
+		result := <-results
+		accepted, err := g.check(question, result.member, result.response, result.err, requireAddresses)
+		if accepted {
+			return result.response, nil
+		}
+		if err != nil {
+			errors = append(errors, err)
+		} else {
+			candidate = preferredResponse(candidate, result.response)
+		}
+	}
+	if candidate != nil {
+		return candidate, nil
+	}
+	return nil, E.Errors(errors...)
+}
+
+func (g *DNSGroup) exchange(ctx context.Context, member dns.Transport, message *mDNS.Msg) (*mDNS.Msg, error) {
+	if member.Raw() {
+		return member.Exchange(ctx, message.Copy())
+	}
+	question := message.Question[0]
+	var strategy dns.DomainStrategy
+	switch question.Qtype {
+	case mDNS.TypeA:
+		strategy = dns.DomainStrategyUseIPv4
+	case mDNS.TypeAAAA:
+		strategy = dns.DomainStrategyUseIPv6
+	default:
+		return nil, dns.ErrNoRawSupport
+	}
+	addresses, err := member.Lookup(ctx, strings.TrimSuffix(question.Name, "."), strategy)
+	if err != nil {
+		return nil, err
+	}
+	response := lookupResponse(question, addresses, dns.DefaultTTL)
+	response.Id = message.Id
+	return response, nil
+}
+
+// check reports whether response can be returned as is. Rejected responses
+// without error may still be returned if no member gives a better one.
+func (g *DNSGroup) check(question mDNS.Question, member dns.Transport, response *mDNS.Msg, err error, requireAddresses bool) (bool, error) {
+	if err != nil {
+		return false, E.Cause(err, member.Name())
+	}
+	if g.bogusIPSet != nil {
+		addresses, _ := dns.MessageToAddresses(response)
+		for _, address := range addresses {
+			if g.bogusIPSet.Contains(address.Unmap()) {
+				g.logger.Debug("rejected bogus answer ", address, " for ", question.Name, " from ", member.Name())
+				return false, E.New(member.Name(), ": bogus answer ", address)
+			}
+		}
+	}
+	if isFailureResponse(response) {
+		return false, nil
+	}
+	if requireAddresses && response.Rcode == mDNS.RcodeSuccess {
+		addresses, _ := dns.MessageToAddresses(response)
+		if len(addresses) == 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func isFailureResponse(response *mDNS.Msg) bool {
+	return response.Rcode == mDNS.RcodeServerFailure || response.Rcode == mDNS.RcodeRefused
+}
+
+func preferredResponse(candidate *mDNS.Msg, response *mDNS.Msg) *mDNS.Msg {
+	if candidate == nil || isFailureResponse(candidate) && !isFailureResponse(response) {
+		return response
+	}
+	return candidate
+}
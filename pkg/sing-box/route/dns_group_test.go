@@ -0,0 +1,174 @@
+package route_test
+
+import (
+	"context"
+	"net/netip"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/route"
+	"github.com/sagernet/sing-dns"
+	E "github.com/sagernet/sing/common/exceptions"
+
+	mDNS "github.com/miekg/dns"
+	"github.com/stretchr/testify/require"
+)
+
+type testDNSMember struct {
+	name      string
+	delay     time.Duration
+	rcode     int
+	addresses []string
+	err       error
+	queries   atomic.Int32
+}
+
+func (m *testDNSMember) Name() string {
+	return m.name
+}
+
+func (m *testDNSMember) Start() error {
+	return nil
+}
+
+func (m *testDNSMember) Reset() {
+}
+
+func (m *testDNSMember) Close() error {
+	return nil
+}
+
+func (m *testDNSMember) Raw() bool {
+	return true
+}
+
+func (m *testDNSMember) Exchange(ctx context.Context, message *mDNS.Msg) (*mDNS.Msg, error) {
+	m.queries.Add(1)
+	select {
+	case <-time.After(m.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if m.err != nil {
+		return nil, m.err
+	}
+	response := new(mDNS.Msg)
+	response.SetReply(message)
+	response.Rcode = m.rcode
+	for _, address := range m.addresses {
+		response.Answer = append(response.Answer, &mDNS.A{
+			Hdr: mDNS.RR_Header{Name: message.Question[0].Name, Rrtype: mDNS.TypeA, Class: mDNS.ClassINET, Ttl: 60},
+			A:   netip.MustParseAddr(address).AsSlice(),
+		})
+	}
+	return response, nil
+}
+
+func (m *testDNSMember) Lookup(ctx context.Context, domain string, strategy dns.DomainStrategy) ([]netip.Addr, error) {
+	return nil, os.ErrInvalid
+}
+
+func exchangeGroup(t *testing.T, options option.DNSGroupOptions, members ...*testDNSMember) (*mDNS.Msg, error) {
+	transports := make([]dns.Transport, len(members))
+	for i, member := range members {
+		transports[i] = member
+	}
+	group, err := route.NewDNSGroup("group", log.NewNOPFactory().Logger(), transports, options)
+	require.NoError(t, err)
+	message := new(mDNS.Msg)
+	message.SetQuestion("example.com.", mDNS.TypeA)
+	return group.Exchange(context.Background(), message)
+}
+
+func answerAddresses(response *mDNS.Msg) []string {
+	addresses, _ := dns.MessageToAddresses(response)
+	var result []string
+	for _, address := range addresses {
+		result = append(result, address.String())
+	}
+	return result
+}
+
+func TestDNSGroupRace(t *testing.T) {
+	t.Parallel()
+	response, err := exchangeGroup(t, option.DNSGroupOptions{},
+		&testDNSMember{name: "slow", delay: 200 * time.Millisecond, addresses: []string{"1.1.1.1"}},
+		&testDNSMember{name: "fast", addresses: []string{"2.2.2.2"}},
+	)
+	require.NoError(t, err)
+	require.Equal(t, []string{"2.2.2.2"}, answerAddresses(response))
+}
+
+func TestDNSGroupFastestRequiresAddresses(t *testing.T) {
+	t.Parallel()
+	options := option.DNSGroupOptions{Strategy: C.DNSGroupStrategyFastest}
+	response, err := exchangeGroup(t, options,
+		&testDNSMember{name: "empty"},
+		&testDNSMember{name: "slow", delay: 100 * time.Millisecond, addresses: []string{"1.1.1.1"}},
+	)
+	require.NoError(t, err)
+	require.Equal(t, []string{"1.1.1.1"}, answerAddresses(response))
+	response, err = exchangeGroup(t, options,
+		&testDNSMember{name: "empty"},
+		&testDNSMember{name: "failed", err: E.New("unreachable")},
+	)
+	require.NoError(t, err)
+	require.Equal(t, mDNS.RcodeSuccess, response.Rcode)
+	require.Empty(t, response.Answer)
+}
+
+func TestDNSGroupFallback(t *testing.T) {
+	t.Parallel()
+	options := option.DNSGroupOptions{
+		Strategy: C.DNSGroupStrategyFallback,
+		Timeout:  option.Duration(100 * time.Millisecond),
+	}
+	failed := &testDNSMember{name: "failed", err: E.New("unreachable")}
+	timeout := &testDNSMember{name: "timeout", delay: time.Second}
+	serverFailure := &testDNSMember{name: "servfail", rcode: mDNS.RcodeServerFailure}
+	working := &testDNSMember{name: "working", addresses: []string{"1.1.1.1"}}
+	unused := &testDNSMember{name: "unused", addresses: []string{"2.2.2.2"}}
+	response, err := exchangeGroup(t, options, failed, timeout, serverFailure, working, unused)
+	require.NoError(t, err)
+	require.Equal(t, []string{"1.1.1.1"}, answerAddresses(response))
+	require.Equal(t, int32(1), working.queries.Load())
+	require.Zero(t, unused.queries.Load())
+
+	response, err = exchangeGroup(t, options,
+		&testDNSMember{name: "servfail", rcode: mDNS.RcodeServerFailure},
+		&testDNSMember{name: "nxdomain", rcode: mDNS.RcodeNameError},
+	)
+	require.NoError(t, err)
+	require.Equal(t, mDNS.RcodeNameError, response.Rcode)
+
+	_, err = exchangeGroup(t, options,
+		&testDNSMember{name: "failed", err: E.New("unreachable")},
+		&testDNSMember{name: "timeout", delay: time.Second},
+	)
+	require.Error(t, err)
+}
+
+func TestDNSGroupRejectBogus(t *testing.T) {
+	t.Parallel()
+	options := option.DNSGroupOptions{
+		Strategy:    C.DNSGroupStrategyFallback,
+		RejectBogus: true,
+		BogusIPCIDR: []string{"203.0.113.1"},
+	}
+	response, err := exchangeGroup(t, options,
+		&testDNSMember{name: "private", addresses: []string{"127.0.0.1"}},
+		&testDNSMember{name: "poisoned", addresses: []string{"203.0.113.1"}},
+		&testDNSMember{name: "clean", addresses: []string{"1.1.1.1"}},
+	)
+	require.NoError(t, err)
+	require.Equal(t, []string{"1.1.1.1"}, answerAddresses(response))
+	_, err = exchangeGroup(t, option.DNSGroupOptions{RejectBogus: true},
+		&testDNSMember{name: "private", addresses: []string{"10.0.0.1"}},
+	)
+	require.Error(t, err)
+}
@@ -0,0 +1,97 @@
+package route
+
+import (
+	"context"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-dns"
+	F "github.com/sagernet/sing/common/format"
+
+	mDNS "github.com/miekg/dns"
+)
+
+type dnsQueryTraceKey struct{}
+
+// dnsQueryTrace lets cached transports report a cache hit
+// for a query recorded by the router.
+type dnsQueryTrace struct {
+	cached bool
+}
+
+func contextWithDNSQueryTrace(ctx context.Context) (context.Context, *dnsQueryTrace) {
+	trace := &dnsQueryTrace{}
+	return context.WithValue(ctx, dnsQueryTraceKey{}, trace), trace
+}
+
+func markDNSQueryCached(ctx context.Context) {
+	if trace, loaded := ctx.Value(dnsQueryTraceKey{}).(*dnsQueryTrace); loaded {
+		trace.cached = true
+	}
+}
+
+func newDNSQueryRecord(ctx context.Context, startAt time.Time, domain string, queryType string, transport dns.Transport, rule adapter.DNSRule, cached bool, err error) adapter.DNSQueryRecord {
+	record := adapter.DNSQueryRecord{
+		Time:      startAt,
+		Domain:    domain,
+		QueryType: queryType,
+		Latency:   time.Since(startAt).Milliseconds(),
+		Cached:    cached,
+	}
+	if rule != nil {
+		record.Rule = rule.String()
+	}
+	if transport != nil && !cached {
+		record.Upstream = transport.Name()
+	}
+	if metadata := adapter.ContextFrom(ctx); metadata != nil {
+		if metadata.Source.IsValid() {
+			record.Client = metadata.Source.String()
+		}
+		record.Inbound = metadata.Inbound
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	return record
+}
+
+func (r *Router) recordExchange(ctx context.Context, startAt time.Time, message *mDNS.Msg, response *mDNS.Msg, transport dns.Transport, rule adapter.DNSRule, cached bool, err error) {
+	if r.dnsQueryRecorder == nil || len(message.Question) == 0 {
+		return
+	}
+	question := message.Question[0]
+	record := newDNSQueryRecord(ctx, startAt, fqdnToDomain(question.Name), mDNS.TypeToString[question.Qtype], transport, rule, cached, err)
+	if response != nil {
+		record.RCode = mDNS.RcodeToString[response.Rcode]
+		for _, answer := range response.Answer {
+			record.Answers = append(record.Answers, strings.TrimSpace(strings.TrimPrefix(answer.String(), answer.Header().String())))
+		}
+	}
+	r.dnsQueryRecorder.RecordDNSQuery(record)
+}
+
+func (r *Router) recordLookup(ctx context.Context, startAt time.Time, domain string, strategy dns.DomainStrategy, addresses []netip.Addr, transport dns.Transport, rule adapter.DNSRule, cached bool, err error) {
+	if r.dnsQueryRecorder == nil {
+		return
+	}
+	var queryType string
+	switch strategy {
+	case dns.DomainStrategyUseIPv4:
+		queryType = "A"
+	case dns.DomainStrategyUseIPv6:
+		queryType = "AAAA"
+	default:
+		queryType = "A/AAAA"
+	}
+	record := newDNSQueryRecord(ctx, startAt, domain, queryType, transport, rule, cached, err)
+	if err == nil {
+		record.RCode = mDNS.RcodeToString[mDNS.RcodeSuccess]
+	} else if rcodeError, isRCodeError := err.(dns.RCodeError); isRCodeError {
+		record.RCode = mDNS.RcodeToString[int(rcodeError)]
+	}
+	record.Answers = F.MapToString(addresses)
+	r.dnsQueryRecorder.RecordDNSQuery(record)
+}
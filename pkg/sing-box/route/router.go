@@ -18,8 +18,11 @@ import (
 	"github.com/sagernet/sing-box/common/geoip"
 	"github.com/sagernet/sing-box/common/geosite"
 	"github.com/sagernet/sing-box/common/process"
+	"github.com/sagernet/sing-box/common/ratelimit"
+	"github.com/sagernet/sing-box/common/schedule"
 	"github.com/sagernet/sing-box/common/sniff"
 	"github.com/sagernet/sing-box/common/taskmonitor"
+	"github.com/sagernet/sing-box/common/userlimit"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/experimental/libbox/platform"
 	"github.com/sagernet/sing-box/log"
@@ -27,6 +30,7 @@ import (
 	"github.com/sagernet/sing-box/option"
 	"github.com/sagernet/sing-box/outbound"
 	"github.com/sagernet/sing-box/transport/fakeip"
+	"github.com/sagernet/sing-box/transport/hosts"
 	"github.com/sagernet/sing-dns"
 	mux "github.com/sagernet/sing-mux"
 	"github.com/sagernet/sing-tun"
@@ -36,6 +40,7 @@ import (
 	"github.com/sagernet/sing/common/bufio"
 	"github.com/sagernet/sing/common/bufio/deadline"
 	"github.com/sagernet/sing/common/control"
+	"github.com/sagernet/sing/common/domain"
 	E "github.com/sagernet/sing/common/exceptions"
 	F "github.com/sagernet/sing/common/format"
 	M "github.com/sagernet/sing/common/metadata"
@@ -54,6 +59,7 @@ type Router struct {
 	ctx                                context.Context
 	logger                             log.ContextLogger
 	dnsLogger                          log.ContextLogger
+	inbounds                           []adapter.Inbound
 	inboundByTag                       map[string]adapter.Inbound
 	outbounds                          []adapter.Outbound
 	outboundByTag                      map[string]adapter.Outbound
@@ -71,6 +77,10 @@ type Router struct {
 	needFindProcess                    bool
 	dnsClient                          *dns.Client
 	dnsIndependentCache                bool
+	dnsCache                           *dnsCache
+	dnsQueryRecorder                   adapter.DNSQueryRecorder
+	userLimiter                        *userlimit.Limiter
+	rateLimitManager                   *ratelimit.Manager
 	defaultDomainStrategy              dns.DomainStrategy
 	dnsRules                           []adapter.DNSRule
 	ruleSets                           []adapter.RuleSet
@@ -81,6 +91,7 @@ type Router struct {
 	transportDomainStrategy            map[dns.Transport]dns.DomainStrategy
 	dnsReverseMapping                  *DNSReverseMapping
 	fakeIPStore                        adapter.FakeIPStore
+	fakeIPExclude                      *domain.Matcher
 	interfaceFinder                    myInterfaceFinder
 	autoDetectInterface                bool
 	defaultInterface                   string
@@ -91,9 +102,12 @@ type Router struct {
 	powerListener                      winpowrprof.EventListener
 	processSearcher                    process.Searcher
 	timeService                        *ntp.Service
+	scheduleOptions                    []option.Schedule
+	scheduler                          *schedule.Scheduler
 	pauseManager                       pause.Manager
 	clashServer                        adapter.ClashServer
 	v2rayServer                        adapter.V2RayServer
+	trackers                           []adapter.ConnectionTracker
 	platformInterface                  platform.Interface
 	needWIFIState                      bool
 	needPackageManager                 bool
@@ -108,6 +122,7 @@ func NewRouter(
 	dnsOptions option.DNSOptions,
 	ntpOptions option.NTPOptions,
 	inbounds []option.Inbound,
+	outbounds []option.Outbound,
 	platformInterface platform.Interface,
 ) (*Router, error) {
 	router := &Router{
@@ -125,6 +140,8 @@ func NewRouter(
 		geositeCache:          make(map[string]adapter.Rule),
 		needFindProcess:       hasRule(options.Rules, isProcessRule) || hasDNSRule(dnsOptions.Rules, isProcessDNSRule) || options.FindProcess,
 		dnsIndependentCache:   dnsOptions.IndependentCache,
+		userLimiter:           userlimit.NewLimiter(ctx, logFactory.NewLogger("user-limit"), inbounds),
+		rateLimitManager:      ratelimit.NewManager(outbounds),
 		defaultDetour:         options.Final,
 		defaultDomainStrategy: dns.DomainStrategy(dnsOptions.Strategy),
 		autoDetectInterface:   options.AutoDetectInterface,
@@ -137,8 +154,24 @@ func NewRouter(
 			return len(inbound.TunOptions.IncludePackage) > 0 || len(inbound.TunOptions.ExcludePackage) > 0
 		}),
 	}
+	if clientOptions := dnsOptions.DNSClientOptions; clientOptions.ServeStale || clientOptions.Prefetch || clientOptions.StoreCache {
+		if clientOptions.DisableCache {
+			return nil, E.New("serve_stale, prefetch and store_cache require DNS cache enabled")
+		}
+		router.dnsCache = &dnsCache{
+			logger:        router.dnsLogger,
+			disableExpire: clientOptions.DisableExpire,
+			serveStale:    clientOptions.ServeStale,
+			staleTimeout:  time.Duration(clientOptions.StaleTimeout),
+			prefetch:      clientOptions.Prefetch,
+			storeCache:    clientOptions.StoreCache,
+		}
+		if router.dnsCache.staleTimeout == 0 {
+			router.dnsCache.staleTimeout = defaultDNSStaleTimeout
+		}
+	}
 	router.dnsClient = dns.NewClient(dns.ClientOptions{
-		DisableCache:     dnsOptions.DNSClientOptions.DisableCache,
+		DisableCache:     dnsOptions.DNSClientOptions.DisableCache || router.dnsCache != nil,
 		DisableExpire:    dnsOptions.DNSClientOptions.DisableExpire,
 		IndependentCache: dnsOptions.DNSClientOptions.IndependentCache,
 		RDRC: func() dns.RDRCStore {
@@ -154,14 +187,14 @@ func NewRouter(
 		Logger: router.dnsLogger,
 	})
 	for i, ruleOptions := range options.Rules {
-		routeRule, err := NewRule(router, router.logger, ruleOptions, true)
+		routeRule, err := NewRule(ctx, router, router.logger, ruleOptions, true)
 		if err != nil {
 			return nil, E.Cause(err, "parse rule[", i, "]")
 		}
 		router.rules = append(router.rules, routeRule)
 	}
 	for i, dnsRuleOptions := range dnsOptions.Rules {
-		dnsRule, err := NewDNSRule(router, router.logger, dnsRuleOptions, true)
+		dnsRule, err := NewDNSRule(ctx, router, router.logger, dnsRuleOptions, true)
 		if err != nil {
 			return nil, E.Cause(err, "parse dns rule[", i, "]")
 		}
@@ -178,6 +211,18 @@ func NewRouter(
 		router.ruleSets = append(router.ruleSets, ruleSet)
 		router.ruleSetMap[ruleSetOptions.Tag] = ruleSet
 	}
+	if len(options.Schedule) > 0 {
+		scheduleTasks := make([]schedule.Task, 0, len(options.Schedule))
+		for i, scheduleOptions := range options.Schedule {
+			task, err := router.newScheduleTask(scheduleOptions)
+			if err != nil {
+				return nil, E.Cause(err, "parse schedule[", i, "]")
+			}
+			scheduleTasks = append(scheduleTasks, task)
+		}
+		router.scheduleOptions = options.Schedule
+		router.scheduler = schedule.NewScheduler(ctx, scheduleTasks)
+	}
 
 	transports := make([]dns.Transport, len(dnsOptions.Servers))
 	dummyTransportMap := make(map[string]dns.Transport)
@@ -213,7 +258,23 @@ func NewRouter(
 				detour = dialer.NewDetour(router, server.Detour)
 			}
 			switch server.Address {
-			case "local":
+			case "local", "hosts":
+			case "group":
+				if server.Group == nil {
+					return nil, E.New("parse dns server[", tag, "]: missing group options")
+				}
+				var pending bool
+				for _, member := range server.Group.Servers {
+					if !transportTagMap[member] {
+						return nil, E.New("parse dns server[", tag, "]: group server not found: ", member)
+					}
+					if _, exists := dummyTransportMap[member]; !exists {
+						pending = true
+					}
+				}
+				if pending {
+					continue
+				}
 			default:
 				serverURL, _ := url.Parse(server.Address)
 				var serverAddress string
@@ -243,17 +304,58 @@ func NewRouter(
 			} else if dnsOptions.ClientSubnet != nil {
 				clientSubnet = dnsOptions.ClientSubnet.Build()
 			}
-			transport, err := dns.CreateTransport(dns.TransportOptions{
-				Context:      ctx,
-				Logger:       logFactory.NewLogger(F.ToString("dns/transport[", tag, "]")),
-				Name:         tag,
-				Dialer:       detour,
-				Address:      server.Address,
-				ClientSubnet: clientSubnet,
-			})
+			var (
+				transport dns.Transport
+				err       error
+			)
+			if server.Address == "hosts" {
+				transport, err = hosts.NewTransport(tag, logFactory.NewLogger(F.ToString("dns/transport[", tag, "]")), router, common.PtrValueOrDefault(server.Hosts))
+			} else if server.Hosts != nil {
+				err = E.New("hosts options is only available for hosts server")
+			} else if server.Address == "group" {
+				members := make([]dns.Transport, 0, len(server.Group.Servers))
+				for _, member := range server.Group.Servers {
+					memberTransport := dummyTransportMap[member]
+					if _, isFakeIP := memberTransport.(adapter.FakeIPTransport); isFakeIP {
+						return nil, E.New("parse dns server[", tag, "]: fakeip server can not be used in group: ", member)
+					}
+					if cachedTransport, isCached := memberTransport.(*cachedDNSTransport); isCached {
+						memberTransport = cachedTransport.Transport
+					}
+					members = append(members, memberTransport)
+				}
+				transport, err = NewDNSGroup(tag, logFactory.NewLogger(F.ToString("dns/transport[", tag, "]")), members, *server.Group)
+			} else if server.Group != nil {
+				err = E.New("group options is only available for group server")
+			} else if server.Address == "fakeip" {
+				if server.FakeIPPool != "" && !common.Any(common.PtrValueOrDefault(dnsOptions.FakeIP).Pools, func(it option.DNSFakeIPPoolOptions) bool {
+					return it.Tag == server.FakeIPPool
+				}) {
+					return nil, E.New("parse dns server[", tag, "]: fakeip pool not found: ", server.FakeIPPool)
+				}
+				transport, err = fakeip.NewTransport(dns.TransportOptions{
+					Context: ctx,
+					Logger:  logFactory.NewLogger(F.ToString("dns/transport[", tag, "]")),
+					Name:    tag,
+				}, server.FakeIPPool)
+			} else if server.FakeIPPool != "" {
+				err = E.New("fakeip_pool is only available for fakeip server")
+			} else {
+				transport, err = dns.CreateTransport(dns.TransportOptions{
+					Context:      ctx,
+					Logger:       logFactory.NewLogger(F.ToString("dns/transport[", tag, "]")),
+					Name:         tag,
+					Dialer:       detour,
+					Address:      server.Address,
+					ClientSubnet: clientSubnet,
+				})
+			}
 			if err != nil {
 				return nil, E.Cause(err, "parse dns server[", tag, "]")
 			}
+			if router.dnsCache != nil {
+				transport = wrapCachedDNSTransport(ctx, transport, router.dnsCache)
+			}
 			transports[i] = transport
 			dummyTransportMap[tag] = transport
 			if server.Tag != "" {
@@ -312,15 +414,14 @@ func NewRouter(
 	}
 
 	if fakeIPOptions := dnsOptions.FakeIP; fakeIPOptions != nil && dnsOptions.FakeIP.Enabled {
-		var inet4Range netip.Prefix
-		var inet6Range netip.Prefix
-		if fakeIPOptions.Inet4Range != nil {
-			inet4Range = *fakeIPOptions.Inet4Range
+		fakeIPStore, err := fakeip.NewStore(ctx, router.logger, *fakeIPOptions)
+		if err != nil {
+			return nil, E.Cause(err, "parse fakeip")
 		}
-		if fakeIPOptions.Inet6Range != nil {
-			inet6Range = *fakeIPOptions.Inet6Range
+		router.fakeIPStore = fakeIPStore
+		if len(fakeIPOptions.ExcludeDomain) > 0 || len(fakeIPOptions.ExcludeDomainSuffix) > 0 {
+			router.fakeIPExclude = domain.NewMatcher(fakeIPOptions.ExcludeDomain, fakeIPOptions.ExcludeDomainSuffix)
 		}
-		router.fakeIPStore = fakeip.NewStore(ctx, router.logger, inet4Range, inet6Range)
 	}
 
 	usePlatformDefaultInterfaceMonitor := platformInterface != nil && platformInterface.UsePlatformDefaultInterfaceMonitor()
@@ -370,6 +471,7 @@ func NewRouter(
 		service.ContextWith[serviceNTP.TimeService](ctx, timeService)
 		router.timeService = timeService
 	}
+	service.MustRegister[adapter.UserLimiter](ctx, router.userLimiter)
 	return router, nil
 }
 
@@ -442,17 +544,33 @@ func (r *Router) Initialize(inbounds []adapter.Inbound, outbounds []adapter.Outb
 		r.logger.Info("using ", defaultOutboundForConnection.Type(), "[", description, "] as default outbound for connection")
 		r.logger.Info("using ", defaultOutboundForPacketConnection.Type(), "[", packetDescription, "] as default outbound for packet connection")
 	}
+	r.inbounds = inbounds
 	r.inboundByTag = inboundByTag
 	r.outbounds = outbounds
 	r.defaultOutboundForConnection = defaultOutboundForConnection
 	r.defaultOutboundForPacketConnection = defaultOutboundForPacketConnection
 	r.outboundByTag = outboundByTag
 	for i, rule := range r.rules {
+		if rule.Outbound() == "" {
+			continue
+		}
 		if _, loaded := outboundByTag[rule.Outbound()]; !loaded {
 			return E.New("outbound not found for rule[", i, "]: ", rule.Outbound())
 		}
 	}
-	return nil
+	return r.checkScheduleOptions(r.scheduleOptions)
+}
+
+func (r *Router) Inbounds() []adapter.Inbound {
+	if !r.started {
+		return nil
+	}
+	return r.inbounds
+}
+
+func (r *Router) Inbound(tag string) (adapter.Inbound, bool) {
+	inbound, loaded := r.inboundByTag[tag]
+	return inbound, loaded
 }
 
 func (r *Router) Outbounds() []adapter.Outbound {
@@ -642,6 +760,13 @@ func (r *Router) Start() error {
 			return E.Cause(err, "initialize DNS rule[", i, "]")
 		}
 	}
+	if r.dnsCache != nil && r.dnsCache.storeCache {
+		cacheFile := service.FromContext[adapter.CacheFile](r.ctx)
+		if cacheFile == nil {
+			return E.New("store_cache requires cache_file enabled")
+		}
+		r.dnsCache.start(cacheFile)
+	}
 	for i, transport := range r.transports {
 		monitor.Start("initialize DNS transport[", i, "]")
 		err := transport.Start()
@@ -650,6 +775,12 @@ func (r *Router) Start() error {
 			return E.Cause(err, "initialize DNS server[", i, "]")
 		}
 	}
+	monitor.Start("initialize user limiter")
+	err := r.userLimiter.Start()
+	monitor.Finish()
+	if err != nil {
+		return E.Cause(err, "initialize user limiter")
+	}
 	if r.timeService != nil {
 		monitor.Start("initialize time service")
 		err := r.timeService.Start()
@@ -678,6 +809,11 @@ func (r *Router) Close() error {
 		})
 		monitor.Finish()
 	}
+	monitor.Start("close user limiter")
+	err = E.Append(err, r.userLimiter.Close(), func(err error) error {
+		return E.Cause(err, "close user limiter")
+	})
+	monitor.Finish()
 	for i, transport := range r.transports {
 		monitor.Start("close dns transport[", i, "]")
 		err = E.Append(err, transport.Close(), func(err error) error {
@@ -685,6 +821,11 @@ func (r *Router) Close() error {
 		})
 		monitor.Finish()
 	}
+	if r.dnsCache != nil {
+		monitor.Start("save dns cache")
+		r.dnsCache.close()
+		monitor.Finish()
+	}
 	if r.geoIPReader != nil {
 		monitor.Start("close geoip reader")
 		err = E.Append(err, r.geoIPReader.Close(), func(err error) error {
@@ -720,6 +861,13 @@ func (r *Router) Close() error {
 		})
 		monitor.Finish()
 	}
+	if r.scheduler != nil {
+		monitor.Start("close scheduler")
+		err = E.Append(err, r.scheduler.Close(), func(err error) error {
+			return E.Cause(err, "close scheduler")
+		})
+		monitor.Finish()
+	}
 	if r.timeService != nil {
 		monitor.Start("close time service")
 		err = E.Append(err, r.timeService.Close(), func(err error) error {
@@ -746,6 +894,16 @@ func (r *Router) PostStart() error {
 			}
 		}
 	}
+	if r.scheduler != nil {
+		err := r.checkScheduleClashMode(r.scheduleOptions)
+		if err != nil {
+			return err
+		}
+		err = r.scheduler.Start()
+		if err != nil {
+			return E.Cause(err, "start scheduler")
+		}
+	}
 	r.started = true
 	return nil
 }
@@ -778,11 +936,15 @@ func (r *Router) RuleSet(tag string) (adapter.RuleSet, bool) {
 	return ruleSet, loaded
 }
 
+func (r *Router) RuleSets() map[string]adapter.RuleSet {
+	return r.ruleSetMap
+}
+
 func (r *Router) NeedWIFIState() bool {
 	return r.needWIFIState
 }
 
-func (r *Router) RouteConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
+func (r *Router) RouteConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) (err error) {
 	if r.pauseManager.IsDevicePaused() {
 		return E.New("reject connection to ", metadata.Destination, " while device paused")
 	}
@@ -812,6 +974,11 @@ func (r *Router) RouteConnection(ctx context.Context, conn net.Conn, metadata ad
 		return nil
 	}
 	conntrack.KillerCheck()
+	conn, releaseUser, err := r.userLimiter.RoutedConnection(ctx, conn, metadata)
+	if err != nil {
+		return err
+	}
+	defer releaseUser()
 	metadata.Network = N.NetworkTCP
 	switch metadata.Destination.Fqdn {
 	case mux.Destination.Fqdn:
@@ -844,7 +1011,7 @@ func (r *Router) RouteConnection(ctx context.Context, conn net.Conn, metadata ad
 
 	if metadata.InboundOptions.SniffEnabled {
 		buffer := buf.NewPacket()
-		sniffMetadata, err := sniff.PeekStream(ctx, conn, buffer, time.Duration(metadata.InboundOptions.SniffTimeout), sniff.StreamDomainNameQuery, sniff.TLSClientHello, sniff.HTTPHost)
+		sniffMetadata, err := sniff.PeekStream(ctx, conn, buffer, time.Duration(metadata.InboundOptions.SniffTimeout), sniff.StreamDomainNameQuery, sniff.TLSClientHello, sniff.HTTP2Authority, sniff.HTTPHost, sniff.SSHBanner, sniff.BitTorrentHandshake, sniff.RDPConnectionRequest)
 		if sniffMetadata != nil {
 			metadata.Protocol = sniffMetadata.Protocol
 			metadata.Domain = sniffMetadata.Domain
@@ -890,17 +1057,20 @@ func (r *Router) RouteConnection(ctx context.Context, conn net.Conn, metadata ad
 	} else if metadata.Destination.IsIPv6() {
 		metadata.IPVersion = 6
 	}
-	ctx, matchedRule, detour, err := r.match(ctx, &metadata, r.defaultOutboundForConnection)
+	ctx, matchedRule, detour, rateLimiters, err := r.match(ctx, &metadata, r.defaultOutboundForConnection)
 	if err != nil {
 		return err
 	}
 	if !common.Contains(detour.Network(), N.NetworkTCP) {
 		return E.New("missing supported outbound, closing connection")
 	}
-	if r.clashServer != nil {
-		trackerConn, tracker := r.clashServer.RoutedConnection(ctx, conn, metadata, matchedRule)
-		defer tracker.Leave()
-		conn = trackerConn
+	conn = ratelimit.NewConn(conn, append(r.rateLimitManager.Limiters(metadata, adapter.AppendOutboundChain(nil, r, detour.Tag())), rateLimiters...))
+	for _, connectionTracker := range r.trackers {
+		var tracker adapter.Tracker
+		conn, tracker = connectionTracker.RoutedConnection(ctx, conn, metadata, matchedRule)
+		defer func() {
+			tracker.Leave(err)
+		}()
 	}
 	if r.v2rayServer != nil {
 		if statsService := r.v2rayServer.StatsService(); statsService != nil {
@@ -910,7 +1080,7 @@ func (r *Router) RouteConnection(ctx context.Context, conn net.Conn, metadata ad
 	return detour.NewConnection(ctx, conn, metadata)
 }
 
-func (r *Router) RoutePacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext) error {
+func (r *Router) RoutePacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext) (err error) {
 	if r.pauseManager.IsDevicePaused() {
 		return E.New("reject packet connection to ", metadata.Destination, " while device paused")
 	}
@@ -939,6 +1109,11 @@ func (r *Router) RoutePacketConnection(ctx context.Context, conn N.PacketConn, m
 		return nil
 	}
 	conntrack.KillerCheck()
+	conn, releaseUser, err := r.userLimiter.RoutedPacketConnection(ctx, conn, metadata)
+	if err != nil {
+		return err
+	}
+	defer releaseUser()
 	metadata.Network = N.NetworkUDP
 
 	if r.fakeIPStore != nil && r.fakeIPStore.Contains(metadata.Destination.Addr) {
@@ -971,7 +1146,7 @@ func (r *Router) RoutePacketConnection(ctx context.Context, conn N.PacketConn, m
 			metadata.Destination = destination
 		}
 		if metadata.InboundOptions.SniffEnabled {
-			sniffMetadata, _ := sniff.PeekPacket(ctx, buffer.Bytes(), sniff.DomainNameQuery, sniff.QUICClientHello, sniff.STUNMessage)
+			sniffMetadata, _ := sniff.PeekPacket(ctx, buffer.Bytes(), sniff.DomainNameQuery, sniff.QUICClientHello, sniff.STUNMessage, sniff.BitTorrentDHT, sniff.BitTorrentUTP)
 			if sniffMetadata != nil {
 				metadata.Protocol = sniffMetadata.Protocol
 				metadata.Domain = sniffMetadata.Domain
@@ -1010,17 +1185,20 @@ func (r *Router) RoutePacketConnection(ctx context.Context, conn N.PacketConn, m
 	} else if metadata.Destination.IsIPv6() {
 		metadata.IPVersion = 6
 	}
-	ctx, matchedRule, detour, err := r.match(ctx, &metadata, r.defaultOutboundForPacketConnection)
+	ctx, matchedRule, detour, rateLimiters, err := r.match(ctx, &metadata, r.defaultOutboundForPacketConnection)
 	if err != nil {
 		return err
 	}
 	if !common.Contains(detour.Network(), N.NetworkUDP) {
 		return E.New("missing supported outbound, closing packet connection")
 	}
-	if r.clashServer != nil {
-		trackerConn, tracker := r.clashServer.RoutedPacketConnection(ctx, conn, metadata, matchedRule)
-		defer tracker.Leave()
-		conn = trackerConn
+	conn = ratelimit.NewPacketConn(conn, append(r.rateLimitManager.Limiters(metadata, adapter.AppendOutboundChain(nil, r, detour.Tag())), rateLimiters...))
+	for _, connectionTracker := range r.trackers {
+		var tracker adapter.Tracker
+		conn, tracker = connectionTracker.RoutedPacketConnection(ctx, conn, metadata, matchedRule)
+		defer func() {
+			tracker.Leave(err)
+		}()
 	}
 	if r.v2rayServer != nil {
 		if statsService := r.v2rayServer.StatsService(); statsService != nil {
@@ -1033,18 +1211,23 @@ func (r *Router) RoutePacketConnection(ctx context.Context, conn N.PacketConn, m
 	return detour.NewPacketConnection(ctx, conn, metadata)
 }
 
-func (r *Router) match(ctx context.Context, metadata *adapter.InboundContext, defaultOutbound adapter.Outbound) (context.Context, adapter.Rule, adapter.Outbound, error) {
-	matchRule, matchOutbound := r.match0(ctx, metadata, defaultOutbound)
+func (r *Router) match(ctx context.Context, metadata *adapter.InboundContext, defaultOutbound adapter.Outbound) (context.Context, adapter.Rule, adapter.Outbound, []*ratelimit.Limiter, error) {
+	matchRule, matchOutbound, rateLimiters := r.match0(ctx, metadata, defaultOutbound)
 	if contextOutbound, loaded := outbound.TagFromContext(ctx); loaded {
 		if contextOutbound == matchOutbound.Tag() {
-			return nil, nil, nil, E.New("connection loopback in outbound/", matchOutbound.Type(), "[", matchOutbound.Tag(), "]")
+			return nil, nil, nil, nil, E.New("connection loopback in outbound/", matchOutbound.Type(), "[", matchOutbound.Tag(), "]")
 		}
 	}
 	ctx = outbound.ContextWithTag(ctx, matchOutbound.Tag())
-	return ctx, matchRule, matchOutbound, nil
+	return ctx, matchRule, matchOutbound, rateLimiters, nil
+}
+
+// rateLimitRule is a route rule with rate_limit.
+type rateLimitRule interface {
+	RateLimiter() *ratelimit.Limiter
 }
 
-func (r *Router) match0(ctx context.Context, metadata *adapter.InboundContext, defaultOutbound adapter.Outbound) (adapter.Rule, adapter.Outbound) {
+func (r *Router) match0(ctx context.Context, metadata *adapter.InboundContext, defaultOutbound adapter.Outbound) (adapter.Rule, adapter.Outbound, []*ratelimit.Limiter) {
 	if r.processSearcher != nil {
 		var originDestination netip.AddrPort
 		if metadata.OriginDestination.IsValid() {
@@ -1076,18 +1259,26 @@ func (r *Router) match0(ctx context.Context, metadata *adapter.InboundContext, d
 			metadata.ProcessInfo = processInfo
 		}
 	}
+	var rateLimiters []*ratelimit.Limiter
 	for i, rule := range r.rules {
 		metadata.ResetRuleCache()
 		if rule.Match(metadata) {
+			if limitRule, isLimitRule := rule.(rateLimitRule); isLimitRule && limitRule.RateLimiter() != nil {
+				rateLimiters = append(rateLimiters, limitRule.RateLimiter())
+			}
 			detour := rule.Outbound()
+			if detour == "" {
+				r.logger.DebugContext(ctx, "match[", i, "] ", rule.String(), " => rate-limit")
+				continue
+			}
 			r.logger.DebugContext(ctx, "match[", i, "] ", rule.String(), " => ", detour)
 			if outbound, loaded := r.Outbound(detour); loaded {
-				return rule, outbound
+				return rule, outbound, rateLimiters
 			}
 			r.logger.ErrorContext(ctx, "outbound not found: ", detour)
 		}
 	}
-	return nil, defaultOutbound
+	return nil, defaultOutbound, rateLimiters
 }
 
 func (r *Router) InterfaceFinder() control.InterfaceFinder {
@@ -1175,6 +1366,10 @@ func (r *Router) SetClashServer(server adapter.ClashServer) {
 	r.clashServer = server
 }
 
+func (r *Router) AppendTracker(tracker adapter.ConnectionTracker) {
+	r.trackers = append(r.trackers, tracker)
+}
+
 func (r *Router) V2RayServer() adapter.V2RayServer {
 	return r.v2rayServer
 }
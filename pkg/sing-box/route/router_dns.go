@@ -57,7 +57,10 @@ func (r *Router) matchDNS(ctx context.Context, allowFakeIP bool, index int) (con
 					continue
 				}
 				_, isFakeIP := transport.(adapter.FakeIPTransport)
-				if isFakeIP && !allowFakeIP {
+				if isFakeIP && (!allowFakeIP || r.fakeIPExclude != nil && r.fakeIPExclude.Match(metadata.Domain)) {
+					continue
+				}
+				if hostsTransport, isHosts := transport.(adapter.HostsTransport); isHosts && !hostsTransport.Contains(metadata.Domain) {
 					continue
 				}
 				displayRuleIndex := ruleIndex
@@ -97,8 +100,11 @@ func (r *Router) Exchange(ctx context.Context, message *mDNS.Msg) (*mDNS.Msg, er
 		response  *mDNS.Msg
 		cached    bool
 		transport dns.Transport
+		rule      adapter.DNSRule
 		err       error
 	)
+	startAt := time.Now()
+	ctx, trace := contextWithDNSQueryTrace(ctx)
 	response, cached = r.dnsClient.ExchangeCache(ctx, message)
 	if !cached {
 		var metadata *adapter.InboundContext
@@ -115,7 +121,6 @@ func (r *Router) Exchange(ctx context.Context, message *mDNS.Msg) (*mDNS.Msg, er
 		}
 		var (
 			strategy  dns.DomainStrategy
-			rule      adapter.DNSRule
 			ruleIndex int
 		)
 		ruleIndex = -1
@@ -155,6 +160,7 @@ func (r *Router) Exchange(ctx context.Context, message *mDNS.Msg) (*mDNS.Msg, er
 			}
 		}
 	}
+	r.recordExchange(ctx, startAt, message, response, transport, rule, cached || trace.cached, err)
 	if err != nil {
 		return nil, err
 	}
@@ -179,10 +185,13 @@ func (r *Router) Lookup(ctx context.Context, domain string, strategy dns.DomainS
 		cached        bool
 		err           error
 	)
+	startAt := time.Now()
 	responseAddrs, cached = r.dnsClient.LookupCache(ctx, domain, strategy)
 	if cached {
+		r.recordLookup(ctx, startAt, domain, strategy, responseAddrs, nil, nil, true, nil)
 		return responseAddrs, nil
 	}
+	ctx, trace := contextWithDNSQueryTrace(ctx)
 	r.dnsLogger.DebugContext(ctx, "lookup domain ", domain)
 	ctx, metadata := adapter.AppendContext(ctx)
 	metadata.Domain = domain
@@ -233,6 +242,7 @@ func (r *Router) Lookup(ctx context.Context, domain string, strategy dns.DomainS
 			break
 		}
 	}
+	r.recordLookup(ctx, startAt, domain, strategy, responseAddrs, transport, rule, trace.cached, err)
 	if len(responseAddrs) > 0 {
 		r.dnsLogger.InfoContext(ctx, "lookup succeed for ", domain, ": ", strings.Join(F.MapToString(responseAddrs), " "))
 	}
@@ -245,11 +255,36 @@ func (r *Router) LookupDefault(ctx context.Context, domain string) ([]netip.Addr
 
 func (r *Router) ClearDNSCache() {
 	r.dnsClient.ClearCache()
+	if r.dnsCache != nil {
+		for _, transport := range r.transports {
+			if cachedTransport, isCached := transport.(*cachedDNSTransport); isCached {
+				cachedTransport.Expire()
+			}
+		}
+	}
+	if r.platformInterface != nil {
+		r.platformInterface.ClearDNSCache()
+	}
+}
+
+func (r *Router) FlushDNSCache() {
+	r.dnsClient.ClearCache()
+	if r.dnsCache != nil {
+		for _, transport := range r.transports {
+			if cachedTransport, isCached := transport.(*cachedDNSTransport); isCached {
+				cachedTransport.Clear()
+			}
+		}
+	}
 	if r.platformInterface != nil {
 		r.platformInterface.ClearDNSCache()
 	}
 }
 
+func (r *Router) SetDNSQueryRecorder(recorder adapter.DNSQueryRecorder) {
+	r.dnsQueryRecorder = recorder
+}
+
 func isAddressQuery(message *mDNS.Msg) bool {
 	for _, question := range message.Question {
 		if question.Qtype == mDNS.TypeA || question.Qtype == mDNS.TypeAAAA {
@@ -0,0 +1,120 @@
+package route
+
+import (
+	"strings"
+
+	"github.com/sagernet/sing-box/common/schedule"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/outbound"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+)
+
+func (r *Router) newScheduleTask(options option.Schedule) (schedule.Task, error) {
+	cron, err := schedule.ParseCron(options.Cron)
+	if err != nil {
+		return schedule.Task{}, err
+	}
+	location, err := schedule.LoadLocation(options.TimeZone)
+	if err != nil {
+		return schedule.Task{}, err
+	}
+	task := schedule.Task{
+		Cron:     cron,
+		Location: location,
+	}
+	switch {
+	case options.ClashMode != "" && options.Selector != "":
+		return schedule.Task{}, E.New("clash_mode and selector are mutually exclusive")
+	case options.ClashMode != "":
+		mode := options.ClashMode
+		task.Name = F.ToString("clash_mode=", mode)
+		task.Action = func() {
+			r.scheduleClashMode(mode)
+		}
+	case options.Selector != "":
+		if options.Outbound == "" {
+			return schedule.Task{}, E.New("missing outbound for selector ", options.Selector)
+		}
+		selectorTag, outboundTag := options.Selector, options.Outbound
+		task.Name = F.ToString("selector[", selectorTag, "]=", outboundTag)
+		task.Action = func() {
+			r.scheduleSelectOutbound(selectorTag, outboundTag)
+		}
+	default:
+		return schedule.Task{}, E.New("missing action: clash_mode or selector required")
+	}
+	return task, nil
+}
+
+func (r *Router) checkScheduleOptions(options []option.Schedule) error {
+	for i, scheduleOptions := range options {
+		if scheduleOptions.Selector == "" {
+			continue
+		}
+		detour, loaded := r.outboundByTag[scheduleOptions.Selector]
+		if !loaded {
+			return E.New("schedule[", i, "]: selector not found: ", scheduleOptions.Selector)
+		}
+		selector, isSelector := detour.(*outbound.Selector)
+		if !isSelector {
+			return E.New("schedule[", i, "]: outbound is not a selector: ", scheduleOptions.Selector)
+		}
+		var found bool
+		for _, tag := range selector.All() {
+			if tag == scheduleOptions.Outbound {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return E.New("schedule[", i, "]: outbound ", scheduleOptions.Outbound, " not found in selector ", scheduleOptions.Selector)
+		}
+	}
+	return nil
+}
+
+// checkScheduleClashMode runs after the clash server is set,
+// as the mode list is only known to it.
+func (r *Router) checkScheduleClashMode(options []option.Schedule) error {
+	for i, scheduleOptions := range options {
+		if scheduleOptions.ClashMode == "" {
+			continue
+		}
+		if r.clashServer == nil {
+			return E.New("schedule[", i, "]: clash_mode requires the clash api")
+		}
+		modeList := r.clashServer.ModeList()
+		if !common.Any(modeList, func(it string) bool {
+			return strings.EqualFold(it, scheduleOptions.ClashMode)
+		}) {
+			return E.New("schedule[", i, "]: unknown clash mode: ", scheduleOptions.ClashMode, ", available: ", strings.Join(modeList, ", "))
+		}
+	}
+	return nil
+}
+
+func (r *Router) scheduleClashMode(mode string) {
+	if r.clashServer == nil {
+		r.logger.Warn("schedule: clash_mode=", mode, " requires the clash api")
+		return
+	}
+	r.logger.Info("schedule: switch clash mode to ", mode)
+	r.clashServer.SetMode(mode)
+}
+
+func (r *Router) scheduleSelectOutbound(selectorTag string, outboundTag string) {
+	var selector *outbound.Selector
+	if detour, loaded := r.Outbound(selectorTag); loaded {
+		selector, _ = detour.(*outbound.Selector)
+	}
+	if selector == nil {
+		r.logger.Error("schedule: selector not found: ", selectorTag)
+		return
+	}
+	r.logger.Info("schedule: select ", outboundTag, " in ", selectorTag)
+	if !selector.SelectOutbound(outboundTag) {
+		r.logger.Error("schedule: outbound ", outboundTag, " not found in selector ", selectorTag)
+	}
+}
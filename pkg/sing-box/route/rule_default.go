@@ -1,40 +1,64 @@
 package route
 
 import (
+	"context"
+
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/ratelimit"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing-box/option"
 	E "github.com/sagernet/sing/common/exceptions"
 )
 
-func NewRule(router adapter.Router, logger log.ContextLogger, options option.Rule, checkOutbound bool) (adapter.Rule, error) {
+func NewRule(ctx context.Context, router adapter.Router, logger log.ContextLogger, options option.Rule, checkOutbound bool) (adapter.Rule, error) {
 	switch options.Type {
 	case "", C.RuleTypeDefault:
 		if !options.DefaultOptions.IsValid() {
 			return nil, E.New("missing conditions")
 		}
-		if options.DefaultOptions.Outbound == "" && checkOutbound {
-			return nil, E.New("missing outbound field")
+		if checkOutbound {
+			err := checkRuleAction(options.DefaultOptions.Outbound, options.DefaultOptions.RateLimit)
+			if err != nil {
+				return nil, err
+			}
 		}
-		return NewDefaultRule(router, logger, options.DefaultOptions)
+		return NewDefaultRule(ctx, router, logger, options.DefaultOptions)
 	case C.RuleTypeLogical:
 		if !options.LogicalOptions.IsValid() {
 			return nil, E.New("missing conditions")
 		}
-		if options.LogicalOptions.Outbound == "" && checkOutbound {
-			return nil, E.New("missing outbound field")
+		if checkOutbound {
+			err := checkRuleAction(options.LogicalOptions.Outbound, options.LogicalOptions.RateLimit)
+			if err != nil {
+				return nil, err
+			}
 		}
-		return NewLogicalRule(router, logger, options.LogicalOptions)
+		return NewLogicalRule(ctx, router, logger, options.LogicalOptions)
 	default:
 		return nil, E.New("unknown rule type: ", options.Type)
 	}
 }
 
+// checkRuleAction requires a rule to route or to limit the connections it matches.
+func checkRuleAction(outbound string, rateLimit *option.RateLimitOptions) error {
+	if outbound != "" {
+		return nil
+	}
+	if rateLimit == nil {
+		return E.New("missing outbound field")
+	}
+	if ratelimit.NewLimiter(rateLimit) == nil {
+		return E.New("missing up_mbps or down_mbps in rate_limit")
+	}
+	return nil
+}
+
 var _ adapter.Rule = (*DefaultRule)(nil)
 
 type DefaultRule struct {
 	abstractDefaultRule
+	rateLimiter *ratelimit.Limiter
 }
 
 type RuleItem interface {
@@ -42,12 +66,13 @@ type RuleItem interface {
 	String() string
 }
 
-func NewDefaultRule(router adapter.Router, logger log.ContextLogger, options option.DefaultRule) (*DefaultRule, error) {
+func NewDefaultRule(ctx context.Context, router adapter.Router, logger log.ContextLogger, options option.DefaultRule) (*DefaultRule, error) {
 	rule := &DefaultRule{
 		abstractDefaultRule{
 			invert:   options.Invert,
 			outbound: options.Outbound,
 		},
+		ratelimit.NewLimiter(options.RateLimit),
 	}
 	if len(options.Inbound) > 0 {
 		item := NewInboundRule(options.Inbound)
@@ -204,6 +229,22 @@ func NewDefaultRule(router adapter.Router, logger log.ContextLogger, options opt
 		rule.items = append(rule.items, item)
 		rule.allItems = append(rule.allItems, item)
 	}
+	if len(options.TimeRange) > 0 {
+		item, err := NewTimeRangeItem(ctx, options.TimeRange, options.Weekday, options.TimeZone)
+		if err != nil {
+			return nil, E.Cause(err, "time_range")
+		}
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.Weekday) > 0 && len(options.TimeRange) == 0 {
+		item, err := NewWeekdayItem(ctx, options.Weekday, options.TimeZone)
+		if err != nil {
+			return nil, E.Cause(err, "weekday")
+		}
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
 	if len(options.RuleSet) > 0 {
 		item := NewRuleSetItem(router, options.RuleSet, options.RuleSetIPCIDRMatchSource)
 		rule.items = append(rule.items, item)
@@ -212,19 +253,25 @@ func NewDefaultRule(router adapter.Router, logger log.ContextLogger, options opt
 	return rule, nil
 }
 
+func (r *DefaultRule) RateLimiter() *ratelimit.Limiter {
+	return r.rateLimiter
+}
+
 var _ adapter.Rule = (*LogicalRule)(nil)
 
 type LogicalRule struct {
 	abstractLogicalRule
+	rateLimiter *ratelimit.Limiter
 }
 
-func NewLogicalRule(router adapter.Router, logger log.ContextLogger, options option.LogicalRule) (*LogicalRule, error) {
+func NewLogicalRule(ctx context.Context, router adapter.Router, logger log.ContextLogger, options option.LogicalRule) (*LogicalRule, error) {
 	r := &LogicalRule{
 		abstractLogicalRule{
 			rules:    make([]adapter.HeadlessRule, len(options.Rules)),
 			invert:   options.Invert,
 			outbound: options.Outbound,
 		},
+		ratelimit.NewLimiter(options.RateLimit),
 	}
 	switch options.Mode {
 	case C.LogicalTypeAnd:
@@ -235,7 +282,7 @@ func NewLogicalRule(router adapter.Router, logger log.ContextLogger, options opt
 		return nil, E.New("unknown logical mode: ", options.Mode)
 	}
 	for i, subRule := range options.Rules {
-		rule, err := NewRule(router, logger, subRule, false)
+		rule, err := NewRule(ctx, router, logger, subRule, false)
 		if err != nil {
 			return nil, E.Cause(err, "sub rule[", i, "]")
 		}
@@ -243,3 +290,7 @@ func NewLogicalRule(router adapter.Router, logger log.ContextLogger, options opt
 	}
 	return r, nil
 }
+
+func (r *LogicalRule) RateLimiter() *ratelimit.Limiter {
+	return r.rateLimiter
+}
@@ -0,0 +1,29 @@
+package route
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuleRateLimit(t *testing.T) {
+	t.Parallel()
+	newRule := func(outbound string, rateLimit *option.RateLimitOptions) error {
+		_, err := NewRule(context.Background(), nil, log.NewNOPFactory().Logger(), option.Rule{
+			DefaultOptions: option.DefaultRule{
+				Domain:    []string{"example.com"},
+				Outbound:  outbound,
+				RateLimit: rateLimit,
+			},
+		}, true)
+		return err
+	}
+	require.NoError(t, newRule("direct", nil))
+	require.NoError(t, newRule("", &option.RateLimitOptions{DownMbps: 10}))
+	require.EqualError(t, newRule("", nil), "missing outbound field")
+	require.EqualError(t, newRule("", &option.RateLimitOptions{Burst: 1024}), "missing up_mbps or down_mbps in rate_limit")
+}
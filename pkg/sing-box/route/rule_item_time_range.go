@@ -0,0 +1,94 @@
+package route
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/schedule"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/ntp"
+)
+
+var _ RuleItem = (*TimeRangeItem)(nil)
+
+// TimeRangeItem matches the wall clock against daily windows. With weekdays,
+// a window matches on the days it starts, including its part after midnight.
+type TimeRangeItem struct {
+	ctx         context.Context
+	timeFunc    func() time.Time
+	location    *time.Location
+	rangeList   []schedule.TimeRange
+	weekdayList []string
+	weekdayMap  map[time.Weekday]bool
+}
+
+func NewTimeRangeItem(ctx context.Context, rangeList []string, weekdayList []string, timeZone string) (*TimeRangeItem, error) {
+	location, err := schedule.LoadLocation(timeZone)
+	if err != nil {
+		return nil, err
+	}
+	item := &TimeRangeItem{
+		ctx:         ctx,
+		timeFunc:    time.Now,
+		location:    location,
+		weekdayList: weekdayList,
+	}
+	for _, timeRange := range rangeList {
+		parsed, err := schedule.ParseTimeRange(timeRange)
+		if err != nil {
+			return nil, err
+		}
+		item.rangeList = append(item.rangeList, parsed)
+	}
+	if len(weekdayList) > 0 {
+		item.weekdayMap, err = parseWeekdayMap(weekdayList)
+		if err != nil {
+			return nil, E.Cause(err, "weekday")
+		}
+	}
+	return item, nil
+}
+
+func (r *TimeRangeItem) Start() error {
+	if timeFunc := ntp.TimeFuncFromContext(r.ctx); timeFunc != nil {
+		r.timeFunc = timeFunc
+	}
+	return nil
+}
+
+func (r *TimeRangeItem) Match(metadata *adapter.InboundContext) bool {
+	now := r.timeFunc().In(r.location)
+	for _, timeRange := range r.rangeList {
+		if !timeRange.Contains(now) {
+			continue
+		}
+		if r.weekdayMap == nil || r.weekdayMap[timeRange.StartDay(now)] {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *TimeRangeItem) String() string {
+	var description string
+	if len(r.rangeList) == 1 {
+		description = "time_range=" + r.rangeList[0].String()
+	} else {
+		rangeStrings := make([]string, 0, len(r.rangeList))
+		for _, timeRange := range r.rangeList {
+			rangeStrings = append(rangeStrings, timeRange.String())
+		}
+		description = "time_range=[" + strings.Join(rangeStrings, " ") + "]"
+	}
+	if len(r.weekdayList) == 1 {
+		description += " weekday=" + r.weekdayList[0]
+	} else if len(r.weekdayList) > 1 {
+		description += " weekday=[" + strings.Join(r.weekdayList, " ") + "]"
+	}
+	if r.location != time.Local {
+		description += "@" + r.location.String()
+	}
+	return description
+}
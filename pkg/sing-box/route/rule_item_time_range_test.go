@@ -0,0 +1,47 @@
+package route_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/route"
+	"github.com/sagernet/sing/common/ntp"
+	"github.com/sagernet/sing/service"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fixedTimeService time.Time
+
+func (s fixedTimeService) TimeFunc() func() time.Time {
+	return func() time.Time {
+		return time.Time(s)
+	}
+}
+
+func matchTimeRange(t *testing.T, now time.Time, rangeList []string, weekdayList []string) bool {
+	ctx := service.ContextWith[ntp.TimeService](context.Background(), fixedTimeService(now))
+	item, err := route.NewTimeRangeItem(ctx, rangeList, weekdayList, "UTC")
+	require.NoError(t, err)
+	require.NoError(t, item.Start())
+	return item.Match(&adapter.InboundContext{})
+}
+
+func TestTimeRangeItemMidnight(t *testing.T) {
+	t.Parallel()
+	fridayNight := time.Date(2024, time.March, 1, 23, 0, 0, 0, time.UTC)
+	saturdayMorning := time.Date(2024, time.March, 2, 3, 0, 0, 0, time.UTC)
+	sundayMorning := time.Date(2024, time.March, 3, 3, 0, 0, 0, time.UTC)
+	overnight := []string{"22:00-06:00"}
+	require.True(t, matchTimeRange(t, fridayNight, overnight, nil))
+	require.True(t, matchTimeRange(t, saturdayMorning, overnight, nil))
+	require.True(t, matchTimeRange(t, fridayNight, overnight, []string{"fri"}))
+	require.True(t, matchTimeRange(t, saturdayMorning, overnight, []string{"fri"}))
+	require.False(t, matchTimeRange(t, sundayMorning, overnight, []string{"fri"}))
+	require.False(t, matchTimeRange(t, saturdayMorning, overnight, []string{"sat"}))
+	require.True(t, matchTimeRange(t, sundayMorning, overnight, []string{"sat"}))
+	require.True(t, matchTimeRange(t, saturdayMorning, []string{"00:00-06:00"}, []string{"sat"}))
+	require.False(t, matchTimeRange(t, saturdayMorning, []string{"00:00-06:00"}, []string{"fri"}))
+}
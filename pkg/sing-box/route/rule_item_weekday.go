@@ -0,0 +1,75 @@
+package route
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/schedule"
+	"github.com/sagernet/sing/common/ntp"
+)
+
+var _ RuleItem = (*WeekdayItem)(nil)
+
+type WeekdayItem struct {
+	ctx         context.Context
+	timeFunc    func() time.Time
+	location    *time.Location
+	weekdayList []string
+	weekdayMap  map[time.Weekday]bool
+}
+
+func NewWeekdayItem(ctx context.Context, weekdayList []string, timeZone string) (*WeekdayItem, error) {
+	location, err := schedule.LoadLocation(timeZone)
+	if err != nil {
+		return nil, err
+	}
+	weekdayMap, err := parseWeekdayMap(weekdayList)
+	if err != nil {
+		return nil, err
+	}
+	return &WeekdayItem{
+		ctx:         ctx,
+		timeFunc:    time.Now,
+		location:    location,
+		weekdayList: weekdayList,
+		weekdayMap:  weekdayMap,
+	}, nil
+}
+
+func parseWeekdayMap(weekdayList []string) (map[time.Weekday]bool, error) {
+	weekdayMap := make(map[time.Weekday]bool)
+	for _, weekday := range weekdayList {
+		parsed, err := schedule.ParseWeekday(weekday)
+		if err != nil {
+			return nil, err
+		}
+		weekdayMap[parsed] = true
+	}
+	return weekdayMap, nil
+}
+
+func (r *WeekdayItem) Start() error {
+	if timeFunc := ntp.TimeFuncFromContext(r.ctx); timeFunc != nil {
+		r.timeFunc = timeFunc
+	}
+	return nil
+}
+
+func (r *WeekdayItem) Match(metadata *adapter.InboundContext) bool {
+	return r.weekdayMap[r.timeFunc().In(r.location).Weekday()]
+}
+
+func (r *WeekdayItem) String() string {
+	var description string
+	if len(r.weekdayList) == 1 {
+		description = "weekday=" + r.weekdayList[0]
+	} else {
+		description = "weekday=[" + strings.Join(r.weekdayList, " ") + "]"
+	}
+	if r.location != time.Local {
+		description += "@" + r.location.String()
+	}
+	return description
+}
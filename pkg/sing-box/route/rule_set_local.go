@@ -3,64 +3,117 @@ package route
 import (
 	"context"
 	"os"
+	"time"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/filewatcher"
+	"github.com/sagernet/sing-box/common/ruleset"
 	"github.com/sagernet/sing-box/common/srs"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/atomic"
 	E "github.com/sagernet/sing/common/exceptions"
 	"github.com/sagernet/sing/common/json"
+	"github.com/sagernet/sing/common/logger"
 )
 
 var _ adapter.RuleSet = (*LocalRuleSet)(nil)
 
 type LocalRuleSet struct {
-	rules    []adapter.HeadlessRule
+	router   adapter.Router
+	logger   logger.ContextLogger
+	tag      string
+	format   string
+	path     string
+	rules    atomic.TypedValue[[]adapter.HeadlessRule]
 	metadata adapter.RuleSetMetadata
+	watcher  *filewatcher.Watcher
+
+	lastUpdated atomic.TypedValue[time.Time]
 }
 
-func NewLocalRuleSet(router adapter.Router, options option.RuleSet) (*LocalRuleSet, error) {
+func NewLocalRuleSet(router adapter.Router, logger logger.ContextLogger, options option.RuleSet) (*LocalRuleSet, error) {
+	ruleSet := &LocalRuleSet{
+		router: router,
+		logger: logger,
+		tag:    options.Tag,
+		format: options.Format,
+		path:   options.LocalOptions.Path,
+	}
 	var plainRuleSet option.PlainRuleSet
-	switch options.Format {
-	case C.RuleSetFormatSource, "":
-		content, err := os.ReadFile(options.LocalOptions.Path)
+	if options.Type == C.RuleSetTypeInline {
+		plainRuleSet = options.InlineOptions
+	} else {
+		var err error
+		plainRuleSet, err = ruleSet.readFile()
 		if err != nil {
 			return nil, err
 		}
+	}
+	rules, err := ruleSet.newRules(plainRuleSet)
+	if err != nil {
+		return nil, err
+	}
+	ruleSet.rules.Store(rules)
+	ruleSet.metadata = newRuleSetMetadata(plainRuleSet)
+	ruleSet.lastUpdated.Store(time.Now())
+	return ruleSet, nil
+}
+
+func (s *LocalRuleSet) readFile() (option.PlainRuleSet, error) {
+	switch s.format {
+	case C.RuleSetFormatSource, "":
+		content, err := os.ReadFile(s.path)
+		if err != nil {
+			return option.PlainRuleSet{}, err
+		}
 		compat, err := json.UnmarshalExtended[option.PlainRuleSetCompat](content)
 		if err != nil {
-			return nil, err
+			return option.PlainRuleSet{}, err
 		}
-		plainRuleSet = compat.Upgrade()
+		return compat.Upgrade(), nil
 	case C.RuleSetFormatBinary:
-		setFile, err := os.Open(options.LocalOptions.Path)
+		setFile, err := os.Open(s.path)
 		if err != nil {
-			return nil, err
+			return option.PlainRuleSet{}, err
 		}
-		plainRuleSet, err = srs.Read(setFile, false)
+		defer setFile.Close()
+		return srs.Read(setFile, false)
+	case C.RuleSetFormatClash, C.RuleSetFormatHosts, C.RuleSetFormatAdBlock, C.RuleSetFormatDomainList:
+		content, err := os.ReadFile(s.path)
 		if err != nil {
-			return nil, err
+			return option.PlainRuleSet{}, err
 		}
+		plainRuleSet, _, err := ruleset.Convert(s.format, content)
+		return plainRuleSet, err
 	default:
-		return nil, E.New("unknown rule set format: ", options.Format)
+		return option.PlainRuleSet{}, E.New("unknown rule set format: ", s.format)
 	}
+}
+
+func (s *LocalRuleSet) newRules(plainRuleSet option.PlainRuleSet) ([]adapter.HeadlessRule, error) {
 	rules := make([]adapter.HeadlessRule, len(plainRuleSet.Rules))
 	var err error
 	for i, ruleOptions := range plainRuleSet.Rules {
-		rules[i], err = NewHeadlessRule(router, ruleOptions)
+		rules[i], err = NewHeadlessRule(s.router, ruleOptions)
 		if err != nil {
 			return nil, E.Cause(err, "parse rule_set.rules.[", i, "]")
 		}
 	}
+	return rules, nil
+}
+
+func newRuleSetMetadata(plainRuleSet option.PlainRuleSet) adapter.RuleSetMetadata {
 	var metadata adapter.RuleSetMetadata
 	metadata.ContainsProcessRule = hasHeadlessRule(plainRuleSet.Rules, isProcessHeadlessRule)
 	metadata.ContainsWIFIRule = hasHeadlessRule(plainRuleSet.Rules, isWIFIHeadlessRule)
 	metadata.ContainsIPCIDRRule = hasHeadlessRule(plainRuleSet.Rules, isIPCIDRHeadlessRule)
-	return &LocalRuleSet{rules, metadata}, nil
+	return metadata
 }
 
 func (s *LocalRuleSet) Match(metadata *adapter.InboundContext) bool {
-	for _, rule := range s.rules {
+	for _, rule := range s.rules.Load() {
 		if rule.Match(metadata) {
 			return true
 		}
@@ -69,6 +122,37 @@ func (s *LocalRuleSet) Match(metadata *adapter.InboundContext) bool {
 }
 
 func (s *LocalRuleSet) StartContext(ctx context.Context, startContext adapter.RuleSetStartContext) error {
+	if s.path == "" {
+		return nil
+	}
+	s.watcher = filewatcher.New(s.logger, []string{s.path}, s.reload)
+	err := s.watcher.Start()
+	if err != nil {
+		s.logger.Warn("watch rule-set ", s.tag, ": ", err)
+	}
+	return nil
+}
+
+// reload keeps the current rules if the changed file can not be parsed, or if
+// it changes the metadata, as the router only reads the metadata on start.
+func (s *LocalRuleSet) reload() error {
+	plainRuleSet, err := s.readFile()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return E.Cause(err, "reload rule-set ", s.tag)
+	}
+	if newRuleSetMetadata(plainRuleSet) != s.metadata {
+		return E.New("reload rule-set ", s.tag, ": adding or removing all process, WIFI or IP CIDR rules requires a restart")
+	}
+	rules, err := s.newRules(plainRuleSet)
+	if err != nil {
+		return E.Cause(err, "reload rule-set ", s.tag)
+	}
+	s.rules.Store(rules)
+	s.lastUpdated.Store(time.Now())
+	s.logger.Info("reloaded rule-set ", s.tag)
 	return nil
 }
 
@@ -76,10 +160,14 @@ func (s *LocalRuleSet) PostStart() error {
 	return nil
 }
 
+func (s *LocalRuleSet) LastUpdated() time.Time {
+	return s.lastUpdated.Load()
+}
+
 func (s *LocalRuleSet) Metadata() adapter.RuleSetMetadata {
 	return s.metadata
 }
 
 func (s *LocalRuleSet) Close() error {
-	return nil
+	return common.Close(common.PtrOrNil(s.watcher))
 }
@@ -0,0 +1,81 @@
+package route_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/route"
+	M "github.com/sagernet/sing/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeRuleSet(t *testing.T, path string, content string) {
+	// write to a temporary file and rename, as editors do
+	temporaryPath := path + ".tmp"
+	require.NoError(t, os.WriteFile(temporaryPath, []byte(content), 0o644))
+	require.NoError(t, os.Rename(temporaryPath, path))
+}
+
+func matchDomain(ruleSet adapter.RuleSet, domain string) bool {
+	return ruleSet.Match(&adapter.InboundContext{
+		Domain:      domain,
+		Destination: M.Socksaddr{Fqdn: domain, Port: 443},
+	})
+}
+
+func waitReload(t *testing.T, ruleSet adapter.RuleSet, lastUpdated time.Time, timeout time.Duration) bool {
+	for deadline := time.Now().Add(timeout); time.Now().Before(deadline); {
+		if ruleSet.LastUpdated().After(lastUpdated) {
+			return true
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return false
+}
+
+func TestLocalRuleSetReload(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "rules.json")
+	writeRuleSet(t, path, `{"version":1,"rules":[{"domain_suffix":["first.example"]}]}`)
+	ruleSet, err := route.NewLocalRuleSet(nil, log.NewNOPFactory().Logger(), option.RuleSet{
+		Type:         C.RuleSetTypeLocal,
+		Tag:          "test",
+		Format:       C.RuleSetFormatSource,
+		LocalOptions: option.LocalRuleSet{Path: path},
+	})
+	require.NoError(t, err)
+	require.NoError(t, ruleSet.StartContext(context.Background(), nil))
+	defer ruleSet.Close()
+	require.True(t, matchDomain(ruleSet, "www.first.example"))
+
+	lastUpdated := ruleSet.LastUpdated()
+	writeRuleSet(t, path, `{"version":1,"rules":[{"domain_suffix":["second.example"]}]}`)
+	require.True(t, waitReload(t, ruleSet, lastUpdated, 3*time.Second))
+	require.False(t, matchDomain(ruleSet, "www.first.example"))
+	require.True(t, matchDomain(ruleSet, "www.second.example"))
+
+	// invalid content keeps the current rules
+	lastUpdated = ruleSet.LastUpdated()
+	writeRuleSet(t, path, `{"version":1,"rules":[{"domain_regex":["("]}]}`)
+	require.False(t, waitReload(t, ruleSet, lastUpdated, 1500*time.Millisecond))
+	require.True(t, matchDomain(ruleSet, "www.second.example"))
+
+	// so does content changing the metadata
+	writeRuleSet(t, path, `{"version":1,"rules":[{"domain_suffix":["third.example"]},{"process_name":["curl"]}]}`)
+	require.False(t, waitReload(t, ruleSet, lastUpdated, 1500*time.Millisecond))
+	require.True(t, matchDomain(ruleSet, "www.second.example"))
+	require.False(t, matchDomain(ruleSet, "www.third.example"))
+	require.Equal(t, adapter.RuleSetMetadata{}, ruleSet.Metadata())
+
+	writeRuleSet(t, path, `{"version":1,"rules":[{"domain_suffix":["third.example"]}]}`)
+	require.True(t, waitReload(t, ruleSet, lastUpdated, 3*time.Second))
+	require.True(t, matchDomain(ruleSet, "www.third.example"))
+}
@@ -10,9 +10,11 @@ import (
 	"time"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/ruleset"
 	"github.com/sagernet/sing-box/common/srs"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/atomic"
 	E "github.com/sagernet/sing/common/exceptions"
 	"github.com/sagernet/sing/common/json"
 	"github.com/sagernet/sing/common/logger"
@@ -34,7 +36,7 @@ type RemoteRuleSet struct {
 	updateInterval time.Duration
 	dialer         N.Dialer
 	rules          []adapter.HeadlessRule
-	lastUpdated    time.Time
+	lastUpdated    atomic.TypedValue[time.Time]
 	lastEtag       string
 	updateTicker   *time.Ticker
 	pauseManager   pause.Manager
@@ -91,11 +93,11 @@ func (s *RemoteRuleSet) StartContext(ctx context.Context, startContext adapter.R
 			if err != nil {
 				return E.Cause(err, "restore cached rule-set")
 			}
-			s.lastUpdated = savedSet.LastUpdated
+			s.lastUpdated.Store(savedSet.LastUpdated)
 			s.lastEtag = savedSet.LastEtag
 		}
 	}
-	if s.lastUpdated.IsZero() {
+	if s.lastUpdated.Load().IsZero() {
 		err := s.fetchOnce(ctx, startContext)
 		if err != nil {
 			return E.Cause(err, "initial rule-set: ", s.options.Tag)
@@ -107,7 +109,7 @@ func (s *RemoteRuleSet) StartContext(ctx context.Context, startContext adapter.R
 }
 
 func (s *RemoteRuleSet) PostStart() error {
-	if s.lastUpdated.IsZero() {
+	if s.lastUpdated.Load().IsZero() {
 		err := s.fetchOnce(s.ctx, nil)
 		if err != nil {
 			s.logger.Error("fetch rule-set ", s.options.Tag, ": ", err)
@@ -116,6 +118,10 @@ func (s *RemoteRuleSet) PostStart() error {
 	return nil
 }
 
+func (s *RemoteRuleSet) LastUpdated() time.Time {
+	return s.lastUpdated.Load()
+}
+
 func (s *RemoteRuleSet) Metadata() adapter.RuleSetMetadata {
 	return s.metadata
 }
@@ -138,6 +144,15 @@ func (s *RemoteRuleSet) loadBytes(content []byte) error {
 		if err != nil {
 			return err
 		}
+	case C.RuleSetFormatClash, C.RuleSetFormatHosts, C.RuleSetFormatAdBlock, C.RuleSetFormatDomainList:
+		var skipped []string
+		plainRuleSet, skipped, err = ruleset.Convert(s.options.Format, content)
+		if err != nil {
+			return err
+		}
+		if len(skipped) > 0 {
+			s.logger.Debug("rule-set ", s.options.Tag, ": skipped ", len(skipped), " unsupported entries")
+		}
 	default:
 		return E.New("unknown rule set format: ", s.options.Format)
 	}
@@ -148,15 +163,13 @@ func (s *RemoteRuleSet) loadBytes(content []byte) error {
 			return E.Cause(err, "parse rule_set.rules.[", i, "]")
 		}
 	}
-	s.metadata.ContainsProcessRule = hasHeadlessRule(plainRuleSet.Rules, isProcessHeadlessRule)
-	s.metadata.ContainsWIFIRule = hasHeadlessRule(plainRuleSet.Rules, isWIFIHeadlessRule)
-	s.metadata.ContainsIPCIDRRule = hasHeadlessRule(plainRuleSet.Rules, isIPCIDRHeadlessRule)
+	s.metadata = newRuleSetMetadata(plainRuleSet)
 	s.rules = rules
 	return nil
 }
 
 func (s *RemoteRuleSet) loopUpdate() {
-	if time.Since(s.lastUpdated) > s.updateInterval {
+	if time.Since(s.lastUpdated.Load()) > s.updateInterval {
 		err := s.fetchOnce(s.ctx, nil)
 		if err != nil {
 			s.logger.Error("fetch rule-set ", s.options.Tag, ": ", err)
@@ -207,12 +220,12 @@ func (s *RemoteRuleSet) fetchOnce(ctx context.Context, startContext adapter.Rule
 	switch response.StatusCode {
 	case http.StatusOK:
 	case http.StatusNotModified:
-		s.lastUpdated = time.Now()
+		s.lastUpdated.Store(time.Now())
 		cacheFile := service.FromContext[adapter.CacheFile](s.ctx)
 		if cacheFile != nil {
 			savedRuleSet := cacheFile.LoadRuleSet(s.options.Tag)
 			if savedRuleSet != nil {
-				savedRuleSet.LastUpdated = s.lastUpdated
+				savedRuleSet.LastUpdated = s.lastUpdated.Load()
 				err = cacheFile.SaveRuleSet(s.options.Tag, savedRuleSet)
 				if err != nil {
 					s.logger.Error("save rule-set updated time: ", err)
@@ -240,11 +253,11 @@ func (s *RemoteRuleSet) fetchOnce(ctx context.Context, startContext adapter.Rule
 	if eTagHeader != "" {
 		s.lastEtag = eTagHeader
 	}
-	s.lastUpdated = time.Now()
+	s.lastUpdated.Store(time.Now())
 	cacheFile := service.FromContext[adapter.CacheFile](s.ctx)
 	if cacheFile != nil {
 		err = cacheFile.SaveRuleSet(s.options.Tag, &adapter.SavedRuleSet{
-			LastUpdated: s.lastUpdated,
+			LastUpdated: s.lastUpdated.Load(),
 			Content:     content,
 			LastEtag:    s.lastEtag,
 		})
@@ -64,7 +64,7 @@ func createSelfSignedCertificate(t *testing.T, domain string) (caPem, certPem, k
 		},
 		NotBefore: time.Now(), NotAfter: time.Now().AddDate(0, 0, 30),
 		KeyUsage:    x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
-		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
 	}
 	domainTpl.DNSNames = append(domainTpl.DNSNames, domain)
 	cert, err := x509.CreateCertificate(rand.Reader, domainTpl, caTpl, key.Public(), caKey)
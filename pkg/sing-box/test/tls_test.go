@@ -1,11 +1,24 @@
 package main
 
 import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
 	"net/netip"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	sTLS "github.com/sagernet/sing-box/common/tls"
 	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing-box/option"
+
+	"github.com/stretchr/testify/require"
 )
 
 func TestUTLS(t *testing.T) {
@@ -86,3 +99,355 @@ func TestUTLS(t *testing.T) {
 	})
 	testSuit(t, clientPort, testPort)
 }
+
+func TestTLSCertificatePublicKeySHA256(t *testing.T) {
+	_, certPem, keyPem := createSelfSignedCertificate(t, "example.org")
+	publicKeySHA256 := certificatePublicKeySHA256(t, certPem)
+	for _, utlsEnabled := range []bool{false, true} {
+		t.Run(map[bool]string{false: "std", true: "utls"}[utlsEnabled], func(t *testing.T) {
+			startInstance(t, option.Options{
+				Inbounds: []option.Inbound{
+					{
+						Type: C.TypeMixed,
+						Tag:  "mixed-in",
+						MixedOptions: option.HTTPMixedInboundOptions{
+							ListenOptions: option.ListenOptions{
+								Listen:     option.NewListenAddress(netip.IPv4Unspecified()),
+								ListenPort: clientPort,
+							},
+						},
+					},
+					{
+						Type: C.TypeTrojan,
+						TrojanOptions: option.TrojanInboundOptions{
+							ListenOptions: option.ListenOptions{
+								Listen:     option.NewListenAddress(netip.IPv4Unspecified()),
+								ListenPort: serverPort,
+							},
+							Users: []option.TrojanUser{
+								{
+									Name:     "sekai",
+									Password: "password",
+								},
+							},
+							InboundTLSOptionsContainer: option.InboundTLSOptionsContainer{
+								TLS: &option.InboundTLSOptions{
+									Enabled:         true,
+									ServerName:      "example.org",
+									CertificatePath: certPem,
+									KeyPath:         keyPem,
+								},
+							},
+						},
+					},
+				},
+				Outbounds: []option.Outbound{
+					{
+						Type: C.TypeDirect,
+					},
+					{
+						Type: C.TypeTrojan,
+						Tag:  "trojan-out",
+						TrojanOptions: option.TrojanOutboundOptions{
+							ServerOptions: option.ServerOptions{
+								Server:     "127.0.0.1",
+								ServerPort: serverPort,
+							},
+							Password: "password",
+							OutboundTLSOptionsContainer: option.OutboundTLSOptionsContainer{
+								TLS: &option.OutboundTLSOptions{
+									Enabled:                    true,
+									ServerName:                 "example.org",
+									CertificatePublicKeySHA256: [][]byte{publicKeySHA256},
+									UTLS: &option.OutboundUTLSOptions{
+										Enabled:     utlsEnabled,
+										Fingerprint: "chrome",
+									},
+								},
+							},
+						},
+					},
+				},
+				Route: &option.RouteOptions{
+					Rules: []option.Rule{
+						{
+							DefaultOptions: option.DefaultRule{
+								Inbound:  []string{"mixed-in"},
+								Outbound: "trojan-out",
+							},
+						},
+					},
+				},
+			})
+			testSuit(t, clientPort, testPort)
+		})
+	}
+}
+
+func TestTLSCertificatePublicKeySHA256Mismatch(t *testing.T) {
+	_, certPem, keyPem := createSelfSignedCertificate(t, "example.org")
+	_, otherCertPem, _ := createSelfSignedCertificate(t, "example.org")
+	serverCertificate, err := tls.LoadX509KeyPair(certPem, keyPem)
+	require.NoError(t, err)
+	listener := startTLSServer(t, &tls.Config{
+		Certificates: []tls.Certificate{serverCertificate},
+	}, nil)
+	_, err = clientHandshake(t, listener.Addr(), option.OutboundTLSOptions{
+		Enabled:                    true,
+		ServerName:                 "example.org",
+		CertificatePublicKeySHA256: [][]byte{certificatePublicKeySHA256(t, otherCertPem)},
+	})
+	require.ErrorContains(t, err, "unrecognized server public key")
+}
+
+func TestTLSClientCertificate(t *testing.T) {
+	caPem, certPem, keyPem := createSelfSignedCertificate(t, "example.org")
+	_, clientCertPem, clientKeyPem := createSelfSignedCertificate(t, "client.example.org")
+	serverCertificate, err := tls.LoadX509KeyPair(certPem, keyPem)
+	require.NoError(t, err)
+	clientCertificates := make(chan []*x509.Certificate, 1)
+	listener := startTLSServer(t, &tls.Config{
+		Certificates: []tls.Certificate{serverCertificate},
+		ClientAuth:   tls.RequireAnyClientCert,
+	}, clientCertificates)
+	clientCertificate, err := tls.LoadX509KeyPair(clientCertPem, clientKeyPem)
+	require.NoError(t, err)
+	for _, utlsEnabled := range []bool{false, true} {
+		conn, err := clientHandshake(t, listener.Addr(), option.OutboundTLSOptions{
+			Enabled:               true,
+			ServerName:            "example.org",
+			CertificatePath:       caPem,
+			ClientCertificatePath: clientCertPem,
+			ClientKeyPath:         clientKeyPem,
+			UTLS: &option.OutboundUTLSOptions{
+				Enabled:     utlsEnabled,
+				Fingerprint: "chrome",
+			},
+		})
+		require.NoError(t, err)
+		peerCertificates := <-clientCertificates
+		conn.Close()
+		require.NotEmpty(t, peerCertificates)
+		require.Equal(t, clientCertificate.Certificate[0], peerCertificates[0].Raw)
+	}
+}
+
+func TestTLSClientAuthentication(t *testing.T) {
+	caPem, certPem, keyPem := createSelfSignedCertificate(t, "example.org")
+	clientCaPem, clientCertPem, clientKeyPem := createSelfSignedCertificate(t, "client.example.org")
+	startInstance(t, option.Options{
+		Inbounds: []option.Inbound{
+			{
+				Type: C.TypeMixed,
+				Tag:  "mixed-in",
+				MixedOptions: option.HTTPMixedInboundOptions{
+					ListenOptions: option.ListenOptions{
+						Listen:     option.NewListenAddress(netip.IPv4Unspecified()),
+						ListenPort: clientPort,
+					},
+				},
+			},
+			{
+				Type: C.TypeTrojan,
+				TrojanOptions: option.TrojanInboundOptions{
+					ListenOptions: option.ListenOptions{
+						Listen:     option.NewListenAddress(netip.IPv4Unspecified()),
+						ListenPort: serverPort,
+					},
+					Users: []option.TrojanUser{
+						{
+							Name:     "sekai",
+							Password: "password",
+						},
+					},
+					InboundTLSOptionsContainer: option.InboundTLSOptionsContainer{
+						TLS: &option.InboundTLSOptions{
+							Enabled:               true,
+							ServerName:            "example.org",
+							CertificatePath:       certPem,
+							KeyPath:               keyPem,
+							ClientAuthentication:  "require-and-verify",
+							ClientCertificatePath: clientCaPem,
+						},
+					},
+				},
+			},
+		},
+		Outbounds: []option.Outbound{
+			{
+				Type: C.TypeDirect,
+			},
+			{
+				Type: C.TypeTrojan,
+				Tag:  "trojan-out",
+				TrojanOptions: option.TrojanOutboundOptions{
+					ServerOptions: option.ServerOptions{
+						Server:     "127.0.0.1",
+						ServerPort: serverPort,
+					},
+					Password: "password",
+					OutboundTLSOptionsContainer: option.OutboundTLSOptionsContainer{
+						TLS: &option.OutboundTLSOptions{
+							Enabled:               true,
+							ServerName:            "example.org",
+							CertificatePath:       caPem,
+							ClientCertificatePath: clientCertPem,
+							ClientKeyPath:         clientKeyPem,
+						},
+					},
+				},
+			},
+		},
+		Route: &option.RouteOptions{
+			Rules: []option.Rule{
+				{
+					DefaultOptions: option.DefaultRule{
+						Inbound:  []string{"mixed-in"},
+						Outbound: "trojan-out",
+					},
+				},
+			},
+		},
+	})
+	testSuit(t, clientPort, testPort)
+}
+
+func TestTLSClientAuthenticationMissingCertificate(t *testing.T) {
+	caPem, certPem, keyPem := createSelfSignedCertificate(t, "example.org")
+	clientCaPem, _, _ := createSelfSignedCertificate(t, "client.example.org")
+	listener := startServerConfig(t, option.InboundTLSOptions{
+		Enabled:               true,
+		CertificatePath:       certPem,
+		KeyPath:               keyPem,
+		ClientAuthentication:  "require-and-verify",
+		ClientCertificatePath: clientCaPem,
+	})
+	conn, err := clientHandshake(t, listener.Addr(), option.OutboundTLSOptions{
+		Enabled:         true,
+		ServerName:      "example.org",
+		CertificatePath: caPem,
+	})
+	if err == nil {
+		// With TLS 1.3, the client sees the rejection on the first read.
+		_, err = conn.Read(make([]byte, 1))
+		conn.Close()
+	}
+	require.Error(t, err)
+}
+
+func TestTLSServerReloadCertificate(t *testing.T) {
+	_, certPem, keyPem := createSelfSignedCertificate(t, "example.org")
+	_, newCertPem, newKeyPem := createSelfSignedCertificate(t, "example.org")
+	tempDir := t.TempDir()
+	certPath := filepath.Join(tempDir, "cert.pem")
+	keyPath := filepath.Join(tempDir, "key.pem")
+	replaceFile(t, certPem, certPath)
+	replaceFile(t, keyPem, keyPath)
+	listener := startServerConfig(t, option.InboundTLSOptions{
+		Enabled:         true,
+		CertificatePath: certPath,
+		KeyPath:         keyPath,
+	})
+	require.Equal(t, certificatePublicKeySHA256(t, certPem), serverPublicKeySHA256(t, listener.Addr()))
+	replaceFile(t, newCertPem, certPath)
+	replaceFile(t, newKeyPem, keyPath)
+	require.Eventually(t, func() bool {
+		return string(certificatePublicKeySHA256(t, newCertPem)) == string(serverPublicKeySHA256(t, listener.Addr()))
+	}, 5*time.Second, 100*time.Millisecond)
+}
+
+func certificatePublicKeySHA256(t *testing.T, certPem string) []byte {
+	content, err := os.ReadFile(certPem)
+	require.NoError(t, err)
+	block, _ := pem.Decode(content)
+	require.NotNil(t, block)
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	hashValue := sha256.Sum256(certificate.RawSubjectPublicKeyInfo)
+	return hashValue[:]
+}
+
+func startTLSServer(t *testing.T, config *tls.Config, peerCertificates chan<- []*x509.Certificate) net.Listener {
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		listener.Close()
+	})
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			tlsConn := conn.(*tls.Conn)
+			if tlsConn.Handshake() == nil && peerCertificates != nil {
+				peerCertificates <- tlsConn.ConnectionState().PeerCertificates
+			}
+			tlsConn.Close()
+		}
+	}()
+	return listener
+}
+
+func clientHandshake(t *testing.T, serverAddr net.Addr, options option.OutboundTLSOptions) (net.Conn, error) {
+	ctx := context.Background()
+	config, err := sTLS.NewClient(ctx, "", options)
+	require.NoError(t, err)
+	conn, err := net.Dial("tcp", serverAddr.String())
+	require.NoError(t, err)
+	tlsConn, err := sTLS.ClientHandshake(ctx, conn, config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+func startServerConfig(t *testing.T, options option.InboundTLSOptions) net.Listener {
+	ctx := context.Background()
+	config, err := sTLS.NewServer(ctx, log.NewNOPFactory().NewLogger("tls"), options)
+	require.NoError(t, err)
+	require.NoError(t, config.Start())
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		listener.Close()
+		config.Close()
+	})
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				tlsConn, err := sTLS.ServerHandshake(ctx, conn, config)
+				if err == nil {
+					tlsConn.Close()
+				} else {
+					conn.Close()
+				}
+			}()
+		}
+	}()
+	return listener
+}
+
+func serverPublicKeySHA256(t *testing.T, serverAddr net.Addr) []byte {
+	conn, err := tls.Dial("tcp", serverAddr.String(), &tls.Config{
+		InsecureSkipVerify: true,
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+	hashValue := sha256.Sum256(conn.ConnectionState().PeerCertificates[0].RawSubjectPublicKeyInfo)
+	return hashValue[:]
+}
+
+// replaceFile copies the file by rename, as tools renewing certificates do.
+func replaceFile(t *testing.T, source string, destination string) {
+	content, err := os.ReadFile(source)
+	require.NoError(t, err)
+	tempPath := destination + ".tmp"
+	require.NoError(t, os.WriteFile(tempPath, content, 0o644))
+	require.NoError(t, os.Rename(tempPath, destination))
+}
@@ -5,8 +5,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/sagernet/sing-box/adapter"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/option"
+
+	"github.com/stretchr/testify/require"
 )
 
 func _TestWireGuard(t *testing.T) {
@@ -49,3 +52,90 @@ func _TestWireGuard(t *testing.T) {
 	})
 	testSuitWg(t, clientPort, testPort)
 }
+
+func TestWireGuardInbound(t *testing.T) {
+	instance := startInstance(t, option.Options{
+		Inbounds: []option.Inbound{
+			{
+				Type: C.TypeMixed,
+				Tag:  "mixed-in",
+				MixedOptions: option.HTTPMixedInboundOptions{
+					ListenOptions: option.ListenOptions{
+						Listen:     option.NewListenAddress(netip.IPv4Unspecified()),
+						ListenPort: clientPort,
+					},
+				},
+			},
+			{
+				Type: C.TypeWireGuard,
+				Tag:  "wg-in",
+				WireGuardOptions: option.WireGuardInboundOptions{
+					ListenOptions: option.ListenOptions{
+						Listen:     option.NewListenAddress(netip.IPv4Unspecified()),
+						ListenPort: serverPort,
+					},
+					PrivateKey: "aLf77wohZSqig9G3TpCMuZFXCoFVVuagUllyPKzDyn0=",
+					Peers: []option.WireGuardInboundPeer{
+						{
+							Name:       "sekai",
+							PublicKey:  "9ZEFqCqzy55OXl+yfGD4A9pgO5oHzBw75A+T9+zkGEg=",
+							AllowedIPs: []netip.Prefix{netip.MustParsePrefix("10.0.0.2/32")},
+						},
+					},
+				},
+			},
+		},
+		Outbounds: []option.Outbound{
+			{
+				Type: C.TypeBlock,
+				Tag:  "block",
+			},
+			{
+				Type: C.TypeDirect,
+				Tag:  "direct",
+				DirectOptions: option.DirectOutboundOptions{
+					OverrideAddress: "127.0.0.1",
+				},
+			},
+			{
+				Type: C.TypeWireGuard,
+				Tag:  "wg-out",
+				WireGuardOptions: option.WireGuardOutboundOptions{
+					ServerOptions: option.ServerOptions{
+						Server:     "127.0.0.1",
+						ServerPort: serverPort,
+					},
+					LocalAddress:  []netip.Prefix{netip.MustParsePrefix("10.0.0.2/32")},
+					PrivateKey:    "oEXEw4B/6cB6O33CVboVdL5/iX4er9Yz00ZCouCPk30=",
+					PeerPublicKey: "sgIiUTxdykfnRJgCF/53qY/wZph2ytGWabxj5OEbeBY=",
+				},
+			},
+		},
+		Route: &option.RouteOptions{
+			Rules: []option.Rule{
+				{
+					DefaultOptions: option.DefaultRule{
+						Inbound:  []string{"mixed-in"},
+						Outbound: "wg-out",
+					},
+				},
+				{
+					DefaultOptions: option.DefaultRule{
+						AuthUser: []string{"sekai"},
+						Outbound: "direct",
+					},
+				},
+			},
+			Final: "block",
+		},
+	})
+	testSuitWg(t, clientPort, testPort)
+	inbound, loaded := instance.Router().Inbound("wg-in")
+	require.True(t, loaded)
+	peers := inbound.(adapter.WireGuardInbound).Peers()
+	require.Len(t, peers, 1)
+	require.Equal(t, "sekai", peers[0].Name)
+	require.False(t, peers[0].LastHandshake.IsZero())
+	require.NotZero(t, peers[0].RxBytes)
+	require.NotZero(t, peers[0].TxBytes)
+}
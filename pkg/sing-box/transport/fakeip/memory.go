@@ -40,12 +40,15 @@ func (s *MemoryStorage) FakeIPSaveMetadataAsync(metadata *adapter.FakeIPMetadata
 func (s *MemoryStorage) FakeIPStore(address netip.Addr, domain string) error {
 	s.addressAccess.Lock()
 	s.domainAccess.Lock()
-	s.addressCache[address] = domain
-	if address.Is4() {
-		s.domainCache4[domain] = address
-	} else {
-		s.domainCache6[domain] = address
+	domainCache := s.domainCache4
+	if !address.Is4() {
+		domainCache = s.domainCache6
+	}
+	if oldDomain, loaded := s.addressCache[address]; loaded && oldDomain != domain && domainCache[oldDomain] == address {
+		delete(domainCache, oldDomain)
 	}
+	s.addressCache[address] = domain
+	domainCache[domain] = address
 	s.domainAccess.Unlock()
 	s.addressAccess.Unlock()
 	return nil
@@ -74,6 +77,15 @@ func (s *MemoryStorage) FakeIPLoadDomain(domain string, isIPv6 bool) (netip.Addr
 	}
 }
 
+func (s *MemoryStorage) FakeIPForEach(fn func(address netip.Addr, domain string)) error {
+	s.addressAccess.RLock()
+	defer s.addressAccess.RUnlock()
+	for address, domain := range s.addressCache {
+		fn(address, domain)
+	}
+	return nil
+}
+
 func (s *MemoryStorage) FakeIPReset() error {
 	s.addressCache = make(map[netip.Addr]string)
 	s.domainCache4 = make(map[string]netip.Addr)
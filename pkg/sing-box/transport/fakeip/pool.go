@@ -0,0 +1,137 @@
+package fakeip
+
+import (
+	"container/list"
+	"math"
+	"net/netip"
+	"time"
+
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+type poolEntry struct {
+	address  netip.Addr
+	domain   string
+	lastUsed time.Time
+}
+
+// addressPool allocates addresses of one range sequentially and recycles
+// the least recently used address once the range is exhausted.
+type addressPool struct {
+	prefix    netip.Prefix
+	first     netip.Addr
+	current   netip.Addr
+	capacity  uint64
+	addresses map[netip.Addr]*list.Element
+	domains   map[string]*list.Element
+	lru       list.List
+	recycled  uint64
+}
+
+func newAddressPool(prefix netip.Prefix) (*addressPool, error) {
+	prefix = prefix.Masked()
+	hostBits := prefix.Addr().BitLen() - prefix.Bits()
+	var capacity uint64
+	if hostBits >= 64 {
+		capacity = math.MaxUint64
+	} else {
+		capacity = 1<<hostBits - 2
+	}
+	if hostBits < 2 || capacity == 0 {
+		return nil, E.New("fakeip range too small: ", prefix)
+	}
+	pool := &addressPool{
+		prefix:   prefix,
+		first:    prefix.Addr().Next().Next(),
+		capacity: capacity,
+	}
+	pool.reset()
+	return pool, nil
+}
+
+func (p *addressPool) reset() {
+	p.current = netip.Addr{}
+	p.addresses = make(map[netip.Addr]*list.Element)
+	p.domains = make(map[string]*list.Element)
+	p.lru.Init()
+	p.recycled = 0
+}
+
+func (p *addressPool) load(address netip.Addr, domain string) {
+	if address.Less(p.first) || p.addresses[address] != nil || p.domains[domain] != nil {
+		return
+	}
+	element := p.lru.PushBack(&poolEntry{
+		address: address,
+		domain:  domain,
+	})
+	p.addresses[address] = element
+	p.domains[domain] = element
+	if !p.current.IsValid() || p.current.Less(address) {
+		p.current = address
+	}
+}
+
+func (p *addressPool) lookupDomain(domain string) (netip.Addr, bool) {
+	element := p.domains[domain]
+	if element == nil {
+		return netip.Addr{}, false
+	}
+	entry := p.touch(element)
+	return entry.address, true
+}
+
+func (p *addressPool) lookupAddress(address netip.Addr) (string, bool) {
+	element := p.addresses[address]
+	if element == nil {
+		return "", false
+	}
+	entry := p.touch(element)
+	return entry.domain, true
+}
+
+func (p *addressPool) touch(element *list.Element) *poolEntry {
+	entry := element.Value.(*poolEntry)
+	entry.lastUsed = time.Now()
+	p.lru.MoveToFront(element)
+	return entry
+}
+
+// allocate assigns an address to domain. The returned domain is the one
+// whose address was recycled, if any.
+func (p *addressPool) allocate(domain string) (netip.Addr, string) {
+	var (
+		address        netip.Addr
+		recycledDomain string
+	)
+	if uint64(p.lru.Len()) < p.capacity {
+		for {
+			if p.current.IsValid() {
+				address = p.current.Next()
+			}
+			if !address.IsValid() || !p.prefix.Contains(address) {
+				address = p.first
+			}
+			p.current = address
+			if p.addresses[address] == nil {
+				break
+			}
+		}
+	} else {
+		element := p.lru.Back()
+		entry := p.lru.Remove(element).(*poolEntry)
+		delete(p.addresses, entry.address)
+		delete(p.domains, entry.domain)
+		address = entry.address
+		recycledDomain = entry.domain
+		p.recycled++
+	}
+	element := p.lru.PushFront(&poolEntry{
+		address:  address,
+		domain:   domain,
+		lastUsed: time.Now(),
+	})
+	p.addresses[address] = element
+	p.domains[domain] = element
+	return address, recycledDomain
+}
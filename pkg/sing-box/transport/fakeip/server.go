@@ -20,7 +20,7 @@ var (
 
 func init() {
 	dns.RegisterTransport([]string{"fakeip"}, func(options dns.TransportOptions) (dns.Transport, error) {
-		return NewTransport(options)
+		return NewTransport(options, "")
 	})
 }
 
@@ -29,9 +29,10 @@ type Transport struct {
 	router adapter.Router
 	store  adapter.FakeIPStore
 	logger logger.ContextLogger
+	pool   string
 }
 
-func NewTransport(options dns.TransportOptions) (*Transport, error) {
+func NewTransport(options dns.TransportOptions, pool string) (*Transport, error) {
 	router := adapter.RouterFromContext(options.Context)
 	if router == nil {
 		return nil, E.New("missing router in context")
@@ -40,6 +41,7 @@ func NewTransport(options dns.TransportOptions) (*Transport, error) {
 		name:   options.Name,
 		router: router,
 		logger: options.Logger,
+		pool:   pool,
 	}, nil
 }
 
@@ -73,14 +75,14 @@ func (s *Transport) Exchange(ctx context.Context, message *mDNS.Msg) (*mDNS.Msg,
 func (s *Transport) Lookup(ctx context.Context, domain string, strategy dns.DomainStrategy) ([]netip.Addr, error) {
 	var addresses []netip.Addr
 	if strategy != dns.DomainStrategyUseIPv6 {
-		inet4Address, err := s.store.Create(domain, false)
+		inet4Address, err := s.store.Create(s.pool, domain, false)
 		if err != nil {
 			return nil, err
 		}
 		addresses = append(addresses, inet4Address)
 	}
 	if strategy != dns.DomainStrategyUseIPv4 {
-		inet6Address, err := s.store.Create(domain, true)
+		inet6Address, err := s.store.Create(s.pool, domain, true)
 		if err != nil {
 			return nil, err
 		}
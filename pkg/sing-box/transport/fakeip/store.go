@@ -3,8 +3,10 @@ package fakeip
 import (
 	"context"
 	"net/netip"
+	"sync"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/option"
 	E "github.com/sagernet/sing/common/exceptions"
 	"github.com/sagernet/sing/common/logger"
 	"github.com/sagernet/sing/service"
@@ -13,22 +15,84 @@ import (
 var _ adapter.FakeIPStore = (*Store)(nil)
 
 type Store struct {
-	ctx          context.Context
-	logger       logger.Logger
-	inet4Range   netip.Prefix
-	inet6Range   netip.Prefix
-	storage      adapter.FakeIPStorage
-	inet4Current netip.Addr
-	inet6Current netip.Addr
+	ctx     context.Context
+	logger  logger.Logger
+	storage adapter.FakeIPStorage
+	access  sync.Mutex
+	pools   []*storePool
+	poolMap map[string]*storePool
 }
 
-func NewStore(ctx context.Context, logger logger.Logger, inet4Range netip.Prefix, inet6Range netip.Prefix) *Store {
-	return &Store{
-		ctx:        ctx,
-		logger:     logger,
-		inet4Range: inet4Range,
-		inet6Range: inet6Range,
+type storePool struct {
+	tag   string
+	inet4 *addressPool
+	inet6 *addressPool
+}
+
+func NewStore(ctx context.Context, logger logger.Logger, options option.DNSFakeIPOptions) (*Store, error) {
+	store := &Store{
+		ctx:     ctx,
+		logger:  logger,
+		poolMap: make(map[string]*storePool),
+	}
+	err := store.addPool("", options.Inet4Range, options.Inet6Range)
+	if err != nil {
+		return nil, err
+	}
+	for i, poolOptions := range options.Pools {
+		if poolOptions.Tag == "" {
+			return nil, E.New("parse pool[", i, "]: missing tag")
+		}
+		if _, exists := store.poolMap[poolOptions.Tag]; exists {
+			return nil, E.New("parse pool[", i, "]: duplicate tag: ", poolOptions.Tag)
+		}
+		err = store.addPool(poolOptions.Tag, poolOptions.Inet4Range, poolOptions.Inet6Range)
+		if err != nil {
+			return nil, E.Cause(err, "parse pool[", i, "]")
+		}
+	}
+	return store, nil
+}
+
+func (s *Store) addPool(tag string, inet4Range *netip.Prefix, inet6Range *netip.Prefix) error {
+	pool := &storePool{tag: tag}
+	for _, prefix := range []*netip.Prefix{inet4Range, inet6Range} {
+		if prefix == nil {
+			continue
+		}
+		for _, otherPool := range s.pools {
+			for _, otherRange := range []*addressPool{otherPool.inet4, otherPool.inet6} {
+				if otherRange != nil && otherRange.prefix.Overlaps(*prefix) {
+					return E.New("fakeip range ", *prefix, " overlaps with ", otherRange.prefix)
+				}
+			}
+		}
+	}
+	var err error
+	if inet4Range != nil {
+		if !inet4Range.Addr().Is4() {
+			return E.New("invalid inet4_range: ", *inet4Range)
+		}
+		pool.inet4, err = newAddressPool(*inet4Range)
+		if err != nil {
+			return err
+		}
+	}
+	if inet6Range != nil {
+		if !inet6Range.Addr().Is6() {
+			return E.New("invalid inet6_range: ", *inet6Range)
+		}
+		pool.inet6, err = newAddressPool(*inet6Range)
+		if err != nil {
+			return err
+		}
 	}
+	if tag != "" && pool.inet4 == nil && pool.inet6 == nil {
+		return E.New("missing fakeip address range")
+	}
+	s.pools = append(s.pools, pool)
+	s.poolMap[tag] = pool
+	return nil
 }
 
 func (s *Store) Start() error {
@@ -41,78 +105,202 @@ func (s *Store) Start() error {
 		storage = NewMemoryStorage()
 	}
 	metadata := storage.FakeIPMetadata()
-	if metadata != nil && metadata.Inet4Range == s.inet4Range && metadata.Inet6Range == s.inet6Range {
-		s.inet4Current = metadata.Inet4Current
-		s.inet6Current = metadata.Inet6Current
-	} else {
-		if s.inet4Range.IsValid() {
-			s.inet4Current = s.inet4Range.Addr().Next().Next()
-		}
-		if s.inet6Range.IsValid() {
-			s.inet6Current = s.inet6Range.Addr().Next().Next()
+	if metadata != nil && s.rangesEqual(metadata) {
+		err := storage.FakeIPForEach(func(address netip.Addr, domain string) {
+			if addressPool := s.addressPool(address); addressPool != nil {
+				addressPool.load(address, domain)
+			}
+		})
+		if err != nil {
+			s.logger.Warn("load FakeIP cache: ", err)
 		}
+	} else {
 		_ = storage.FakeIPReset()
 	}
 	s.storage = storage
 	return nil
 }
 
+// rangesEqual reports whether the cached entries were allocated with the
+// same ranges for every pool, so that they can be restored as is.
+func (s *Store) rangesEqual(metadata *adapter.FakeIPMetadata) bool {
+	current := s.metadata()
+	if metadata.Inet4Range != current.Inet4Range || metadata.Inet6Range != current.Inet6Range {
+		return false
+	}
+	if len(metadata.Pools) != len(current.Pools) {
+		return false
+	}
+	for i := range current.Pools {
+		if metadata.Pools[i] != current.Pools[i] {
+			return false
+		}
+	}
+	return true
+}
+
 func (s *Store) Contains(address netip.Addr) bool {
-	return s.inet4Range.Contains(address) || s.inet6Range.Contains(address)
+	return s.addressPool(address) != nil
+}
+
+func (s *Store) addressPool(address netip.Addr) *addressPool {
+	for _, pool := range s.pools {
+		if pool.inet4 != nil && pool.inet4.prefix.Contains(address) {
+			return pool.inet4
+		}
+		if pool.inet6 != nil && pool.inet6.prefix.Contains(address) {
+			return pool.inet6
+		}
+	}
+	return nil
 }
 
 func (s *Store) Close() error {
 	if s.storage == nil {
 		return nil
 	}
-	return s.storage.FakeIPSaveMetadata(&adapter.FakeIPMetadata{
-		Inet4Range:   s.inet4Range,
-		Inet6Range:   s.inet6Range,
-		Inet4Current: s.inet4Current,
-		Inet6Current: s.inet6Current,
-	})
+	return s.storage.FakeIPSaveMetadata(s.metadata())
 }
 
-func (s *Store) Create(domain string, isIPv6 bool) (netip.Addr, error) {
-	if address, loaded := s.storage.FakeIPLoadDomain(domain, isIPv6); loaded {
-		return address, nil
+func (s *Store) metadata() *adapter.FakeIPMetadata {
+	defaultPool := s.poolMap[""]
+	metadata := &adapter.FakeIPMetadata{
+		Inet4Range: defaultPool.inet4Range(),
+		Inet6Range: defaultPool.inet6Range(),
 	}
-	var address netip.Addr
-	if !isIPv6 {
-		if !s.inet4Current.IsValid() {
-			return netip.Addr{}, E.New("missing IPv4 fakeip address range")
-		}
-		nextAddress := s.inet4Current.Next()
-		if !s.inet4Range.Contains(nextAddress) {
-			nextAddress = s.inet4Range.Addr().Next().Next()
+	if defaultPool.inet4 != nil {
+		metadata.Inet4Current = defaultPool.inet4.current
+	}
+	if defaultPool.inet6 != nil {
+		metadata.Inet6Current = defaultPool.inet6.current
+	}
+	for _, pool := range s.pools {
+		if pool.tag == "" {
+			continue
 		}
-		s.inet4Current = nextAddress
-		address = nextAddress
-	} else {
-		if !s.inet6Current.IsValid() {
+		metadata.Pools = append(metadata.Pools, adapter.FakeIPPoolMetadata{
+			Tag:        pool.tag,
+			Inet4Range: pool.inet4Range(),
+			Inet6Range: pool.inet6Range(),
+		})
+	}
+	return metadata
+}
+
+func (s *Store) Create(pool string, domain string, isIPv6 bool) (netip.Addr, error) {
+	storePool, loaded := s.poolMap[pool]
+	if !loaded {
+		return netip.Addr{}, E.New("fakeip pool not found: ", pool)
+	}
+	addressPool := storePool.inet4
+	if isIPv6 {
+		addressPool = storePool.inet6
+	}
+	if addressPool == nil {
+		if isIPv6 {
 			return netip.Addr{}, E.New("missing IPv6 fakeip address range")
+		} else {
+			return netip.Addr{}, E.New("missing IPv4 fakeip address range")
 		}
-		nextAddress := s.inet6Current.Next()
-		if !s.inet6Range.Contains(nextAddress) {
-			nextAddress = s.inet6Range.Addr().Next().Next()
-		}
-		s.inet6Current = nextAddress
-		address = nextAddress
+	}
+	s.access.Lock()
+	if address, loaded := addressPool.lookupDomain(domain); loaded {
+		s.access.Unlock()
+		return address, nil
+	}
+	address, recycledDomain := addressPool.allocate(domain)
+	metadata := s.metadata()
+	s.access.Unlock()
+	if recycledDomain != "" {
+		s.logger.Debug("recycled fakeip ", address, " from ", recycledDomain, " for ", domain)
 	}
 	s.storage.FakeIPStoreAsync(address, domain, s.logger)
-	s.storage.FakeIPSaveMetadataAsync(&adapter.FakeIPMetadata{
-		Inet4Range:   s.inet4Range,
-		Inet6Range:   s.inet6Range,
-		Inet4Current: s.inet4Current,
-		Inet6Current: s.inet6Current,
-	})
+	s.storage.FakeIPSaveMetadataAsync(metadata)
 	return address, nil
 }
 
 func (s *Store) Lookup(address netip.Addr) (string, bool) {
-	return s.storage.FakeIPLoad(address)
+	addressPool := s.addressPool(address)
+	if addressPool == nil {
+		return "", false
+	}
+	s.access.Lock()
+	defer s.access.Unlock()
+	return addressPool.lookupAddress(address)
+}
+
+func (s *Store) Entries() []adapter.FakeIPEntry {
+	s.access.Lock()
+	defer s.access.Unlock()
+	var entries []adapter.FakeIPEntry
+	for _, pool := range s.pools {
+		for _, addressPool := range []*addressPool{pool.inet4, pool.inet6} {
+			if addressPool == nil {
+				continue
+			}
+			for element := addressPool.lru.Front(); element != nil; element = element.Next() {
+				entry := element.Value.(*poolEntry)
+				entries = append(entries, adapter.FakeIPEntry{
+					Pool:     pool.tag,
+					Address:  entry.address,
+					Domain:   entry.domain,
+					LastUsed: entry.lastUsed,
+				})
+			}
+		}
+	}
+	return entries
+}
+
+func (s *Store) Pools() []adapter.FakeIPPoolStatus {
+	s.access.Lock()
+	defer s.access.Unlock()
+	statusList := make([]adapter.FakeIPPoolStatus, 0, len(s.pools))
+	for _, pool := range s.pools {
+		status := adapter.FakeIPPoolStatus{
+			Tag:        pool.tag,
+			Inet4Range: pool.inet4Range(),
+			Inet6Range: pool.inet6Range(),
+		}
+		if pool.inet4 != nil {
+			status.Inet4Used = uint64(pool.inet4.lru.Len())
+			status.Inet4Capacity = pool.inet4.capacity
+			status.Recycled += pool.inet4.recycled
+		}
+		if pool.inet6 != nil {
+			status.Inet6Used = uint64(pool.inet6.lru.Len())
+			status.Inet6Capacity = pool.inet6.capacity
+			status.Recycled += pool.inet6.recycled
+		}
+		statusList = append(statusList, status)
+	}
+	return statusList
 }
 
 func (s *Store) Reset() error {
+	s.access.Lock()
+	for _, pool := range s.pools {
+		if pool.inet4 != nil {
+			pool.inet4.reset()
+		}
+		if pool.inet6 != nil {
+			pool.inet6.reset()
+		}
+	}
+	s.access.Unlock()
 	return s.storage.FakeIPReset()
 }
+
+func (p *storePool) inet4Range() netip.Prefix {
+	if p.inet4 == nil {
+		return netip.Prefix{}
+	}
+	return p.inet4.prefix
+}
+
+func (p *storePool) inet6Range() netip.Prefix {
+	if p.inet6 == nil {
+		return netip.Prefix{}
+	}
+	return p.inet6.prefix
+}
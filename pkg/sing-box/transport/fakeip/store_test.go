@@ -0,0 +1,146 @@
+package fakeip_test
+
+import (
+	"context"
+	"net/netip"
+	"path/filepath"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/experimental/cachefile"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/transport/fakeip"
+	"github.com/sagernet/sing/service"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newStore(t *testing.T, options option.DNSFakeIPOptions) *fakeip.Store {
+	store, err := fakeip.NewStore(context.Background(), log.NewNOPFactory().Logger(), options)
+	require.NoError(t, err)
+	require.NoError(t, store.Start())
+	t.Cleanup(func() {
+		store.Close()
+	})
+	return store
+}
+
+func prefix(s string) *netip.Prefix {
+	p := netip.MustParsePrefix(s)
+	return &p
+}
+
+func TestStoreRecycleLeastRecentlyUsed(t *testing.T) {
+	t.Parallel()
+	store := newStore(t, option.DNSFakeIPOptions{
+		Inet4Range: prefix("198.18.0.0/30"),
+	})
+	first, err := store.Create("", "a.test", false)
+	require.NoError(t, err)
+	require.Equal(t, netip.MustParseAddr("198.18.0.2"), first)
+	second, err := store.Create("", "b.test", false)
+	require.NoError(t, err)
+	require.Equal(t, netip.MustParseAddr("198.18.0.3"), second)
+	domain, loaded := store.Lookup(first)
+	require.True(t, loaded)
+	require.Equal(t, "a.test", domain)
+	third, err := store.Create("", "c.test", false)
+	require.NoError(t, err)
+	require.Equal(t, second, third)
+	_, loaded = store.Lookup(second)
+	require.True(t, loaded)
+	domain, _ = store.Lookup(third)
+	require.Equal(t, "c.test", domain)
+	address, err := store.Create("", "a.test", false)
+	require.NoError(t, err)
+	require.Equal(t, first, address)
+	pools := store.Pools()
+	require.Len(t, pools, 1)
+	require.Equal(t, uint64(2), pools[0].Inet4Used)
+	require.Equal(t, uint64(2), pools[0].Inet4Capacity)
+	require.Equal(t, uint64(1), pools[0].Recycled)
+}
+
+func TestStorePools(t *testing.T) {
+	t.Parallel()
+	store := newStore(t, option.DNSFakeIPOptions{
+		Inet4Range: prefix("198.18.0.0/24"),
+		Pools: []option.DNSFakeIPPoolOptions{{
+			Tag:        "app",
+			Inet4Range: prefix("198.19.0.0/24"),
+		}},
+	})
+	defaultAddress, err := store.Create("", "a.test", false)
+	require.NoError(t, err)
+	poolAddress, err := store.Create("app", "a.test", false)
+	require.NoError(t, err)
+	require.True(t, netip.MustParsePrefix("198.18.0.0/24").Contains(defaultAddress))
+	require.True(t, netip.MustParsePrefix("198.19.0.0/24").Contains(poolAddress))
+	require.True(t, store.Contains(poolAddress))
+	domain, loaded := store.Lookup(poolAddress)
+	require.True(t, loaded)
+	require.Equal(t, "a.test", domain)
+	_, err = store.Create("app", "a.test", true)
+	require.Error(t, err)
+	_, err = store.Create("missing", "a.test", false)
+	require.Error(t, err)
+	require.Len(t, store.Entries(), 2)
+	require.NoError(t, store.Reset())
+	require.Empty(t, store.Entries())
+}
+
+func TestStoreOverlappingPools(t *testing.T) {
+	t.Parallel()
+	_, err := fakeip.NewStore(context.Background(), log.NewNOPFactory().Logger(), option.DNSFakeIPOptions{
+		Inet4Range: prefix("198.18.0.0/15"),
+		Pools: []option.DNSFakeIPPoolOptions{{
+			Tag:        "app",
+			Inet4Range: prefix("198.19.0.0/16"),
+		}},
+	})
+	require.Error(t, err)
+}
+
+func TestStoreCachePoolRanges(t *testing.T) {
+	t.Parallel()
+	cachePath := filepath.Join(t.TempDir(), "cache.db")
+	openStore := func(poolRange string) (*fakeip.Store, func()) {
+		cacheFile := cachefile.New(context.Background(), option.CacheFileOptions{
+			Enabled:     true,
+			Path:        cachePath,
+			StoreFakeIP: true,
+		})
+		require.NoError(t, cacheFile.PreStart())
+		ctx := service.ContextWith[adapter.CacheFile](context.Background(), cacheFile)
+		store, err := fakeip.NewStore(ctx, log.NewNOPFactory().Logger(), option.DNSFakeIPOptions{
+			Inet4Range: prefix("198.18.0.0/24"),
+			Pools: []option.DNSFakeIPPoolOptions{{
+				Tag:        "app",
+				Inet4Range: prefix(poolRange),
+			}},
+		})
+		require.NoError(t, err)
+		require.NoError(t, store.Start())
+		return store, func() {
+			require.NoError(t, store.Close())
+			require.NoError(t, cacheFile.Close())
+		}
+	}
+	store, closeStore := openStore("198.19.0.0/24")
+	address, err := store.Create("app", "a.test", false)
+	require.NoError(t, err)
+	closeStore()
+
+	store, closeStore = openStore("198.19.0.0/24")
+	domain, loaded := store.Lookup(address)
+	require.True(t, loaded)
+	require.Equal(t, "a.test", domain)
+	closeStore()
+
+	store, closeStore = openStore("198.19.0.0/16")
+	_, loaded = store.Lookup(address)
+	require.False(t, loaded)
+	require.Empty(t, store.Entries())
+	closeStore()
+}
@@ -0,0 +1,153 @@
+package hosts
+
+import (
+	"bufio"
+	"bytes"
+	"net/netip"
+	"strings"
+
+	E "github.com/sagernet/sing/common/exceptions"
+
+	mDNS "github.com/miekg/dns"
+)
+
+type entry struct {
+	addresses []netip.Addr
+	cname     string
+}
+
+// Table maps domain names to addresses or a canonical name.
+// Wildcard entries (*.example.com) match subdomains at any depth,
+// the longest matching wildcard wins and exact entries win over wildcards.
+type Table struct {
+	exact    map[string]*entry
+	wildcard map[string]*entry
+}
+
+func NewTable() *Table {
+	return &Table{
+		exact:    make(map[string]*entry),
+		wildcard: make(map[string]*entry),
+	}
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+func (t *Table) entryFor(name string, create bool) (*entry, error) {
+	name = normalizeName(name)
+	entries := t.exact
+	if strings.HasPrefix(name, "*.") {
+		name = name[2:]
+		entries = t.wildcard
+	}
+	if _, isDomain := mDNS.IsDomainName(name); !isDomain || name == "" || strings.Contains(name, "*") {
+		return nil, E.New("invalid domain name: ", name)
+	}
+	hostEntry := entries[name]
+	if hostEntry == nil && create {
+		hostEntry = &entry{}
+		entries[name] = hostEntry
+	}
+	return hostEntry, nil
+}
+
+// Add appends addresses to name, or points name at the canonical name
+// if target is not an IP address. Addresses and a canonical name can not be mixed.
+func (t *Table) Add(name string, targets []string) error {
+	hostEntry, err := t.entryFor(name, true)
+	if err != nil {
+		return err
+	}
+	for _, target := range targets {
+		address, parseErr := netip.ParseAddr(target)
+		if parseErr == nil {
+			if hostEntry.cname != "" {
+				return E.New(name, ": both address and canonical name configured")
+			}
+			hostEntry.addresses = append(hostEntry.addresses, address.Unmap())
+			continue
+		}
+		cname := normalizeName(target)
+		if _, isDomain := mDNS.IsDomainName(cname); !isDomain || cname == "" {
+			return E.New(name, ": invalid address or domain name: ", target)
+		}
+		if len(hostEntry.addresses) > 0 || hostEntry.cname != "" && hostEntry.cname != cname {
+			return E.New(name, ": multiple canonical names or both address and canonical name configured")
+		}
+		hostEntry.cname = cname
+	}
+	return nil
+}
+
+// Merge copies entries of other that are not present in t.
+func (t *Table) Merge(other *Table) {
+	for name, hostEntry := range other.exact {
+		if _, loaded := t.exact[name]; !loaded {
+			t.exact[name] = hostEntry
+		}
+	}
+	for name, hostEntry := range other.wildcard {
+		if _, loaded := t.wildcard[name]; !loaded {
+			t.wildcard[name] = hostEntry
+		}
+	}
+}
+
+func (t *Table) Lookup(name string) (addresses []netip.Addr, cname string, loaded bool) {
+	name = normalizeName(name)
+	hostEntry := t.exact[name]
+	if hostEntry == nil {
+		for parent := name; ; {
+			index := strings.IndexByte(parent, '.')
+			if index == -1 {
+				break
+			}
+			parent = parent[index+1:]
+			if hostEntry = t.wildcard[parent]; hostEntry != nil {
+				break
+			}
+		}
+	}
+	if hostEntry == nil {
+		return nil, "", false
+	}
+	return hostEntry.addresses, hostEntry.cname, true
+}
+
+func (t *Table) Len() int {
+	return len(t.exact) + len(t.wildcard)
+}
+
+// ParseFile reads the hosts file format: an address followed by names,
+// with comments starting with #. Lines that can not be parsed are skipped.
+func ParseFile(content []byte) *Table {
+	table := NewTable()
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if index := strings.IndexByte(line, '#'); index != -1 {
+			line = line[:index]
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		address, err := netip.ParseAddr(fields[0])
+		if err != nil {
+			continue
+		}
+		if address.Is6() && address.Zone() != "" {
+			address = address.WithZone("")
+		}
+		for _, name := range fields[1:] {
+			hostEntry, err := table.entryFor(name, true)
+			if err != nil || hostEntry.cname != "" {
+				continue
+			}
+			hostEntry.addresses = append(hostEntry.addresses, address.Unmap())
+		}
+	}
+	return table
+}
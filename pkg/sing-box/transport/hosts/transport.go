@@ -0,0 +1,227 @@
+package hosts
+
+import (
+	"context"
+	"net/netip"
+	"os"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/filewatcher"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-dns"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/atomic"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+
+	mDNS "github.com/miekg/dns"
+)
+
+var _ adapter.HostsTransport = (*Transport)(nil)
+
+const (
+	DefaultTTL    = 60
+	maxCNAMEDepth = 8
+)
+
+// Upstream resolves CNAME targets the hosts have no entry for.
+type Upstream interface {
+	Exchange(ctx context.Context, message *mDNS.Msg) (*mDNS.Msg, error)
+}
+
+type Transport struct {
+	name       string
+	logger     logger.ContextLogger
+	upstream   Upstream
+	ttl        uint32
+	paths      []string
+	predefined *Table
+	table      atomic.TypedValue[*Table]
+	watcher    *filewatcher.Watcher
+}
+
+// NewTransport creates a hosts transport. CNAME targets without an entry are
+// resolved with upstream, or answered with NXDOMAIN if upstream is nil.
+func NewTransport(name string, logger logger.ContextLogger, upstream Upstream, options option.DNSHostsOptions) (*Transport, error) {
+	transport := &Transport{
+		name:       name,
+		logger:     logger,
+		upstream:   upstream,
+		ttl:        options.TTL,
+		paths:      options.Path,
+		predefined: NewTable(),
+	}
+	if transport.ttl == 0 {
+		transport.ttl = DefaultTTL
+	}
+	for name, targets := range options.Predefined {
+		err := transport.predefined.Add(name, targets)
+		if err != nil {
+			return nil, E.Cause(err, "parse predefined hosts")
+		}
+	}
+	table, err := transport.loadTable()
+	if err != nil {
+		return nil, err
+	}
+	transport.table.Store(table)
+	return transport, nil
+}
+
+// loadTable merges predefined entries with hosts files,
+// entries found first take precedence.
+func (t *Transport) loadTable() (*Table, error) {
+	table := NewTable()
+	table.Merge(t.predefined)
+	for _, path := range t.paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, E.Cause(err, "read hosts file")
+		}
+		table.Merge(ParseFile(content))
+	}
+	return table, nil
+}
+
+func (t *Transport) Name() string {
+	return t.name
+}
+
+func (t *Transport) Start() error {
+	if len(t.paths) == 0 {
+		return nil
+	}
+	t.watcher = filewatcher.New(t.logger, t.paths, t.reload)
+	err := t.watcher.Start()
+	if err != nil {
+		t.logger.Warn("watch hosts file: ", err)
+	}
+	return nil
+}
+
+// reload keeps the current entries if a file can not be read.
+func (t *Transport) reload() error {
+	table, err := t.loadTable()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return E.Cause(err, "reload hosts")
+	}
+	t.table.Store(table)
+	t.logger.Info("reloaded hosts: ", table.Len(), " entries")
+	return nil
+}
+
+func (t *Transport) Reset() {
+}
+
+func (t *Transport) Close() error {
+	return common.Close(common.PtrOrNil(t.watcher))
+}
+
+func (t *Transport) Raw() bool {
+	return true
+}
+
+func (t *Transport) Contains(domain string) bool {
+	_, _, loaded := t.table.Load().Lookup(domain)
+	return loaded
+}
+
+func (t *Transport) Exchange(ctx context.Context, message *mDNS.Msg) (*mDNS.Msg, error) {
+	response := &mDNS.Msg{
+		MsgHdr: mDNS.MsgHdr{
+			Id:                 message.Id,
+			Response:           true,
+			Authoritative:      true,
+			RecursionDesired:   message.RecursionDesired,
+			RecursionAvailable: true,
+			Rcode:              mDNS.RcodeSuccess,
+		},
+		Question: message.Question,
+	}
+	if len(message.Question) != 1 {
+		response.Rcode = mDNS.RcodeFormatError
+		return response, nil
+	}
+	question := message.Question[0]
+	table := t.table.Load()
+	name := question.Name
+	for depth := 0; ; depth++ {
+		addresses, cname, loaded := table.Lookup(name)
+		if !loaded {
+			if depth == 0 {
+				response.Rcode = mDNS.RcodeNameError
+				break
+			}
+			return t.exchangeTarget(ctx, response, name, question)
+		}
+		header := mDNS.RR_Header{
+			Name:  name,
+			Class: mDNS.ClassINET,
+			Ttl:   t.ttl,
+		}
+		if cname != "" {
+			header.Rrtype = mDNS.TypeCNAME
+			response.Answer = append(response.Answer, &mDNS.CNAME{
+				Hdr:    header,
+				Target: mDNS.Fqdn(cname),
+			})
+			if question.Qtype == mDNS.TypeCNAME {
+				break
+			}
+			if depth == maxCNAMEDepth {
+				response.Answer = nil
+				response.Rcode = mDNS.RcodeServerFailure
+				break
+			}
+			name = mDNS.Fqdn(cname)
+			continue
+		}
+		for _, address := range addresses {
+			if question.Qtype == mDNS.TypeA && address.Is4() {
+				header.Rrtype = mDNS.TypeA
+				response.Answer = append(response.Answer, &mDNS.A{
+					Hdr: header,
+					A:   address.AsSlice(),
+				})
+			} else if question.Qtype == mDNS.TypeAAAA && address.Is6() {
+				header.Rrtype = mDNS.TypeAAAA
+				response.Answer = append(response.Answer, &mDNS.AAAA{
+					Hdr:  header,
+					AAAA: address.AsSlice(),
+				})
+			}
+		}
+		break
+	}
+	return response, nil
+}
+
+// exchangeTarget completes a CNAME chain leaving the hosts with an answer
+// from upstream, so that the response never ends with a dangling CNAME.
+func (t *Transport) exchangeTarget(ctx context.Context, response *mDNS.Msg, target string, question mDNS.Question) (*mDNS.Msg, error) {
+	if t.upstream == nil {
+		response.Answer = nil
+		response.Rcode = mDNS.RcodeNameError
+		return response, nil
+	}
+	var message mDNS.Msg
+	message.SetQuestion(target, question.Qtype)
+	message.RecursionDesired = true
+	ctx, _ = adapter.ExtendContext(ctx)
+	upstreamResponse, err := t.upstream.Exchange(ctx, &message)
+	if err != nil {
+		return nil, E.Cause(err, "resolve CNAME target ", target)
+	}
+	response.Authoritative = false
+	response.Rcode = upstreamResponse.Rcode
+	response.Answer = append(response.Answer, upstreamResponse.Answer...)
+	response.Ns = upstreamResponse.Ns
+	return response, nil
+}
+
+func (t *Transport) Lookup(ctx context.Context, domain string, strategy dns.DomainStrategy) ([]netip.Addr, error) {
+	return nil, os.ErrInvalid
+}
@@ -0,0 +1,140 @@
+package hosts_test
+
+import (
+	"context"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/transport/hosts"
+
+	mDNS "github.com/miekg/dns"
+	"github.com/stretchr/testify/require"
+)
+
+func exchange(t *testing.T, transport *hosts.Transport, name string, qtype uint16) *mDNS.Msg {
+	var message mDNS.Msg
+	message.SetQuestion(name, qtype)
+	response, err := transport.Exchange(context.Background(), &message)
+	require.NoError(t, err)
+	return response
+}
+
+func TestHostsPredefined(t *testing.T) {
+	t.Parallel()
+	transport, err := hosts.NewTransport("hosts", log.NewNOPFactory().Logger(), nil, option.DNSHostsOptions{
+		Predefined: map[string]option.Listable[string]{
+			"dev.internal":        {"10.0.0.5", "fd00::5"},
+			"*.example.com":       {"10.0.0.6"},
+			"*.inner.example.com": {"10.0.0.7"},
+			"alias.internal":      {"dev.internal"},
+		},
+		TTL: 30,
+	})
+	require.NoError(t, err)
+
+	response := exchange(t, transport, "dev.internal.", mDNS.TypeA)
+	require.Len(t, response.Answer, 1)
+	require.Equal(t, "10.0.0.5", response.Answer[0].(*mDNS.A).A.String())
+	require.Equal(t, uint32(30), response.Answer[0].Header().Ttl)
+
+	response = exchange(t, transport, "DEV.internal.", mDNS.TypeAAAA)
+	require.Len(t, response.Answer, 1)
+	require.Equal(t, "fd00::5", response.Answer[0].(*mDNS.AAAA).AAAA.String())
+
+	response = exchange(t, transport, "a.b.example.com.", mDNS.TypeA)
+	require.Len(t, response.Answer, 1)
+	require.Equal(t, "10.0.0.6", response.Answer[0].(*mDNS.A).A.String())
+
+	response = exchange(t, transport, "a.inner.example.com.", mDNS.TypeA)
+	require.Equal(t, "10.0.0.7", response.Answer[0].(*mDNS.A).A.String())
+
+	response = exchange(t, transport, "example.com.", mDNS.TypeA)
+	require.Equal(t, mDNS.RcodeNameError, response.Rcode)
+	require.False(t, transport.Contains("example.com"))
+
+	response = exchange(t, transport, "alias.internal.", mDNS.TypeA)
+	require.Len(t, response.Answer, 2)
+	require.Equal(t, "dev.internal.", response.Answer[0].(*mDNS.CNAME).Target)
+	require.Equal(t, "10.0.0.5", response.Answer[1].(*mDNS.A).A.String())
+
+	response = exchange(t, transport, "alias.internal.", mDNS.TypeCNAME)
+	require.Len(t, response.Answer, 1)
+}
+
+type testUpstream map[string]string
+
+func (u testUpstream) Exchange(ctx context.Context, message *mDNS.Msg) (*mDNS.Msg, error) {
+	response := new(mDNS.Msg)
+	response.SetReply(message)
+	address, loaded := u[message.Question[0].Name]
+	if !loaded {
+		response.Rcode = mDNS.RcodeNameError
+		return response, nil
+	}
+	response.Answer = append(response.Answer, &mDNS.A{
+		Hdr: mDNS.RR_Header{Name: message.Question[0].Name, Rrtype: mDNS.TypeA, Class: mDNS.ClassINET, Ttl: 60},
+		A:   netip.MustParseAddr(address).AsSlice(),
+	})
+	return response, nil
+}
+
+func TestHostsExternalCNAME(t *testing.T) {
+	t.Parallel()
+	options := option.DNSHostsOptions{
+		Predefined: map[string]option.Listable[string]{
+			"alias.internal":   {"cdn.example.com"},
+			"missing.internal": {"missing.example.com"},
+		},
+	}
+	transport, err := hosts.NewTransport("hosts", log.NewNOPFactory().Logger(), nil, options)
+	require.NoError(t, err)
+	response := exchange(t, transport, "alias.internal.", mDNS.TypeA)
+	require.Equal(t, mDNS.RcodeNameError, response.Rcode)
+	require.Empty(t, response.Answer)
+
+	transport, err = hosts.NewTransport("hosts", log.NewNOPFactory().Logger(), testUpstream{"cdn.example.com.": "1.2.3.4"}, options)
+	require.NoError(t, err)
+	response = exchange(t, transport, "alias.internal.", mDNS.TypeA)
+	require.Equal(t, mDNS.RcodeSuccess, response.Rcode)
+	require.Len(t, response.Answer, 2)
+	require.Equal(t, "cdn.example.com.", response.Answer[0].(*mDNS.CNAME).Target)
+	require.Equal(t, "1.2.3.4", response.Answer[1].(*mDNS.A).A.String())
+
+	response = exchange(t, transport, "missing.internal.", mDNS.TypeA)
+	require.Equal(t, mDNS.RcodeNameError, response.Rcode)
+	require.Len(t, response.Answer, 1)
+
+	response = exchange(t, transport, "alias.internal.", mDNS.TypeCNAME)
+	require.Len(t, response.Answer, 1)
+}
+
+func TestHostsFileReload(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "hosts")
+	require.NoError(t, os.WriteFile(path, []byte("# comment\n10.0.0.1 one.internal\n"), 0o644))
+	transport, err := hosts.NewTransport("hosts", log.NewNOPFactory().Logger(), nil, option.DNSHostsOptions{
+		Path: []string{path},
+		Predefined: map[string]option.Listable[string]{
+			"two.internal": {"10.0.0.22"},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, transport.Start())
+	defer transport.Close()
+	require.True(t, transport.Contains("one.internal"))
+	require.False(t, transport.Contains("three.internal"))
+
+	require.NoError(t, os.WriteFile(path, []byte("10.0.0.2 two.internal\n10.0.0.3 three.internal\n"), 0o644))
+	require.Eventually(t, func() bool {
+		return transport.Contains("three.internal")
+	}, 5*time.Second, 50*time.Millisecond)
+	require.False(t, transport.Contains("one.internal"))
+	response := exchange(t, transport, "two.internal.", mDNS.TypeA)
+	require.Len(t, response.Answer, 1)
+	require.Equal(t, netip.MustParseAddr("10.0.0.22").AsSlice(), []byte(response.Answer[0].(*mDNS.A).A))
+}
@@ -3,6 +3,7 @@ package trojan
 import (
 	"context"
 	"net"
+	"sync/atomic"
 
 	"github.com/sagernet/sing/common/auth"
 	"github.com/sagernet/sing/common/buf"
@@ -20,19 +21,18 @@ type Handler interface {
 }
 
 type Service[K comparable] struct {
-	users           map[K][56]byte
-	keys            map[[56]byte]K
+	keys            atomic.Pointer[map[[56]byte]K]
 	handler         Handler
 	fallbackHandler N.TCPConnectionHandler
 }
 
 func NewService[K comparable](handler Handler, fallbackHandler N.TCPConnectionHandler) *Service[K] {
-	return &Service[K]{
-		users:           make(map[K][56]byte),
-		keys:            make(map[[56]byte]K),
+	service := &Service[K]{
 		handler:         handler,
 		fallbackHandler: fallbackHandler,
 	}
+	service.keys.Store(&map[[56]byte]K{})
+	return service
 }
 
 var ErrUserExists = E.New("user already exists")
@@ -51,8 +51,7 @@ func (s *Service[K]) UpdateUsers(userList []K, passwordList []string) error {
 		users[user] = key
 		keys[key] = user
 	}
-	s.users = users
-	s.keys = keys
+	s.keys.Store(&keys)
 	return nil
 }
 
@@ -65,7 +64,7 @@ func (s *Service[K]) NewConnection(ctx context.Context, conn net.Conn, metadata
 		return s.fallback(ctx, conn, metadata, key[:n], E.New("bad request size"))
 	}
 
-	if user, loaded := s.keys[key]; loaded {
+	if user, loaded := (*s.keys.Load())[key]; loaded {
 		ctx = auth.ContextWithUser(ctx, user)
 	} else {
 		return s.fallback(ctx, conn, metadata, key[:], E.New("bad request"))
@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"io"
 	"net"
+	"sync/atomic"
 
 	"github.com/sagernet/sing-vmess"
 	"github.com/sagernet/sing/common/auth"
@@ -19,10 +20,16 @@ import (
 )
 
 type Service[T comparable] struct {
+	users   atomic.Pointer[serviceUsers[T]]
+	logger  logger.Logger
+	handler Handler
+}
+
+// serviceUsers is replaced as a whole, so a request never sees the users of
+// an update together with the flows of another.
+type serviceUsers[T comparable] struct {
 	userMap  map[[16]byte]T
 	userFlow map[T]string
-	logger   logger.Logger
-	handler  Handler
 }
 
 type Handler interface {
@@ -32,10 +39,12 @@ type Handler interface {
 }
 
 func NewService[T comparable](logger logger.Logger, handler Handler) *Service[T] {
-	return &Service[T]{
+	service := &Service[T]{
 		logger:  logger,
 		handler: handler,
 	}
+	service.users.Store(&serviceUsers[T]{})
+	return service
 }
 
 func (s *Service[T]) UpdateUsers(userList []T, userUUIDList []string, userFlowList []string) {
@@ -49,8 +58,7 @@ func (s *Service[T]) UpdateUsers(userList []T, userUUIDList []string, userFlowLi
 		userMap[userID] = userName
 		userFlowMap[userName] = userFlowList[i]
 	}
-	s.userMap = userMap
-	s.userFlow = userFlowMap
+	s.users.Store(&serviceUsers[T]{userMap: userMap, userFlow: userFlowMap})
 }
 
 var _ N.TCPConnectionHandler = (*Service[int])(nil)
@@ -60,14 +68,15 @@ func (s *Service[T]) NewConnection(ctx context.Context, conn net.Conn, metadata
 	if err != nil {
 		return err
 	}
-	user, loaded := s.userMap[request.UUID]
+	users := s.users.Load()
+	user, loaded := users.userMap[request.UUID]
 	if !loaded {
 		return E.New("unknown UUID: ", uuid.FromBytesOrNil(request.UUID[:]))
 	}
 	ctx = auth.ContextWithUser(ctx, user)
 	metadata.Destination = request.Destination
 
-	userFlow := s.userFlow[user]
+	userFlow := users.userFlow[user]
 	if request.Flow == FlowVision && request.Command == vmess.NetworkUDP {
 		return E.New(FlowVision, " flow does not support UDP")
 	} else if request.Flow != userFlow {
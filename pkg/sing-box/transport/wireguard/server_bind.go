@@ -0,0 +1,107 @@
+package wireguard
+
+import (
+	"net"
+	"net/netip"
+	"sync"
+
+	"github.com/sagernet/sing/common"
+	M "github.com/sagernet/sing/common/metadata"
+	"github.com/sagernet/wireguard-go/conn"
+)
+
+var _ conn.Bind = (*ServerBind)(nil)
+
+// ServerBind receives packets of peers on a listening UDP socket.
+// The socket is opened by Open and closed by Close, as the device reopens
+// its bind every time it is brought up.
+type ServerBind struct {
+	listen func() (net.PacketConn, error)
+	access sync.Mutex
+	conn   net.PacketConn
+}
+
+func NewServerBind(listen func() (net.PacketConn, error)) *ServerBind {
+	return &ServerBind{
+		listen: listen,
+	}
+}
+
+func (b *ServerBind) Open(port uint16) (fns []conn.ReceiveFunc, actualPort uint16, err error) {
+	b.access.Lock()
+	defer b.access.Unlock()
+	if b.conn != nil {
+		return nil, 0, conn.ErrBindAlreadyOpen
+	}
+	packetConn, err := b.listen()
+	if err != nil {
+		return nil, 0, err
+	}
+	b.conn = packetConn
+	return []conn.ReceiveFunc{b.receiveFunc(packetConn)}, M.SocksaddrFromNet(packetConn.LocalAddr()).Port, nil
+}
+
+func (b *ServerBind) receiveFunc(packetConn net.PacketConn) conn.ReceiveFunc {
+	return func(packets [][]byte, sizes []int, eps []conn.Endpoint) (count int, err error) {
+		n, addr, err := packetConn.ReadFrom(packets[0])
+		if err != nil {
+			return
+		}
+		sizes[0] = n
+		if n > 3 {
+			b := packets[0]
+			common.ClearArray(b[1:4])
+		}
+		source := M.AddrPortFromNet(addr)
+		eps[0] = Endpoint(netip.AddrPortFrom(source.Addr().Unmap(), source.Port()))
+		count = 1
+		return
+	}
+}
+
+func (b *ServerBind) Close() error {
+	b.access.Lock()
+	defer b.access.Unlock()
+	if b.conn == nil {
+		return nil
+	}
+	err := b.conn.Close()
+	b.conn = nil
+	return err
+}
+
+func (b *ServerBind) SetMark(mark uint32) error {
+	return nil
+}
+
+func (b *ServerBind) Send(bufs [][]byte, ep conn.Endpoint) error {
+	b.access.Lock()
+	packetConn := b.conn
+	b.access.Unlock()
+	if packetConn == nil {
+		return net.ErrClosed
+	}
+	destination := net.UDPAddrFromAddrPort(netip.AddrPort(ep.(Endpoint)))
+	for _, b := range bufs {
+		_, err := packetConn.WriteTo(b, destination)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *ServerBind) ParseEndpoint(s string) (conn.Endpoint, error) {
+	ap, err := netip.ParseAddrPort(s)
+	if err != nil {
+		return nil, err
+	}
+	return Endpoint(ap), nil
+}
+
+func (b *ServerBind) BatchSize() int {
+	return 1
+}
+
+func (b *ServerBind) SetReservedForEndpoint(destination netip.AddrPort, reserved [3]byte) {
+}
@@ -0,0 +1,231 @@
+//go:build with_gvisor
+
+package wireguard
+
+import (
+	"os"
+	"sync"
+
+	"github.com/sagernet/gvisor/pkg/buffer"
+	"github.com/sagernet/gvisor/pkg/tcpip"
+	"github.com/sagernet/gvisor/pkg/tcpip/header"
+	"github.com/sagernet/gvisor/pkg/tcpip/stack"
+	"github.com/sagernet/sing-tun"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/buf"
+	wgTun "github.com/sagernet/wireguard-go/tun"
+)
+
+var (
+	_ wgTun.Device  = (*ServerDevice)(nil)
+	_ tun.GVisorTun = (*serverTun)(nil)
+)
+
+// ServerDevice connects the WireGuard device of an inbound to a gVisor stack
+// of sing-tun: packets decrypted from peers are delivered to the stack,
+// and packets written by the stack are encrypted and sent to peers.
+type ServerDevice struct {
+	mtu            uint32
+	events         chan wgTun.Event
+	outbound       chan stack.PacketBufferPtr
+	packetOutbound chan *buf.Buffer
+	done           chan struct{}
+	closeOnce      sync.Once
+	dispatcher     stack.NetworkDispatcher
+}
+
+func NewServerDevice(mtu uint32) (*ServerDevice, error) {
+	return &ServerDevice{
+		mtu:            mtu,
+		events:         make(chan wgTun.Event, 1),
+		outbound:       make(chan stack.PacketBufferPtr, 256),
+		packetOutbound: make(chan *buf.Buffer, 256),
+		done:           make(chan struct{}),
+	}, nil
+}
+
+// Tun returns the device as seen by the stack.
+func (w *ServerDevice) Tun() tun.Tun {
+	return (*serverTun)(w)
+}
+
+func (w *ServerDevice) Start() error {
+	w.events <- wgTun.EventUp
+	return nil
+}
+
+func (w *ServerDevice) File() *os.File {
+	return nil
+}
+
+func (w *ServerDevice) Read(bufs [][]byte, sizes []int, offset int) (count int, err error) {
+	select {
+	case packetBuffer := <-w.outbound:
+		defer packetBuffer.DecRef()
+		p := bufs[0]
+		p = p[offset:]
+		n := 0
+		for _, slice := range packetBuffer.AsSlices() {
+			n += copy(p[n:], slice)
+		}
+		sizes[0] = n
+		count = 1
+		return
+	case packet := <-w.packetOutbound:
+		defer packet.Release()
+		sizes[0] = copy(bufs[0][offset:], packet.Bytes())
+		count = 1
+		return
+	case <-w.done:
+		return 0, os.ErrClosed
+	}
+}
+
+func (w *ServerDevice) Write(bufs [][]byte, offset int) (count int, err error) {
+	if w.dispatcher == nil {
+		return len(bufs), nil
+	}
+	for _, b := range bufs {
+		b = b[offset:]
+		if len(b) == 0 {
+			continue
+		}
+		var networkProtocol tcpip.NetworkProtocolNumber
+		switch header.IPVersion(b) {
+		case header.IPv4Version:
+			networkProtocol = header.IPv4ProtocolNumber
+		case header.IPv6Version:
+			networkProtocol = header.IPv6ProtocolNumber
+		}
+		packetBuffer := stack.NewPacketBuffer(stack.PacketBufferOptions{
+			Payload: buffer.MakeWithData(b),
+		})
+		w.dispatcher.DeliverNetworkPacket(networkProtocol, packetBuffer)
+		packetBuffer.DecRef()
+		count++
+	}
+	return
+}
+
+func (w *ServerDevice) Flush() error {
+	return nil
+}
+
+func (w *ServerDevice) MTU() (int, error) {
+	return int(w.mtu), nil
+}
+
+func (w *ServerDevice) Name() (string, error) {
+	return "sing-box", nil
+}
+
+func (w *ServerDevice) Events() <-chan wgTun.Event {
+	return w.events
+}
+
+func (w *ServerDevice) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.done)
+	})
+	return nil
+}
+
+func (w *ServerDevice) BatchSize() int {
+	return 1
+}
+
+type serverTun ServerDevice
+
+func (t *serverTun) Read(p []byte) (n int, err error) {
+	<-t.done
+	return 0, os.ErrClosed
+}
+
+func (t *serverTun) Write(p []byte) (n int, err error) {
+	packet := buf.NewSize(len(p))
+	common.Must1(packet.Write(p))
+	err = t.writePacket(packet)
+	if err != nil {
+		return
+	}
+	return len(p), nil
+}
+
+func (t *serverTun) WriteVectorised(buffers []*buf.Buffer) error {
+	defer buf.ReleaseMulti(buffers)
+	packet := buf.NewSize(buf.LenMulti(buffers))
+	packet.Truncate(buf.CopyMulti(packet.FreeBytes(), buffers))
+	return t.writePacket(packet)
+}
+
+func (t *serverTun) writePacket(packet *buf.Buffer) error {
+	select {
+	case t.packetOutbound <- packet:
+		return nil
+	case <-t.done:
+		packet.Release()
+		return os.ErrClosed
+	}
+}
+
+func (t *serverTun) Close() error {
+	return (*ServerDevice)(t).Close()
+}
+
+func (t *serverTun) NewEndpoint() (stack.LinkEndpoint, error) {
+	return (*serverEndpoint)(t), nil
+}
+
+var _ stack.LinkEndpoint = (*serverEndpoint)(nil)
+
+type serverEndpoint ServerDevice
+
+func (ep *serverEndpoint) MTU() uint32 {
+	return ep.mtu
+}
+
+func (ep *serverEndpoint) MaxHeaderLength() uint16 {
+	return 0
+}
+
+func (ep *serverEndpoint) LinkAddress() tcpip.LinkAddress {
+	return ""
+}
+
+func (ep *serverEndpoint) Capabilities() stack.LinkEndpointCapabilities {
+	return stack.CapabilityRXChecksumOffload
+}
+
+func (ep *serverEndpoint) Attach(dispatcher stack.NetworkDispatcher) {
+	ep.dispatcher = dispatcher
+}
+
+func (ep *serverEndpoint) IsAttached() bool {
+	return ep.dispatcher != nil
+}
+
+func (ep *serverEndpoint) Wait() {
+}
+
+func (ep *serverEndpoint) ARPHardwareType() header.ARPHardwareType {
+	return header.ARPHardwareNone
+}
+
+func (ep *serverEndpoint) AddHeader(buffer stack.PacketBufferPtr) {
+}
+
+func (ep *serverEndpoint) ParseHeader(ptr stack.PacketBufferPtr) bool {
+	return true
+}
+
+func (ep *serverEndpoint) WritePackets(list stack.PacketBufferList) (int, tcpip.Error) {
+	for _, packetBuffer := range list.AsSlice() {
+		packetBuffer.IncRef()
+		select {
+		case <-ep.done:
+			return 0, &tcpip.ErrClosedForSend{}
+		case ep.outbound <- packetBuffer:
+		}
+	}
+	return list.Len(), nil
+}
@@ -0,0 +1,24 @@
+//go:build !with_gvisor
+
+package wireguard
+
+import (
+	"github.com/sagernet/sing-tun"
+	wgTun "github.com/sagernet/wireguard-go/tun"
+)
+
+type ServerDevice struct {
+	wgTun.Device
+}
+
+func NewServerDevice(mtu uint32) (*ServerDevice, error) {
+	return nil, tun.ErrGVisorNotIncluded
+}
+
+func (w *ServerDevice) Tun() tun.Tun {
+	return nil
+}
+
+func (w *ServerDevice) Start() error {
+	return nil
+}